@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/juju/ansiterm/tabwriter"
+	"github.com/spf13/cobra"
+
+	"dagger.io/dagger"
+	"github.com/dagger/dagger/engine/client"
+)
+
+var debugWhyJSONOutput bool
+
+func init() {
+	debugWhyCmd.Flags().BoolVar(&debugWhyJSONOutput, "json", false, "Output the explanation in JSON format")
+
+	debugCmd.AddCommand(debugWhyCmd)
+}
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Debug the Dagger engine",
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+}
+
+const cacheMissQuery = `query CacheMiss($call: String!) {
+	engine {
+		cacheMiss(call: $call) {
+			reason
+			description
+			call
+			previousCall
+			changedCall
+			argument
+		}
+	}
+}`
+
+var debugWhyCmd = &cobra.Command{
+	Use:   "why [options] <call>",
+	Short: "Explain why a call was not cached",
+	Long: `Explain why a call was not cached.
+
+The call is given by the digest recorded in its telemetry, or by its encoded ID.
+It's compared to the most similar call of the same field made before it, to
+find the input that changed or the cache policy that applies to it.
+
+Only the calls of the current session are explained, and only until the
+session ends, so the command has to run in the session that made the call,
+e.g. with "dagger run".`,
+	Example: "dagger debug why xxh3:3f1c5a0b2e9d7c64",
+	Args:    cobra.ExactArgs(1),
+	Annotations: map[string]string{
+		"experimental": "true",
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withEngine(cmd.Context(), client.Params{}, func(ctx context.Context, engineClient *client.Client) error {
+			var res struct {
+				Engine struct {
+					CacheMiss struct {
+						Reason       string `json:"reason"`
+						Description  string `json:"description"`
+						Call         string `json:"call"`
+						PreviousCall string `json:"previousCall"`
+						ChangedCall  string `json:"changedCall"`
+						Argument     string `json:"argument"`
+					}
+				}
+			}
+			err := engineClient.Dagger().Do(ctx, &dagger.Request{
+				Query: cacheMissQuery,
+				Variables: map[string]any{
+					"call": args[0],
+				},
+			}, &dagger.Response{
+				Data: &res,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to explain cache miss: %w", err)
+			}
+			miss := res.Engine.CacheMiss
+
+			if debugWhyJSONOutput {
+				bs, err := json.MarshalIndent(miss, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal explanation: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(bs))
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', tabwriter.DiscardEmptyColumns)
+			fmt.Fprintf(tw, "Reason:\t%s\n", miss.Reason)
+			fmt.Fprintf(tw, "Call:\t%s\n", miss.Call)
+			if miss.PreviousCall != "" {
+				fmt.Fprintf(tw, "Previous call:\t%s\n", miss.PreviousCall)
+			}
+			if miss.ChangedCall != "" {
+				fmt.Fprintf(tw, "Changed call:\t%s\n", miss.ChangedCall)
+			}
+			if miss.Argument != "" {
+				fmt.Fprintf(tw, "Argument:\t%s\n", miss.Argument)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "\n%s\n", miss.Description)
+			return nil
+		})
+	},
+}
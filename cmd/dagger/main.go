@@ -105,6 +105,7 @@ func init() {
 		shellCmd,
 		clientCmd,
 		mcpCmd,
+		debugCmd,
 	)
 
 	rootCmd.AddGroup(moduleGroup)
@@ -264,9 +264,14 @@ func mainSession() error {
 		return fmt.Errorf("failed to connect to session server: %w", err)
 	}
 
+	secretProvider, err := secretprovider.NewSecretProvider()
+	if err != nil {
+		return fmt.Errorf("secret provider: %w", err)
+	}
+
 	attachables := []bksession.Attachable{
 		// secrets
-		secretprovider.NewSecretProvider(),
+		secretProvider,
 		// sockets
 		client.SocketProvider{EnableHostNetworkAccess: true},
 		// host=>container networking
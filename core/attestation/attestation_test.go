@@ -0,0 +1,139 @@
+package attestation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanPackages(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, contents string) {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(contents), 0o644))
+	}
+	write("etc/os-release", "NAME=\"Alpine Linux\"\nID=alpine\nVERSION_ID=3.19.1\n")
+	write("lib/apk/db/installed", `C:Q1abc=
+P:musl
+V:1.2.4_git20230717-r4
+A:x86_64
+L:MIT
+T:the musl c library
+
+P:busybox
+V:1.36.1-r15
+A:x86_64
+L:GPL-2.0-only
+`)
+	write("var/lib/dpkg/status", `Package: bash
+Status: install ok installed
+Architecture: amd64
+Version: 5.2.15-2+b2
+Description: GNU Bourne Again SHell
+ continuation line
+
+Package: removed
+Status: deinstall ok config-files
+Version: 1.0
+`)
+	write("usr/lib/python3.12/site-packages/Requests-2.31.0.dist-info/METADATA", `Metadata-Version: 2.1
+Name: Requests
+Version: 2.31.0
+License: Apache 2.0
+
+Name: not a header
+`)
+	// symlinks must not escape the root
+	require.NoError(t, os.Symlink("/", filepath.Join(root, "usr/local")))
+
+	pkgs, err := ScanPackages(context.Background(), root)
+	require.NoError(t, err)
+
+	var purls []string
+	for _, pkg := range pkgs {
+		purls = append(purls, pkg.PURL())
+	}
+	require.Equal(t, []string{
+		"pkg:apk/alpine/busybox@1.36.1-r15?arch=x86_64&distro=alpine-3.19.1",
+		"pkg:apk/alpine/musl@1.2.4_git20230717-r4?arch=x86_64&distro=alpine-3.19.1",
+		"pkg:deb/alpine/bash@5.2.15-2+b2?arch=amd64&distro=alpine-3.19.1",
+		"pkg:pypi/requests@2.31.0",
+	}, purls)
+	require.Equal(t, "MIT", pkgs[1].License)
+
+	t.Run("empty", func(t *testing.T) {
+		pkgs, err := ScanPackages(context.Background(), t.TempDir())
+		require.NoError(t, err)
+		require.Empty(t, pkgs)
+	})
+}
+
+func TestSBOM(t *testing.T) {
+	pkgs := []Package{
+		{Name: "musl", Version: "1.2.4-r4", Type: "apk", Namespace: "alpine", Arch: "x86_64", License: "MIT"},
+		{Name: "requests", Version: "2.31.0", Type: "pypi"},
+	}
+	created := time.Unix(1700000000, 0)
+
+	t.Run("spdx", func(t *testing.T) {
+		contents, err := SPDX("my-image", pkgs, created)
+		require.NoError(t, err)
+		var doc spdxDocument
+		require.NoError(t, json.Unmarshal(contents, &doc))
+		require.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+		require.Equal(t, "2023-11-14T22:13:20Z", doc.CreationInfo.Created)
+		require.Len(t, doc.Packages, 2)
+		require.Equal(t, "MIT", doc.Packages[0].LicenseDeclared)
+		require.Equal(t, "NOASSERTION", doc.Packages[1].LicenseDeclared)
+		require.Equal(t, "pkg:pypi/requests@2.31.0", doc.Packages[1].ExternalRefs[0].ReferenceLocator)
+		require.Len(t, doc.Relationships, 2)
+
+		again, err := SPDX("my-image", pkgs, created)
+		require.NoError(t, err)
+		require.Equal(t, string(contents), string(again))
+	})
+
+	t.Run("cyclonedx", func(t *testing.T) {
+		contents, err := CycloneDX("my-image", pkgs, created)
+		require.NoError(t, err)
+		var doc cycloneDXDocument
+		require.NoError(t, json.Unmarshal(contents, &doc))
+		require.Equal(t, "1.5", doc.SpecVersion)
+		require.Equal(t, "container", doc.Metadata.Component.Type)
+		require.Len(t, doc.Components, 2)
+		require.Equal(t, "pkg:apk/alpine/musl@1.2.4-r4?arch=x86_64", doc.Components[0].PURL)
+	})
+}
+
+func TestProvenance(t *testing.T) {
+	callDigest := digest.FromString("call")
+	materials := []Material{
+		{
+			URI:    "pkg:docker/alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+			Digest: map[string]string{"sha256": "c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b"},
+		},
+	}
+	for i := range MaxMaterials {
+		materials = append(materials, Material{
+			URI:    fmt.Sprintf("git+https://example.com/repo-%d@%040d", i, i),
+			Digest: map[string]string{"gitCommit": fmt.Sprintf("%040d", i)},
+		})
+	}
+
+	contents, err := Provenance(callDigest, materials, "v0.18.0")
+	require.NoError(t, err)
+	var pred provenance
+	require.NoError(t, json.Unmarshal(contents, &pred))
+	require.Equal(t, BuildType, pred.BuildDefinition.BuildType)
+	require.Equal(t, callDigest.String(), pred.BuildDefinition.ExternalParameters.CallDigest)
+	require.Equal(t, map[string]string{"dagger": "v0.18.0"}, pred.RunDetails.Builder.Version)
+	require.Equal(t, materials[:MaxMaterials], pred.BuildDefinition.ResolvedDependencies)
+}
@@ -0,0 +1,316 @@
+// Package attestation generates the SBOM and provenance attestations attached
+// to exported container images.
+package attestation
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	continuityfs "github.com/containerd/continuity/fs"
+)
+
+// Package is a software package installed in a container filesystem.
+type Package struct {
+	Name    string
+	Version string
+	// Type is the package URL type: apk, deb or pypi.
+	Type string
+	// Namespace is the package URL namespace, such as the distribution of OS
+	// packages.
+	Namespace string
+	Arch      string
+	License   string
+	// Distro is the distribution and version OS packages were installed
+	// from, such as alpine-3.19.
+	Distro string
+}
+
+// PURL returns the package URL of the package.
+func (pkg Package) PURL() string {
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(pkg.Type)
+	b.WriteByte('/')
+	if pkg.Namespace != "" {
+		b.WriteString(url.PathEscape(pkg.Namespace))
+		b.WriteByte('/')
+	}
+	b.WriteString(url.PathEscape(pkg.Name))
+	if pkg.Version != "" {
+		b.WriteByte('@')
+		b.WriteString(url.PathEscape(pkg.Version))
+	}
+	var qualifiers []string
+	if pkg.Arch != "" {
+		qualifiers = append(qualifiers, "arch="+url.QueryEscape(pkg.Arch))
+	}
+	if pkg.Distro != "" {
+		qualifiers = append(qualifiers, "distro="+url.QueryEscape(pkg.Distro))
+	}
+	if len(qualifiers) > 0 {
+		b.WriteByte('?')
+		b.WriteString(strings.Join(qualifiers, "&"))
+	}
+	return b.String()
+}
+
+// ScanPackages returns the packages recorded in the package databases of the
+// filesystem at root: apk and dpkg for OS packages, and the metadata of
+// installed Python distributions. Packages are sorted by package URL.
+func ScanPackages(ctx context.Context, root string) ([]Package, error) {
+	distroID, distroVersion, err := readOSRelease(root)
+	if err != nil {
+		return nil, err
+	}
+	distro := distroID
+	if distroID != "" && distroVersion != "" {
+		distro += "-" + distroVersion
+	}
+
+	var pkgs []Package
+	for _, scan := range []func(context.Context, string) ([]Package, error){
+		scanAPK,
+		scanDPKG,
+		scanPython,
+	} {
+		found, err := scan(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		for _, pkg := range found {
+			if pkg.Type != "pypi" {
+				pkg.Namespace = distroID
+				pkg.Distro = distro
+			}
+			pkgs = append(pkgs, pkg)
+		}
+	}
+
+	// the same package may be recorded several times, e.g. by Python
+	// distributions installed both from OS packages and pip
+	seen := map[string]bool{}
+	unique := pkgs[:0]
+	for _, pkg := range pkgs {
+		purl := pkg.PURL()
+		if seen[purl] {
+			continue
+		}
+		seen[purl] = true
+		unique = append(unique, pkg)
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].PURL() < unique[j].PURL()
+	})
+	return unique, nil
+}
+
+// openRooted opens the file at name below root, resolving symlinks within
+// root. It returns nil if the file doesn't exist.
+func openRooted(root, name string) (*os.File, error) {
+	p, err := continuityfs.RootPath(root, name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// readDirRooted lists the directory at name below root, resolving symlinks
+// within root. It returns nil if the directory doesn't exist.
+func readDirRooted(root, name string) ([]os.DirEntry, error) {
+	p, err := continuityfs.RootPath(root, name)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(p)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, os.ErrPermission) {
+		return nil, nil
+	}
+	return entries, err
+}
+
+// readOSRelease returns the ID and VERSION_ID of the os-release file.
+func readOSRelease(root string) (id string, version string, _ error) {
+	for _, name := range []string{"etc/os-release", "usr/lib/os-release"} {
+		f, err := openRooted(root, name)
+		if err != nil {
+			return "", "", err
+		}
+		if f == nil {
+			continue
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			key, value, ok := strings.Cut(scanner.Text(), "=")
+			if !ok {
+				continue
+			}
+			value = strings.Trim(value, `"'`)
+			switch key {
+			case "ID":
+				id = value
+			case "VERSION_ID":
+				version = value
+			}
+		}
+		return id, version, scanner.Err()
+	}
+	return "", "", nil
+}
+
+// parseStanzas parses files made of "Key: value" stanzas separated by blank
+// lines, like the apk and dpkg databases. Continuation lines are ignored.
+func parseStanzas(r io.Reader, sep string, fn func(map[string]string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	stanza := map[string]string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(stanza) > 0 {
+				fn(stanza)
+				stanza = map[string]string{}
+			}
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key, value, ok := strings.Cut(line, sep)
+		if !ok {
+			continue
+		}
+		stanza[key] = strings.TrimSpace(value)
+	}
+	if len(stanza) > 0 {
+		fn(stanza)
+	}
+	return scanner.Err()
+}
+
+func scanAPK(ctx context.Context, root string) ([]Package, error) {
+	f, err := openRooted(root, "lib/apk/db/installed")
+	if err != nil || f == nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkgs []Package
+	err = parseStanzas(f, ":", func(stanza map[string]string) {
+		if stanza["P"] == "" {
+			return
+		}
+		pkgs = append(pkgs, Package{
+			Name:    stanza["P"],
+			Version: stanza["V"],
+			Type:    "apk",
+			Arch:    stanza["A"],
+			License: stanza["L"],
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("apk database: %w", err)
+	}
+	return pkgs, nil
+}
+
+func scanDPKG(ctx context.Context, root string) ([]Package, error) {
+	f, err := openRooted(root, "var/lib/dpkg/status")
+	if err != nil || f == nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkgs []Package
+	err = parseStanzas(f, ": ", func(stanza map[string]string) {
+		if stanza["Package"] == "" || !strings.HasSuffix(stanza["Status"], " installed") {
+			return
+		}
+		pkgs = append(pkgs, Package{
+			Name:    stanza["Package"],
+			Version: stanza["Version"],
+			Type:    "deb",
+			Arch:    stanza["Architecture"],
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dpkg database: %w", err)
+	}
+	return pkgs, nil
+}
+
+func scanPython(ctx context.Context, root string) ([]Package, error) {
+	var pkgs []Package
+	for _, libDir := range []string{"usr/lib", "usr/local/lib"} {
+		libs, err := readDirRooted(root, libDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, lib := range libs {
+			if !strings.HasPrefix(lib.Name(), "python3") {
+				continue
+			}
+			for _, sitePackages := range []string{"site-packages", "dist-packages"} {
+				dir := path.Join(libDir, lib.Name(), sitePackages)
+				entries, err := readDirRooted(root, dir)
+				if err != nil {
+					return nil, err
+				}
+				for _, entry := range entries {
+					if err := ctx.Err(); err != nil {
+						return nil, err
+					}
+					if !strings.HasSuffix(entry.Name(), ".dist-info") {
+						continue
+					}
+					pkg, err := readPythonMetadata(root, path.Join(dir, entry.Name(), "METADATA"))
+					if err != nil {
+						return nil, err
+					}
+					if pkg != nil {
+						pkgs = append(pkgs, *pkg)
+					}
+				}
+			}
+		}
+	}
+	return pkgs, nil
+}
+
+func readPythonMetadata(root, name string) (*Package, error) {
+	f, err := openRooted(root, name)
+	if err != nil || f == nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pkg *Package
+	// only the headers, before the first blank line, are relevant
+	err = parseStanzas(io.LimitReader(f, 1024*1024), ": ", func(stanza map[string]string) {
+		if pkg != nil || stanza["Name"] == "" {
+			return
+		}
+		pkg = &Package{
+			Name:    strings.ToLower(stanza["Name"]),
+			Version: stanza["Version"],
+			Type:    "pypi",
+			License: stanza["License"],
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return pkg, nil
+}
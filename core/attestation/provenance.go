@@ -0,0 +1,83 @@
+package attestation
+
+import (
+	"encoding/json"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// BuildType identifies how to interpret the build definition of the
+	// provenance: the external parameters identify the dagql call that
+	// produced the container.
+	BuildType = "https://dagger.io/provenance/container/v1"
+
+	// BuilderID identifies the Dagger Engine as the builder in provenance.
+	BuilderID = "https://dagger.io/engine"
+
+	// MaxMaterials is the maximum number of resolved dependencies listed in
+	// provenance, so that its size doesn't grow with the size of the build.
+	MaxMaterials = 64
+)
+
+type provenance struct {
+	BuildDefinition provenanceBuildDefinition `json:"buildDefinition"`
+	RunDetails      provenanceRunDetails      `json:"runDetails"`
+}
+
+type provenanceBuildDefinition struct {
+	BuildType            string               `json:"buildType"`
+	ExternalParameters   provenanceParameters `json:"externalParameters"`
+	ResolvedDependencies []Material           `json:"resolvedDependencies,omitempty"`
+}
+
+type provenanceParameters struct {
+	// CallDigest is the digest of the call that produced the container.
+	//
+	// The call itself isn't included, since it holds the values of all the
+	// arguments of the build.
+	CallDigest string `json:"callDigest"`
+}
+
+type provenanceRunDetails struct {
+	Builder provenanceBuilder `json:"builder"`
+}
+
+type provenanceBuilder struct {
+	ID      string            `json:"id"`
+	Version map[string]string `json:"version,omitempty"`
+}
+
+// Material is an external resource that a container was built from, pinned
+// to the version that was resolved, like a base image or a git commit.
+type Material struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// Provenance returns a SLSA v1 provenance predicate for the container
+// produced by the call with the given digest, built by the given engine
+// version from the given materials.
+//
+// Only the first MaxMaterials materials are listed.
+func Provenance(callDigest digest.Digest, materials []Material, engineVersion string) ([]byte, error) {
+	if len(materials) > MaxMaterials {
+		materials = materials[:MaxMaterials]
+	}
+	pred := provenance{
+		BuildDefinition: provenanceBuildDefinition{
+			BuildType: BuildType,
+			ExternalParameters: provenanceParameters{
+				CallDigest: callDigest.String(),
+			},
+			ResolvedDependencies: materials,
+		},
+		RunDetails: provenanceRunDetails{
+			Builder: provenanceBuilder{
+				ID:      BuilderID,
+				Version: map[string]string{"dagger": engineVersion},
+			},
+		},
+	}
+	return json.Marshal(pred)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/dagger/dagger/engine/slog"
+	"github.com/dagger/dagger/network"
 	"github.com/moby/buildkit/session/sshforward"
 	"github.com/sourcegraph/conc/pool"
 )
@@ -43,7 +44,9 @@ func (d *c2hTunnel) Tunnel(ctx context.Context) (rerr error) {
 			)
 
 			listener, err := buildkit.RunInNetNS(ctx, d.bk, d.ns, func() (net.Listener, error) {
-				return net.Listen(port.Protocol.Network(), fmt.Sprintf(":%d", frontend))
+				// UDP is accepted as a conn per client address, each forwarded
+				// through its own stream
+				return network.Listen(port.Protocol.Network(), fmt.Sprintf(":%d", frontend))
 			})
 			if err != nil {
 				srvSlog.Error("failed to listen", "error", err)
@@ -83,7 +86,11 @@ func (d *c2hTunnel) Tunnel(ctx context.Context) (rerr error) {
 				}
 
 				proxyConnPool.Go(func(ctx context.Context) error {
-					err := sshforward.Copy(ctx, downstreamConn, upstreamClient, upstreamClient.CloseSend)
+					copyConn := sshforward.Copy
+					if network.IsPacketNetwork(port.Protocol.Network()) {
+						copyConn = network.CopyDatagrams
+					}
+					err := copyConn(ctx, downstreamConn, upstreamClient, upstreamClient.CloseSend)
 					if err != nil {
 						connSlog.Error("failed to copy data", "error", err)
 					}
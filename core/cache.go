@@ -1,11 +1,19 @@
 package core
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
+	bkcache "github.com/moby/buildkit/cache"
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/bklog"
+	fscopy "github.com/tonistiigi/fsutil/copy"
 	"github.com/vektah/gqlparser/v2/ast"
 
 	"github.com/dagger/dagger/dagql"
@@ -48,6 +56,110 @@ func (cache *CacheVolume) Sum() string {
 	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
 }
 
+// Delete deletes the contents of the cache volume from the local cache.
+func (cache *CacheVolume) Delete(ctx context.Context, query *Query) error {
+	return query.DeleteCacheVolume(ctx, cache)
+}
+
+// Seed replaces the contents of the cache volume with the contents of a
+// directory.
+func (cache *CacheVolume) Seed(ctx context.Context, query *Query, source *Directory) error {
+	res, err := source.Evaluate(ctx)
+	if err != nil {
+		return err
+	}
+	ref, err := res.SingleRef()
+	if err != nil {
+		return err
+	}
+	return query.MountCacheVolume(ctx, cache, func(volume string) error {
+		if err := removeDirContents(volume); err != nil {
+			return err
+		}
+		if ref == nil {
+			// the source is empty
+			return nil
+		}
+		return ref.Mount(ctx, func(root string) error {
+			return copyCacheVolume(ctx, root, source.Dir, volume)
+		})
+	})
+}
+
+// Snapshot returns a copy of the current contents of the cache volume.
+//
+// It must be called within a FSDagOp.
+func (cache *CacheVolume) Snapshot(ctx context.Context, query *Query) (_ *Directory, rerr error) {
+	op, ok := DagOpFromContext[FSDagOp](ctx)
+	if !ok {
+		return nil, fmt.Errorf("no dagop")
+	}
+
+	bkref, err := op.CreateRef(ctx, nil,
+		bkcache.CachePolicyRetain,
+		bkcache.WithRecordType(bkclient.UsageRecordTypeRegular),
+		bkcache.WithDescription(op.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr != nil && bkref != nil {
+			bkref.Release(context.WithoutCancel(ctx))
+		}
+	}()
+	err = op.Mount(ctx, bkref, func(out string) error {
+		return query.MountCacheVolume(ctx, cache, func(volume string) error {
+			return copyCacheVolume(ctx, volume, "/", out)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snapshot cache volume: %w", err)
+	}
+
+	snap, err := bkref.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bkref = nil
+
+	dir := NewDirectory(query, nil, "/", query.Platform(), nil)
+	dir.Result = snap
+	return dir, nil
+}
+
+// copyCacheVolume copies the contents of a directory beneath root to dest.
+func copyCacheVolume(ctx context.Context, root, srcPath, dest string) error {
+	err := fscopy.Copy(ctx,
+		root, srcPath,
+		dest, "/",
+		func(ci *fscopy.CopyInfo) {
+			ci.CopyDirContents = true
+		},
+		fscopy.WithXAttrErrorHandler(func(dst, src, key string, err error) error {
+			bklog.G(ctx).Debugf("xattr error during cache volume copy: %v", err)
+			return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("copy cache volume: %w", err)
+	}
+	return nil
+}
+
+// removeDirContents removes everything under dir, but not dir itself.
+func removeDirContents(dir string) error {
+	dirents, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, dirent := range dirents {
+		if err := os.RemoveAll(filepath.Join(dir, dirent.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type CacheSharingMode string
 
 var CacheSharingModes = dagql.NewEnum[CacheSharingMode]()
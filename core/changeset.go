@@ -0,0 +1,414 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/core/patch"
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// Changeset is the set of file changes between two directories.
+type Changeset struct {
+	Query *Query
+
+	Entries []ChangesetEntry
+}
+
+func (*Changeset) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "Changeset",
+		NonNull:   true,
+	}
+}
+
+func (*Changeset) TypeDescription() string {
+	return "The file changes between two directories."
+}
+
+// Paths returns the paths of the entries of the given kind.
+func (changes *Changeset) Paths(kind ChangeKind) []string {
+	paths := []string{}
+	for _, entry := range changes.Entries {
+		if entry.Kind == kind {
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths
+}
+
+// Patch returns the changes as a unified diff in the format of git diff,
+// which can be applied with Directory.withPatch.
+func (changes *Changeset) Patch() string {
+	var b strings.Builder
+	for _, entry := range changes.Entries {
+		b.WriteString(entry.Diff)
+	}
+	return b.String()
+}
+
+// ChangeKind is a GraphQL enum type.
+type ChangeKind string
+
+var ChangeKinds = dagql.NewEnum[ChangeKind]()
+
+var (
+	ChangeKindAdded    = ChangeKinds.Register("ADDED", "The file was created.")
+	ChangeKindModified = ChangeKinds.Register("MODIFIED", "The contents or permissions of the file were changed.")
+	ChangeKindRemoved  = ChangeKinds.Register("REMOVED", "The file was deleted.")
+	ChangeKindRenamed  = ChangeKinds.Register("RENAMED", "The file was moved without changing its contents.")
+)
+
+func (kind ChangeKind) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ChangeKind",
+		NonNull:   true,
+	}
+}
+
+func (kind ChangeKind) TypeDescription() string {
+	return "The kind of change made to a file."
+}
+
+func (kind ChangeKind) Decoder() dagql.InputDecoder {
+	return ChangeKinds
+}
+
+func (kind ChangeKind) ToLiteral() call.Literal {
+	return ChangeKinds.Literal(kind)
+}
+
+// ChangesetEntry is the change made to a single file.
+type ChangesetEntry struct {
+	Kind    ChangeKind `field:"true" doc:"The kind of change."`
+	Path    string     `field:"true" doc:"The path of the file after the change, or before it if it was removed."`
+	OldPath string     `field:"true" doc:"The path of the file before the change, if it was renamed."`
+	Diff    string     `field:"true" doc:"The unified diff of the file, in the format of git diff."`
+}
+
+func (ChangesetEntry) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ChangesetEntry",
+		NonNull:   true,
+	}
+}
+
+func (ChangesetEntry) TypeDescription() string {
+	return "A change made to a file."
+}
+
+// Changes returns the file changes from this directory to other.
+func (dir *Directory) Changes(ctx context.Context, other *Directory) (*Changeset, error) {
+	// mount both directories at once, so that only the files that changed
+	// are read
+	var entries []ChangesetEntry
+	err := dir.mount(ctx, func(root string) error {
+		src, err := fs.RootPath(root, dir.Dir)
+		if err != nil {
+			return err
+		}
+		before, err := readTree(ctx, src)
+		if err != nil {
+			return err
+		}
+		return other.mount(ctx, func(root string) error {
+			src, err := fs.RootPath(root, other.Dir)
+			if err != nil {
+				return err
+			}
+			after, err := readTree(ctx, src)
+			if err != nil {
+				return err
+			}
+			entries, err = compareTrees(before, after)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Changeset{
+		Query:   dir.Query,
+		Entries: entries,
+	}, nil
+}
+
+// treeFile is a file of a directory tree, with its mode in the format of git:
+// 100644 or 100755 for regular files, or 120000 for symlinks, whose contents
+// is their target.
+//
+// The contents of regular files read by readTree are only read from path when
+// needed.
+type treeFile struct {
+	mode     uint32
+	size     int64
+	path     string
+	contents []byte
+}
+
+const gitSymlinkMode = 0o120000
+
+// len returns the size of the contents of the file.
+func (f *treeFile) len() int64 {
+	if f.path == "" {
+		return int64(len(f.contents))
+	}
+	return f.size
+}
+
+// read returns the contents of the file.
+func (f *treeFile) read() ([]byte, error) {
+	if f.path == "" {
+		return f.contents, nil
+	}
+	return os.ReadFile(f.path)
+}
+
+// sum returns the SHA-256 of the contents of the file, without reading them
+// all in memory.
+func (f *treeFile) sum() (sum [sha256.Size]byte, _ error) {
+	if f.path == "" {
+		return sha256.Sum256(f.contents), nil
+	}
+	r, err := os.Open(f.path)
+	if err != nil {
+		return sum, err
+	}
+	defer r.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// sameFile returns whether both files have the same mode and contents. Their
+// contents are only hashed if their sizes match.
+func sameFile(a, b *treeFile) (bool, error) {
+	if a.mode != b.mode || a.len() != b.len() {
+		return false, nil
+	}
+	aSum, err := a.sum()
+	if err != nil {
+		return false, err
+	}
+	bSum, err := b.sum()
+	if err != nil {
+		return false, err
+	}
+	return aSum == bSum, nil
+}
+
+// readTree lists the regular files and symlinks below root, keyed by their
+// path relative to root.
+func readTree(ctx context.Context, root string) (map[string]treeFile, error) {
+	files := map[string]treeFile{}
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.Type().IsRegular():
+			fi, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files[rel] = treeFile{mode: 0o100000 | uint32(fi.Mode().Perm()), size: fi.Size(), path: path}
+		case d.Type()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			files[rel] = treeFile{mode: gitSymlinkMode, contents: []byte(target)}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// compareTrees returns the changes from before to after, sorted by path.
+//
+// A removed file is reported as renamed if a file with the same mode and
+// non-empty contents was added.
+func compareTrees(before, after map[string]treeFile) ([]ChangesetEntry, error) {
+	var added, removed []string
+	var entries []ChangesetEntry
+	for name, newFile := range after {
+		oldFile, ok := before[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		same, err := sameFile(&oldFile, &newFile)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			continue
+		}
+		diff, err := fileDiff(name, name, &oldFile, &newFile)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ChangesetEntry{
+			Kind: ChangeKindModified,
+			Path: name,
+			Diff: diff,
+		})
+	}
+	for name := range before {
+		if _, ok := after[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	type renameKey struct {
+		mode uint32
+		sum  [sha256.Size]byte
+	}
+	addedByContents := map[renameKey][]string{}
+	for _, name := range added {
+		f := after[name]
+		if f.len() == 0 {
+			continue
+		}
+		sum, err := f.sum()
+		if err != nil {
+			return nil, err
+		}
+		key := renameKey{f.mode, sum}
+		addedByContents[key] = append(addedByContents[key], name)
+	}
+	renamedTo := map[string]bool{}
+	for _, oldName := range removed {
+		oldFile := before[oldName]
+		if oldFile.len() > 0 && len(addedByContents) > 0 {
+			sum, err := oldFile.sum()
+			if err != nil {
+				return nil, err
+			}
+			key := renameKey{oldFile.mode, sum}
+			if candidates := addedByContents[key]; len(candidates) > 0 {
+				newName := candidates[0]
+				addedByContents[key] = candidates[1:]
+				renamedTo[newName] = true
+				entries = append(entries, ChangesetEntry{
+					Kind:    ChangeKindRenamed,
+					Path:    newName,
+					OldPath: oldName,
+					Diff:    renameDiff(oldName, newName),
+				})
+				continue
+			}
+		}
+		diff, err := fileDiff(oldName, oldName, &oldFile, nil)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ChangesetEntry{
+			Kind: ChangeKindRemoved,
+			Path: oldName,
+			Diff: diff,
+		})
+	}
+	for _, name := range added {
+		if renamedTo[name] {
+			continue
+		}
+		newFile := after[name]
+		diff, err := fileDiff(name, name, nil, &newFile)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, ChangesetEntry{
+			Kind: ChangeKindAdded,
+			Path: name,
+			Diff: diff,
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries, nil
+}
+
+// renameDiff returns the diff of a file moved without changes in the format
+// of git diff.
+func renameDiff(oldName, newName string) string {
+	return fmt.Sprintf("diff --git a/%s b/%s\nsimilarity index 100%%\nrename from %s\nrename to %s\n", oldName, newName, oldName, newName)
+}
+
+// fileDiff returns the diff of a file in the format of git diff. oldFile is
+// nil for added files, and newFile for removed files.
+func fileDiff(oldName, newName string, oldFile, newFile *treeFile) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", oldName, newName)
+
+	oldHeader, newHeader := "a/"+oldName, "b/"+newName
+	var oldContents, newContents []byte
+	var err error
+	switch {
+	case oldFile == nil:
+		fmt.Fprintf(&b, "new file mode %06o\n", newFile.mode)
+		oldHeader = "/dev/null"
+		newContents, err = newFile.read()
+	case newFile == nil:
+		fmt.Fprintf(&b, "deleted file mode %06o\n", oldFile.mode)
+		newHeader = "/dev/null"
+		oldContents, err = oldFile.read()
+	default:
+		if oldFile.mode != newFile.mode {
+			fmt.Fprintf(&b, "old mode %06o\nnew mode %06o\n", oldFile.mode, newFile.mode)
+		}
+		oldContents, err = oldFile.read()
+		if err == nil {
+			newContents, err = newFile.read()
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if bytes.Equal(oldContents, newContents) {
+		return b.String(), nil
+	}
+	if isBinary(oldContents) || isBinary(newContents) {
+		fmt.Fprintf(&b, "Binary files %s and %s differ\n", oldHeader, newHeader)
+		return b.String(), nil
+	}
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldHeader, newHeader)
+	b.WriteString(patch.Unified(oldContents, newContents, patch.DefaultContext))
+	return b.String(), nil
+}
+
+// isBinary returns whether contents look binary, the same way as git and
+// content searches: if there is a NUL byte near the start.
+func isBinary(contents []byte) bool {
+	return bytes.IndexByte(contents[:min(len(contents), binarySniffLen)], 0) != -1
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/core/patch"
+)
+
+func TestCompareTrees(t *testing.T) {
+	before := map[string]treeFile{
+		"same.txt":    {mode: 0o100644, contents: []byte("same\n")},
+		"edit.txt":    {mode: 0o100644, contents: []byte("one\ntwo\nthree\n")},
+		"chmod.sh":    {mode: 0o100644, contents: []byte("#!/bin/sh\n")},
+		"gone.txt":    {mode: 0o100644, contents: []byte("bye\n")},
+		"old/name.md": {mode: 0o100644, contents: []byte("moved\n")},
+	}
+	after := map[string]treeFile{
+		"same.txt":    {mode: 0o100644, contents: []byte("same\n")},
+		"edit.txt":    {mode: 0o100644, contents: []byte("one\n2\nthree\n")},
+		"chmod.sh":    {mode: 0o100755, contents: []byte("#!/bin/sh\n")},
+		"new.txt":     {mode: 0o100644, contents: []byte("hi")},
+		"new/name.md": {mode: 0o100644, contents: []byte("moved\n")},
+		"link":        {mode: gitSymlinkMode, contents: []byte("new.txt")},
+	}
+
+	entries, err := compareTrees(before, after)
+	require.NoError(t, err)
+	var summary []string
+	for _, entry := range entries {
+		summary = append(summary, string(entry.Kind)+" "+entry.OldPath+" "+entry.Path)
+	}
+	require.Equal(t, []string{
+		"MODIFIED  chmod.sh",
+		"MODIFIED  edit.txt",
+		"REMOVED  gone.txt",
+		"ADDED  link",
+		"ADDED  new.txt",
+		"RENAMED old/name.md new/name.md",
+	}, summary)
+
+	require.Equal(t, `diff --git a/edit.txt b/edit.txt
+--- a/edit.txt
++++ b/edit.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++2
+ three
+`, entries[1].Diff)
+	require.Equal(t, `diff --git a/chmod.sh b/chmod.sh
+old mode 100644
+new mode 100755
+`, entries[0].Diff)
+	require.Equal(t, `diff --git a/new.txt b/new.txt
+new file mode 100644
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++hi
+\ No newline at end of file
+`, entries[4].Diff)
+
+	t.Run("binary", func(t *testing.T) {
+		entries, err := compareTrees(nil, map[string]treeFile{
+			"blob": {mode: 0o100644, contents: []byte("a\x00b")},
+		})
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		require.Contains(t, entries[0].Diff, "Binary files /dev/null and b/blob differ\n")
+	})
+}
+
+func TestChangesetPatchRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	beforeDir, afterDir := t.TempDir(), t.TempDir()
+	write := func(root, name, contents string, mode os.FileMode) {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(contents), mode))
+		require.NoError(t, os.Chmod(p, mode))
+	}
+	write(beforeDir, "a.txt", "1\n2\n3\n4\n5\n6\n7\n8\n9\n", 0o644)
+	write(beforeDir, "b.txt", "removed\n", 0o644)
+	write(beforeDir, "dir/c.txt", "renamed\n", 0o644)
+	write(beforeDir, "run.sh", "echo hi\n", 0o644)
+	write(afterDir, "a.txt", "1\n2\nthree\n4\n5\n6\n7\n8\nnine\n", 0o644)
+	write(afterDir, "c.txt", "renamed\n", 0o644)
+	write(afterDir, "d/e.txt", "added\n", 0o644)
+	write(afterDir, "run.sh", "echo hi\n", 0o755)
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(beforeDir, "link")))
+	require.NoError(t, os.Symlink("c.txt", filepath.Join(afterDir, "link")))
+	require.NoError(t, os.Symlink("d/e.txt", filepath.Join(afterDir, "new-link")))
+
+	before, err := readTree(ctx, beforeDir)
+	require.NoError(t, err)
+	after, err := readTree(ctx, afterDir)
+	require.NoError(t, err)
+	entries, err := compareTrees(before, after)
+	require.NoError(t, err)
+	changes := &Changeset{Entries: entries}
+	require.Equal(t, []string{"d/e.txt", "new-link"}, changes.Paths(ChangeKindAdded))
+	require.Equal(t, []string{"a.txt", "link", "run.sh"}, changes.Paths(ChangeKindModified))
+	require.Equal(t, []string{"b.txt"}, changes.Paths(ChangeKindRemoved))
+	require.Equal(t, []string{"c.txt"}, changes.Paths(ChangeKindRenamed))
+
+	diffs, err := patch.Parse(changes.Patch())
+	require.NoError(t, err)
+	require.NoError(t, patch.ApplyDir(beforeDir, diffs, patch.ApplyOpts{}))
+	patched, err := readTree(ctx, beforeDir)
+	require.NoError(t, err)
+	entries, err = compareTrees(after, patched)
+	require.NoError(t, err)
+	require.Empty(t, entries)
+
+	target, err := os.Readlink(filepath.Join(beforeDir, "new-link"))
+	require.NoError(t, err)
+	require.Equal(t, "d/e.txt", target)
+}
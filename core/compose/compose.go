@@ -0,0 +1,452 @@
+// Package compose parses the subset of the Compose file format needed to run
+// the services of a docker-compose.yml file as Dagger services.
+package compose
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/shlex"
+	"gopkg.in/yaml.v3"
+)
+
+// Project is a parsed Compose file.
+type Project struct {
+	Name     string              `yaml:"name"`
+	Services map[string]*Service `yaml:"services"`
+	Volumes  map[string]*Volume  `yaml:"volumes"`
+}
+
+// Volume is a top-level named volume.
+type Volume struct {
+	// Name overrides the name of the volume, which is otherwise prefixed
+	// with the project name.
+	Name string `yaml:"name"`
+}
+
+// Service is a service of a Compose file.
+type Service struct {
+	Image       string       `yaml:"image"`
+	Build       *Build       `yaml:"build"`
+	Command     Command      `yaml:"command"`
+	Entrypoint  Command      `yaml:"entrypoint"`
+	Environment Mapping      `yaml:"environment"`
+	WorkingDir  string       `yaml:"working_dir"`
+	User        string       `yaml:"user"`
+	Ports       []Port       `yaml:"ports"`
+	Expose      []Port       `yaml:"expose"`
+	Volumes     []Mount      `yaml:"volumes"`
+	DependsOn   DependsOn    `yaml:"depends_on"`
+	Healthcheck *Healthcheck `yaml:"healthcheck"`
+}
+
+// Build is the build configuration of a service built from a Dockerfile.
+type Build struct {
+	Context    string  `yaml:"context"`
+	Dockerfile string  `yaml:"dockerfile"`
+	Args       Mapping `yaml:"args"`
+	Target     string  `yaml:"target"`
+}
+
+func (b *Build) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		b.Context = node.Value
+		return nil
+	}
+	type plain Build
+	return node.Decode((*plain)(b))
+}
+
+// Command is a command, given either as a list or as a string split like a
+// shell would. It is nil if unset.
+type Command []string
+
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		args, err := shlex.Split(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid command: %w", node.Line, err)
+		}
+		*c = append(Command{}, args...)
+		return nil
+	}
+	var args []string
+	if err := node.Decode(&args); err != nil {
+		return err
+	}
+	*c = append(Command{}, args...)
+	return nil
+}
+
+// Mapping is a set of variables, given either as a map or as a list of
+// NAME=VALUE entries. Variables without a value, which Compose would take
+// from the host, are skipped.
+type Mapping map[string]string
+
+func (m *Mapping) UnmarshalYAML(node *yaml.Node) error {
+	*m = Mapping{}
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Tag == "!!null" {
+				continue
+			}
+			(*m)[key.Value] = value.Value
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			name, value, ok := strings.Cut(item.Value, "=")
+			if !ok {
+				continue
+			}
+			(*m)[name] = value
+		}
+	default:
+		return fmt.Errorf("line %d: expected a map or a list", node.Line)
+	}
+	return nil
+}
+
+// Sorted returns the names of the variables in order.
+func (m Mapping) Sorted() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Port is a port exposed by a service.
+type Port struct {
+	Target    int    `yaml:"target"`
+	Published string `yaml:"published"`
+	Protocol  string `yaml:"protocol"`
+}
+
+func (p *Port) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		type plain Port
+		if err := node.Decode((*plain)(p)); err != nil {
+			return err
+		}
+	} else {
+		// [[ip:]published:]target[/protocol]
+		spec, proto, _ := strings.Cut(node.Value, "/")
+		p.Protocol = proto
+		if i := strings.LastIndex(spec, ":"); i >= 0 {
+			p.Published = spec[strings.LastIndex(spec[:i], ":")+1 : i]
+			spec = spec[i+1:]
+		}
+		if strings.Contains(spec, "-") {
+			return fmt.Errorf("line %d: port ranges are not supported: %q", node.Line, node.Value)
+		}
+		target, err := strconv.Atoi(spec)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid port %q", node.Line, node.Value)
+		}
+		p.Target = target
+	}
+	switch strings.ToLower(p.Protocol) {
+	case "", "tcp":
+		p.Protocol = "tcp"
+	case "udp":
+		p.Protocol = "udp"
+	default:
+		return fmt.Errorf("line %d: unsupported protocol %q", node.Line, p.Protocol)
+	}
+	if p.Target <= 0 || p.Target > 65535 {
+		return fmt.Errorf("line %d: invalid port %d", node.Line, p.Target)
+	}
+	return nil
+}
+
+// Mount types.
+const (
+	MountVolume = "volume"
+	MountBind   = "bind"
+	MountTmpfs  = "tmpfs"
+)
+
+// Mount is a volume, bind mount or tmpfs mounted in a service.
+type Mount struct {
+	Type     string `yaml:"type"`
+	Source   string `yaml:"source"`
+	Target   string `yaml:"target"`
+	ReadOnly bool   `yaml:"read_only"`
+}
+
+func (m *Mount) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		type plain Mount
+		if err := node.Decode((*plain)(m)); err != nil {
+			return err
+		}
+		if m.Type == "" {
+			m.Type = MountVolume
+		}
+	} else {
+		// [source:]target[:mode]
+		parts := strings.Split(node.Value, ":")
+		switch len(parts) {
+		case 1:
+			m.Target = parts[0]
+		case 2:
+			m.Source, m.Target = parts[0], parts[1]
+		case 3:
+			m.Source, m.Target = parts[0], parts[1]
+			m.ReadOnly = slices.Contains(strings.Split(parts[2], ","), "ro")
+		default:
+			return fmt.Errorf("line %d: invalid volume %q", node.Line, node.Value)
+		}
+		m.Type = MountVolume
+		if isPath(m.Source) {
+			m.Type = MountBind
+		}
+	}
+	if m.Target == "" {
+		return fmt.Errorf("line %d: volume has no target", node.Line)
+	}
+	switch m.Type {
+	case MountVolume, MountTmpfs:
+	case MountBind:
+		if m.Source == "" {
+			return fmt.Errorf("line %d: bind mount has no source", node.Line)
+		}
+	default:
+		return fmt.Errorf("line %d: unsupported volume type %q", node.Line, m.Type)
+	}
+	return nil
+}
+
+func isPath(source string) bool {
+	return strings.HasPrefix(source, ".") || strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~")
+}
+
+// Conditions of the services a service depends on.
+const (
+	ConditionStarted   = "service_started"
+	ConditionHealthy   = "service_healthy"
+	ConditionCompleted = "service_completed_successfully"
+)
+
+// DependsOn maps the services a service depends on to the condition they must
+// meet before it starts.
+type DependsOn map[string]string
+
+func (d *DependsOn) UnmarshalYAML(node *yaml.Node) error {
+	*d = DependsOn{}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var names []string
+		if err := node.Decode(&names); err != nil {
+			return err
+		}
+		for _, name := range names {
+			(*d)[name] = ConditionStarted
+		}
+	case yaml.MappingNode:
+		var deps map[string]struct {
+			Condition string `yaml:"condition"`
+		}
+		if err := node.Decode(&deps); err != nil {
+			return err
+		}
+		for name, dep := range deps {
+			switch dep.Condition {
+			case "":
+				dep.Condition = ConditionStarted
+			case ConditionStarted, ConditionHealthy, ConditionCompleted:
+			default:
+				return fmt.Errorf("line %d: unsupported condition %q", node.Line, dep.Condition)
+			}
+			(*d)[name] = dep.Condition
+		}
+	default:
+		return fmt.Errorf("line %d: expected a map or a list", node.Line)
+	}
+	return nil
+}
+
+// Sorted returns the names of the dependencies in order.
+func (d DependsOn) Sorted() []string {
+	return Mapping(d).Sorted()
+}
+
+// Healthcheck is the health check of a service.
+type Healthcheck struct {
+	Test        Command `yaml:"-"`
+	Interval    string  `yaml:"interval"`
+	Timeout     string  `yaml:"timeout"`
+	Retries     int     `yaml:"retries"`
+	StartPeriod string  `yaml:"start_period"`
+	Disable     bool    `yaml:"disable"`
+}
+
+func (hc *Healthcheck) UnmarshalYAML(node *yaml.Node) error {
+	type plain Healthcheck
+	if err := node.Decode((*plain)(hc)); err != nil {
+		return err
+	}
+	var raw struct {
+		Test yaml.Node `yaml:"test"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	switch raw.Test.Kind {
+	case 0:
+	case yaml.ScalarNode:
+		hc.Test = Command{"sh", "-c", raw.Test.Value}
+	case yaml.SequenceNode:
+		var test []string
+		if err := raw.Test.Decode(&test); err != nil {
+			return err
+		}
+		if len(test) == 0 {
+			return fmt.Errorf("line %d: empty healthcheck test", raw.Test.Line)
+		}
+		switch test[0] {
+		case "NONE":
+			hc.Disable = true
+		case "CMD":
+			hc.Test = test[1:]
+		case "CMD-SHELL":
+			hc.Test = Command{"sh", "-c", strings.Join(test[1:], " ")}
+		default:
+			return fmt.Errorf("line %d: unsupported healthcheck test %q", raw.Test.Line, test[0])
+		}
+	default:
+		return fmt.Errorf("line %d: expected a string or a list", raw.Test.Line)
+	}
+	return nil
+}
+
+// Load parses a Compose file, interpolating the variables in its values from
+// the given environment.
+func Load(data []byte, env map[string]string) (*Project, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err := interpolateNode(&doc, env); err != nil {
+		return nil, err
+	}
+	var project Project
+	if err := doc.Decode(&project); err != nil {
+		return nil, err
+	}
+	if err := project.validate(); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (project *Project) validate() error {
+	if len(project.Services) == 0 {
+		return errors.New("no services defined")
+	}
+	for _, name := range project.ServiceNames() {
+		svc := project.Services[name]
+		if svc == nil {
+			return fmt.Errorf("service %q: empty definition", name)
+		}
+		if svc.Image == "" && svc.Build == nil {
+			return fmt.Errorf("service %q: neither an image nor a build is specified", name)
+		}
+		for _, mnt := range svc.Volumes {
+			if mnt.Type != MountVolume || mnt.Source == "" {
+				continue
+			}
+			if _, ok := project.Volumes[mnt.Source]; !ok {
+				return fmt.Errorf("service %q: refers to undefined volume %q", name, mnt.Source)
+			}
+		}
+		for _, dep := range svc.DependsOn.Sorted() {
+			if _, ok := project.Services[dep]; !ok {
+				return fmt.Errorf("service %q: depends on undefined service %q", name, dep)
+			}
+		}
+	}
+	return project.checkCycles()
+}
+
+func (project *Project) checkCycles() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(name string, stack []string) error
+	visit = func(name string, stack []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(stack, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range project.Services[name].DependsOn.Sorted() {
+			if err := visit(dep, append(stack, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for _, name := range project.ServiceNames() {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServiceNames returns the names of the services in order.
+func (project *Project) ServiceNames() []string {
+	names := make([]string, 0, len(project.Services))
+	for name := range project.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// VolumeName returns the name of a top-level named volume, prefixed with the
+// project name unless overridden.
+func (project *Project) VolumeName(volume string) string {
+	if v := project.Volumes[volume]; v != nil && v.Name != "" {
+		return v.Name
+	}
+	name := project.Name
+	if name == "" {
+		name = "compose"
+	}
+	return name + "_" + volume
+}
+
+// AnonymousVolumeName returns the name of the volume mounted at the target
+// path of a service without a source, shared by all runs of the service.
+func (project *Project) AnonymousVolumeName(service, target string) string {
+	return project.VolumeName(service + strings.ReplaceAll(path.Clean(target), "/", "_"))
+}
+
+// ResolvePath returns the path of a bind mount source or build context,
+// relative to the directory of the Compose file, within the project
+// directory.
+func ResolvePath(composeDir, source string) (string, error) {
+	if strings.HasPrefix(source, "/") || strings.HasPrefix(source, "~") {
+		return "", fmt.Errorf("path %q is outside of the project directory", source)
+	}
+	p := path.Join(composeDir, source)
+	if p == ".." || strings.HasPrefix(p, "../") {
+		return "", fmt.Errorf("path %q is outside of the project directory", source)
+	}
+	return p, nil
+}
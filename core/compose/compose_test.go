@@ -0,0 +1,192 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	project, err := Load([]byte(`
+name: shop
+services:
+  db:
+    image: postgres:${PG_VERSION:-16}
+    environment:
+      POSTGRES_PASSWORD: $DB_PASSWORD
+      FROM_HOST:
+    volumes:
+      - db-data:/var/lib/postgresql/data
+    healthcheck:
+      test: ["CMD-SHELL", "pg_isready -U postgres"]
+      interval: 1s
+      retries: 10
+  migrate:
+    build: ./migrations
+    command: migrate -path "/migrations dir" up
+    depends_on:
+      db:
+        condition: service_healthy
+  api:
+    build:
+      context: .
+      dockerfile: api.Dockerfile
+      args:
+        - VERSION=1.2
+      target: prod
+    entrypoint: []
+    environment:
+      - DB_HOST=db
+      - FROM_HOST
+      - PRICE=$$5
+    ports:
+      - "8080:80"
+      - 127.0.0.1:5353:53/udp
+      - target: 9090
+        published: "9091"
+    expose:
+      - 3000
+    volumes:
+      - ./src:/app/src:ro
+      - /tmp/cache
+      - type: tmpfs
+        target: /run
+    depends_on:
+      migrate:
+        condition: service_completed_successfully
+      db:
+volumes:
+  db-data:
+`), map[string]string{"DB_PASSWORD": "secret"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"api", "db", "migrate"}, project.ServiceNames())
+	require.Equal(t, "shop_db-data", project.VolumeName("db-data"))
+
+	db := project.Services["db"]
+	require.Equal(t, "postgres:16", db.Image)
+	require.Equal(t, Mapping{"POSTGRES_PASSWORD": "secret"}, db.Environment)
+	require.Equal(t, []Mount{{Type: MountVolume, Source: "db-data", Target: "/var/lib/postgresql/data"}}, db.Volumes)
+	require.Equal(t, &Healthcheck{
+		Test:     Command{"sh", "-c", "pg_isready -U postgres"},
+		Interval: "1s",
+		Retries:  10,
+	}, db.Healthcheck)
+
+	migrate := project.Services["migrate"]
+	require.Equal(t, &Build{Context: "./migrations"}, migrate.Build)
+	require.Equal(t, Command{"migrate", "-path", "/migrations dir", "up"}, migrate.Command)
+	require.Equal(t, DependsOn{"db": ConditionHealthy}, migrate.DependsOn)
+
+	api := project.Services["api"]
+	require.Equal(t, &Build{
+		Context:    ".",
+		Dockerfile: "api.Dockerfile",
+		Args:       Mapping{"VERSION": "1.2"},
+		Target:     "prod",
+	}, api.Build)
+	require.Nil(t, api.Command)
+	require.NotNil(t, api.Entrypoint)
+	require.Empty(t, api.Entrypoint)
+	require.Equal(t, Mapping{"DB_HOST": "db", "PRICE": "$5"}, api.Environment)
+	require.Equal(t, []Port{
+		{Target: 80, Published: "8080", Protocol: "tcp"},
+		{Target: 53, Published: "5353", Protocol: "udp"},
+		{Target: 9090, Published: "9091", Protocol: "tcp"},
+	}, api.Ports)
+	require.Equal(t, []Port{{Target: 3000, Protocol: "tcp"}}, api.Expose)
+	require.Equal(t, []Mount{
+		{Type: MountBind, Source: "./src", Target: "/app/src", ReadOnly: true},
+		{Type: MountVolume, Target: "/tmp/cache"},
+		{Type: MountTmpfs, Target: "/run"},
+	}, api.Volumes)
+	require.Equal(t, DependsOn{"migrate": ConditionCompleted, "db": ConditionStarted}, api.DependsOn)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file string
+		err  string
+	}{
+		"no services": {
+			file: `volumes: {}`,
+			err:  "no services defined",
+		},
+		"no image": {
+			file: `services: {web: {command: ["true"]}}`,
+			err:  "neither an image nor a build",
+		},
+		"undefined volume": {
+			file: `services: {web: {image: alpine, volumes: ["data:/data"]}}`,
+			err:  `refers to undefined volume "data"`,
+		},
+		"undefined dependency": {
+			file: `services: {web: {image: alpine, depends_on: [db]}}`,
+			err:  `depends on undefined service "db"`,
+		},
+		"cycle": {
+			file: `services: {a: {image: alpine, depends_on: [b]}, b: {image: alpine, depends_on: [a]}}`,
+			err:  "dependency cycle: a -> b -> a",
+		},
+		"port range": {
+			file: `services: {web: {image: alpine, ports: ["3000-3005"]}}`,
+			err:  "port ranges are not supported",
+		},
+		"required variable": {
+			file: `services: {web: {image: "${IMAGE:?must be set}"}}`,
+			err:  "required variable IMAGE is missing a value: must be set",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := Load([]byte(tc.file), nil)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"SET": "value", "EMPTY": ""}
+	for in, out := range map[string]string{
+		"plain":                "plain",
+		"$SET/${SET}":          "value/value",
+		"${UNSET}":             "",
+		"${UNSET:-default}":    "default",
+		"${EMPTY:-default}":    "default",
+		"${EMPTY-default}":     "",
+		"${SET:+alt}":          "alt",
+		"${EMPTY+alt}":         "alt",
+		"${UNSET+alt}":         "",
+		"$$SET costs $5 and $": "$SET costs $5 and $",
+	} {
+		res, err := interpolate(in, env)
+		require.NoError(t, err)
+		require.Equal(t, out, res, in)
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	env, err := ParseEnvFile([]byte(`
+# comment
+PG_VERSION=16
+export NAME = "quoted value"
+EMPTY=
+`))
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"PG_VERSION": "16", "NAME": "quoted value", "EMPTY": ""}, env)
+
+	_, err = ParseEnvFile([]byte("nope"))
+	require.ErrorContains(t, err, "line 1")
+}
+
+func TestResolvePath(t *testing.T) {
+	p, err := ResolvePath("stack", "./src")
+	require.NoError(t, err)
+	require.Equal(t, "stack/src", p)
+	p, err = ResolvePath(".", ".")
+	require.NoError(t, err)
+	require.Equal(t, ".", p)
+	_, err = ResolvePath(".", "../src")
+	require.Error(t, err)
+	_, err = ResolvePath(".", "/var/run/docker.sock")
+	require.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package compose
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ParseEnvFile parses a .env file of NAME=VALUE lines, as read by Compose to
+// interpolate variables.
+func ParseEnvFile(data []byte) (map[string]string, error) {
+	env := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected NAME=VALUE", n)
+		}
+		name = strings.TrimSpace(name)
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		env[name] = value
+	}
+	return env, scanner.Err()
+}
+
+// interpolateNode interpolates the variables in all values of the document,
+// leaving the keys alone.
+func interpolateNode(node *yaml.Node, env map[string]string) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value, err := interpolate(node.Value, env)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		node.Value = value
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			if err := interpolateNode(node.Content[i], env); err != nil {
+				return err
+			}
+		}
+	default:
+		for _, child := range node.Content {
+			if err := interpolateNode(child, env); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// interpolate replaces $NAME and ${NAME} in s with the value of the variable,
+// supporting the ${NAME:-default}, ${NAME-default}, ${NAME:+alt},
+// ${NAME+alt}, ${NAME:?err} and ${NAME?err} forms. $$ is a literal $.
+func interpolate(s string, env map[string]string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated variable in %q", s)
+			}
+			value, err := expand(s[i+2:i+end], env)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(value)
+			i += end
+		case isNameStart(next):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			b.WriteString(env[s[i+1:j]])
+			i = j - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	return b.String(), nil
+}
+
+func expand(expr string, env map[string]string) (string, error) {
+	end := 0
+	for end < len(expr) && isNameChar(expr[end]) {
+		end++
+	}
+	name, op := expr[:end], expr[end:]
+	if name == "" || !isNameStart(name[0]) {
+		return "", fmt.Errorf("invalid variable ${%s}", expr)
+	}
+	value, set := env[name]
+	nonEmpty := set && value != ""
+	switch {
+	case op == "":
+		return value, nil
+	case strings.HasPrefix(op, ":-"):
+		if nonEmpty {
+			return value, nil
+		}
+		return op[2:], nil
+	case strings.HasPrefix(op, "-"):
+		if set {
+			return value, nil
+		}
+		return op[1:], nil
+	case strings.HasPrefix(op, ":+"):
+		if nonEmpty {
+			return op[2:], nil
+		}
+		return "", nil
+	case strings.HasPrefix(op, "+"):
+		if set {
+			return op[1:], nil
+		}
+		return "", nil
+	case strings.HasPrefix(op, ":?"):
+		if nonEmpty {
+			return value, nil
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, op[2:])
+	case strings.HasPrefix(op, "?"):
+		if set {
+			return value, nil
+		}
+		return "", fmt.Errorf("required variable %s is missing a value: %s", name, op[1:])
+	default:
+		return "", fmt.Errorf("invalid variable ${%s}", expr)
+	}
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/core/compose"
+	"github.com/dagger/dagger/dagql"
+)
+
+// ComposeStack is the set of services defined by a Compose file.
+type ComposeStack struct {
+	Query *Query
+
+	// Dir is the project directory containing the Compose file.
+	Dir dagql.Instance[*Directory]
+
+	// File is the path of the Compose file in the directory.
+	File string
+
+	Project *compose.Project
+}
+
+func (*ComposeStack) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ComposeStack",
+		NonNull:   true,
+	}
+}
+
+func (*ComposeStack) TypeDescription() string {
+	return "The services defined by a Compose file."
+}
+
+// NewComposeStack loads the Compose file at the given path in the directory,
+// interpolating variables from the .env file next to it, if any.
+func NewComposeStack(ctx context.Context, dir dagql.Instance[*Directory], file string) (*ComposeStack, error) {
+	f, err := dir.Self.File(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	data, err := f.Contents(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	env := map[string]string{}
+	if f, err := dir.Self.File(ctx, path.Join(path.Dir(file), ".env")); err == nil {
+		envData, err := f.Contents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		env, err = compose.ParseEnvFile(envData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse .env file: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	project, err := compose.Load(data, env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load compose file %s: %w", file, err)
+	}
+	return &ComposeStack{
+		Query:   dir.Self.Query,
+		Dir:     dir,
+		File:    file,
+		Project: project,
+	}, nil
+}
+
+// ComposeService returns the definition of the service with the given name.
+func (stack *ComposeStack) ComposeService(name string) (*compose.Service, error) {
+	svc, ok := stack.Project.Services[name]
+	if !ok {
+		return nil, fmt.Errorf("service %q is not defined in %s", name, stack.File)
+	}
+	return svc, nil
+}
+
+// ResolvePath returns the path in the project directory of a path relative
+// to the Compose file.
+func (stack *ComposeStack) ResolvePath(p string) (string, error) {
+	return compose.ResolvePath(path.Dir(stack.File), p)
+}
+
+// ComposeDependencyCondition returns the condition of a Compose dependency.
+func ComposeDependencyCondition(cond string) ServiceDependencyCondition {
+	switch cond {
+	case compose.ConditionHealthy:
+		return ServiceDependencyHealthy
+	case compose.ConditionCompleted:
+		return ServiceDependencyCompleted
+	default:
+		return ServiceDependencyStarted
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"github.com/containerd/containerd/pkg/transfer/archive"
 	"github.com/containerd/platforms"
 	"github.com/distribution/reference"
+	bkcache "github.com/moby/buildkit/cache"
+	bkclient "github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/client/llb/sourceresolver"
 	"github.com/moby/buildkit/exporter/containerimage/exptypes"
@@ -101,6 +103,15 @@ type Container struct {
 
 	// DefaultArgs have been explicitly set by the user
 	DefaultArgs bool
+
+	// SourceDateEpoch is the time in seconds since the Unix epoch that the
+	// timestamps of exported images are clamped to, following the
+	// SOURCE_DATE_EPOCH specification.
+	SourceDateEpoch *int64
+
+	// Healthcheck checks that the container is ready when run as a service,
+	// instead of waiting for its exposed ports.
+	Healthcheck *Healthcheck
 }
 
 func (*Container) Type() *ast.Type {
@@ -238,6 +249,9 @@ type ContainerMount struct {
 	// Persist changes to the mount under this cache ID.
 	CacheVolumeID string
 
+	// The keys of the cache volume, which CacheVolumeID is a checksum of.
+	CacheVolumeKeys []string
+
 	// How to share the cache across concurrent runs.
 	CacheSharingMode CacheSharingMode
 
@@ -680,6 +694,7 @@ func (container *Container) WithMountedCache(ctx context.Context, target string,
 	mount := ContainerMount{
 		Target:           target,
 		CacheVolumeID:    cache.Sum(),
+		CacheVolumeKeys:  cache.Keys,
 		CacheSharingMode: sharingMode,
 	}
 
@@ -1208,12 +1223,28 @@ func (container *Container) WithoutAnnotation(ctx context.Context, name string)
 	return container, nil
 }
 
+func (container *Container) WithSourceDateEpoch(ctx context.Context, epoch int64) (*Container, error) {
+	if epoch < 0 {
+		return nil, fmt.Errorf("source date epoch must not be negative, got %d", epoch)
+	}
+
+	container = container.Clone()
+	container.SourceDateEpoch = &epoch
+
+	// set image ref to empty string
+	container.ImageRef = ""
+
+	return container, nil
+}
+
 func (container *Container) Publish(
 	ctx context.Context,
 	ref string,
 	platformVariants []*Container,
 	forcedCompression ImageLayerCompression,
 	mediaTypes ImageMediaTypes,
+	attestations ImageAttestationOpts,
+	signing ImageSigningOpts,
 ) (string, error) {
 	if mediaTypes == "" {
 		// Modern registry implementations support oci types and docker daemons
@@ -1233,31 +1264,105 @@ func (container *Container) Publish(
 		opts[string(exptypes.OptKeyForceCompression)] = strconv.FormatBool(true)
 	}
 
+	if attestations.Name == "" {
+		attestations.Name = ref
+	}
+	inputByPlatform, services, err := container.imageExportInputs(ctx, platformVariants, opts, attestations)
+	if err != nil {
+		return "", err
+	}
+
+	svcs, err := container.Query.Services(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get services: %w", err)
+	}
+	bk, err := container.Query.Buildkit(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get buildkit client: %w", err)
+	}
+
+	detach, _, err := svcs.StartBindings(ctx, services)
+	if err != nil {
+		return "", err
+	}
+	defer detach()
+
+	resp, err := bk.PublishContainerImage(ctx, inputByPlatform, opts)
+	if err != nil {
+		return "", err
+	}
+
+	refName, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", err
+	}
+
+	imageDigest, found := resp[exptypes.ExporterImageDigestKey]
+	if found {
+		dig, err := digest.Parse(imageDigest)
+		if err != nil {
+			return "", fmt.Errorf("parse digest: %w", err)
+		}
+
+		withDig, err := reference.WithDigest(refName, dig)
+		if err != nil {
+			return "", fmt.Errorf("with digest: %w", err)
+		}
+
+		if signing.Key != nil {
+			if err := signImage(ctx, bk, withDig, signing); err != nil {
+				return "", err
+			}
+		}
+
+		return withDig.String(), nil
+	}
+	if signing.Key != nil {
+		return "", fmt.Errorf("cannot sign image %s: the registry did not return its digest", ref)
+	}
+
+	return ref, nil
+}
+
+// imageExportInputs returns the exporter inputs for the container and its
+// platform variants, setting their annotations in the exporter opts.
+func (container *Container) imageExportInputs(
+	ctx context.Context,
+	platformVariants []*Container,
+	opts map[string]string,
+	attestations ImageAttestationOpts,
+) (map[string]buildkit.ContainerExport, ServiceBindings, error) {
 	inputByPlatform := map[string]buildkit.ContainerExport{}
 	services := ServiceBindings{}
 
 	variants := append([]*Container{container}, platformVariants...)
-	for _, variant := range variants {
+	for i, variant := range variants {
 		if variant.FS == nil {
 			continue
 		}
 		st, err := variant.FSState()
 		if err != nil {
-			return "", err
+			return nil, nil, err
 		}
+
 		platformSpec := variant.Platform.Spec()
 		def, err := st.Marshal(ctx, llb.Platform(platformSpec))
 		if err != nil {
-			return "", err
+			return nil, nil, err
 		}
 
 		platformString := variant.Platform.Format()
 		if _, ok := inputByPlatform[platformString]; ok {
-			return "", fmt.Errorf("duplicate platform %q", platformString)
+			return nil, nil, fmt.Errorf("duplicate platform %q", platformString)
+		}
+		atts, err := variant.imageAttestations(ctx, i, attestations)
+		if err != nil {
+			return nil, nil, err
 		}
 		inputByPlatform[platformString] = buildkit.ContainerExport{
-			Definition: def.ToPB(),
-			Config:     variant.Config,
+			Definition:   def.ToPB(),
+			Config:       variant.Config,
+			Attestations: atts,
 		}
 
 		if len(variants) == 1 {
@@ -1278,53 +1383,122 @@ func (container *Container) Publish(
 	}
 	if len(inputByPlatform) == 0 {
 		// Could also just ignore and do nothing, airing on side of error until proven otherwise.
-		return "", errors.New("no containers to export")
+		return nil, nil, errors.New("no containers to export")
+	}
+
+	// the exporter clamps the timestamps of the whole image, so every
+	// platform must agree on them
+	epoch := container.SourceDateEpoch
+	for _, variant := range platformVariants {
+		if (variant.SourceDateEpoch == nil) != (epoch == nil) ||
+			(epoch != nil && *variant.SourceDateEpoch != *epoch) {
+			return nil, nil, errors.New("platform variants must have the same source date epoch")
+		}
+	}
+	if epoch != nil {
+		opts[string(exptypes.OptKeySourceDateEpoch)] = strconv.FormatInt(*epoch, 10)
+		opts[string(exptypes.OptKeyRewriteTimestamp)] = strconv.FormatBool(true)
 	}
 
+	return inputByPlatform, services, nil
+}
+
+func (container *Container) Export(
+	ctx context.Context,
+	dest string,
+	platformVariants []*Container,
+	forcedCompression ImageLayerCompression,
+	mediaTypes ImageMediaTypes,
+) error {
 	svcs, err := container.Query.Services(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get services: %w", err)
+		return fmt.Errorf("failed to get services: %w", err)
 	}
 	bk, err := container.Query.Buildkit(ctx)
 	if err != nil {
-		return "", fmt.Errorf("failed to get buildkit client: %w", err)
+		return fmt.Errorf("failed to get buildkit client: %w", err)
 	}
 
-	detach, _, err := svcs.StartBindings(ctx, services)
-	if err != nil {
-		return "", err
+	if mediaTypes == "" {
+		// Modern registry implementations support oci types and docker daemons
+		// have been capable of pulling them since 2018:
+		// https://github.com/moby/moby/pull/37359
+		// So they are a safe default.
+		mediaTypes = OCIMediaTypes
 	}
-	defer detach()
 
-	resp, err := bk.PublishContainerImage(ctx, inputByPlatform, opts)
+	opts := map[string]string{
+		"tar":                           strconv.FormatBool(true),
+		string(exptypes.OptKeyOCITypes): strconv.FormatBool(mediaTypes == OCIMediaTypes),
+	}
+	if forcedCompression != "" {
+		opts[string(exptypes.OptKeyLayerCompression)] = strings.ToLower(string(forcedCompression))
+		opts[string(exptypes.OptKeyForceCompression)] = strconv.FormatBool(true)
+	}
+
+	inputByPlatform, services, err := container.imageExportInputs(ctx, platformVariants, opts, ImageAttestationOpts{})
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	refName, err := reference.ParseNormalizedNamed(ref)
+	detach, _, err := svcs.StartBindings(ctx, services)
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer detach()
 
-	imageDigest, found := resp[exptypes.ExporterImageDigestKey]
-	if found {
-		dig, err := digest.Parse(imageDigest)
-		if err != nil {
-			return "", fmt.Errorf("parse digest: %w", err)
-		}
+	_, err = bk.ExportContainerImage(ctx, inputByPlatform, dest, opts)
+	return err
+}
 
-		withDig, err := reference.WithDigest(refName, dig)
-		if err != nil {
-			return "", fmt.Errorf("with digest: %w", err)
+// AsTarball returns the container and its platform variants as an OCI
+// tarball.
+//
+// It must be called within a FSDagOp.
+func (container *Container) AsTarball(
+	ctx context.Context,
+	platformVariants []*Container,
+	forcedCompression ImageLayerCompression,
+	mediaTypes ImageMediaTypes,
+) (_ *File, rerr error) {
+	op, ok := DagOpFromContext[FSDagOp](ctx)
+	if !ok {
+		return nil, fmt.Errorf("no dagop")
+	}
+
+	bkref, err := op.CreateRef(ctx, nil,
+		bkcache.CachePolicyRetain,
+		bkcache.WithRecordType(bkclient.UsageRecordTypeRegular),
+		bkcache.WithDescription(op.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr != nil && bkref != nil {
+			bkref.Release(context.WithoutCancel(ctx))
 		}
+	}()
+	err = op.Mount(ctx, bkref, func(out string) error {
+		return container.writeTarball(ctx, filepath.Join(out, op.Path), platformVariants, forcedCompression, mediaTypes)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("container image to tarball file conversion failed: %w", err)
+	}
 
-		return withDig.String(), nil
+	snap, err := bkref.Commit(ctx)
+	if err != nil {
+		return nil, err
 	}
+	bkref = nil
 
-	return ref, nil
+	f := NewFile(container.Query, nil, op.Path, container.Query.Platform(), nil)
+	f.Result = snap
+	return f, nil
 }
 
-func (container *Container) Export(
+// writeTarball writes the container and its platform variants as an OCI
+// tarball to dest on the engine host.
+func (container *Container) writeTarball(
 	ctx context.Context,
 	dest string,
 	platformVariants []*Container,
@@ -1357,53 +1531,9 @@ func (container *Container) Export(
 		opts[string(exptypes.OptKeyForceCompression)] = strconv.FormatBool(true)
 	}
 
-	inputByPlatform := map[string]buildkit.ContainerExport{}
-	services := ServiceBindings{}
-
-	variants := append([]*Container{container}, platformVariants...)
-	for _, variant := range variants {
-		if variant.FS == nil {
-			continue
-		}
-		st, err := variant.FSState()
-		if err != nil {
-			return err
-		}
-
-		platformSpec := variant.Platform.Spec()
-		def, err := st.Marshal(ctx, llb.Platform(platformSpec))
-		if err != nil {
-			return err
-		}
-
-		platformString := variant.Platform.Format()
-		if _, ok := inputByPlatform[platformString]; ok {
-			return fmt.Errorf("duplicate platform %q", platformString)
-		}
-		inputByPlatform[platformString] = buildkit.ContainerExport{
-			Definition: def.ToPB(),
-			Config:     variant.Config,
-		}
-
-		if len(variants) == 1 {
-			// single platform case
-			for _, annotation := range variant.Annotations {
-				opts[exptypes.AnnotationManifestKey(nil, annotation.Key)] = annotation.Value
-				opts[exptypes.AnnotationManifestDescriptorKey(nil, annotation.Key)] = annotation.Value
-			}
-		} else {
-			// multi platform case
-			for _, annotation := range variant.Annotations {
-				opts[exptypes.AnnotationManifestKey(&platformSpec, annotation.Key)] = annotation.Value
-				opts[exptypes.AnnotationManifestDescriptorKey(&platformSpec, annotation.Key)] = annotation.Value
-			}
-		}
-
-		services.Merge(variant.Services)
-	}
-	if len(inputByPlatform) == 0 {
-		// Could also just ignore and do nothing, airing on side of error until proven otherwise.
-		return errors.New("no containers to export")
+	inputByPlatform, services, err := container.imageExportInputs(ctx, platformVariants, opts, ImageAttestationOpts{})
+	if err != nil {
+		return err
 	}
 
 	detach, _, err := svcs.StartBindings(ctx, services)
@@ -1412,14 +1542,41 @@ func (container *Container) Export(
 	}
 	defer detach()
 
-	_, err = bk.ExportContainerImage(ctx, inputByPlatform, dest, opts)
-	return err
+	err = bk.ContainerImageToTarball(ctx, container.Query.Platform().Spec(), dest, inputByPlatform, opts)
+	if err != nil {
+		return fmt.Errorf("container image to tarball file conversion failed: %w", err)
+	}
+	return nil
 }
 
 func (container *Container) Import(
 	ctx context.Context,
 	source *File,
 	tag string,
+) (*Container, error) {
+	return container.importImage(ctx, tag, func(ctx context.Context, store content.Store) (specs.Descriptor, error) {
+		src, err := source.Open(ctx)
+		if err != nil {
+			return specs.Descriptor{}, err
+		}
+		defer src.Close()
+
+		stream := archive.NewImageImportStream(src, "")
+
+		desc, err := stream.Import(ctx, store)
+		if err != nil {
+			return specs.Descriptor{}, fmt.Errorf("image archive import: %w", err)
+		}
+		return desc, nil
+	})
+}
+
+// importImage sets the container to the image with the given tag from the
+// index loaded into the OCI store by load.
+func (container *Container) importImage(
+	ctx context.Context,
+	tag string,
+	load func(context.Context, content.Store) (specs.Descriptor, error),
 ) (*Container, error) {
 	bk, err := container.Query.Buildkit(ctx)
 	if err != nil {
@@ -1432,24 +1589,15 @@ func (container *Container) Import(
 
 	var release func(context.Context) error
 	loadManifest := func(ctx context.Context) (*specs.Descriptor, error) {
-		src, err := source.Open(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		defer src.Close()
-
 		// override outer ctx with release ctx and set release
 		ctx, release, err = leaseutil.WithLease(ctx, lm, leaseutil.MakeTemporary)
 		if err != nil {
 			return nil, err
 		}
 
-		stream := archive.NewImageImportStream(src, "")
-
-		desc, err := stream.Import(ctx, store)
+		desc, err := load(ctx, store)
 		if err != nil {
-			return nil, fmt.Errorf("image archive import: %w", err)
+			return nil, err
 		}
 
 		return resolveIndex(ctx, store, desc, container.Platform.Spec(), tag)
@@ -1548,6 +1696,22 @@ func (container *Container) WithExposedPort(port Port) (*Container, error) {
 	return container, nil
 }
 
+func (container *Container) WithHealthcheck(hc Healthcheck) (*Container, error) {
+	if err := hc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid health check: %w", err)
+	}
+	container = container.Clone()
+	hc.Args = cloneSlice(hc.Args)
+	container.Healthcheck = &hc
+	return container, nil
+}
+
+func (container *Container) WithoutHealthcheck() *Container {
+	container = container.Clone()
+	container.Healthcheck = nil
+	return container
+}
+
 func (container *Container) WithoutExposedPort(port int, protocol NetworkProtocol) (*Container, error) {
 	container = container.Clone()
 
@@ -0,0 +1,259 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/core/attestation"
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+// SBOMFormat is a GraphQL enum type.
+type SBOMFormat string
+
+var SBOMFormats = dagql.NewEnum[SBOMFormat]()
+
+var (
+	SBOMFormatSPDX      = SBOMFormats.Register("SPDX", "SPDX 2.3 JSON document.")
+	SBOMFormatCycloneDX = SBOMFormats.Register("CYCLONEDX", "CycloneDX 1.5 JSON document.")
+)
+
+func (format SBOMFormat) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "SBOMFormat",
+		NonNull:   true,
+	}
+}
+
+func (format SBOMFormat) TypeDescription() string {
+	return "Format of a software bill of materials."
+}
+
+func (format SBOMFormat) Decoder() dagql.InputDecoder {
+	return SBOMFormats
+}
+
+func (format SBOMFormat) ToLiteral() call.Literal {
+	return SBOMFormats.Literal(format)
+}
+
+// ImageAttestationOpts configures the attestations attached to an image.
+type ImageAttestationOpts struct {
+	// Name is the name of the image described by the attestations.
+	Name string
+
+	// SBOM is the format of the software bill of materials to generate by
+	// scanning the package databases of each platform's filesystem, if any.
+	SBOM SBOMFormat
+
+	// Provenance is the provenance of the container and each of its
+	// platform variants, in order, to attach as SLSA provenance. No
+	// provenance is attached if empty.
+	Provenance []*ImageProvenance
+}
+
+// ImageProvenance describes how an image was built.
+type ImageProvenance struct {
+	// CallDigest is the digest of the call that produced the image.
+	CallDigest digest.Digest
+
+	// Materials are the base images and git commits the image was built
+	// from, in the order they were loaded.
+	Materials []attestation.Material
+}
+
+// NewImageProvenance returns the provenance of the image of the container
+// produced by the given call.
+//
+// It resolves the commits of the git refs loaded by the call and its inputs,
+// and lists the base images pulled by digest, as the call always pins them.
+func NewImageProvenance(ctx context.Context, srv *dagql.Server, id *call.ID) (*ImageProvenance, error) {
+	prov := &ImageProvenance{CallDigest: id.Digest()}
+	seenMaterials := map[string]bool{}
+	addMaterial := func(m attestation.Material) {
+		if seenMaterials[m.URI] || len(prov.Materials) >= attestation.MaxMaterials {
+			return
+		}
+		seenMaterials[m.URI] = true
+		prov.Materials = append(prov.Materials, m)
+	}
+
+	seen := map[digest.Digest]bool{}
+	var visitID func(*call.ID) error
+	var visitLiteral func(call.Literal) error
+	visitLiteral = func(lit call.Literal) error {
+		switch lit := lit.(type) {
+		case *call.LiteralID:
+			return visitID(lit.Value())
+		case *call.LiteralList:
+			return lit.Range(func(_ int, v call.Literal) error {
+				return visitLiteral(v)
+			})
+		case *call.LiteralObject:
+			return lit.Range(func(_ int, _ string, v call.Literal) error {
+				return visitLiteral(v)
+			})
+		}
+		return nil
+	}
+	visitID = func(id *call.ID) error {
+		if id == nil || seen[id.Digest()] {
+			return nil
+		}
+		seen[id.Digest()] = true
+		// visit inputs first, so materials are listed in the order they
+		// were loaded
+		if err := visitID(id.Receiver()); err != nil {
+			return err
+		}
+		for _, arg := range id.Args() {
+			if err := visitLiteral(arg.Value()); err != nil {
+				return err
+			}
+		}
+		m, ok, err := material(ctx, srv, id)
+		if err != nil {
+			return err
+		}
+		if ok {
+			addMaterial(m)
+		}
+		return nil
+	}
+	if err := visitID(id); err != nil {
+		return nil, err
+	}
+	return prov, nil
+}
+
+// material returns the external resource loaded by a call, if any.
+func material(ctx context.Context, srv *dagql.Server, id *call.ID) (attestation.Material, bool, error) {
+	switch {
+	case id.Field() == "from" && id.Type().NamedType() == "Container":
+		var address string
+		for _, arg := range id.Args() {
+			if lit, ok := arg.Value().(*call.LiteralString); ok && arg.Name() == "address" {
+				address = lit.Value()
+			}
+		}
+		ref, err := reference.ParseNormalizedNamed(address)
+		if err != nil {
+			return attestation.Material{}, false, nil
+		}
+		digested, ok := ref.(reference.Digested)
+		if !ok {
+			return attestation.Material{}, false, nil
+		}
+		return attestation.Material{
+			URI: "pkg:docker/" + reference.FamiliarName(ref) + "@" + digested.Digest().String(),
+			Digest: map[string]string{
+				digested.Digest().Algorithm().String(): digested.Digest().Encoded(),
+			},
+		}, true, nil
+	case id.Type().NamedType() == "GitRef":
+		obj, err := srv.Load(ctx, id)
+		if err != nil {
+			return attestation.Material{}, false, err
+		}
+		ref, ok := obj.(dagql.Instance[*GitRef])
+		if !ok {
+			return attestation.Material{}, false, nil
+		}
+		remote, ok := ref.Self.Repo.Backend.(*RemoteGitRepository)
+		if !ok {
+			return attestation.Material{}, false, nil
+		}
+		var commit dagql.String
+		if err := srv.Select(ctx, ref, &commit, dagql.Selector{Field: "commit"}); err != nil {
+			return attestation.Material{}, false, err
+		}
+		uri := remote.URL
+		if u, err := url.Parse(uri); err == nil && u.User != nil {
+			// never leak credentials embedded in the URL
+			u.User = nil
+			uri = u.String()
+		}
+		return attestation.Material{
+			URI:    "git+" + uri + "@" + commit.String(),
+			Digest: map[string]string{"gitCommit": commit.String()},
+		}, true, nil
+	}
+	return attestation.Material{}, false, nil
+}
+
+// imageAttestations returns the attestations to attach to the image of a
+// platform variant; idx is the index of the variant, 0 being the container
+// itself.
+func (variant *Container) imageAttestations(ctx context.Context, idx int, opts ImageAttestationOpts) ([]buildkit.ContainerAttestation, error) {
+	var atts []buildkit.ContainerAttestation
+
+	if opts.SBOM != "" {
+		var pkgs []attestation.Package
+		rootfs, err := variant.RootFS(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = rootfs.mount(ctx, func(root string) error {
+			src, err := fs.RootPath(root, rootfs.Dir)
+			if err != nil {
+				return err
+			}
+			pkgs, err = attestation.ScanPackages(ctx, src)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan packages: %w", err)
+		}
+
+		created := time.Now()
+		if variant.SourceDateEpoch != nil {
+			created = time.Unix(*variant.SourceDateEpoch, 0)
+		}
+		name := opts.Name
+		if name == "" {
+			name = variant.Platform.Format()
+		}
+
+		var att buildkit.ContainerAttestation
+		switch opts.SBOM {
+		case SBOMFormatSPDX:
+			att.PredicateType = attestation.PredicateSPDX
+			att.Predicate, err = attestation.SPDX(name, pkgs, created)
+		case SBOMFormatCycloneDX:
+			att.PredicateType = attestation.PredicateCycloneDX
+			att.Predicate, err = attestation.CycloneDX(name, pkgs, created)
+		default:
+			return nil, fmt.Errorf("unsupported SBOM format %q", opts.SBOM)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate SBOM: %w", err)
+		}
+		att.Reason = "sbom"
+		atts = append(atts, att)
+	}
+
+	if idx < len(opts.Provenance) && opts.Provenance[idx] != nil {
+		prov := opts.Provenance[idx]
+		predicate, err := attestation.Provenance(prov.CallDigest, prov.Materials, engine.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate provenance: %w", err)
+		}
+		atts = append(atts, buildkit.ContainerAttestation{
+			PredicateType: attestation.PredicateProvenance,
+			Predicate:     predicate,
+			Reason:        "provenance",
+		})
+	}
+
+	return atts, nil
+}
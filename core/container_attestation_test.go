@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/core/attestation"
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestImageProvenance(t *testing.T) {
+	ctx := context.Background()
+
+	ctrType := &ast.Type{NamedType: "Container", NonNull: true}
+	dirType := &ast.Type{NamedType: "Directory", NonNull: true}
+	src := call.New().
+		Append(dirType, "directory", "", nil, 0, "").
+		Append(dirType, "withNewFile", "", nil, 0, "",
+			call.NewArgument("path", call.NewLiteralString("main.go"), false),
+			call.NewArgument("contents", call.NewLiteralString("package main // secret sauce"), false))
+	ctr := call.New().
+		Append(ctrType, "container", "", nil, 0, "").
+		Append(ctrType, "from", "", nil, 0, "",
+			call.NewArgument("address", call.NewLiteralString("docker.io/library/alpine:3.19@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b"), false)).
+		Append(ctrType, "withEnvVariable", "", nil, 0, "",
+			call.NewArgument("name", call.NewLiteralString("API_TOKEN"), false),
+			call.NewArgument("value", call.NewLiteralString("hunter2"), false)).
+		Append(ctrType, "withDirectory", "", nil, 0, "",
+			call.NewArgument("path", call.NewLiteralString("/src"), false),
+			call.NewArgument("directory", call.NewLiteralID(src), false))
+
+	// no git refs to resolve, so no server is needed
+	prov, err := NewImageProvenance(ctx, nil, ctr)
+	require.NoError(t, err)
+	require.Equal(t, ctr.Digest(), prov.CallDigest)
+	require.Equal(t, []attestation.Material{
+		{
+			URI:    "pkg:docker/alpine@sha256:c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b",
+			Digest: map[string]string{"sha256": "c5b1261d6d3e43071626931fc004f70149baeba2c8ec672bd4f27761f8e1ad6b"},
+		},
+	}, prov.Materials)
+
+	predicate, err := attestation.Provenance(prov.CallDigest, prov.Materials, "v0.18.0")
+	require.NoError(t, err)
+	for _, literal := range []string{
+		"3.19",
+		"API_TOKEN",
+		"hunter2",
+		"/src",
+		"main.go",
+		"secret sauce",
+	} {
+		require.NotContains(t, string(predicate), literal)
+	}
+}
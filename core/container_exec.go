@@ -2,12 +2,15 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dagger/dagger/dagql"
 	"github.com/dagger/dagger/engine"
@@ -16,6 +19,7 @@ import (
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/identity"
 	"github.com/pkg/errors"
+	"github.com/vektah/gqlparser/v2/ast"
 )
 
 var ErrNoCommand = errors.New("no command has been set")
@@ -40,6 +44,21 @@ type ContainerExecOpts struct {
 	// Exit codes this exec is allowed to exit with
 	Expect ReturnTypes `default:"SUCCESS"`
 
+	// Exact exit codes this exec is allowed to exit with, instead of Expect
+	ExpectExitCodes []int `default:"[]"`
+
+	// Fail the exec if its stdout doesn't match this regular expression
+	ExpectStdoutRegex string `default:""`
+
+	// Fail the exec if its stderr doesn't match this regular expression
+	ExpectStderrRegex string `default:""`
+
+	// Fail the exec if its stdout contains this string
+	ExpectStdoutNotContains string `default:""`
+
+	// Fail the exec if its stderr contains this string
+	ExpectStderrNotContains string `default:""`
+
 	// Provide the executed command access back to the Dagger API
 	ExperimentalPrivilegedNesting bool `default:"false"`
 
@@ -55,6 +74,112 @@ type ContainerExecOpts struct {
 	// Skip the init process injected into containers by default so that the
 	// user's process is PID 1
 	NoInit bool `default:"false"`
+
+	// Resource limits enforced on the command
+	Limits dagql.Optional[dagql.InputObject[ExecLimits]]
+
+	// Kill the command if it runs for longer than this duration
+	Timeout string `default:""`
+
+	// Run the command again when it fails
+	Retry dagql.Optional[dagql.InputObject[ExecRetry]]
+}
+
+// outputAssertions returns the assertions on the output of the exec, if any.
+func (opts ContainerExecOpts) outputAssertions() *buildkit.ExecOutputAssertions {
+	assertions := buildkit.ExecOutputAssertions{
+		StdoutRegex:       opts.ExpectStdoutRegex,
+		StderrRegex:       opts.ExpectStderrRegex,
+		StdoutNotContains: opts.ExpectStdoutNotContains,
+		StderrNotContains: opts.ExpectStderrNotContains,
+	}
+	if assertions == (buildkit.ExecOutputAssertions{}) {
+		return nil
+	}
+	return &assertions
+}
+
+// ExecLimits are the resource limits enforced on an exec.
+type ExecLimits struct {
+	Cpus        *float64 `doc:"Number of CPUs the command may use, possibly fractional (e.g., 0.5)." json:"cpus,omitempty"`
+	MemoryBytes *int     `doc:"Memory the command may use in bytes, beyond which it is killed." json:"memoryBytes,omitempty"`
+	Pids        *int     `doc:"Number of processes and threads the command may run at once." json:"pids,omitempty"`
+}
+
+func (ExecLimits) TypeName() string {
+	return "ExecLimits"
+}
+
+func (ExecLimits) TypeDescription() string {
+	return "Resource limits enforced on a command. A limit of zero is no limit."
+}
+
+// Engine returns the limits to apply with the engine's executor.
+func (limits ExecLimits) Engine() (*buildkit.ExecLimits, error) {
+	var res buildkit.ExecLimits
+	if limits.Cpus != nil {
+		if *limits.Cpus < 0 {
+			return nil, fmt.Errorf("cpus must not be negative, got %v", *limits.Cpus)
+		}
+		res.CPUs = *limits.Cpus
+	}
+	if limits.MemoryBytes != nil {
+		if *limits.MemoryBytes < 0 {
+			return nil, fmt.Errorf("memoryBytes must not be negative, got %d", *limits.MemoryBytes)
+		}
+		res.MemoryBytes = int64(*limits.MemoryBytes)
+	}
+	if limits.Pids != nil {
+		if *limits.Pids < 0 {
+			return nil, fmt.Errorf("pids must not be negative, got %d", *limits.Pids)
+		}
+		res.Pids = int64(*limits.Pids)
+	}
+	return &res, nil
+}
+
+// ExecRetry is the retry policy of an exec.
+type ExecRetry struct {
+	Attempts           int     `doc:"Maximum number of times to run the command, including the first attempt." json:"attempts"`
+	Backoff            *string `doc:"Delay before the first retry as a duration string, doubled after each retry until it reaches a minute. Defaults to retrying immediately." json:"backoff,omitempty"`
+	RetryOnExitCodes   []int   `default:"[]" doc:"Only retry when the command exits with one of these codes." json:"retryOnExitCodes,omitempty"`
+	RetryOnStderrRegex *string `doc:"Only retry when the standard error of the command matches this regular expression (RE2 syntax)." json:"retryOnStderrRegex,omitempty"`
+}
+
+func (ExecRetry) TypeName() string {
+	return "ExecRetry"
+}
+
+func (ExecRetry) TypeDescription() string {
+	return "A policy for running a failed command again."
+}
+
+// Engine returns the retry policy to apply with the engine's executor.
+func (retry ExecRetry) Engine() (*buildkit.ExecRetry, error) {
+	if retry.Attempts < 1 {
+		return nil, fmt.Errorf("attempts must be at least 1, got %d", retry.Attempts)
+	}
+	res := buildkit.ExecRetry{
+		Attempts:  retry.Attempts,
+		ExitCodes: retry.RetryOnExitCodes,
+	}
+	if retry.Backoff != nil && *retry.Backoff != "" {
+		backoff, err := time.ParseDuration(*retry.Backoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backoff: %w", err)
+		}
+		if backoff < 0 {
+			return nil, fmt.Errorf("backoff must not be negative, got %s", *retry.Backoff)
+		}
+		res.Backoff = backoff
+	}
+	if retry.RetryOnStderrRegex != nil && *retry.RetryOnStderrRegex != "" {
+		if _, err := regexp.Compile(*retry.RetryOnStderrRegex); err != nil {
+			return nil, fmt.Errorf("invalid stderr regex: %w", err)
+		}
+		res.StderrRegex = *retry.RetryOnStderrRegex
+	}
+	return &res, nil
 }
 
 func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts) (*Container, error) { //nolint:gocyclo
@@ -107,8 +232,52 @@ func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts
 		runOpts = append(runOpts, llb.AddEnv(buildkit.DaggerNoInitEnv, "true"))
 	}
 
+	if opts.Limits.Valid {
+		execMD.Limits, err = opts.Limits.Value.Value.Engine()
+		if err != nil {
+			return nil, fmt.Errorf("invalid limits: %w", err)
+		}
+		// ensure the limits are in the cache key
+		runOpts = append(runOpts, llb.AddEnv(buildkit.DaggerExecLimitsEnv, execMD.Limits.String()))
+	}
+
+	if opts.Timeout != "" {
+		timeout, err := time.ParseDuration(opts.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		if timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout: must be positive, got %s", opts.Timeout)
+		}
+		execMD.Timeout = timeout
+		// ensure the timeout is in the cache key
+		runOpts = append(runOpts, llb.AddEnv(buildkit.DaggerExecTimeoutEnv, timeout.String()))
+	}
+
+	if assertions := opts.outputAssertions(); assertions != nil {
+		for _, regex := range []string{assertions.StdoutRegex, assertions.StderrRegex} {
+			if _, err := regexp.Compile(regex); err != nil {
+				return nil, fmt.Errorf("invalid output assertion regex: %w", err)
+			}
+		}
+		execMD.OutputAssertions = assertions
+		// ensure the assertions are in the cache key
+		runOpts = append(runOpts, llb.AddEnv(buildkit.DaggerExecAssertionsEnv, assertions.String()))
+	}
+
+	if opts.Retry.Valid {
+		// not part of the cache key: a successful result is the same however
+		// many attempts it took
+		execMD.Retry, err = opts.Retry.Value.Value.Engine()
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry: %w", err)
+		}
+	}
+
+	var modName string
 	mod, err := container.Query.CurrentModule(ctx)
 	if err == nil {
+		modName = mod.Name()
 		if mod.InstanceID == nil {
 			return nil, fmt.Errorf("current module has no instance ID")
 		}
@@ -245,6 +414,7 @@ func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts
 		runOpts = append(runOpts, llb.AddSSHSocket(socketOpts...))
 	}
 
+	cacheVolumes := map[string]buildkit.CacheVolumeMetadata{}
 	for _, mnt := range mounts {
 		srcSt, err := mnt.SourceState()
 		if err != nil {
@@ -271,6 +441,17 @@ func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts
 			}
 
 			mountOpts = append(mountOpts, llb.AsPersistentCacheDir(mnt.CacheVolumeID, sharingMode))
+
+			if execMD.Retry != nil {
+				// changes to cache volumes are kept across attempts
+				execMD.Retry.CacheMountPaths = append(execMD.Retry.CacheMountPaths, mnt.Target)
+			}
+
+			cacheVolumes[mnt.CacheVolumeID] = buildkit.CacheVolumeMetadata{
+				Keys:        mnt.CacheVolumeKeys,
+				SharingMode: string(mnt.CacheSharingMode),
+				Module:      modName,
+			}
 		}
 
 		if mnt.Tmpfs {
@@ -284,7 +465,13 @@ func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts
 		runOpts = append(runOpts, llb.AddMount(mnt.Target, srcSt, mountOpts...))
 	}
 
-	if opts.Expect != ReturnSuccess {
+	switch {
+	case len(opts.ExpectExitCodes) > 0:
+		if opts.Expect != ReturnSuccess {
+			return nil, fmt.Errorf("expect and expectExitCodes are mutually exclusive")
+		}
+		runOpts = append(runOpts, llb.ValidExitCodes(opts.ExpectExitCodes...))
+	case opts.Expect != ReturnSuccess:
 		runOpts = append(runOpts, llb.ValidExitCodes(opts.Expect.ReturnCodes()...))
 	}
 
@@ -297,6 +484,10 @@ func (container *Container) WithExec(ctx context.Context, opts ContainerExecOpts
 		return nil, fmt.Errorf("fs state: %w", err)
 	}
 
+	if len(cacheVolumes) > 0 {
+		execMD.CacheVolumes = cacheVolumes
+	}
+
 	execMDOpt, err := execMD.AsConstraintsOpt()
 	if err != nil {
 		return nil, fmt.Errorf("execution metadata: %w", err)
@@ -369,6 +560,46 @@ func (container *Container) ExitCode(ctx context.Context) (int, error) {
 	return int(code), nil
 }
 
+// ExecMetrics is the resource usage of an executed command.
+type ExecMetrics struct {
+	DurationMicroseconds int `field:"true" name:"durationMicroseconds" doc:"The wall-clock duration of the command in microseconds."`
+	CPUTimeMicroseconds  int `field:"true" name:"cpuTimeMicroseconds" doc:"The CPU time used by the command in microseconds."`
+	MemoryPeakBytes      int `field:"true" name:"memoryPeakBytes" doc:"The peak memory usage of the command in bytes."`
+	IOReadBytes          int `field:"true" name:"ioReadBytes" doc:"The number of bytes read from disk by the command."`
+	IOWriteBytes         int `field:"true" name:"ioWriteBytes" doc:"The number of bytes written to disk by the command."`
+}
+
+func (*ExecMetrics) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ExecMetrics",
+		NonNull:   true,
+	}
+}
+
+func (*ExecMetrics) TypeDescription() string {
+	return "The resource usage of an executed command."
+}
+
+func (container *Container) ExecMetrics(ctx context.Context) (*ExecMetrics, error) {
+	contents, err := container.metaFileContents(ctx, buildkit.MetaMountMetricsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics buildkit.ExecMetrics
+	if err := json.Unmarshal([]byte(contents), &metrics); err != nil {
+		return nil, fmt.Errorf("could not parse exec metrics: %w", err)
+	}
+
+	return &ExecMetrics{
+		DurationMicroseconds: int(metrics.DurationMicroseconds),
+		CPUTimeMicroseconds:  int(metrics.CPUTimeMicroseconds),
+		MemoryPeakBytes:      int(metrics.MemoryPeakBytes),
+		IOReadBytes:          int(metrics.IOReadBytes),
+		IOWriteBytes:         int(metrics.IOWriteBytes),
+	}, nil
+}
+
 func (container *Container) usedClientID(ctx context.Context) (string, error) {
 	return container.metaFileContents(ctx, buildkit.MetaMountClientIDPath)
 }
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/distribution/reference"
+
+	"github.com/dagger/dagger/core/cosign"
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+// ImageSigningOpts configures the signing of a published image.
+type ImageSigningOpts struct {
+	// Key is the PEM encoded private key to sign the image with, if any.
+	Key []byte
+
+	// Password decrypts the private key, if encrypted.
+	Password []byte
+}
+
+// signImage signs the published image and pushes its signature to the
+// registry in the cosign format.
+func signImage(ctx context.Context, bk *buildkit.Client, ref reference.Canonical, opts ImageSigningOpts) error {
+	signer, err := cosign.LoadPrivateKey(opts.Key, opts.Password)
+	if err != nil {
+		return fmt.Errorf("failed to load signing key: %w", err)
+	}
+	resolver := bk.RegistryResolver(ref.String(), true)
+	if err := cosign.SignImage(ctx, resolver, ref, ref.Digest(), signer); err != nil {
+		return fmt.Errorf("failed to sign image %s: %w", ref, err)
+	}
+	return nil
+}
+
+// VerifyImageSignature checks that the image has a cosign signature made with
+// the private key of the given PEM encoded public key.
+func (container *Container) VerifyImageSignature(ctx context.Context, ref reference.Canonical, key []byte) error {
+	pub, err := cosign.LoadPublicKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to load verification key: %w", err)
+	}
+	bk, err := container.Query.Buildkit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get buildkit client: %w", err)
+	}
+	resolver := bk.RegistryResolver(ref.String(), false)
+	if err := cosign.VerifyImage(ctx, resolver, ref, ref.Digest(), pub); err != nil {
+		return fmt.Errorf("failed to verify image %s: %w", ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package cosign
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/distribution/reference"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+func encodePrivateKey(t *testing.T, key crypto.Signer, password []byte) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	if password == nil {
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	var enc encryptedKey
+	enc.KDF.Name = "scrypt"
+	enc.KDF.Params.N = 1 << 10
+	enc.KDF.Params.R = 8
+	enc.KDF.Params.P = 1
+	enc.KDF.Salt = make([]byte, 32)
+	_, err = rand.Read(enc.KDF.Salt)
+	require.NoError(t, err)
+	enc.Cipher.Name = "nacl/secretbox"
+	enc.Cipher.Nonce = make([]byte, 24)
+	_, err = rand.Read(enc.Cipher.Nonce)
+	require.NoError(t, err)
+
+	derived, err := scrypt.Key(password, enc.KDF.Salt, enc.KDF.Params.N, enc.KDF.Params.R, enc.KDF.Params.P, 32)
+	require.NoError(t, err)
+	var secret [32]byte
+	copy(secret[:], derived)
+	var nonce [24]byte
+	copy(nonce[:], enc.Cipher.Nonce)
+	enc.Ciphertext = secretbox.Seal(nil, der, &nonce, &secret)
+
+	data, err := json.Marshal(enc)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: encryptedSigstoreKeyType, Bytes: data})
+}
+
+func encodePublicKey(t *testing.T, key crypto.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func TestKeys(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{
+		"ecdsa":   ecKey,
+		"rsa":     rsaKey,
+		"ed25519": edKey,
+	} {
+		t.Run(name, func(t *testing.T) {
+			signer, err := LoadPrivateKey(encodePrivateKey(t, key, nil), nil)
+			require.NoError(t, err)
+			pub, err := LoadPublicKey(encodePublicKey(t, key.Public()))
+			require.NoError(t, err)
+
+			payload, err := NewPayload("registry.example.com/foo", digest.FromString("image"))
+			require.NoError(t, err)
+			sig, err := Sign(signer, payload)
+			require.NoError(t, err)
+			require.NoError(t, Verify(pub, payload, sig))
+			require.Error(t, Verify(pub, append(payload, ' '), sig))
+		})
+	}
+
+	t.Run("encrypted", func(t *testing.T) {
+		data := encodePrivateKey(t, ecKey, []byte("hunter2"))
+
+		signer, err := LoadPrivateKey(data, []byte("hunter2"))
+		require.NoError(t, err)
+		require.True(t, ecKey.Equal(signer))
+
+		_, err = LoadPrivateKey(data, []byte("wrong"))
+		require.ErrorContains(t, err, "wrong password")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := LoadPrivateKey([]byte("not a key"), nil)
+		require.Error(t, err)
+		_, err = LoadPublicKey(encodePrivateKey(t, ecKey, nil))
+		require.ErrorContains(t, err, "unsupported public key type")
+	})
+}
+
+func TestPayload(t *testing.T) {
+	dgst := digest.FromString("image")
+	payload, err := NewPayload("registry.example.com/foo", dgst)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"critical": {
+			"identity": {"docker-reference": "registry.example.com/foo"},
+			"image": {"docker-manifest-digest": "`+dgst.String()+`"},
+			"type": "cosign container image signature"
+		},
+		"optional": null
+	}`, string(payload))
+	require.Equal(t, "sha256-"+dgst.Encoded()+".sig", SignatureTag(dgst))
+}
+
+func TestSignAndVerifyImage(t *testing.T) {
+	ctx := context.Background()
+
+	srv := httptest.NewServer(registry.New())
+	t.Cleanup(srv.Close)
+	host := strings.TrimPrefix(srv.URL, "http://")
+	resolver := docker.NewResolver(docker.ResolverOptions{
+		Hosts: docker.ConfigureDefaultRegistries(docker.WithPlainHTTP(docker.MatchAllHosts)),
+	})
+
+	repo, err := reference.ParseNormalizedNamed(host + "/foo:latest")
+	require.NoError(t, err)
+	dgst := digest.FromString("image")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	err = VerifyImage(ctx, resolver, repo, dgst, key.Public())
+	require.ErrorContains(t, err, "is not signed")
+
+	require.NoError(t, SignImage(ctx, resolver, repo, dgst, key))
+	require.NoError(t, VerifyImage(ctx, resolver, repo, dgst, key.Public()))
+
+	err = VerifyImage(ctx, resolver, repo, dgst, otherKey.Public())
+	require.ErrorContains(t, err, "matches the key")
+	err = VerifyImage(ctx, resolver, repo, digest.FromString("other image"), key.Public())
+	require.ErrorContains(t, err, "is not signed")
+
+	// signatures are appended to the existing ones
+	require.NoError(t, SignImage(ctx, resolver, repo, dgst, otherKey))
+	require.NoError(t, VerifyImage(ctx, resolver, repo, dgst, key.Public()))
+	require.NoError(t, VerifyImage(ctx, resolver, repo, dgst, otherKey.Public()))
+}
@@ -0,0 +1,148 @@
+// Package cosign signs images and verifies their signatures in the format of
+// cosign, with signatures stored in the registry next to the images they
+// sign.
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PEM block types of the keys generated by cosign.
+const (
+	encryptedSigstoreKeyType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	encryptedCosignKeyType   = "ENCRYPTED COSIGN PRIVATE KEY"
+)
+
+// encryptedKey is the contents of an encrypted cosign private key: a PKCS #8
+// key encrypted with NaCl secretbox, using a key derived from the password
+// with scrypt.
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// LoadPrivateKey parses a PEM encoded private key, either generated by cosign
+// and encrypted with the given password, or an unencrypted PKCS #8, EC or
+// RSA private key.
+func LoadPrivateKey(data, password []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid private key: no PEM block found")
+	}
+
+	var key any
+	var err error
+	switch block.Type {
+	case encryptedSigstoreKeyType, encryptedCosignKeyType:
+		var der []byte
+		der, err = decryptKey(block.Bytes, password)
+		if err != nil {
+			return nil, err
+		}
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	case ed25519.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key algorithm %T", key)
+	}
+}
+
+func decryptKey(data, password []byte) ([]byte, error) {
+	var enc encryptedKey
+	if err := json.Unmarshal(data, &enc); err != nil {
+		return nil, fmt.Errorf("invalid encrypted private key: %w", err)
+	}
+	if enc.KDF.Name != "scrypt" {
+		return nil, fmt.Errorf("unsupported key derivation function %q", enc.KDF.Name)
+	}
+	if enc.Cipher.Name != "nacl/secretbox" {
+		return nil, fmt.Errorf("unsupported cipher %q", enc.Cipher.Name)
+	}
+	if len(enc.Cipher.Nonce) != 24 {
+		return nil, errors.New("invalid encrypted private key: bad nonce length")
+	}
+
+	derived, err := scrypt.Key(password, enc.KDF.Salt, enc.KDF.Params.N, enc.KDF.Params.R, enc.KDF.Params.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encrypted private key: %w", err)
+	}
+	var key [32]byte
+	copy(key[:], derived)
+	var nonce [24]byte
+	copy(nonce[:], enc.Cipher.Nonce)
+
+	der, ok := secretbox.Open(nil, enc.Ciphertext, &nonce, &key)
+	if !ok {
+		return nil, errors.New("failed to decrypt private key: wrong password?")
+	}
+	return der, nil
+}
+
+// LoadPublicKey parses a PEM encoded public key, as exported by cosign.
+func LoadPublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid public key: no PEM block found")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %q", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+
+	switch key := key.(type) {
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key algorithm %T", key)
+	}
+}
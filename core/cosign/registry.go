@@ -0,0 +1,205 @@
+package cosign
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/remotes"
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// maxBlobSize is the maximum size of the manifests and payloads read from
+// signature manifests.
+const maxBlobSize = 4 << 20
+
+// SignImage signs the image with the given manifest digest in the repository
+// of the reference, and pushes the signature next to the existing signatures
+// of the image.
+func SignImage(ctx context.Context, resolver remotes.Resolver, repo reference.Named, dgst digest.Digest, signer crypto.Signer) error {
+	sigRef := signatureRef(repo, dgst)
+
+	payload, err := NewPayload(repo.Name(), dgst)
+	if err != nil {
+		return err
+	}
+	sig, err := Sign(signer, payload)
+	if err != nil {
+		return fmt.Errorf("failed to sign image: %w", err)
+	}
+
+	manifest, err := fetchSignatureManifest(ctx, resolver, sigRef)
+	if err != nil {
+		return err
+	}
+	if manifest == nil {
+		manifest = &ocispecs.Manifest{}
+		manifest.SchemaVersion = 2
+	}
+	manifest.MediaType = ocispecs.MediaTypeImageManifest
+
+	payloadDesc := ocispecs.Descriptor{
+		MediaType:   PayloadMediaType,
+		Digest:      digest.FromBytes(payload),
+		Size:        int64(len(payload)),
+		Annotations: map[string]string{SignatureAnnotation: sig},
+	}
+	manifest.Layers = append(manifest.Layers, payloadDesc)
+
+	// the payloads are uncompressed, so the diff IDs are the layer digests
+	config := ocispecs.Image{RootFS: ocispecs.RootFS{Type: "layers"}}
+	for _, layer := range manifest.Layers {
+		config.RootFS.DiffIDs = append(config.RootFS.DiffIDs, layer.Digest)
+	}
+	configBytes, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	manifest.Config = ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageConfig,
+		Digest:    digest.FromBytes(configBytes),
+		Size:      int64(len(configBytes)),
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	manifestDesc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifestBytes),
+		Size:      int64(len(manifestBytes)),
+	}
+
+	pusher, err := resolver.Pusher(ctx, sigRef)
+	if err != nil {
+		return err
+	}
+	for _, blob := range []struct {
+		desc ocispecs.Descriptor
+		data []byte
+	}{
+		{payloadDesc, payload},
+		{manifest.Config, configBytes},
+		// the manifest goes last, once everything it references is pushed
+		{manifestDesc, manifestBytes},
+	} {
+		if err := pushBlob(ctx, pusher, blob.desc, blob.data); err != nil {
+			return fmt.Errorf("failed to push signature to %s: %w", sigRef, err)
+		}
+	}
+	return nil
+}
+
+// VerifyImage checks that the image with the given manifest digest in the
+// repository of the reference has a signature made with the key.
+func VerifyImage(ctx context.Context, resolver remotes.Resolver, repo reference.Named, dgst digest.Digest, pub crypto.PublicKey) error {
+	sigRef := signatureRef(repo, dgst)
+	manifest, err := fetchSignatureManifest(ctx, resolver, sigRef)
+	if err != nil {
+		return err
+	}
+	if manifest == nil || len(manifest.Layers) == 0 {
+		return fmt.Errorf("image %s@%s is not signed", repo.Name(), dgst)
+	}
+
+	fetcher, err := resolver.Fetcher(ctx, sigRef)
+	if err != nil {
+		return err
+	}
+	verifyErr := fmt.Errorf("no signature of image %s@%s matches the key", repo.Name(), dgst)
+	for _, layer := range manifest.Layers {
+		sig, ok := layer.Annotations[SignatureAnnotation]
+		if layer.MediaType != PayloadMediaType || !ok {
+			continue
+		}
+		payload, err := fetchBlob(ctx, fetcher, layer)
+		if err != nil {
+			return err
+		}
+		if err := Verify(pub, payload, sig); err != nil {
+			continue
+		}
+		var p Payload
+		if err := json.Unmarshal(payload, &p); err != nil {
+			verifyErr = fmt.Errorf("invalid signature payload: %w", err)
+			continue
+		}
+		if p.Critical.Image.DockerManifestDigest != dgst.String() {
+			verifyErr = fmt.Errorf("signature is for image digest %s, not %s", p.Critical.Image.DockerManifestDigest, dgst)
+			continue
+		}
+		return nil
+	}
+	return verifyErr
+}
+
+func signatureRef(repo reference.Named, dgst digest.Digest) string {
+	return reference.TrimNamed(repo).String() + ":" + SignatureTag(dgst)
+}
+
+// fetchSignatureManifest returns the signature manifest at the reference, or
+// nil if there is none.
+func fetchSignatureManifest(ctx context.Context, resolver remotes.Resolver, sigRef string) (*ocispecs.Manifest, error) {
+	name, desc, err := resolver.Resolve(ctx, sigRef)
+	if err != nil {
+		if errdefs.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to resolve signatures %s: %w", sigRef, err)
+	}
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := fetchBlob(ctx, fetcher, desc)
+	if err != nil {
+		return nil, err
+	}
+	var manifest ocispecs.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid signature manifest %s: %w", sigRef, err)
+	}
+	return &manifest, nil
+}
+
+func fetchBlob(ctx context.Context, fetcher remotes.Fetcher, desc ocispecs.Descriptor) ([]byte, error) {
+	if desc.Size > maxBlobSize {
+		return nil, fmt.Errorf("blob %s is too large: %d bytes", desc.Digest, desc.Size)
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", desc.Digest, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxBlobSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", desc.Digest, err)
+	}
+	if digest.FromBytes(data) != desc.Digest {
+		return nil, fmt.Errorf("blob %s does not match its digest", desc.Digest)
+	}
+	return data, nil
+}
+
+func pushBlob(ctx context.Context, pusher remotes.Pusher, desc ocispecs.Descriptor, data []byte) error {
+	w, err := pusher.Push(ctx, desc)
+	if err != nil {
+		if errdefs.IsAlreadyExists(err) {
+			return nil
+		}
+		return err
+	}
+	defer w.Close()
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Commit(ctx, desc.Size, desc.Digest); err != nil && !errdefs.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
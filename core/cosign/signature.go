@@ -0,0 +1,101 @@
+package cosign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// PayloadMediaType is the media type of the layers of a signature
+	// manifest, holding the signed payloads.
+	PayloadMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+
+	// SignatureAnnotation is the annotation of a payload layer holding the
+	// base64 encoded signature of the payload.
+	SignatureAnnotation = "dev.cosignproject.cosign/signature"
+
+	payloadType = "cosign container image signature"
+)
+
+// Payload is the simple signing payload signed for an image.
+type Payload struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]any `json:"optional"`
+}
+
+// NewPayload returns the payload to sign for the image with the given
+// manifest digest in the given repository.
+func NewPayload(repo string, dgst digest.Digest) ([]byte, error) {
+	var payload Payload
+	payload.Critical.Identity.DockerReference = repo
+	payload.Critical.Image.DockerManifestDigest = dgst.String()
+	payload.Critical.Type = payloadType
+	return json.Marshal(payload)
+}
+
+// SignatureTag returns the tag of the signature manifest of the image with
+// the given manifest digest.
+func SignatureTag(dgst digest.Digest) string {
+	return fmt.Sprintf("%s-%s.sig", dgst.Algorithm(), dgst.Encoded())
+}
+
+// Sign returns the base64 encoded signature of the payload.
+func Sign(signer crypto.Signer, payload []byte) (string, error) {
+	var sig []byte
+	var err error
+	switch signer.(type) {
+	case ed25519.PrivateKey:
+		sig, err = signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	default:
+		sum := sha256.Sum256(payload)
+		sig, err = signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+	}
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify checks the base64 encoded signature of the payload.
+func Verify(pub crypto.PublicKey, payload []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, sum[:], sig) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig); err != nil {
+			return errors.New("invalid signature")
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, payload, sig) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key algorithm %T", pub)
+	}
+	return nil
+}
@@ -11,7 +11,9 @@ import (
 	"syscall"
 	"time"
 
+	continuityfs "github.com/containerd/continuity/fs"
 	bkcache "github.com/moby/buildkit/cache"
+	bkclient "github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/client/llb"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/solver/pb"
@@ -21,6 +23,7 @@ import (
 	"github.com/vektah/gqlparser/v2/ast"
 
 	"dagger.io/dagger/telemetry"
+	"github.com/dagger/dagger/core/patch"
 	"github.com/dagger/dagger/dagql"
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/buildkit"
@@ -743,6 +746,73 @@ func (dir *Directory) Diff(ctx context.Context, other *Directory) (*Directory, e
 	return dir, nil
 }
 
+// WithPatch applies a unified diff to the directory, failing without any
+// change if some hunks can't be applied.
+//
+// It must be called within a FSDagOp.
+func (dir *Directory) WithPatch(ctx context.Context, patchText string, opts patch.ApplyOpts) (_ *Directory, rerr error) {
+	diffs, err := patch.Parse(patchText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patch: %w", err)
+	}
+
+	op, ok := DagOpFromContext[FSDagOp](ctx)
+	if !ok {
+		return nil, fmt.Errorf("no dagop")
+	}
+
+	var parentRef bkcache.ImmutableRef
+	res, err := dir.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		ref, err := res.SingleRef()
+		if err != nil {
+			return nil, err
+		}
+		if ref != nil {
+			parentRef, err = ref.CacheRef(ctx)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	bkref, err := op.CreateRef(ctx, parentRef,
+		bkcache.CachePolicyRetain,
+		bkcache.WithRecordType(bkclient.UsageRecordTypeRegular),
+		bkcache.WithDescription(op.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr != nil && bkref != nil {
+			bkref.Release(context.WithoutCancel(ctx))
+		}
+	}()
+	err = op.Mount(ctx, bkref, func(root string) error {
+		src, err := continuityfs.RootPath(root, dir.Dir)
+		if err != nil {
+			return err
+		}
+		return patch.ApplyDir(src, diffs, opts)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	snap, err := bkref.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bkref = nil
+
+	dir = NewDirectory(dir.Query, nil, dir.Dir, dir.Platform, nil)
+	dir.Result = snap
+	return dir, nil
+}
+
 func (dir *Directory) Without(ctx context.Context, paths ...string) (*Directory, error) {
 	dir = dir.Clone()
 
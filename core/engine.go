@@ -2,6 +2,9 @@ package core
 
 import (
 	"context"
+	"regexp"
+	"slices"
+	"time"
 
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/vektah/gqlparser/v2/ast"
@@ -66,6 +69,8 @@ func (*EngineCacheEntrySet) Evaluate(context.Context) (*buildkit.Result, error)
 }
 
 type EngineCacheEntry struct {
+	EntryID                   string `field:"true" name:"entryID" doc:"The identifier of the cache entry, which can be used to prune it."`
+	RecordType                string `field:"true" doc:"The type of the cache entry (e.g. regular, exec.cachemount, source.local)."`
 	Description               string `field:"true" doc:"The description of the cache entry."`
 	DiskSpaceBytes            int    `field:"true" doc:"The disk space used by the cache entry."`
 	CreatedTimeUnixNano       int    `field:"true" doc:"The time the cache entry was created, in Unix nanoseconds."`
@@ -83,3 +88,113 @@ func (*EngineCacheEntry) Type() *ast.Type {
 func (*EngineCacheEntry) TypeDescription() string {
 	return "An individual cache entry in a cache entry set"
 }
+
+type EngineCacheVolume struct {
+	Keys                      []string         `field:"true" doc:"The keys identifying the cache volume."`
+	SharingMode               CacheSharingMode `field:"true" doc:"The sharing mode the cache volume was most recently mounted with."`
+	DiskSpaceBytes            int              `field:"true" doc:"The disk space used by the cache volume."`
+	MostRecentUseTimeUnixNano int              `field:"true" doc:"The most recent time the cache volume was used, in Unix nanoseconds."`
+	ActivelyUsed              bool             `field:"true" doc:"Whether the cache volume is actively being used."`
+	EntryIDs                  []string         `field:"true" name:"entryIDs" doc:"The IDs of the cache entries holding the contents of the cache volume."`
+}
+
+func (*EngineCacheVolume) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "EngineCacheVolume",
+		NonNull:   true,
+	}
+}
+
+func (*EngineCacheVolume) TypeDescription() string {
+	return "A cache volume in the local cache"
+}
+
+// The reasons a call may not have been cached.
+const (
+	CacheMissFirstSeen       = "FIRST_SEEN"
+	CacheMissArgumentChanged = "ARGUMENT_CHANGED"
+	CacheMissContentChanged  = "CONTENT_CHANGED"
+	CacheMissCachePolicy     = "CACHE_POLICY"
+	CacheMissExpired         = "EXPIRED"
+	CacheMissUnknown         = "UNKNOWN"
+)
+
+type EngineCacheMiss struct {
+	Reason       string `field:"true" doc:"Why the call was not cached: FIRST_SEEN, ARGUMENT_CHANGED, CONTENT_CHANGED, CACHE_POLICY, EXPIRED or UNKNOWN."`
+	Description  string `field:"true" doc:"A human readable explanation of the cache miss."`
+	Call         string `field:"true" doc:"The digest of the call that was not cached."`
+	PreviousCall string `field:"true" doc:"The digest of the most similar previous call it was compared against, if any."`
+	ChangedCall  string `field:"true" doc:"The call, within the inputs of the call, that changed or has a cache policy, if any."`
+	Argument     string `field:"true" doc:"The argument of the changed call that changed, if any."`
+}
+
+func (*EngineCacheMiss) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "EngineCacheMiss",
+		NonNull:   true,
+	}
+}
+
+func (*EngineCacheMiss) TypeDescription() string {
+	return "An explanation of why a call was not cached"
+}
+
+// EngineCachePruneOpts selects the entries to prune from a cache. Entries
+// must match every filter that is set.
+type EngineCachePruneOpts struct {
+	// Only prune entries created at least this long ago.
+	OlderThan time.Duration
+	// Only prune entries not used for at least this long.
+	UnusedFor time.Duration
+	// Only prune entries whose description matches.
+	DescriptionRegex *regexp.Regexp
+	// Only prune entries of these record types.
+	RecordTypes []string
+	// Only prune entries using at least this much disk space.
+	MinSizeBytes int
+	// Only prune the entries with these IDs.
+	EntryIDs []string
+
+	// Return the entries that would be pruned without pruning them.
+	DryRun bool
+}
+
+// Filtered returns whether any filter is set; otherwise everything that is
+// releasable is pruned.
+func (opts EngineCachePruneOpts) Filtered() bool {
+	return opts.OlderThan > 0 ||
+		opts.UnusedFor > 0 ||
+		opts.DescriptionRegex != nil ||
+		len(opts.RecordTypes) > 0 ||
+		opts.MinSizeBytes > 0 ||
+		len(opts.EntryIDs) > 0
+}
+
+// Matches returns whether the entry matches every filter that is set.
+func (opts EngineCachePruneOpts) Matches(ent *EngineCacheEntry, now time.Time) bool {
+	if opts.OlderThan > 0 && now.Sub(time.Unix(0, int64(ent.CreatedTimeUnixNano))) < opts.OlderThan {
+		return false
+	}
+	if opts.UnusedFor > 0 {
+		lastUsed := ent.MostRecentUseTimeUnixNano
+		if lastUsed == 0 {
+			lastUsed = ent.CreatedTimeUnixNano
+		}
+		if now.Sub(time.Unix(0, int64(lastUsed))) < opts.UnusedFor {
+			return false
+		}
+	}
+	if opts.DescriptionRegex != nil && !opts.DescriptionRegex.MatchString(ent.Description) {
+		return false
+	}
+	if len(opts.RecordTypes) > 0 && !slices.Contains(opts.RecordTypes, ent.RecordType) {
+		return false
+	}
+	if ent.DiskSpaceBytes < opts.MinSizeBytes {
+		return false
+	}
+	if len(opts.EntryIDs) > 0 && !slices.Contains(opts.EntryIDs, ent.EntryID) {
+		return false
+	}
+	return true
+}
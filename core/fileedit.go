@@ -0,0 +1,194 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bkcache "github.com/moby/buildkit/cache"
+	bkclient "github.com/moby/buildkit/client"
+
+	"github.com/dagger/dagger/core/patch"
+)
+
+// WithEditedContents returns the file with its contents transformed by edit,
+// keeping its name, permissions, ownership and timestamps.
+//
+// It must be called within a FSDagOp, and stores the result in a new snapshot
+// so it can be cached without sending the contents over the wire.
+func (file *File) WithEditedContents(ctx context.Context, edit func([]byte) ([]byte, error)) (_ *File, rerr error) {
+	op, ok := DagOpFromContext[FSDagOp](ctx)
+	if !ok {
+		return nil, fmt.Errorf("no dagop")
+	}
+
+	contents, err := file.Contents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	st, err := file.Stat(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contents, err = edit(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	bkref, err := op.CreateRef(ctx, nil,
+		bkcache.CachePolicyRetain,
+		bkcache.WithRecordType(bkclient.UsageRecordTypeRegular),
+		bkcache.WithDescription(op.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr != nil && bkref != nil {
+			bkref.Release(context.WithoutCancel(ctx))
+		}
+	}()
+	err = op.Mount(ctx, bkref, func(out string) error {
+		dest := filepath.Join(out, op.Path)
+		if err := os.WriteFile(dest, contents, os.FileMode(st.Mode).Perm()); err != nil {
+			return err
+		}
+		// chmod again, since WriteFile is subject to the umask
+		if err := os.Chmod(dest, os.FileMode(st.Mode).Perm()); err != nil {
+			return err
+		}
+		if err := os.Lchown(dest, int(st.Uid), int(st.Gid)); err != nil {
+			return err
+		}
+		mtime := time.Unix(0, st.ModTime)
+		return os.Chtimes(dest, mtime, mtime)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	snap, err := bkref.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bkref = nil
+
+	f := NewFile(file.Query, nil, op.Path, file.Platform, nil)
+	f.Result = snap
+	return f, nil
+}
+
+// ReplaceContents replaces occurrences of search in contents.
+//
+// Unless all is set, exactly one occurrence is replaced: the first one
+// starting at line firstFrom if set, or else the only one, erroring if
+// there are several to avoid ambiguous edits.
+func ReplaceContents(contents []byte, search, replacement string, all bool, firstFrom *int) ([]byte, error) {
+	if search == "" {
+		return nil, errors.New("search string must not be empty")
+	}
+	s := string(contents)
+	count := strings.Count(s, search)
+	if count == 0 {
+		return nil, fmt.Errorf("search string not found")
+	}
+
+	if all {
+		return []byte(strings.ReplaceAll(s, search, replacement)), nil
+	}
+
+	if firstFrom != nil {
+		if *firstFrom < 1 {
+			return nil, fmt.Errorf("firstFrom must be at least 1, got %d", *firstFrom)
+		}
+		lineStart := lineOffset(s, *firstFrom)
+		if lineStart < 0 {
+			return nil, fmt.Errorf("firstFrom %d is past the end of the file", *firstFrom)
+		}
+		idx := strings.Index(s[lineStart:], search)
+		if idx == -1 {
+			return nil, fmt.Errorf("search string not found at or after line %d", *firstFrom)
+		}
+		idx += lineStart
+		return []byte(s[:idx] + replacement + s[idx+len(search):]), nil
+	}
+
+	if count > 1 {
+		var lines []string
+		for offset := 0; ; {
+			idx := strings.Index(s[offset:], search)
+			if idx == -1 {
+				break
+			}
+			offset += idx
+			lines = append(lines, fmt.Sprint(strings.Count(s[:offset], "\n")+1))
+			offset += len(search)
+		}
+		return nil, fmt.Errorf("search string found %d times (at lines %s): set all to replace every occurrence, or firstFrom to pick one", count, strings.Join(lines, ", "))
+	}
+	return []byte(strings.Replace(s, search, replacement, 1)), nil
+}
+
+// ReplaceLines replaces lines start to end (starting at 1, inclusive) of
+// contents.
+func ReplaceLines(contents []byte, start, end int, replacement string) ([]byte, error) {
+	lines := bytes.SplitAfter(contents, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if start < 1 || end < start {
+		return nil, fmt.Errorf("invalid line range %d-%d", start, end)
+	}
+	if end > len(lines) {
+		return nil, fmt.Errorf("line range %d-%d is past the end of the file (%d lines)", start, end, len(lines))
+	}
+
+	// keep the line structure of the file by terminating the replacement
+	// like the last replaced line
+	if replacement != "" && !strings.HasSuffix(replacement, "\n") && bytes.HasSuffix(lines[end-1], []byte("\n")) {
+		replacement += "\n"
+	}
+
+	var out bytes.Buffer
+	for _, line := range lines[:start-1] {
+		out.Write(line)
+	}
+	out.WriteString(replacement)
+	for _, line := range lines[end:] {
+		out.Write(line)
+	}
+	return out.Bytes(), nil
+}
+
+// PatchContents applies a unified diff of a single file to contents.
+func PatchContents(contents []byte, patchText string, opts patch.ApplyOpts) ([]byte, error) {
+	diffs, err := patch.Parse(patchText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse patch: %w", err)
+	}
+	if len(diffs) != 1 {
+		return nil, fmt.Errorf("patch must change exactly one file, got %d", len(diffs))
+	}
+	if diffs[0].IsDelete() {
+		return nil, fmt.Errorf("patch deletes %s, which is not supported on a file", diffs[0].Name())
+	}
+	return patch.Apply(contents, diffs[0], opts)
+}
+
+// lineOffset returns the byte offset of the given line (starting at 1), or
+// -1 if the content has fewer lines.
+func lineOffset(s string, line int) int {
+	offset := 0
+	for i := 1; i < line; i++ {
+		idx := strings.IndexByte(s[offset:], '\n')
+		if idx == -1 || offset+idx+1 == len(s) {
+			return -1
+		}
+		offset += idx + 1
+	}
+	return offset
+}
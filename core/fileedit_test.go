@@ -0,0 +1,58 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplaceContents(t *testing.T) {
+	contents := []byte("foo bar\nbar baz\nqux bar\n")
+	intPtr := func(n int) *int { return &n }
+
+	out, err := ReplaceContents([]byte("hello world\n"), "world", "dagger", false, nil)
+	require.NoError(t, err)
+	require.Equal(t, "hello dagger\n", string(out))
+
+	_, err = ReplaceContents(contents, "bar", "BAR", false, nil)
+	require.ErrorContains(t, err, "search string found 3 times (at lines 1, 2, 3)")
+
+	out, err = ReplaceContents(contents, "bar", "BAR", true, nil)
+	require.NoError(t, err)
+	require.Equal(t, "foo BAR\nBAR baz\nqux BAR\n", string(out))
+
+	out, err = ReplaceContents(contents, "bar", "BAR", false, intPtr(2))
+	require.NoError(t, err)
+	require.Equal(t, "foo bar\nBAR baz\nqux bar\n", string(out))
+
+	_, err = ReplaceContents(contents, "foo", "FOO", false, intPtr(2))
+	require.ErrorContains(t, err, "not found at or after line 2")
+
+	_, err = ReplaceContents(contents, "bar", "BAR", false, intPtr(4))
+	require.ErrorContains(t, err, "past the end of the file")
+
+	_, err = ReplaceContents(contents, "nope", "", false, nil)
+	require.ErrorContains(t, err, "search string not found")
+}
+
+func TestReplaceLines(t *testing.T) {
+	contents := []byte("one\ntwo\nthree\nfour\n")
+
+	out, err := ReplaceLines(contents, 2, 3, "TWO\nTHREE")
+	require.NoError(t, err)
+	require.Equal(t, "one\nTWO\nTHREE\nfour\n", string(out))
+
+	out, err = ReplaceLines(contents, 1, 1, "")
+	require.NoError(t, err)
+	require.Equal(t, "two\nthree\nfour\n", string(out))
+
+	out, err = ReplaceLines([]byte("a\nb"), 2, 2, "B")
+	require.NoError(t, err)
+	require.Equal(t, "a\nB", string(out))
+
+	_, err = ReplaceLines(contents, 3, 5, "x")
+	require.ErrorContains(t, err, "past the end of the file (4 lines)")
+
+	_, err = ReplaceLines(contents, 3, 2, "x")
+	require.ErrorContains(t, err, "invalid line range 3-2")
+}
@@ -1,13 +1,21 @@
 package core
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
 
 	"dagger.io/dagger/telemetry"
 	"github.com/dagger/dagger/engine/buildkit"
@@ -86,3 +94,266 @@ func (d *portHealthChecker) Check(ctx context.Context) (rerr error) {
 
 	return nil
 }
+
+// Healthcheck configures how to check that a container run as a service is
+// ready, replacing the default check of its exposed ports.
+type Healthcheck struct {
+	// Args is a command run in the service container, healthy when it exits
+	// with status 0.
+	Args []string
+
+	// HTTPPath is requested with an HTTP GET on Port, or the first exposed TCP
+	// port, healthy on a 2xx or 3xx response.
+	HTTPPath string
+
+	// Port is a TCP port checked alone to accept connections, or requested
+	// with HTTPPath.
+	Port int
+
+	// Interval is the time between two checks.
+	Interval time.Duration
+
+	// Timeout is the time after which a check fails.
+	Timeout time.Duration
+
+	// Retries is the number of consecutive failed checks after which the
+	// service is unhealthy and fails to start.
+	Retries int
+
+	// StartPeriod is the time during which failed checks don't count towards
+	// Retries, to let the service initialize.
+	StartPeriod time.Duration
+}
+
+func (hc Healthcheck) Validate() error {
+	switch {
+	case len(hc.Args) > 0 && (hc.HTTPPath != "" || hc.Port != 0):
+		return errors.New("a command health check cannot set an HTTP path or port")
+	case len(hc.Args) == 0 && hc.HTTPPath == "" && hc.Port == 0:
+		return errors.New("a health check needs a command, an HTTP path or a port")
+	case hc.HTTPPath != "" && !strings.HasPrefix(hc.HTTPPath, "/"):
+		return fmt.Errorf("HTTP path %q must start with /", hc.HTTPPath)
+	case hc.Port < 0 || hc.Port > 65535:
+		return fmt.Errorf("invalid port %d", hc.Port)
+	case hc.Interval <= 0:
+		return errors.New("interval must be positive")
+	case hc.Timeout <= 0:
+		return errors.New("timeout must be positive")
+	case hc.Retries < 1:
+		return errors.New("retries must be at least 1")
+	case hc.StartPeriod < 0:
+		return errors.New("start period must not be negative")
+	}
+	return nil
+}
+
+// String describes the check, e.g. for its span.
+func (hc Healthcheck) String() string {
+	switch {
+	case len(hc.Args) > 0:
+		return strings.Join(hc.Args, " ")
+	case hc.HTTPPath != "" && hc.Port != 0:
+		return fmt.Sprintf("GET :%d%s", hc.Port, hc.HTTPPath)
+	case hc.HTTPPath != "":
+		return "GET " + hc.HTTPPath
+	default:
+		return fmt.Sprintf("%d/tcp", hc.Port)
+	}
+}
+
+// healthcheckOutputLimit is the amount of output of a failed health check
+// command kept for its error.
+const healthcheckOutputLimit = 4096
+
+type customHealthChecker struct {
+	bk    *buildkit.Client
+	ctr   *buildkit.Container
+	host  string
+	ports []Port
+	check Healthcheck
+	// exec is the process configuration of command checks, inherited from
+	// the service.
+	exec bkgw.StartRequest
+}
+
+func newCustomHealth(
+	bk *buildkit.Client,
+	ctr *buildkit.Container,
+	host string,
+	ports []Port,
+	check Healthcheck,
+	exec bkgw.StartRequest,
+) *customHealthChecker {
+	return &customHealthChecker{
+		bk:    bk,
+		ctr:   ctr,
+		host:  host,
+		ports: ports,
+		check: check,
+		exec:  exec,
+	}
+}
+
+func (d *customHealthChecker) Check(ctx context.Context) (rerr error) {
+	// always show health checks
+	ctx, span := Tracer(ctx).Start(ctx, "healthcheck "+d.check.String())
+	defer telemetry.End(span, func() error { return rerr })
+
+	slog := slog.SpanLogger(ctx, InstrumentationLibrary).With("host", d.host)
+
+	var probe func(context.Context) error
+	switch {
+	case len(d.check.Args) > 0:
+		probe = d.execProbe
+	case d.check.HTTPPath != "":
+		port := d.check.Port
+		if port == 0 {
+			for _, p := range d.ports {
+				if p.Protocol == NetworkProtocolTCP {
+					port = p.Port
+					break
+				}
+			}
+		}
+		if port == 0 {
+			return fmt.Errorf("no port to request %s on: set a port or expose a TCP port", d.check.HTTPPath)
+		}
+		probe = func(ctx context.Context) error {
+			return d.httpProbe(ctx, port)
+		}
+	default:
+		probe = func(ctx context.Context) error {
+			conn, err := d.dial(ctx, d.check.Port)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		}
+	}
+
+	started := time.Now()
+	failures := 0
+	for {
+		probeCtx, cancel := context.WithTimeout(ctx, d.check.Timeout)
+		err := probe(probeCtx)
+		cancel()
+		if err == nil {
+			slog.Info("service is healthy", "elapsed", time.Since(started))
+			return nil
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		if time.Since(started) < d.check.StartPeriod {
+			slog.Warn("service not ready", "error", err, "elapsed", time.Since(started))
+		} else {
+			failures++
+			slog.Warn("service unhealthy", "error", err, "failures", failures)
+			if failures >= d.check.Retries {
+				return fmt.Errorf("unhealthy after %d failed checks: %w", failures, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-time.After(d.check.Interval):
+		}
+	}
+}
+
+func (d *customHealthChecker) execProbe(ctx context.Context) error {
+	out := &limitedBuffer{limit: healthcheckOutputLimit}
+	req := d.exec
+	req.Args = d.check.Args
+	req.Tty = false
+	req.Stdin = nil
+	req.Stdout = out
+	req.Stderr = out
+	proc, err := d.ctr.Start(ctx, req)
+	if err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- proc.Wait()
+	}()
+	select {
+	case err := <-exited:
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, strings.TrimSpace(out.String()))
+		}
+		return nil
+	case <-ctx.Done():
+		_ = proc.Signal(context.WithoutCancel(ctx), syscall.SIGKILL)
+		return fmt.Errorf("timed out after %s", d.check.Timeout)
+	}
+}
+
+func (d *customHealthChecker) httpProbe(ctx context.Context, port int) error {
+	client := &http.Client{
+		Transport: &http.Transport{
+			DisableKeepAlives: true,
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return d.dial(ctx, port)
+			},
+		},
+		// a redirect is a healthy response, don't follow it
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	u := url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort(d.host, strconv.Itoa(port)),
+		Path:   d.check.HTTPPath,
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("GET %s: %s", d.check.HTTPPath, resp.Status)
+	}
+	return nil
+}
+
+// dial connects to the port of the service from its network namespace.
+func (d *customHealthChecker) dial(ctx context.Context, port int) (net.Conn, error) {
+	return buildkit.RunInNetNS(ctx, d.bk, d.ctr, func() (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, "tcp", net.JoinHostPort(d.host, strconv.Itoa(port)))
+	})
+}
+
+// limitedBuffer keeps the first bytes written to it, up to its limit.
+type limitedBuffer struct {
+	mu    sync.Mutex
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n := b.limit - b.buf.Len(); n > 0 {
+		b.buf.Write(p[:min(n, len(p))])
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) Close() error {
+	return nil
+}
+
+func (b *limitedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
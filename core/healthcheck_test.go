@@ -0,0 +1,55 @@
+package core
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthcheckValidate(t *testing.T) {
+	valid := Healthcheck{
+		Interval: time.Second,
+		Timeout:  time.Second,
+		Retries:  3,
+	}
+	with := func(fn func(*Healthcheck)) Healthcheck {
+		hc := valid
+		fn(&hc)
+		return hc
+	}
+
+	for _, hc := range []Healthcheck{
+		with(func(hc *Healthcheck) { hc.Args = []string{"pg_isready"} }),
+		with(func(hc *Healthcheck) { hc.HTTPPath = "/healthz" }),
+		with(func(hc *Healthcheck) { hc.HTTPPath = "/healthz"; hc.Port = 8080 }),
+		with(func(hc *Healthcheck) { hc.Port = 5432; hc.StartPeriod = time.Minute }),
+	} {
+		require.NoError(t, hc.Validate(), hc.String())
+	}
+
+	for errMsg, hc := range map[string]Healthcheck{
+		"needs a command":        valid,
+		"cannot set an HTTP":     with(func(hc *Healthcheck) { hc.Args = []string{"true"}; hc.Port = 80 }),
+		"must start with /":      with(func(hc *Healthcheck) { hc.HTTPPath = "healthz" }),
+		"invalid port":           with(func(hc *Healthcheck) { hc.Port = 70000 }),
+		"interval must be":       with(func(hc *Healthcheck) { hc.Port = 80; hc.Interval = 0 }),
+		"timeout must be":        with(func(hc *Healthcheck) { hc.Port = 80; hc.Timeout = -time.Second }),
+		"retries must be":        with(func(hc *Healthcheck) { hc.Port = 80; hc.Retries = 0 }),
+		"start period must not ": with(func(hc *Healthcheck) { hc.Port = 80; hc.StartPeriod = -time.Second }),
+	} {
+		require.ErrorContains(t, hc.Validate(), errMsg)
+	}
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 8}
+	n, err := buf.Write([]byte("hello "))
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	n, err = buf.Write([]byte(strings.Repeat("world", 10)))
+	require.NoError(t, err)
+	require.Equal(t, 50, n)
+	require.Equal(t, "hello wo", buf.String())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"dagger.io/dagger"
+	"github.com/dagger/dagger/internal/testutil"
 	"github.com/dagger/testctx"
 )
 
@@ -330,3 +332,80 @@ func (CacheSuite) TestCacheNotImpactedByChangeInModuleSource(ctx context.Context
 
 	require.Equal(t, fooID, fooID2)
 }
+
+func (CacheSuite) TestVolumeManagement(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	key := "managed-" + identity.NewID()
+	vol := c.CacheVolume(key)
+
+	ls := func(t *testctx.T) string {
+		out, err := c.Container().From(alpineImage).
+			WithMountedCache("/cache", vol).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"ls", "-A", "/cache"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		return strings.TrimSpace(out)
+	}
+	snapshot := func(t *testctx.T) []string {
+		entries, err := vol.Snapshot().Entries(ctx)
+		require.NoError(t, err)
+		return entries
+	}
+	type listedVolume struct {
+		Keys           []string
+		SharingMode    string
+		DiskSpaceBytes int
+		EntryIDs       []string
+	}
+	list := func(t *testctx.T) *listedVolume {
+		res, err := testutil.QueryWithClient[struct {
+			Engine struct {
+				CacheVolumes []listedVolume
+			}
+		}](c, t, `{
+			engine {
+				cacheVolumes {
+					keys
+					sharingMode
+					diskSpaceBytes
+					entryIDs
+				}
+			}
+		}`, nil)
+		require.NoError(t, err)
+		for _, v := range res.Engine.CacheVolumes {
+			if slices.Contains(v.Keys, "mainClient:"+key) {
+				return &v
+			}
+		}
+		return nil
+	}
+
+	_, err := c.Container().From(alpineImage).
+		WithMountedCache("/cache", vol, dagger.ContainerWithMountedCacheOpts{Sharing: dagger.CacheSharingModeLocked}).
+		WithExec([]string{"sh", "-c", "dd if=/dev/zero of=/cache/poisoned bs=1M count=4"}).
+		Sync(ctx)
+	require.NoError(t, err)
+
+	// the steps depend on each other, so they aren't parallel subtests
+	listed := list(t)
+	require.NotNil(t, listed)
+	require.Equal(t, "LOCKED", listed.SharingMode)
+	require.GreaterOrEqual(t, listed.DiskSpaceBytes, 4<<20)
+	require.NotEmpty(t, listed.EntryIDs)
+
+	require.Equal(t, []string{"poisoned"}, snapshot(t))
+
+	err = vol.Seed(ctx, c.Directory().
+		WithNewFile("sub/seeded", "fresh").
+		Directory("sub"))
+	require.NoError(t, err)
+	require.Equal(t, "seeded", ls(t))
+	require.Equal(t, []string{"seeded"}, snapshot(t))
+
+	require.NoError(t, vol.Delete(ctx))
+	require.Nil(t, list(t))
+	require.Empty(t, ls(t))
+}
@@ -3,12 +3,16 @@ package core
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/md5"
 	"crypto/rand"
+	"crypto/x509"
 	_ "embed"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net"
 	"net/http"
@@ -16,12 +20,14 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/containerd/platforms"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/moby/buildkit/identity"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
@@ -537,6 +543,251 @@ func (ContainerSuite) TestExecStdin(ctx context.Context, t *testctx.T) {
 	require.Equal(t, res.Container.From.WithExec.Stdout, "hello")
 }
 
+func (ContainerSuite) TestExecLimits(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	exec := func(ctx context.Context, cmd string, opts dagger.ContainerWithExecOpts) (string, error) {
+		return c.Container().
+			From(alpineImage).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"sh", "-c", cmd}, opts).
+			Stdout(ctx)
+	}
+
+	t.Run("cgroup", func(ctx context.Context, t *testctx.T) {
+		out, err := exec(ctx, "cat /sys/fs/cgroup/cpu.max /sys/fs/cgroup/memory.max /sys/fs/cgroup/pids.max", dagger.ContainerWithExecOpts{
+			Limits: dagger.ExecLimits{Cpus: 0.5, MemoryBytes: 268435456, Pids: 64},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "50000 100000\n268435456\n64\n", out)
+	})
+
+	t.Run("pids", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, "for i in $(seq 20); do sleep 5 & done; wait", dagger.ContainerWithExecOpts{
+			Limits: dagger.ExecLimits{Pids: 8},
+		})
+		requireErrOut(t, err, "can't fork")
+	})
+
+	t.Run("memory", func(ctx context.Context, t *testctx.T) {
+		// dd allocates a buffer of the block size
+		_, err := exec(ctx, "dd if=/dev/zero of=/dev/null bs=128M count=1", dagger.ContainerWithExecOpts{
+			Limits: dagger.ExecLimits{MemoryBytes: 33554432},
+		})
+		requireErrOut(t, err, "did not complete successfully: exit code: 137")
+	})
+
+	t.Run("timeout", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, "sleep 60", dagger.ContainerWithExecOpts{
+			Timeout: "1s",
+			Expect:  dagger.ReturnTypeAny,
+		})
+		requireErrOut(t, err, "exec timed out after 1s")
+
+		out, err := exec(ctx, "echo fast", dagger.ContainerWithExecOpts{Timeout: "1m"})
+		require.NoError(t, err)
+		require.Equal(t, "fast\n", out)
+	})
+
+	t.Run("invalid", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, "true", dagger.ContainerWithExecOpts{Timeout: "soon"})
+		requireErrOut(t, err, "invalid timeout")
+		_, err = exec(ctx, "true", dagger.ContainerWithExecOpts{
+			Limits: dagger.ExecLimits{Cpus: -1},
+		})
+		requireErrOut(t, err, "cpus must not be negative")
+	})
+}
+
+func (ContainerSuite) TestExecMetrics(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	metrics := c.Container().
+		From(alpineImage).
+		WithEnvVariable("CACHEBUST", identity.NewID()).
+		WithExec([]string{"sh", "-c", "dd if=/dev/zero of=/dev/null bs=16M count=1 && sleep 1"}).
+		ExecMetrics()
+
+	duration, err := metrics.DurationMicroseconds(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, duration, int(time.Second.Microseconds()))
+	cpuTime, err := metrics.CPUTimeMicroseconds(ctx)
+	require.NoError(t, err)
+	require.Positive(t, cpuTime)
+	// dd allocates a buffer of the block size
+	memoryPeak, err := metrics.MemoryPeakBytes(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, memoryPeak, 16<<20)
+
+	_, err = c.Container().From(alpineImage).ExecMetrics().DurationMicroseconds(ctx)
+	requireErrOut(t, err, "no command has been set")
+}
+
+func (ContainerSuite) TestExecRetry(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	// attempts are counted in a cache volume, whose changes are kept across
+	// attempts, and fail if they see the changes of a previous attempt to the
+	// root filesystem or a mounted directory
+	const countAttempt = `[ ! -e /changed ] && [ ! -e /mnt/changed ] || { echo dirty; exit 99; }; ` +
+		`touch /changed /mnt/changed; ` +
+		`n=$(cat /cache/attempts 2>/dev/null || echo 0); n=$((n+1)); echo $n > /cache/attempts; ` +
+		`echo attempt $n; echo flaky $n >&2; `
+
+	exec := func(ctx context.Context, cmd string, opts dagger.ContainerWithExecOpts) (string, error) {
+		return c.Container().
+			From(alpineImage).
+			WithMountedCache("/cache", c.CacheVolume("retry-"+identity.NewID())).
+			WithMountedDirectory("/mnt", c.Directory()).
+			WithExec([]string{"sh", "-c", countAttempt + cmd}, opts).
+			Stdout(ctx)
+	}
+
+	t.Run("succeeds after retries", func(ctx context.Context, t *testctx.T) {
+		out, err := exec(ctx, `[ $n -ge 3 ]`, dagger.ContainerWithExecOpts{
+			Retry: dagger.ExecRetry{Attempts: 3, Backoff: "100ms"},
+		})
+		require.NoError(t, err)
+		// only the output of the last attempt is kept
+		require.Equal(t, "attempt 3\n", out)
+	})
+
+	t.Run("runs out of attempts", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, `exit 1`, dagger.ContainerWithExecOpts{
+			Retry: dagger.ExecRetry{Attempts: 2},
+		})
+		requireErrOut(t, err, "flaky 2")
+	})
+
+	t.Run("exit codes", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, `exit $n`, dagger.ContainerWithExecOpts{
+			Retry: dagger.ExecRetry{Attempts: 5, RetryOnExitCodes: []int{1}},
+		})
+		requireErrOut(t, err, "exit code: 2")
+	})
+
+	t.Run("stderr regex", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, `exit 1`, dagger.ContainerWithExecOpts{
+			Retry: dagger.ExecRetry{Attempts: 5, RetryOnStderrRegex: "flaky [12]"},
+		})
+		requireErrOut(t, err, "flaky 3")
+	})
+
+	t.Run("allowed exit code", func(ctx context.Context, t *testctx.T) {
+		out, err := exec(ctx, `exit 1`, dagger.ContainerWithExecOpts{
+			Retry:  dagger.ExecRetry{Attempts: 3},
+			Expect: dagger.ReturnTypeAny,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "attempt 1\n", out)
+	})
+
+	t.Run("timeout", func(ctx context.Context, t *testctx.T) {
+		out, err := exec(ctx, `[ $n -ge 2 ] || sleep 60`, dagger.ContainerWithExecOpts{
+			Retry:   dagger.ExecRetry{Attempts: 2},
+			Timeout: "1s",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "attempt 2\n", out)
+	})
+
+	t.Run("redirected output", func(ctx context.Context, t *testctx.T) {
+		out, err := c.Container().
+			From(alpineImage).
+			WithMountedCache("/cache", c.CacheVolume("retry-"+identity.NewID())).
+			WithMountedDirectory("/mnt", c.Directory()).
+			WithExec([]string{"sh", "-c", countAttempt + `[ $n -ge 2 ]`}, dagger.ContainerWithExecOpts{
+				Retry:          dagger.ExecRetry{Attempts: 2},
+				RedirectStdout: "/out",
+			}).
+			File("/out").
+			Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "attempt 2\n", out)
+	})
+
+	t.Run("invalid", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, "true", dagger.ContainerWithExecOpts{
+			Retry: dagger.ExecRetry{Attempts: 0, Backoff: "1s"},
+		})
+		requireErrOut(t, err, "attempts must be at least 1")
+		_, err = exec(ctx, "true", dagger.ContainerWithExecOpts{
+			Retry: dagger.ExecRetry{Attempts: 2, RetryOnStderrRegex: "("},
+		})
+		requireErrOut(t, err, "invalid stderr regex")
+	})
+}
+
+func (ContainerSuite) TestExecOutputAssertions(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	exec := func(ctx context.Context, cmd string, opts dagger.ContainerWithExecOpts) (string, error) {
+		return c.Container().
+			From(alpineImage).
+			WithExec([]string{"sh", "-c", cmd}, opts).
+			Stdout(ctx)
+	}
+
+	t.Run("stdout regex", func(ctx context.Context, t *testctx.T) {
+		out, err := exec(ctx, "echo listening on port 8080", dagger.ContainerWithExecOpts{
+			ExpectStdoutRegex: `port \d+`,
+		})
+		require.NoError(t, err)
+		require.Equal(t, "listening on port 8080\n", out)
+
+		_, err = exec(ctx, "echo starting; echo failed to listen", dagger.ContainerWithExecOpts{
+			ExpectStdoutRegex: "listening on",
+		})
+		requireErrOut(t, err, `expected stdout to match regex "listening on", got:\nstarting\nfailed to listen`)
+	})
+
+	t.Run("stderr regex", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, "echo ok", dagger.ContainerWithExecOpts{
+			ExpectStderrRegex: "warning",
+		})
+		requireErrOut(t, err, `expected stderr to match regex "warning", got no output`)
+	})
+
+	t.Run("not contains", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, "echo fine", dagger.ContainerWithExecOpts{
+			ExpectStdoutNotContains: "panic:",
+			ExpectStderrNotContains: "panic:",
+		})
+		require.NoError(t, err)
+
+		_, err = exec(ctx, "echo fine; echo 'panic: oh no' >&2; exit 0", dagger.ContainerWithExecOpts{
+			ExpectStderrNotContains: "panic:",
+		})
+		requireErrOut(t, err, `expected stderr not to contain "panic:", got:\npanic: oh no`)
+	})
+
+	t.Run("exit codes", func(ctx context.Context, t *testctx.T) {
+		out, err := exec(ctx, "echo two; exit 2", dagger.ContainerWithExecOpts{
+			ExpectExitCodes: []int{0, 2},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "two\n", out)
+
+		_, err = exec(ctx, "exit 1", dagger.ContainerWithExecOpts{
+			ExpectExitCodes: []int{0, 2},
+		})
+		requireErrOut(t, err, "exit code: 1")
+
+		_, err = exec(ctx, "true", dagger.ContainerWithExecOpts{
+			ExpectExitCodes: []int{0},
+			Expect:          dagger.ReturnTypeAny,
+		})
+		requireErrOut(t, err, "mutually exclusive")
+	})
+
+	t.Run("only checked on allowed exit codes", func(ctx context.Context, t *testctx.T) {
+		_, err := exec(ctx, "echo panic: nope; exit 3", dagger.ContainerWithExecOpts{
+			ExpectStdoutNotContains: "panic:",
+		})
+		requireErrOut(t, err, "exit code: 3")
+	})
+}
+
 func (ContainerSuite) TestExecRedirectStdoutStderr(ctx context.Context, t *testctx.T) {
 	c := connect(ctx, t)
 	res, err := testutil.QueryWithClient[struct {
@@ -3224,6 +3475,242 @@ func (ContainerSuite) TestImport(ctx context.Context, t *testctx.T) {
 	})
 }
 
+func (ContainerSuite) TestOCILayout(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	t.Run("round trip", func(ctx context.Context, t *testctx.T) {
+		layout := c.Container().From(alpineImage).WithEnvVariable("FOO", "bar").AsOCILayout()
+
+		entries, err := layout.Entries(ctx)
+		require.NoError(t, err)
+		require.Subset(t, entries, []string{"oci-layout", "index.json", "blobs"})
+
+		contents, err := layout.File("index.json").Contents(ctx)
+		require.NoError(t, err)
+		var idx ocispecs.Index
+		require.NoError(t, json.Unmarshal([]byte(contents), &idx))
+		require.Len(t, idx.Manifests, 1)
+
+		env, err := c.Container().ImportOCILayout(layout).EnvVariable(ctx, "FOO")
+		require.NoError(t, err)
+		require.Equal(t, "bar", env)
+	})
+
+	t.Run("platform variants", func(ctx context.Context, t *testctx.T) {
+		var variants []*dagger.Container
+		for _, platform := range []dagger.Platform{"linux/amd64", "linux/arm64"} {
+			variants = append(variants, c.Container(dagger.ContainerOpts{Platform: platform}).From(alpineImage))
+		}
+
+		contents, err := c.Container().
+			AsOCILayout(dagger.ContainerAsOCILayoutOpts{PlatformVariants: variants}).
+			File("index.json").
+			Contents(ctx)
+		require.NoError(t, err)
+
+		var idx ocispecs.Index
+		require.NoError(t, json.Unmarshal([]byte(contents), &idx))
+		require.Len(t, idx.Manifests, 1)
+		require.Equal(t, ocispecs.MediaTypeImageIndex, idx.Manifests[0].MediaType)
+	})
+
+	t.Run("export to host", func(ctx context.Context, t *testctx.T) {
+		dest := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dest, "stale"), []byte("stale"), 0o600))
+
+		_, err := c.Container().From(alpineImage).ExportOCILayout(ctx, dest)
+		require.NoError(t, err)
+		require.FileExists(t, filepath.Join(dest, "oci-layout"))
+		require.FileExists(t, filepath.Join(dest, "index.json"))
+		require.DirExists(t, filepath.Join(dest, "blobs", "sha256"))
+		require.NoFileExists(t, filepath.Join(dest, "stale"))
+	})
+}
+
+func (ContainerSuite) TestWithSourceDateEpoch(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	// builds the same files with different mtimes, and returns the digest of
+	// the image manifest and the config
+	build := func(t *testctx.T, epoch *int) (string, ocispecs.Image) {
+		ctr := c.Container().From(alpineImage).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"sh", "-c", "echo hello > /hello && mkdir -p /out && touch /out/empty"}).
+			WithoutEnvVariable("CACHEBUST")
+		if epoch != nil {
+			ctr = ctr.WithSourceDateEpoch(*epoch)
+		}
+		layout := ctr.AsOCILayout()
+
+		readJSON := func(path string, v any) {
+			contents, err := layout.File(path).Contents(ctx)
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal([]byte(contents), v))
+		}
+		var idx ocispecs.Index
+		readJSON("index.json", &idx)
+		require.Len(t, idx.Manifests, 1)
+		manifestDigest := idx.Manifests[0].Digest
+		var manifest ocispecs.Manifest
+		readJSON(path.Join("blobs", "sha256", manifestDigest.Encoded()), &manifest)
+		var config ocispecs.Image
+		readJSON(path.Join("blobs", "sha256", manifest.Config.Digest.Encoded()), &config)
+		return manifestDigest.String(), config
+	}
+
+	epoch := 1700000000
+	first, config := build(t, &epoch)
+	require.NotNil(t, config.Created)
+	require.Equal(t, int64(epoch), config.Created.Unix())
+	for _, h := range config.History {
+		if h.Created != nil {
+			require.LessOrEqual(t, h.Created.Unix(), int64(epoch))
+		}
+	}
+
+	// make sure the mtimes of the files differ
+	time.Sleep(2 * time.Second)
+
+	second, _ := build(t, &epoch)
+	require.Equal(t, first, second)
+
+	unclamped, _ := build(t, nil)
+	require.NotEqual(t, first, unclamped)
+}
+
+func (ContainerSuite) TestPublishAttestations(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	pushedRef, err := c.Container().From(alpineImage).
+		WithEnvVariable("API_TOKEN", "hunter2").
+		Publish(ctx, registryRef("container-publish-attestations"), dagger.ContainerPublishOpts{
+			Sbom:       dagger.SBOMFormatSpdx,
+			Provenance: true,
+		})
+	require.NoError(t, err)
+	require.Contains(t, pushedRef, "@sha256:")
+
+	parsedRef, err := name.ParseReference(pushedRef, name.Insecure)
+	require.NoError(t, err)
+	imgDesc, err := remote.Get(parsedRef, remote.WithTransport(http.DefaultTransport))
+	require.NoError(t, err)
+	imgs, err := imgDesc.ImageIndex()
+	require.NoError(t, err)
+	idx, err := imgs.IndexManifest()
+	require.NoError(t, err)
+
+	// the image manifest and its attestation manifest
+	require.Len(t, idx.Manifests, 2)
+	attIdx := slices.IndexFunc(idx.Manifests, func(desc v1.Descriptor) bool {
+		return desc.Annotations["vnd.docker.reference.type"] == "attestation-manifest"
+	})
+	require.NotEqual(t, -1, attIdx)
+	att, err := imgs.Image(idx.Manifests[attIdx].Digest)
+	require.NoError(t, err)
+	layers, err := att.Layers()
+	require.NoError(t, err)
+
+	predicates := map[string]json.RawMessage{}
+	for _, layer := range layers {
+		rc, err := layer.Uncompressed()
+		require.NoError(t, err)
+		var stmt struct {
+			PredicateType string          `json:"predicateType"`
+			Predicate     json.RawMessage `json:"predicate"`
+		}
+		require.NoError(t, json.NewDecoder(rc).Decode(&stmt))
+		rc.Close()
+		predicates[stmt.PredicateType] = stmt.Predicate
+	}
+
+	var sbom struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Packages    []struct {
+			Name string `json:"name"`
+		} `json:"packages"`
+	}
+	require.NoError(t, json.Unmarshal(predicates["https://spdx.dev/Document"], &sbom))
+	require.Equal(t, "SPDX-2.3", sbom.SPDXVersion)
+	require.Contains(t, sbom.Packages, struct {
+		Name string `json:"name"`
+	}{Name: "busybox"})
+
+	var provenance struct {
+		BuildDefinition struct {
+			ExternalParameters struct {
+				CallDigest string `json:"callDigest"`
+			} `json:"externalParameters"`
+			ResolvedDependencies []struct {
+				URI string `json:"uri"`
+			} `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+	}
+	require.NoError(t, json.Unmarshal(predicates["https://slsa.dev/provenance/v1"], &provenance))
+	require.NotEmpty(t, provenance.BuildDefinition.ExternalParameters.CallDigest)
+	require.Len(t, provenance.BuildDefinition.ResolvedDependencies, 1)
+	require.Contains(t, provenance.BuildDefinition.ResolvedDependencies[0].URI, "pkg:docker/alpine@sha256:")
+	require.NotContains(t, string(predicates["https://slsa.dev/provenance/v1"]), "hunter2")
+}
+
+func (ContainerSuite) TestPublishSigned(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	genKey := func() (*dagger.Secret, *dagger.Secret) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		privDER, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		pubDER, err := x509.MarshalPKIXPublicKey(key.Public())
+		require.NoError(t, err)
+		id := identity.NewID()
+		return c.SetSecret("signing-key-"+id, string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}))),
+			c.SetSecret("verify-key-"+id, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})))
+	}
+	signingKey, verifyKey := genKey()
+	_, otherVerifyKey := genKey()
+
+	publish := func(ref string, signed bool) string {
+		var opts dagger.ContainerPublishOpts
+		if signed {
+			opts.SigningKey = signingKey
+		}
+		pushedRef, err := c.Container().From(alpineImage).
+			WithNewFile("/hello", identity.NewID()).
+			Publish(ctx, ref, opts)
+		require.NoError(t, err)
+		return pushedRef
+	}
+	from := func(ref string, key *dagger.Secret) error {
+		_, err := c.Container().From(ref, dagger.ContainerFromOpts{VerifyKey: key}).Sync(ctx)
+		return err
+	}
+
+	t.Run("signed", func(ctx context.Context, t *testctx.T) {
+		ref := registryRef("container-publish-signed")
+		pushedRef := publish(ref, true)
+		require.NoError(t, from(pushedRef, verifyKey))
+		// resolving the tag verifies the digest it points to
+		require.NoError(t, from(ref, verifyKey))
+
+		err := from(pushedRef, otherVerifyKey)
+		require.ErrorContains(t, err, "matches the key")
+	})
+
+	t.Run("unsigned", func(ctx context.Context, t *testctx.T) {
+		pushedRef := publish(registryRef("container-publish-unsigned"), false)
+		err := from(pushedRef, verifyKey)
+		require.ErrorContains(t, err, "is not signed")
+	})
+
+	t.Run("tag moved to unsigned image", func(ctx context.Context, t *testctx.T) {
+		ref := registryRef("container-publish-signed-moved")
+		publish(ref, true)
+		publish(ref, false)
+		err := from(ref, verifyKey)
+		require.ErrorContains(t, err, "is not signed")
+	})
+}
+
 func (ContainerSuite) TestFromImagePlatform(ctx context.Context, t *testctx.T) {
 	c := connect(ctx, t)
 
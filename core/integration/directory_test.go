@@ -1349,6 +1349,60 @@ func (DirectorySuite) TestGlob(ctx context.Context, t *testctx.T) {
 	})
 }
 
+func (DirectorySuite) TestSearch(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	type searchResult struct {
+		FilePath    string
+		LineNumber  int
+		Column      int
+		MatchedText string
+	}
+	collect := func(results []dagger.SearchResult, err error) []searchResult {
+		t.Helper()
+		require.NoError(t, err)
+		out := make([]searchResult, 0, len(results))
+		for _, res := range results {
+			filePath, err := res.FilePath(ctx)
+			require.NoError(t, err)
+			lineNumber, err := res.LineNumber(ctx)
+			require.NoError(t, err)
+			column, err := res.Column(ctx)
+			require.NoError(t, err)
+			matchedText, err := res.MatchedText(ctx)
+			require.NoError(t, err)
+			out = append(out, searchResult{
+				FilePath:    filePath,
+				LineNumber:  lineNumber,
+				Column:      column,
+				MatchedText: matchedText,
+			})
+		}
+		return out
+	}
+
+	dir := c.Directory().
+		WithNewFile("main.go", "package main\n\n// TODO: fix\nfunc main() {}\n").
+		WithNewFile("sub/util.go", "package sub\n\nfunc a() {} // TODO a.b\n")
+
+	require.Equal(t, []searchResult{
+		{FilePath: "main.go", LineNumber: 3, Column: 4, MatchedText: "TODO:"},
+		{FilePath: "sub/util.go", LineNumber: 3, Column: 16, MatchedText: "TODO "},
+	}, collect(dir.Search(ctx, `TODO\W`)))
+	require.Equal(t, []searchResult{
+		{FilePath: "sub/util.go", LineNumber: 3, Column: 21, MatchedText: "a.b"},
+	}, collect(dir.Search(ctx, "a.b", dagger.DirectorySearchOpts{Literal: true})))
+	require.Equal(t, []searchResult{
+		{FilePath: "sub/util.go", LineNumber: 3, Column: 16, MatchedText: "TODO"},
+	}, collect(dir.Search(ctx, "TODO", dagger.DirectorySearchOpts{Paths: []string{"sub/"}})))
+	require.Equal(t, []searchResult{
+		{FilePath: "main.go", LineNumber: 3, Column: 10, MatchedText: "fix\nfunc"},
+	}, collect(dir.Search(ctx, "fix\n^func", dagger.DirectorySearchOpts{Multiline: true})))
+	require.Equal(t, []searchResult{
+		{FilePath: "main.go", LineNumber: 4, Column: 1, MatchedText: "func main"},
+	}, collect(dir.File("main.go").Search(ctx, `func (\w+)`)))
+}
+
 func (DirectorySuite) TestDigest(ctx context.Context, t *testctx.T) {
 	c := connect(ctx, t)
 
@@ -1453,3 +1507,137 @@ func (DirectorySuite) TestDirectoryName(ctx context.Context, t *testctx.T) {
 		})
 	})
 }
+
+func (DirectorySuite) TestWithPatch(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	dir := c.Directory().
+		WithNewFile("a.txt", "one\ntwo\nthree\n").
+		WithNewFile("gone.txt", "bye\n")
+
+	patch := `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+diff --git a/sub/new.txt b/sub/new.txt
+new file mode 100644
+--- /dev/null
++++ b/sub/new.txt
+@@ -0,0 +1 @@
++new
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+`
+
+	t.Run("from string", func(ctx context.Context, t *testctx.T) {
+		patched := dir.WithPatch(patch)
+
+		paths, err := patched.Glob(ctx, "**/*.txt")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"a.txt", "sub/new.txt"}, paths)
+
+		contents, err := patched.File("a.txt").Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "one\nTWO\nthree\n", contents)
+	})
+
+	t.Run("from file", func(ctx context.Context, t *testctx.T) {
+		patchFile := c.Directory().WithNewFile("changes.patch", patch).File("changes.patch")
+
+		contents, err := dir.WithPatchFile(patchFile).File("sub/new.txt").Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "new\n", contents)
+	})
+
+	t.Run("rejected hunks", func(ctx context.Context, t *testctx.T) {
+		_, err := dir.
+			WithPatch("--- a/a.txt\n+++ b/a.txt\n@@ -2 +2 @@\n-nope\n+NOPE\n", dagger.DirectoryWithPatchOpts{Strict: true}).
+			Entries(ctx)
+		requireErrOut(t, err, "1 hunk(s) could not be applied")
+		requireErrOut(t, err, "a.txt: hunk #1 @@ -2,1 +2,1 @@")
+	})
+}
+
+func (DirectorySuite) TestChanges(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	before := c.Directory().
+		WithNewFile("a.txt", "one\ntwo\nthree\n").
+		WithNewFile("gone.txt", "bye\n").
+		WithNewFile("old/moved.txt", "moved\n")
+	after := before.
+		WithNewFile("a.txt", "one\nTWO\nthree\n").
+		WithoutFile("gone.txt").
+		WithoutDirectory("old").
+		WithNewFile("new/moved.txt", "moved\n").
+		WithNewFile("added.txt", "hello\n")
+
+	changes := before.Changes(after)
+
+	added, err := changes.AddedPaths(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"added.txt"}, added)
+	modified, err := changes.ModifiedPaths(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.txt"}, modified)
+	removed, err := changes.RemovedPaths(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"gone.txt"}, removed)
+	renamed, err := changes.RenamedPaths(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"new/moved.txt"}, renamed)
+
+	type entry struct {
+		Kind    dagger.ChangeKind
+		Path    string
+		OldPath string
+		Diff    string
+	}
+	entries, err := changes.Entries(ctx)
+	require.NoError(t, err)
+	var got []entry
+	for _, e := range entries {
+		kind, err := e.Kind(ctx)
+		require.NoError(t, err)
+		path, err := e.Path(ctx)
+		require.NoError(t, err)
+		oldPath, err := e.OldPath(ctx)
+		require.NoError(t, err)
+		diff, err := e.Diff(ctx)
+		require.NoError(t, err)
+		got = append(got, entry{Kind: kind, Path: path, OldPath: oldPath, Diff: diff})
+	}
+	require.Contains(t, got, entry{
+		Kind:    dagger.ChangeKindRenamed,
+		Path:    "new/moved.txt",
+		OldPath: "old/moved.txt",
+		Diff:    "diff --git a/old/moved.txt b/new/moved.txt\nsimilarity index 100%\nrename from old/moved.txt\nrename to new/moved.txt\n",
+	})
+
+	name, err := changes.AsPatch().Name(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "changes.patch", name)
+	patch, err := changes.AsPatch().Contents(ctx)
+	require.NoError(t, err)
+	require.Contains(t, patch, "-two\n+TWO\n")
+
+	t.Run("patch applies", func(ctx context.Context, t *testctx.T) {
+		patched := before.WithPatch(patch)
+
+		paths, err := patched.Glob(ctx, "**/*.txt")
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"a.txt", "added.txt", "new/moved.txt"}, paths)
+
+		contents, err := patched.File("a.txt").Contents(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "one\nTWO\nthree\n", contents)
+	})
+}
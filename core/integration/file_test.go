@@ -459,3 +459,69 @@ func (FileSuite) TestSync(ctx context.Context, t *testctx.T) {
 		require.Equal(t, "bar", contents)
 	})
 }
+
+func (FileSuite) TestEdits(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	file := c.Directory().
+		WithNewFile("main.go", "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"hello\")\n}\n", dagger.DirectoryWithNewFileOpts{
+			Permissions: 0o755,
+		}).
+		File("main.go")
+
+	for _, tc := range []struct {
+		name     string
+		file     *dagger.File
+		contents string
+	}{
+		{
+			name:     "all",
+			file:     file.WithReplaced("hello", "bye", dagger.FileWithReplacedOpts{All: true}),
+			contents: "package main\n\nfunc main() {\n\tprintln(\"bye\")\n\tprintln(\"bye\")\n}\n",
+		},
+		{
+			name:     "first from",
+			file:     file.WithReplaced("hello", "bye", dagger.FileWithReplacedOpts{FirstFrom: 5}),
+			contents: "package main\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"bye\")\n}\n",
+		},
+		{
+			name:     "lines",
+			file:     file.WithReplacedLines(4, 5, "\tprintln(\"hi\")"),
+			contents: "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n",
+		},
+		{
+			name:     "patched",
+			file:     file.WithPatch("--- a/main.go\n+++ b/main.go\n@@ -1,3 +1,3 @@\n-package main\n+package other\n \n func main() {\n"),
+			contents: "package other\n\nfunc main() {\n\tprintln(\"hello\")\n\tprintln(\"hello\")\n}\n",
+		},
+	} {
+		t.Run(tc.name, func(ctx context.Context, t *testctx.T) {
+			name, err := tc.file.Name(ctx)
+			require.NoError(t, err)
+			require.Equal(t, "main.go", name)
+
+			contents, err := tc.file.Contents(ctx)
+			require.NoError(t, err)
+			require.Equal(t, tc.contents, contents)
+		})
+	}
+
+	t.Run("keeps permissions", func(ctx context.Context, t *testctx.T) {
+		out, err := c.Container().From(alpineImage).
+			WithMountedFile("/main.go", file.WithReplaced("hello", "bye", dagger.FileWithReplacedOpts{All: true})).
+			WithExec([]string{"stat", "-c", "%a", "/main.go"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "755\n", out)
+	})
+
+	t.Run("ambiguous replacement", func(ctx context.Context, t *testctx.T) {
+		_, err := file.WithReplaced("hello", "bye").Contents(ctx)
+		requireErrOut(t, err, "search string found 2 times (at lines 4, 5)")
+	})
+
+	t.Run("rejected hunk", func(ctx context.Context, t *testctx.T) {
+		_, err := file.WithPatch("--- a/main.go\n+++ b/main.go\n@@ -1 +1 @@\n-package nope\n+package other\n").Contents(ctx)
+		requireErrOut(t, err, "main.go: hunk #1 @@ -1,1 +1,1 @@")
+	})
+}
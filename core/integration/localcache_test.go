@@ -283,6 +283,112 @@ func (EngineSuite) TestLocalCacheAutomaticGC(ctx context.Context, t *testctx.T)
 	}
 }
 
+func (EngineSuite) TestLocalCachePrune(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+	gcDisabled := false
+	engine := devEngineContainer(c, engineWithConfig(ctx, t, func(ctx context.Context, t *testctx.T, cfg config.Config) config.Config {
+		// keep automatic gc from pruning behind the test's back
+		cfg.GC.Enabled = &gcDisabled
+		return cfg
+	}))
+	engineSvc, err := c.Host().Tunnel(devEngineContainerAsService(engine)).Start(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { engineSvc.Stop(ctx) })
+
+	endpoint, err := engineSvc.Endpoint(ctx, dagger.ServiceEndpointOpts{Scheme: "tcp"})
+	require.NoError(t, err)
+
+	c2, err := dagger.Connect(ctx, dagger.WithRunnerHost(endpoint), dagger.WithLogOutput(testutil.NewTWriter(t)))
+	require.NoError(t, err)
+	t.Cleanup(func() { c2.Close() })
+
+	// create a big entry in a session that ends, so that it's releasable
+	c3, err := dagger.Connect(ctx, dagger.WithRunnerHost(endpoint), dagger.WithLogOutput(testutil.NewTWriter(t)))
+	require.NoError(t, err)
+	_, err = c3.Container().From(alpineImage).WithExec([]string{"dd", "if=/dev/zero", "of=/bigfile", "bs=1M", "count=64"}).Sync(ctx)
+	require.NoError(t, err)
+	require.NoError(t, c3.Close())
+
+	type pruneRes struct {
+		Engine struct {
+			LocalCache struct {
+				Prune struct {
+					EntryCount int
+					Entries    []struct {
+						EntryID        string
+						RecordType     string
+						DiskSpaceBytes int
+					}
+				}
+			}
+		}
+	}
+	prune := func(t *testctx.T, args string) (*pruneRes, error) {
+		return testutil.QueryWithClient[pruneRes](c2, t, `{
+			engine {
+				localCache {
+					prune(`+args+`) {
+						entryCount
+						entries {
+							entryID
+							recordType
+							diskSpaceBytes
+						}
+					}
+				}
+			}
+		}`, nil)
+	}
+	entryIDs := func(t *testctx.T) []string {
+		res, err := testutil.QueryWithClient[struct {
+			Engine struct {
+				LocalCache struct {
+					EntrySet struct {
+						Entries []struct {
+							EntryID string
+						}
+					}
+				}
+			}
+		}](c2, t, `{engine{localCache{entrySet{entries{entryID}}}}}`, nil)
+		require.NoError(t, err)
+		var ids []string
+		for _, ent := range res.Engine.LocalCache.EntrySet.Entries {
+			ids = append(ids, ent.EntryID)
+		}
+		return ids
+	}
+
+	// nothing is that old
+	res, err := prune(t, `olderThan: "10000h", dryRun: true`)
+	require.NoError(t, err)
+	require.Zero(t, res.Engine.LocalCache.Prune.EntryCount)
+
+	// the dry run selects the big entry without pruning it
+	res, err = prune(t, `minSizeBytes: 60000000, recordTypes: ["regular"], dryRun: true`)
+	require.NoError(t, err)
+	require.NotZero(t, res.Engine.LocalCache.Prune.EntryCount)
+	var bigEntryID string
+	for _, ent := range res.Engine.LocalCache.Prune.Entries {
+		require.GreaterOrEqual(t, ent.DiskSpaceBytes, 60000000)
+		require.Equal(t, "regular", ent.RecordType)
+		bigEntryID = ent.EntryID
+	}
+	require.Contains(t, entryIDs(t), bigEntryID)
+
+	// pruning by ID removes only that entry
+	res, err = prune(t, `entryIDs: ["`+bigEntryID+`"]`)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Engine.LocalCache.Prune.EntryCount)
+	require.Equal(t, bigEntryID, res.Engine.LocalCache.Prune.Entries[0].EntryID)
+	remaining := entryIDs(t)
+	require.NotContains(t, remaining, bigEntryID)
+	require.NotEmpty(t, remaining)
+
+	_, err = prune(t, `unusedFor: "soon"`)
+	require.ErrorContains(t, err, "invalid unusedFor")
+}
+
 func engineConfigWithKeepBytes(keepStorage string) func(context.Context, *testctx.T, config.Config) config.Config {
 	return func(ctx context.Context, t *testctx.T, cfg config.Config) config.Config {
 		t.Helper()
@@ -6,6 +6,7 @@ import (
 	_ "embed"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"dagger.io/dagger"
@@ -137,6 +138,45 @@ func (SecretSuite) TestSet(ctx context.Context, t *testctx.T) {
 	require.Equal(t, secretName, name)
 }
 
+func (SecretSuite) TestRefresh(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	// resolved secrets are cached by the client, so run one against a dev
+	// engine with a cache TTL set
+	devEngine := devEngineContainerAsService(devEngineContainer(c))
+	clientCtr := engineClientContainer(ctx, t, c, devEngine).
+		WithExec([]string{"apk", "add", "jq", "curl"}).
+		WithEnvVariable("DAGGER_SECRET_CACHE_TTL", "1h").
+		WithNewFile("/query.sh", `set -e
+query() {
+	jq -n --arg q "$1" '{query: $q}' | curl -s \
+		-u $DAGGER_SESSION_TOKEN: \
+		--max-time 30 \
+		-H "content-type:application/json" \
+		-d @- \
+		http://127.0.0.1:$DAGGER_SESSION_PORT/query | jq -c .data
+}
+
+printf original > /tmp/secret.txt
+query '{secret(uri: "file:///tmp/secret.txt") {plaintext}}'
+printf rotated > /tmp/secret.txt
+query '{secret(uri: "file:///tmp/secret.txt") {plaintext}}'
+query '{secret(uri: "file:///tmp/secret.txt") {refresh {plaintext}}}'
+`)
+
+	out, err := clientCtr.
+		WithEnvVariable("NO_COLOR", "1").
+		WithExec([]string{"dagger", "run", "sh", "/query.sh"}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, strings.Join([]string{
+		`{"secret":{"plaintext":"original"}}`,
+		// still cached
+		`{"secret":{"plaintext":"original"}}`,
+		`{"secret":{"refresh":{"plaintext":"rotated"}}}`,
+	}, "\n")+"\n", out)
+}
+
 func (SecretSuite) TestUnsetVariable(ctx context.Context, t *testctx.T) {
 	c := connect(ctx, t)
 
@@ -723,6 +723,322 @@ func (ServiceSuite) TestPortsSkipHealthCheck(ctx context.Context, t *testctx.T)
 	})
 }
 
+func (ServiceSuite) TestHealthcheck(ctx context.Context, t *testctx.T) {
+	// the port accepts connections before the service is ready, which is
+	// only signaled by the /srv/ready file being served
+	slowService := func(c *dagger.Client) *dagger.Container {
+		return c.Container().
+			From("python").
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithWorkdir("/srv").
+			WithExposedPort(8000).
+			WithDefaultArgs([]string{"sh", "-c", "python -m http.server 8000 & sleep 3 && echo ok > /srv/ready && wait"})
+	}
+	fetchReady := func(c *dagger.Client, srv *dagger.Service) (string, error) {
+		return c.Container().
+			From(alpineImage).
+			WithServiceBinding("www", srv).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"wget", "-qO-", "http://www:8000/ready"}).
+			Stdout(ctx)
+	}
+
+	for name, opts := range map[string]dagger.ContainerWithHealthcheckOpts{
+		"command": {Args: []string{"test", "-f", "/srv/ready"}, Interval: "200ms"},
+		"http":    {HTTPPath: "/ready", Interval: "200ms"},
+	} {
+		t.Run(name, func(ctx context.Context, t *testctx.T) {
+			c := connect(ctx, t)
+
+			srv := slowService(c).WithHealthcheck(opts).AsService()
+			out, err := fetchReady(c, srv)
+			require.NoError(t, err)
+			require.Equal(t, "ok\n", out)
+		})
+	}
+
+	t.Run("unhealthy", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		srv := slowService(c).WithHealthcheck(dagger.ContainerWithHealthcheckOpts{
+			Args:     []string{"false"},
+			Interval: "100ms",
+			Retries:  2,
+		}).AsService()
+		_, err := srv.Start(ctx)
+		require.ErrorContains(t, err, "unhealthy after 2 failed checks")
+	})
+
+	t.Run("start period", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		// failures before the service is ready don't count with a long
+		// enough start period
+		srv := slowService(c).WithHealthcheck(dagger.ContainerWithHealthcheckOpts{
+			HTTPPath:    "/ready",
+			Interval:    "200ms",
+			Retries:     1,
+			StartPeriod: "1m",
+		}).AsService()
+		out, err := fetchReady(c, srv)
+		require.NoError(t, err)
+		require.Equal(t, "ok\n", out)
+	})
+
+	t.Run("invalid", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		_, err := slowService(c).WithHealthcheck(dagger.ContainerWithHealthcheckOpts{
+			Args: []string{"true"},
+			Port: 8000,
+		}).Sync(ctx)
+		require.ErrorContains(t, err, "cannot set an HTTP path or port")
+		_, err = slowService(c).WithHealthcheck(dagger.ContainerWithHealthcheckOpts{
+			Port:     8000,
+			Interval: "soon",
+		}).Sync(ctx)
+		require.ErrorContains(t, err, "invalid interval")
+	})
+}
+
+func (ServiceSuite) TestDependencies(ctx context.Context, t *testctx.T) {
+	// the api only serves once the migration job wrote to the shared cache
+	// volume, which it only does once the database is reachable
+	stack := func(c *dagger.Client, migration string) *dagger.Service {
+		cache := c.CacheVolume("migrations-" + identity.NewID())
+
+		db := c.Container().
+			From("python").
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExposedPort(8000).
+			AsService(dagger.ContainerAsServiceOpts{Args: []string{"python", "-m", "http.server", "8000"}}).
+			WithHostname("db")
+
+		migrate := c.Container().
+			From(alpineImage).
+			WithMountedCache("/cache", cache).
+			AsService(dagger.ContainerAsServiceOpts{Args: []string{"sh", "-c", migration}})
+		migrate = migrate.WithDependency(db)
+
+		api := c.Container().
+			From("python").
+			WithMountedCache("/cache", cache).
+			WithWorkdir("/srv").
+			WithExposedPort(8000).
+			AsService(dagger.ContainerAsServiceOpts{Args: []string{"sh", "-c", "cp /cache/status index.html && python -m http.server 8000"}})
+		return api.WithDependency(migrate, dagger.ServiceWithDependencyOpts{
+			Condition: dagger.ServiceDependencyConditionCompleted,
+		})
+	}
+
+	t.Run("in order", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		api := stack(c, "wget -qO- http://db:8000/ >/dev/null && echo migrated > /cache/status")
+		out, err := c.Container().
+			From(alpineImage).
+			WithServiceBinding("api", api).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"wget", "-qO-", "http://api:8000/"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "migrated\n", out)
+	})
+
+	t.Run("failed job", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		_, err := stack(c, "exit 1").Start(ctx)
+		require.ErrorContains(t, err, "did not complete successfully")
+	})
+
+	t.Run("started", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		// a dependency that never becomes healthy doesn't block a service
+		// only waiting for it to start
+		unhealthy := c.Container().
+			From(alpineImage).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExposedPort(8000).
+			AsService(dagger.ContainerAsServiceOpts{Args: []string{"sleep", "infinity"}})
+		srv := c.Container().
+			From(alpineImage).
+			AsService(dagger.ContainerAsServiceOpts{Args: []string{"sleep", "infinity"}})
+		_, err := srv.WithDependency(unhealthy, dagger.ServiceWithDependencyOpts{
+			Condition: dagger.ServiceDependencyConditionStarted,
+		}).Start(ctx)
+		require.NoError(t, err)
+	})
+}
+
+func (ServiceSuite) TestSnapshot(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	// the service seeds its root filesystem and a cache volume with a random
+	// value, and records that it was stopped gracefully
+	srv := c.Container().
+		From(alpineImage).
+		WithEnvVariable("CACHEBUST", identity.NewID()).
+		WithMountedCache("/cache", c.CacheVolume("snapshot-"+identity.NewID())).
+		WithExposedPort(8000).
+		AsService(dagger.ContainerAsServiceOpts{Args: []string{"sh", "-c", `
+			cat /proc/sys/kernel/random/uuid > /seed
+			cp /seed /cache/seed
+			trap 'echo stopped > /stopped; exit 0' TERM
+			httpd -p 8000
+			while true; do sleep 0.1; done
+		`}})
+	snapshot := func() string {
+		out, err := srv.Snapshot().
+			WithExec([]string{"sh", "-c", "cat /seed /cache/seed /stopped"}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		return out
+	}
+
+	out := snapshot()
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	require.Len(t, lines, 3, out)
+	require.Equal(t, lines[0], lines[1])
+	require.Equal(t, "stopped", lines[2])
+
+	// the snapshot is cached, so the service doesn't run again
+	require.Equal(t, out, snapshot())
+}
+
+func (ServiceSuite) TestComposeStack(ctx context.Context, t *testctx.T) {
+	c := connect(ctx, t)
+
+	dir := c.Directory().
+		WithNewFile("stack/compose.yaml", `
+name: stack-`+identity.NewID()+`
+services:
+  web:
+    image: python
+    working_dir: /srv
+    command: sh -c "cp /data/greeting greeting && python -m http.server $${PORT:-8000}"
+    ports:
+      - "8080:${PORT}"
+    volumes:
+      - ./site:/srv
+      - data:/data
+    depends_on:
+      init:
+        condition: service_completed_successfully
+  init:
+    image: `+alpineImage+`
+    command: sh -c 'echo $$GREETING > /data/greeting'
+    environment:
+      GREETING: ${GREETING:-hi}
+    volumes:
+      - data:/data
+volumes:
+  data:
+`).
+		WithNewFile("stack/.env", "GREETING=hello\nPORT=8000\n").
+		WithNewFile("stack/site/index.html", "index\n")
+	stack := dir.AsComposeStack(dagger.DirectoryAsComposeStackOpts{File: "stack/compose.yaml"})
+
+	names, err := stack.ServiceNames(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"init", "web"}, names)
+	web := stack.Service("web")
+
+	hostname, err := web.Hostname(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "web", hostname)
+
+	out, err := c.Container().
+		From(alpineImage).
+		WithServiceBinding("www", web).
+		WithEnvVariable("CACHEBUST", identity.NewID()).
+		WithExec([]string{"sh", "-c", "wget -qO- http://www:8000/ && wget -qO- http://www:8000/greeting"}).
+		Stdout(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "index\nhello\n", out)
+
+	t.Run("invalid", func(ctx context.Context, t *testctx.T) {
+		_, err := c.Directory().
+			WithNewFile("docker-compose.yml", "services: {a: {image: alpine, depends_on: [b]}, b: {image: alpine, depends_on: [a]}}").
+			AsComposeStack().
+			ServiceNames(ctx)
+		require.ErrorContains(t, err, "dependency cycle: a -> b -> a")
+	})
+}
+
+func (ServiceSuite) TestLogs(ctx context.Context, t *testctx.T) {
+	t.Run("started service", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"sh", "-c", "echo out-1; echo err-1 >&2; echo out-2; sleep 600"}).
+			AsService()
+
+		_, err := srv.Logs(ctx)
+		require.ErrorContains(t, err, "has not been started")
+
+		_, err = srv.Start(ctx)
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, _ = srv.Stop(ctx, dagger.ServiceStopOpts{Kill: true})
+		})
+
+		require.Eventually(t, func() bool {
+			logs, err := srv.Logs(ctx)
+			return err == nil && logs == "out-1\nerr-1\nout-2\n"
+		}, time.Minute, 100*time.Millisecond)
+
+		stdout, err := srv.Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "out-1\nout-2\n", stdout)
+		stderr, err := srv.Stderr(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "err-1\n", stderr)
+
+		logs, err := srv.Logs(ctx, dagger.ServiceLogsOpts{Since: "2000-01-01T00:00:00Z", Tail: 1})
+		require.NoError(t, err)
+		require.Equal(t, "out-2\n", logs)
+
+		_, err = srv.Logs(ctx, dagger.ServiceLogsOpts{Since: "yesterday"})
+		require.ErrorContains(t, err, "invalid since")
+	})
+
+	t.Run("wait for exit", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		srv := c.Container().
+			From(alpineImage).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"sh", "-c", "echo first; sleep 2; echo last"}).
+			AsService()
+		_, err := srv.Start(ctx)
+		require.NoError(t, err)
+
+		logs, err := srv.Logs(ctx, dagger.ServiceLogsOpts{WaitForExit: true})
+		require.NoError(t, err)
+		require.Equal(t, "first\nlast\n", logs)
+	})
+
+	t.Run("bound service", func(ctx context.Context, t *testctx.T) {
+		c := connect(ctx, t)
+
+		srv, _ := httpService(ctx, t, c, "hello "+identity.NewID())
+		_, err := c.Container().
+			From(alpineImage).
+			WithServiceBinding("www", srv).
+			WithExec([]string{"wget", "-qO-", "http://www:8000/index.html"}).
+			Sync(ctx)
+		require.NoError(t, err)
+
+		stderr, err := srv.Stderr(ctx)
+		require.NoError(t, err)
+		require.Contains(t, stderr, `"GET /index.html HTTP/1.1" 200`)
+	})
+}
+
 func (ContainerSuite) TestPortLifecycle(ctx context.Context, t *testctx.T) {
 	c := connect(ctx, t)
 
@@ -1935,6 +2251,48 @@ func (ServiceSuite) TestHostToContainer(ctx context.Context, t *testctx.T) {
 		}
 	})
 
+	t.Run("udp", func(ctx context.Context, t *testctx.T) {
+		srv := c.Container().
+			From("python").
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExposedPort(5353, dagger.ContainerWithExposedPortOpts{Protocol: dagger.NetworkProtocolUdp}).
+			WithDefaultArgs([]string{"python", "-c", udpEchoServer}).
+			AsService()
+
+		tunnel, err := c.Host().Tunnel(srv).Start(ctx)
+		require.NoError(t, err)
+
+		defer func() {
+			_, err := tunnel.Stop(ctx)
+			require.NoError(t, err)
+		}()
+
+		srvAddr, err := tunnel.Endpoint(ctx)
+		require.NoError(t, err)
+
+		conn, err := net.Dial("udp", srvAddr)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		// the health check can't tell whether a UDP server is listening, so
+		// retry until the echo server is up
+		buf := make([]byte, 1024)
+		for _, msg := range []string{"ping", "pong"} {
+			var n int
+			for i := 0; i < 10; i++ {
+				_, err = conn.Write([]byte(msg))
+				require.NoError(t, err)
+				require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+				n, err = conn.Read(buf)
+				if err == nil {
+					break
+				}
+			}
+			require.NoError(t, err)
+			require.Equal(t, "echo "+msg, string(buf[:n]))
+		}
+	})
+
 	t.Run("no ports to forward", func(ctx context.Context, t *testctx.T) {
 		srv := c.Container().
 			From("python").
@@ -1952,6 +2310,17 @@ func (ServiceSuite) TestHostToContainer(ctx context.Context, t *testctx.T) {
 	})
 }
 
+// udpEchoServer is a Python program replying to each datagram received on
+// port 5353 with the same datagram prefixed with "echo ".
+const udpEchoServer = `
+import socket
+s = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+s.bind(("", 5353))
+while True:
+    data, addr = s.recvfrom(65535)
+    s.sendto(b"echo " + data, addr)
+`
+
 func (ServiceSuite) TestContainerToHost(ctx context.Context, t *testctx.T) {
 	c := connect(ctx, t)
 
@@ -2054,6 +2423,51 @@ func (ServiceSuite) TestContainerToHost(ctx context.Context, t *testctx.T) {
 		require.Equal(t, "hey hey-2", out)
 	})
 
+	t.Run("udp", func(ctx context.Context, t *testctx.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer pc.Close()
+
+		go func() {
+			buf := make([]byte, 64*1024)
+			for {
+				n, addr, err := pc.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				pc.WriteTo(append([]byte("echo "), buf[:n]...), addr)
+			}
+		}()
+
+		_, udpPortStr, err := net.SplitHostPort(pc.LocalAddr().String())
+		require.NoError(t, err)
+		udpPort, err := strconv.Atoi(udpPortStr)
+		require.NoError(t, err)
+
+		host := c.Host().Service([]dagger.PortForward{
+			{Frontend: 53, Backend: udpPort, Protocol: dagger.NetworkProtocolUdp},
+		})
+
+		out, err := c.Container().
+			From("python").
+			WithServiceBinding("dns", host).
+			WithEnvVariable("CACHEBUST", identity.NewID()).
+			WithExec([]string{"python", "-c", `
+import socket
+s = socket.socket(socket.AF_INET, socket.SOCK_DGRAM)
+s.settimeout(10)
+for msg in [b"ping", b"pong"]:
+    s.sendto(msg, ("dns", 53))
+    print(s.recv(1024).decode())
+# larger than a 32KiB read, must still arrive as a single datagram
+s.sendto(b"x" * 40000, ("dns", 53))
+print(len(s.recv(65535)))
+`}).
+			Stdout(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "echo ping\necho pong\n40005\n", out)
+	})
+
 	t.Run("no ports given", func(ctx context.Context, t *testctx.T) {
 		_, err := c.Host().Service(nil).ID(ctx)
 		require.Error(t, err)
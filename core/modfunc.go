@@ -275,6 +275,14 @@ func (fn *ModuleFunction) Call(ctx context.Context, opts *CallOpts) (t dagql.Typ
 		return nil, err
 	}
 
+	// results recorded by a previous session or engine are reused as-is
+	callIndex := fn.root.CallIndex()
+	if callIndex != nil && fn.persisted() {
+		if res, ok := fn.loadPersistedCall(ctx, callIndex, opts.Server); ok {
+			return res, nil
+		}
+	}
+
 	execMD := buildkit.ExecutionMetadata{
 		ClientID:          identity.NewID(),
 		CallID:            dagql.CurrentID(ctx),
@@ -453,6 +461,10 @@ func (fn *ModuleFunction) Call(ctx context.Context, opts *CallOpts) (t dagql.Typ
 	if err != nil {
 		return nil, fmt.Errorf("failed to create secret transfer post call: %w", err)
 	}
+
+	if callIndex != nil && fn.persisted() {
+		fn.persistCall(ctx, callIndex, opts.Server, returnValueTyped)
+	}
 	return dagql.NewPostCallTyped(returnValueTyped, secretTransferPostCall), nil
 }
 
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/errdefs"
+	"github.com/containerd/containerd/images"
+	continuityfs "github.com/containerd/continuity/fs"
+	bkcache "github.com/moby/buildkit/cache"
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"golang.org/x/sync/errgroup"
+)
+
+// AsOCILayout returns the container and its platform variants as an OCI
+// image layout directory, with an oci-layout file, an index.json and the
+// blobs of the images.
+//
+// It must be called within a FSDagOp.
+func (container *Container) AsOCILayout(
+	ctx context.Context,
+	platformVariants []*Container,
+	forcedCompression ImageLayerCompression,
+	mediaTypes ImageMediaTypes,
+) (_ *Directory, rerr error) {
+	op, ok := DagOpFromContext[FSDagOp](ctx)
+	if !ok {
+		return nil, fmt.Errorf("no dagop")
+	}
+
+	bkref, err := op.CreateRef(ctx, nil,
+		bkcache.CachePolicyRetain,
+		bkcache.WithRecordType(bkclient.UsageRecordTypeRegular),
+		bkcache.WithDescription(op.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr != nil && bkref != nil {
+			bkref.Release(context.WithoutCancel(ctx))
+		}
+	}()
+	err = op.Mount(ctx, bkref, func(out string) error {
+		dest, err := continuityfs.RootPath(out, op.Path)
+		if err != nil {
+			return err
+		}
+		// the exporters only write layouts as tarballs, so stream the tarball
+		// through a pipe and unpack it into the mount as it's written
+		pr, pw, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		defer pr.Close()
+
+		var eg errgroup.Group
+		eg.Go(func() error {
+			defer pw.Close()
+			// the exporter opens its destination by path, so give it the
+			// write end of the pipe as seen by the engine process
+			return container.writeTarball(ctx, fmt.Sprintf("/proc/self/fd/%d", pw.Fd()), platformVariants, forcedCompression, mediaTypes)
+		})
+		eg.Go(func() error {
+			if err := untarOCILayout(pr, dest); err != nil {
+				// unblock the exporter
+				pr.Close()
+				return err
+			}
+			// consume the padding after the end of the archive
+			_, err := io.Copy(io.Discard, pr)
+			return err
+		})
+		return eg.Wait()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack OCI layout: %w", err)
+	}
+
+	snap, err := bkref.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bkref = nil
+
+	dir := NewDirectory(container.Query, nil, op.Path, container.Platform, nil)
+	dir.Result = snap
+	return dir, nil
+}
+
+// untarOCILayout unpacks the regular files and directories of an OCI layout
+// tarball into dest.
+func untarOCILayout(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		p, err := continuityfs.RootPath(dest, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(p, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+				return err
+			}
+			w, err := os.OpenFile(p, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(w, tr); err != nil {
+				w.Close()
+				return err
+			}
+			if err := w.Close(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected entry %q of type %q in OCI layout", hdr.Name, hdr.Typeflag)
+		}
+	}
+}
+
+// ImportOCILayout reads the container from an OCI image layout directory.
+func (container *Container) ImportOCILayout(
+	ctx context.Context,
+	source *Directory,
+	tag string,
+) (*Container, error) {
+	return container.importImage(ctx, tag, func(ctx context.Context, store content.Store) (desc specs.Descriptor, _ error) {
+		err := source.mount(ctx, func(root string) error {
+			src, err := continuityfs.RootPath(root, source.Dir)
+			if err != nil {
+				return err
+			}
+			desc, err = importOCILayout(ctx, store, src)
+			return err
+		})
+		if err != nil {
+			return desc, fmt.Errorf("OCI layout import: %w", err)
+		}
+		return desc, nil
+	})
+}
+
+// importOCILayout copies the content referenced by the index of the OCI
+// image layout at root to store, returning the descriptor of the index.
+func importOCILayout(ctx context.Context, store content.Store, root string) (specs.Descriptor, error) {
+	var desc specs.Descriptor
+
+	layoutBlob, err := os.ReadFile(filepath.Join(root, specs.ImageLayoutFile))
+	if err != nil {
+		return desc, fmt.Errorf("read %s: %w", specs.ImageLayoutFile, err)
+	}
+	var layout specs.ImageLayout
+	if err := json.Unmarshal(layoutBlob, &layout); err != nil {
+		return desc, fmt.Errorf("unmarshal %s: %w", specs.ImageLayoutFile, err)
+	}
+	if layout.Version != specs.ImageLayoutVersion {
+		return desc, fmt.Errorf("unsupported image layout version %q", layout.Version)
+	}
+
+	indexBlob, err := os.ReadFile(filepath.Join(root, specs.ImageIndexFile))
+	if err != nil {
+		return desc, fmt.Errorf("read %s: %w", specs.ImageIndexFile, err)
+	}
+	var idx specs.Index
+	if err := json.Unmarshal(indexBlob, &idx); err != nil {
+		return desc, fmt.Errorf("unmarshal %s: %w", specs.ImageIndexFile, err)
+	}
+
+	provider := ociLayoutProvider(root)
+	copyHandler := images.HandlerFunc(func(ctx context.Context, desc specs.Descriptor) ([]specs.Descriptor, error) {
+		ra, err := provider.ReaderAt(ctx, desc)
+		if err != nil {
+			return nil, err
+		}
+		defer ra.Close()
+		if err := content.WriteBlob(ctx, store, desc.Digest.String(), content.NewReader(ra), desc); err != nil {
+			return nil, fmt.Errorf("copy blob %s: %w", desc.Digest, err)
+		}
+		return nil, nil
+	})
+	err = images.Dispatch(ctx, images.Handlers(copyHandler, images.ChildrenHandler(provider)), nil, idx.Manifests...)
+	if err != nil {
+		return desc, err
+	}
+
+	desc = specs.Descriptor{
+		MediaType: specs.MediaTypeImageIndex,
+		Digest:    digest.FromBytes(indexBlob),
+		Size:      int64(len(indexBlob)),
+	}
+	if err := content.WriteBlob(ctx, store, desc.Digest.String(), bytes.NewReader(indexBlob), desc); err != nil {
+		return desc, fmt.Errorf("write index: %w", err)
+	}
+	return desc, nil
+}
+
+// ociLayoutProvider is a content.Provider reading the blobs of the OCI image
+// layout at its root.
+type ociLayoutProvider string
+
+func (root ociLayoutProvider) ReaderAt(ctx context.Context, desc specs.Descriptor) (content.ReaderAt, error) {
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, err
+	}
+	p, err := continuityfs.RootPath(string(root), filepath.Join(specs.ImageBlobsDir, desc.Digest.Algorithm().String(), desc.Digest.Encoded()))
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("blob %s: %w", desc.Digest, errdefs.ErrNotFound)
+		}
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileReaderAt{File: f, size: fi.Size()}, nil
+}
+
+type fileReaderAt struct {
+	*os.File
+	size int64
+}
+
+func (f *fileReaderAt) Size() int64 {
+	return f.size
+}
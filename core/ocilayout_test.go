@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportOCILayout(t *testing.T) {
+	ctx := context.Background()
+
+	root := t.TempDir()
+	writeBlob := func(mediaType string, data []byte) specs.Descriptor {
+		dgst := digest.FromBytes(data)
+		dir := filepath.Join(root, specs.ImageBlobsDir, dgst.Algorithm().String())
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, dgst.Encoded()), data, 0o644))
+		return specs.Descriptor{MediaType: mediaType, Digest: dgst, Size: int64(len(data))}
+	}
+	writeJSON := func(name string, v any) {
+		data, err := json.Marshal(v)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), data, 0o644))
+	}
+
+	layer := writeBlob(specs.MediaTypeImageLayer, []byte("not really a tarball"))
+	configBlob, err := json.Marshal(specs.Image{Config: specs.ImageConfig{Env: []string{"FOO=bar"}}})
+	require.NoError(t, err)
+	config := writeBlob(specs.MediaTypeImageConfig, configBlob)
+	manifestBlob, err := json.Marshal(specs.Manifest{
+		MediaType: specs.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []specs.Descriptor{layer},
+	})
+	require.NoError(t, err)
+	manifest := writeBlob(specs.MediaTypeImageManifest, manifestBlob)
+	manifest.Annotations = map[string]string{ociTagAnnotation: "latest"}
+
+	writeJSON(specs.ImageLayoutFile, specs.ImageLayout{Version: specs.ImageLayoutVersion})
+	writeJSON(specs.ImageIndexFile, specs.Index{
+		MediaType: specs.MediaTypeImageIndex,
+		Manifests: []specs.Descriptor{manifest},
+	})
+
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	desc, err := importOCILayout(ctx, store, root)
+	require.NoError(t, err)
+	require.Equal(t, specs.MediaTypeImageIndex, desc.MediaType)
+
+	resolved, err := resolveIndex(ctx, store, desc, specs.Platform{OS: "linux", Architecture: "amd64"}, "latest")
+	require.NoError(t, err)
+	require.Equal(t, manifest.Digest, resolved.Digest)
+	for _, blob := range []specs.Descriptor{manifest, config, layer} {
+		_, err := content.ReadBlob(ctx, store, blob)
+		require.NoError(t, err)
+	}
+
+	t.Run("missing blob", func(t *testing.T) {
+		require.NoError(t, os.Remove(filepath.Join(root, specs.ImageBlobsDir, "sha256", layer.Digest.Encoded())))
+		store, err := local.NewStore(t.TempDir())
+		require.NoError(t, err)
+		_, err = importOCILayout(ctx, store, root)
+		require.ErrorContains(t, err, layer.Digest.String())
+	})
+
+	t.Run("not a layout", func(t *testing.T) {
+		_, err := importOCILayout(ctx, store, t.TempDir())
+		require.ErrorContains(t, err, "read oci-layout")
+	})
+}
@@ -0,0 +1,178 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyOpts configures how hunks are matched against file contents.
+type ApplyOpts struct {
+	// Strict requires each hunk to apply at exactly the line numbers in its
+	// header, with all of its context lines matching.
+	Strict bool
+
+	// Fuzz is the maximum number of leading and trailing context lines of a
+	// hunk that may be ignored when it doesn't otherwise match, like the
+	// --fuzz option of GNU patch. Ignored in strict mode.
+	Fuzz int
+}
+
+// RejectedHunk describes a hunk that couldn't be applied.
+type RejectedHunk struct {
+	// Path is the file the hunk applies to.
+	Path string
+	// Index is the position of the hunk in its file diff, starting at 1.
+	Index int
+	Hunk  *Hunk
+}
+
+func (r RejectedHunk) String() string {
+	return fmt.Sprintf("%s: hunk #%d %s", r.Path, r.Index, r.Hunk.Header())
+}
+
+// RejectError is returned when some hunks of a patch couldn't be applied.
+type RejectError struct {
+	Rejected []RejectedHunk
+}
+
+func (e *RejectError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d hunk(s) could not be applied:", len(e.Rejected))
+	for _, r := range e.Rejected {
+		b.WriteString("\n  ")
+		b.WriteString(r.String())
+	}
+	return b.String()
+}
+
+// Apply applies the hunks of a file diff to the given contents. If any hunk
+// doesn't match, a *RejectError listing every rejected hunk is returned.
+func Apply(contents []byte, fd *FileDiff, opts ApplyOpts) ([]byte, error) {
+	if fd.IsBinary {
+		return nil, fmt.Errorf("%s: binary patches are not supported", fd.Name())
+	}
+	if opts.Strict {
+		opts.Fuzz = 0
+	}
+
+	lines := strings.SplitAfter(string(contents), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var out []string
+	var rejected []RejectedHunk
+	// cursor is the first line of the original contents not yet copied to
+	// out; offset is how far the last hunk was from its expected position
+	cursor, offset := 0, 0
+	for i, hunk := range fd.Hunks {
+		at, skipLeading, skipTrailing, ok := locate(lines, cursor, hunk, offset, opts)
+		if !ok {
+			rejected = append(rejected, RejectedHunk{Path: fd.Name(), Index: i + 1, Hunk: hunk})
+			continue
+		}
+		offset = at - (expectedPos(hunk) + skipLeading)
+
+		old, replacement := sides(hunk, skipLeading, skipTrailing)
+		out = append(out, lines[cursor:at]...)
+		out = append(out, replacement...)
+		cursor = at + len(old)
+	}
+	if len(rejected) > 0 {
+		return nil, &RejectError{Rejected: rejected}
+	}
+	out = append(out, lines[cursor:]...)
+	return []byte(strings.Join(out, "")), nil
+}
+
+// expectedPos returns the index of the first line of the hunk in the
+// original contents, according to its header.
+func expectedPos(hunk *Hunk) int {
+	if hunk.OldLines == 0 {
+		// for pure insertions, the start line is the one before the insertion
+		return hunk.OldStart
+	}
+	return hunk.OldStart - 1
+}
+
+// sides returns the original and replacement lines of a hunk, ignoring the
+// given number of leading and trailing context lines.
+func sides(hunk *Hunk, skipLeading, skipTrailing int) (old []string, replacement []string) {
+	hunkLines := hunk.Lines[skipLeading : len(hunk.Lines)-skipTrailing]
+	for _, line := range hunkLines {
+		switch line.Op {
+		case OpContext:
+			old = append(old, line.Text)
+			replacement = append(replacement, line.Text)
+		case OpDelete:
+			old = append(old, line.Text)
+		case OpAdd:
+			replacement = append(replacement, line.Text)
+		}
+	}
+	return old, replacement
+}
+
+// contextRun returns the number of leading and trailing context lines of a
+// hunk.
+func contextRun(hunk *Hunk) (leading int, trailing int) {
+	for leading < len(hunk.Lines) && hunk.Lines[leading].Op == OpContext {
+		leading++
+	}
+	for trailing < len(hunk.Lines)-leading && hunk.Lines[len(hunk.Lines)-1-trailing].Op == OpContext {
+		trailing++
+	}
+	return leading, trailing
+}
+
+// locate finds where the hunk applies in lines, at or after from. It returns
+// the index of the first matching line along with the number of leading and
+// trailing context lines that were ignored to find a match.
+func locate(lines []string, from int, hunk *Hunk, offset int, opts ApplyOpts) (at int, skipLeading int, skipTrailing int, ok bool) {
+	leading, trailing := contextRun(hunk)
+	for fuzz := 0; fuzz <= opts.Fuzz; fuzz++ {
+		skipLeading, skipTrailing = min(fuzz, leading), min(fuzz, trailing)
+		if fuzz > 0 && skipLeading < fuzz && skipTrailing < fuzz {
+			// nothing more to ignore
+			break
+		}
+		old, _ := sides(hunk, skipLeading, skipTrailing)
+		expected := expectedPos(hunk) + skipLeading
+
+		if opts.Strict {
+			if matches(lines, expected, old) && expected >= from {
+				return expected, skipLeading, skipTrailing, true
+			}
+			return 0, 0, 0, false
+		}
+
+		// search outwards from the expected position, adjusted by the offset
+		// of the previous hunk
+		start := expected + offset
+		for delta := 0; ; delta++ {
+			before, after := start-delta, start+delta
+			if before < from && after > len(lines)-len(old) {
+				break
+			}
+			if after >= from && matches(lines, after, old) {
+				return after, skipLeading, skipTrailing, true
+			}
+			if delta > 0 && before >= from && matches(lines, before, old) {
+				return before, skipLeading, skipTrailing, true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+func matches(lines []string, at int, old []string) bool {
+	if at < 0 || at+len(old) > len(lines) {
+		return false
+	}
+	for i, line := range old {
+		if lines[at+i] != line {
+			return false
+		}
+	}
+	return true
+}
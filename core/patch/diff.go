@@ -0,0 +1,177 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultContext is the number of context lines around changes in generated
+// diffs, as for diff -u and git diff.
+const DefaultContext = 3
+
+// Unified returns the hunks of a unified diff from old to new contents, with
+// the given number of context lines, without file headers. It returns an
+// empty string if the contents are equal.
+func Unified(oldContents, newContents []byte, context int) string {
+	oldLines := splitLines(string(oldContents))
+	newLines := splitLines(string(newContents))
+	ops := diffLines(oldLines, newLines)
+
+	var b strings.Builder
+	for _, hunk := range groupHunks(ops, context) {
+		var oldCount, newCount int
+		for _, line := range hunk.Lines {
+			if line.Op != OpAdd {
+				oldCount++
+			}
+			if line.Op != OpDelete {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(hunk.OldStart, oldCount), hunkRange(hunk.NewStart, newCount))
+		for _, line := range hunk.Lines {
+			b.WriteByte(byte(line.Op))
+			b.WriteString(line.Text)
+			if !strings.HasSuffix(line.Text, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// empty ranges refer to the line before the change
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type diffOp struct {
+	Op Op
+	// indexes of the line in the old and new contents
+	OldIdx, NewIdx int
+	Text           string
+}
+
+// diffLines computes a minimal line edit script with the Myers algorithm.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD
+	v := make([]int, 2*maxD+2)
+	var trace [][]int
+
+	var d int
+outer:
+	for d = 0; d <= maxD; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break outer
+			}
+		}
+	}
+
+	// backtrack through the trace to recover the edit script
+	var ops []diffOp
+	x, y := n, m
+	for ; d > 0; d-- {
+		vPrev := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && vPrev[offset+k-1] < vPrev[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := vPrev[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{Op: OpContext, OldIdx: x, NewIdx: y, Text: a[x]})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{Op: OpAdd, OldIdx: x, NewIdx: y, Text: b[y]})
+		} else {
+			x--
+			ops = append(ops, diffOp{Op: OpDelete, OldIdx: x, NewIdx: y, Text: a[x]})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{Op: OpContext, OldIdx: x, NewIdx: y, Text: a[x]})
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// groupHunks splits an edit script into hunks of changes surrounded by up to
+// context unchanged lines.
+func groupHunks(ops []diffOp, context int) []*Hunk {
+	var hunks []*Hunk
+	for i := 0; i < len(ops); {
+		if ops[i].Op == OpContext {
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		// extend the hunk while changes are separated by at most 2*context
+		// unchanged lines
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].Op != OpContext {
+				end = j
+				continue
+			}
+			if j-end > 2*context {
+				break
+			}
+		}
+		end = min(end+context+1, len(ops))
+
+		hunk := &Hunk{
+			OldStart: ops[start].OldIdx + 1,
+			NewStart: ops[start].NewIdx + 1,
+		}
+		for _, op := range ops[start:end] {
+			hunk.Lines = append(hunk.Lines, Line{Op: op.Op, Text: op.Text})
+		}
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return hunks
+}
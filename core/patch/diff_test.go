@@ -0,0 +1,61 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnified(t *testing.T) {
+	require.Empty(t, Unified([]byte(original), []byte(original), DefaultContext))
+
+	require.Equal(t, `@@ -1,5 +1,5 @@
+ one
+-two
++TWO
+ three
+ four
+ five
+@@ -7,4 +7,5 @@
+ seven
+ eight
+ nine
++nine and a half
+ ten
+`, Unified([]byte(original), []byte(strings.NewReplacer("two", "TWO", "nine\n", "nine\nnine and a half\n").Replace(original)), DefaultContext))
+
+	require.Equal(t, `@@ -0,0 +1 @@
++new
+\ No newline at end of file
+`, Unified(nil, []byte("new"), DefaultContext))
+
+	require.Equal(t, `@@ -1 +0,0 @@
+-bye
+`, Unified([]byte("bye\n"), nil, DefaultContext))
+}
+
+func TestUnifiedRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		old, new string
+	}{
+		{"edit", original, strings.Replace(original, "five", "FIVE", 1)},
+		{"prepend", original, "zero\n" + original},
+		{"append", original, original + "eleven\n"},
+		{"remove all", original, ""},
+		{"add all", "", original},
+		{"no trailing newline", "a\nb", "a\nc"},
+		{"add trailing newline", "a\nb", "a\nb\n"},
+		{"far apart", original + original + original, strings.Replace(original, "one", "1", 1) + original + strings.Replace(original, "ten", "10", 1)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			hunks := Unified([]byte(tc.old), []byte(tc.new), DefaultContext)
+			diffs, err := Parse("--- a/f\n+++ b/f\n" + hunks)
+			require.NoError(t, err)
+			out, err := Apply([]byte(tc.old), diffs[0], ApplyOpts{Strict: true})
+			require.NoError(t, err)
+			require.Equal(t, tc.new, string(out))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	continuityfs "github.com/containerd/continuity/fs"
+)
+
+const (
+	// gitTypeMask is the file type bits of a mode in the format of git.
+	gitTypeMask = 0o170000
+	// gitSymlinkMode is the mode of symlinks in the format of git, whose
+	// contents is their target.
+	gitSymlinkMode = 0o120000
+)
+
+// ApplyDir applies file diffs to the directory at root. Symlinks are patched
+// like files whose contents is their target.
+//
+// Every file diff is checked before the directory is modified, so that
+// either the whole patch is applied or nothing is. If hunks don't match, the
+// returned error is a *RejectError listing them for all files.
+func ApplyDir(root string, diffs []*FileDiff, opts ApplyOpts) error {
+	type pendingFile struct {
+		// contents is the target of symlinks
+		contents []byte
+		mode     fs.FileMode
+		symlink  bool
+		deleted  bool
+	}
+	// the state of every touched path once the patch is applied, so that
+	// several diffs may change the same file
+	pending := map[string]*pendingFile{}
+	var order []string
+	setPending := func(name string, pf *pendingFile) {
+		if _, ok := pending[name]; !ok {
+			order = append(order, name)
+		}
+		pending[name] = pf
+	}
+	load := func(name string) (*pendingFile, error) {
+		if pf, ok := pending[name]; ok {
+			if pf.deleted {
+				return nil, fmt.Errorf("%s: no such file", name)
+			}
+			return pf, nil
+		}
+		p, err := rootPath(root, name)
+		if err != nil {
+			return nil, err
+		}
+		fi, err := os.Lstat(p)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("%s: no such file", name)
+			}
+			return nil, err
+		}
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(p)
+			if err != nil {
+				return nil, err
+			}
+			return &pendingFile{contents: []byte(target), symlink: true}, nil
+		}
+		if !fi.Mode().IsRegular() {
+			return nil, fmt.Errorf("%s: not a regular file", name)
+		}
+		contents, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		return &pendingFile{contents: contents, mode: fi.Mode().Perm()}, nil
+	}
+	exists := func(name string) (bool, error) {
+		if pf, ok := pending[name]; ok {
+			return !pf.deleted, nil
+		}
+		p, err := rootPath(root, name)
+		if err != nil {
+			return false, err
+		}
+		_, err = os.Lstat(p)
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	var rejected []RejectedHunk
+	var errs []error
+	for _, fd := range diffs {
+		if fd.IsBinary {
+			errs = append(errs, fmt.Errorf("%s: binary patches are not supported", fd.Name()))
+			continue
+		}
+
+		var src *pendingFile
+		if fd.IsNew() {
+			ok, err := exists(fd.NewName)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok {
+				errs = append(errs, fmt.Errorf("%s: already exists", fd.NewName))
+				continue
+			}
+			src = &pendingFile{mode: 0o644}
+		} else {
+			var err error
+			src, err = load(fd.OldName)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		contents, err := Apply(src.contents, fd, opts)
+		if err != nil {
+			var rejectErr *RejectError
+			if errors.As(err, &rejectErr) {
+				rejected = append(rejected, rejectErr.Rejected...)
+			} else {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		if fd.IsDelete() {
+			if len(contents) != 0 {
+				errs = append(errs, fmt.Errorf("%s: file to delete has unexpected contents", fd.OldName))
+				continue
+			}
+			setPending(fd.OldName, &pendingFile{deleted: true})
+			continue
+		}
+
+		mode, symlink := src.mode, src.symlink
+		if fd.NewMode != 0 {
+			mode = fs.FileMode(fd.NewMode).Perm()
+			symlink = fd.NewMode&gitTypeMask == gitSymlinkMode
+		}
+		if fd.IsRename() {
+			setPending(fd.OldName, &pendingFile{deleted: true})
+		}
+		setPending(fd.NewName, &pendingFile{contents: contents, mode: mode, symlink: symlink})
+	}
+
+	if len(rejected) > 0 {
+		errs = append(errs, &RejectError{Rejected: rejected})
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, name := range order {
+		pf := pending[name]
+		p, err := rootPath(root, name)
+		if err != nil {
+			return err
+		}
+		if pf.deleted {
+			if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return err
+		}
+		// replace symlinks instead of writing through them
+		if fi, err := os.Lstat(p); err == nil && (pf.symlink || fi.Mode()&fs.ModeSymlink != 0) {
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+		}
+		if pf.symlink {
+			if err := os.Symlink(string(pf.contents), p); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.WriteFile(p, pf.contents, pf.mode); err != nil {
+			return err
+		}
+		// chmod again, since WriteFile is subject to the umask and doesn't
+		// change the mode of existing files
+		if err := os.Chmod(p, pf.mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rootPath returns the path of name below root, resolving symlinks in its
+// parent directories but not in name itself, which may be a symlink.
+func rootPath(root, name string) (string, error) {
+	clean := filepath.Clean(string(filepath.Separator) + filepath.FromSlash(name))
+	if clean == string(filepath.Separator) {
+		return "", fmt.Errorf("%q: not a file path", name)
+	}
+	dir, err := continuityfs.RootPath(root, filepath.Dir(clean))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.Base(clean)), nil
+}
@@ -0,0 +1,305 @@
+// Package patch parses unified diffs, including the output of git diff and
+// git format-patch, and applies them to file contents.
+package patch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FileDiff is the set of changes to a single file.
+type FileDiff struct {
+	// OldName is the path of the file before the change, or empty if the
+	// file is created.
+	OldName string
+	// NewName is the path of the file after the change, or empty if the file
+	// is deleted.
+	NewName string
+
+	// NewMode is the file mode set by the change, if any.
+	NewMode uint32
+
+	// IsBinary is set for binary changes, which can't be applied.
+	IsBinary bool
+
+	Hunks []*Hunk
+}
+
+// IsNew returns whether the file is created by the change.
+func (fd *FileDiff) IsNew() bool {
+	return fd.OldName == "" && fd.NewName != ""
+}
+
+// IsDelete returns whether the file is deleted by the change.
+func (fd *FileDiff) IsDelete() bool {
+	return fd.NewName == "" && fd.OldName != ""
+}
+
+// IsRename returns whether the file is moved by the change.
+func (fd *FileDiff) IsRename() bool {
+	return fd.OldName != "" && fd.NewName != "" && fd.OldName != fd.NewName
+}
+
+// Name returns the path of the file the change applies to.
+func (fd *FileDiff) Name() string {
+	if fd.NewName != "" {
+		return fd.NewName
+	}
+	return fd.OldName
+}
+
+// Hunk is a contiguous block of changes in a file.
+type Hunk struct {
+	OldStart, OldLines int
+	NewStart, NewLines int
+
+	// Section is the optional text after the hunk range, usually the
+	// enclosing function.
+	Section string
+
+	Lines []Line
+}
+
+// Header returns the @@ line of the hunk.
+func (h *Hunk) Header() string {
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+	if h.Section != "" {
+		header += " " + h.Section
+	}
+	return header
+}
+
+// Op is the kind of a line in a hunk.
+type Op byte
+
+const (
+	OpContext Op = ' '
+	OpDelete  Op = '-'
+	OpAdd     Op = '+'
+)
+
+// Line is a line of a hunk. Text includes the trailing newline, unless the
+// line is the last of a file without one.
+type Line struct {
+	Op   Op
+	Text string
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@ ?(.*)$`)
+
+// Parse parses the file diffs of a unified diff. Any text before, between or
+// after the file diffs (such as the email headers of git format-patch) is
+// ignored.
+func Parse(patch string) ([]*FileDiff, error) {
+	lines := strings.SplitAfter(patch, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var diffs []*FileDiff
+	var cur *FileDiff
+	// whether cur was started by a "diff --git" line, and its --- +++ headers
+	// are still allowed
+	var inGitHeader bool
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			oldName, newName, err := parseGitDiffLine(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			cur = &FileDiff{OldName: oldName, NewName: newName}
+			diffs = append(diffs, cur)
+			inGitHeader = true
+
+		case inGitHeader && strings.HasPrefix(line, "new file mode "):
+			cur.OldName = ""
+			cur.NewMode = parseMode(strings.TrimPrefix(line, "new file mode "))
+		case inGitHeader && strings.HasPrefix(line, "deleted file mode "):
+			cur.NewName = ""
+		case inGitHeader && strings.HasPrefix(line, "new mode "):
+			cur.NewMode = parseMode(strings.TrimPrefix(line, "new mode "))
+		case inGitHeader && strings.HasPrefix(line, "rename from "):
+			cur.OldName = unquote(strings.TrimPrefix(line, "rename from "))
+		case inGitHeader && strings.HasPrefix(line, "rename to "):
+			cur.NewName = unquote(strings.TrimPrefix(line, "rename to "))
+		case inGitHeader && (strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch"):
+			cur.IsBinary = true
+
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			oldName := parseHeaderName(strings.TrimPrefix(line, "--- "), "a/")
+			newName := parseHeaderName(strings.TrimPrefix(strings.TrimRight(lines[i+1], "\r\n"), "+++ "), "b/")
+			i++
+			if !inGitHeader {
+				cur = &FileDiff{}
+				diffs = append(diffs, cur)
+			}
+			cur.OldName = oldName
+			cur.NewName = newName
+			inGitHeader = false
+
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				return nil, fmt.Errorf("line %d: hunk without file header", i+1)
+			}
+			inGitHeader = false
+			hunk, next, err := parseHunk(lines, i)
+			if err != nil {
+				return nil, err
+			}
+			cur.Hunks = append(cur.Hunks, hunk)
+			i = next - 1
+
+		default:
+			// any other git extended header (index, similarity, mode) or
+			// surrounding text is ignored
+		}
+	}
+
+	if len(diffs) == 0 {
+		return nil, errors.New("no file diffs found in patch")
+	}
+	return diffs, nil
+}
+
+func parseHunk(lines []string, start int) (*Hunk, int, error) {
+	header := strings.TrimRight(lines[start], "\r\n")
+	m := hunkHeader.FindStringSubmatch(header)
+	if m == nil {
+		return nil, 0, fmt.Errorf("line %d: malformed hunk header %q", start+1, header)
+	}
+	hunk := &Hunk{
+		OldStart: atoi(m[1], 0),
+		OldLines: atoi(m[2], 1),
+		NewStart: atoi(m[3], 0),
+		NewLines: atoi(m[4], 1),
+		Section:  m[5],
+	}
+
+	oldLeft, newLeft := hunk.OldLines, hunk.NewLines
+	i := start + 1
+	for ; i < len(lines) && (oldLeft > 0 || newLeft > 0); i++ {
+		text := lines[i]
+		if text == "\n" || text == "\r\n" {
+			// some tools strip the trailing space of empty context lines
+			text = " " + text
+		}
+		if text == "" {
+			break
+		}
+		op := Op(text[0])
+		switch op {
+		case OpContext:
+			oldLeft--
+			newLeft--
+		case OpDelete:
+			oldLeft--
+		case OpAdd:
+			newLeft--
+		case '\\':
+			trimLastNewline(hunk)
+			continue
+		default:
+			return nil, 0, fmt.Errorf("line %d: unexpected line in hunk %q", i+1, hunk.Header())
+		}
+		hunk.Lines = append(hunk.Lines, Line{Op: op, Text: text[1:]})
+	}
+	if oldLeft != 0 || newLeft != 0 {
+		return nil, 0, fmt.Errorf("line %d: hunk %q is truncated", i, hunk.Header())
+	}
+	// a "\ No newline at end of file" marker may follow the last line
+	if i < len(lines) && strings.HasPrefix(lines[i], "\\") {
+		trimLastNewline(hunk)
+		i++
+	}
+	return hunk, i, nil
+}
+
+func trimLastNewline(hunk *Hunk) {
+	if len(hunk.Lines) == 0 {
+		return
+	}
+	last := &hunk.Lines[len(hunk.Lines)-1]
+	last.Text = strings.TrimSuffix(strings.TrimSuffix(last.Text, "\n"), "\r")
+}
+
+func parseGitDiffLine(line string) (string, string, error) {
+	rest := strings.TrimPrefix(line, "diff --git ")
+	if strings.HasPrefix(rest, `"`) {
+		oldName, after, err := cutQuoted(rest)
+		if err != nil {
+			return "", "", err
+		}
+		return strings.TrimPrefix(oldName, "a/"), strings.TrimPrefix(unquote(strings.TrimSpace(after)), "b/"), nil
+	}
+	// names without spaces are unambiguous; otherwise rely on both names
+	// being equal, as git does
+	if idx := strings.Index(rest, " b/"); idx != -1 && strings.HasPrefix(rest, "a/") {
+		return rest[2:idx], rest[idx+3:], nil
+	}
+	oldName, newName, ok := strings.Cut(rest, " ")
+	if !ok {
+		return "", "", fmt.Errorf("malformed diff header %q", line)
+	}
+	return oldName, newName, nil
+}
+
+func parseHeaderName(s, prefix string) string {
+	// strip trailing timestamps, separated by a tab
+	s, _, _ = strings.Cut(s, "\t")
+	s = unquote(strings.TrimSpace(s))
+	if s == "/dev/null" {
+		return ""
+	}
+	return strings.TrimPrefix(s, prefix)
+}
+
+func cutQuoted(s string) (string, string, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			name, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", err
+			}
+			return name, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated quoted name %q", s)
+}
+
+func unquote(s string) string {
+	if strings.HasPrefix(s, `"`) {
+		if name, err := strconv.Unquote(s); err == nil {
+			return name
+		}
+	}
+	return s
+}
+
+func parseMode(s string) uint32 {
+	mode, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(mode)
+}
+
+func atoi(s string, def int) int {
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return def
+	}
+	return n
+}
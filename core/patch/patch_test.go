@@ -0,0 +1,272 @@
+package patch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const original = `one
+two
+three
+four
+five
+six
+seven
+eight
+nine
+ten
+`
+
+func TestParseGitFormatPatch(t *testing.T) {
+	diffs, err := Parse(`From 1234 Mon Sep 17 00:00:00 2001
+From: Someone <someone@example.com>
+Subject: [PATCH] change things
+
+---
+ a.txt | 2 +-
+ 1 file changed
+
+diff --git a/a.txt b/a.txt
+index 1111111..2222222 100644
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@ section
+ one
+-two
++TWO
+ three
+diff --git a/new.txt b/new.txt
+new file mode 100755
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1 @@
++new
+\ No newline at end of file
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/old name.txt b/new name.txt
+similarity index 100%
+rename from old name.txt
+rename to new name.txt
+-- 
+2.40.0
+`)
+	require.NoError(t, err)
+	require.Len(t, diffs, 4)
+
+	require.Equal(t, "a.txt", diffs[0].OldName)
+	require.Equal(t, "a.txt", diffs[0].NewName)
+	require.Len(t, diffs[0].Hunks, 1)
+	require.Equal(t, "@@ -1,3 +1,3 @@ section", diffs[0].Hunks[0].Header())
+	require.Equal(t, []Line{
+		{OpContext, "one\n"},
+		{OpDelete, "two\n"},
+		{OpAdd, "TWO\n"},
+		{OpContext, "three\n"},
+	}, diffs[0].Hunks[0].Lines)
+
+	require.True(t, diffs[1].IsNew())
+	require.Equal(t, uint32(0o100755), diffs[1].NewMode)
+	require.Equal(t, []Line{{OpAdd, "new"}}, diffs[1].Hunks[0].Lines)
+
+	require.True(t, diffs[2].IsDelete())
+	require.Equal(t, "gone.txt", diffs[2].Name())
+
+	require.True(t, diffs[3].IsRename())
+	require.Equal(t, "old name.txt", diffs[3].OldName)
+	require.Equal(t, "new name.txt", diffs[3].NewName)
+	require.Empty(t, diffs[3].Hunks)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("just some text\n")
+	require.ErrorContains(t, err, "no file diffs found")
+
+	_, err = Parse("--- a/x\n+++ b/x\n@@ -1,2 +1,2 @@\n-a\n")
+	require.ErrorContains(t, err, "is truncated")
+}
+
+func TestApply(t *testing.T) {
+	mustParse := func(t *testing.T, s string) *FileDiff {
+		t.Helper()
+		diffs, err := Parse(s)
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		return diffs[0]
+	}
+
+	t.Run("exact", func(t *testing.T) {
+		fd := mustParse(t, `--- a/f
++++ b/f
+@@ -2,3 +2,3 @@
+ two
+-three
++THREE
+ four
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`)
+		out, err := Apply([]byte(original), fd, ApplyOpts{Strict: true})
+		require.NoError(t, err)
+		require.Equal(t, "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n", string(out))
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		fd := mustParse(t, `--- a/f
++++ b/f
+@@ -4,3 +4,3 @@
+ six
+-seven
++SEVEN
+ eight
+`)
+		_, err := Apply([]byte(original), fd, ApplyOpts{Strict: true})
+		var rejectErr *RejectError
+		require.True(t, errors.As(err, &rejectErr))
+		require.Len(t, rejectErr.Rejected, 1)
+		require.ErrorContains(t, err, "f: hunk #1 @@ -4,3 +4,3 @@")
+
+		out, err := Apply([]byte(original), fd, ApplyOpts{})
+		require.NoError(t, err)
+		require.Equal(t, "one\ntwo\nthree\nfour\nfive\nsix\nSEVEN\neight\nnine\nten\n", string(out))
+	})
+
+	t.Run("fuzz", func(t *testing.T) {
+		fd := mustParse(t, `--- a/f
++++ b/f
+@@ -3,5 +3,5 @@
+ changed
+ four
+-five
++FIVE
+ six
+ changed
+`)
+		_, err := Apply([]byte(original), fd, ApplyOpts{})
+		require.ErrorContains(t, err, "1 hunk(s) could not be applied")
+
+		out, err := Apply([]byte(original), fd, ApplyOpts{Fuzz: 1})
+		require.NoError(t, err)
+		require.Equal(t, "one\ntwo\nthree\nfour\nFIVE\nsix\nseven\neight\nnine\nten\n", string(out))
+	})
+
+	t.Run("no newline at end of file", func(t *testing.T) {
+		fd := mustParse(t, `--- a/f
++++ b/f
+@@ -1,2 +1,2 @@
+ a
+-b
+\ No newline at end of file
++b
+`)
+		out, err := Apply([]byte("a\nb"), fd, ApplyOpts{})
+		require.NoError(t, err)
+		require.Equal(t, "a\nb\n", string(out))
+	})
+
+	t.Run("new file", func(t *testing.T) {
+		fd := mustParse(t, `--- /dev/null
++++ b/f
+@@ -0,0 +1,2 @@
++hello
++world
+`)
+		out, err := Apply(nil, fd, ApplyOpts{Strict: true})
+		require.NoError(t, err)
+		require.Equal(t, "hello\nworld\n", string(out))
+	})
+}
+
+func TestApplyDir(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, "a.txt"), []byte("one\ntwo\nthree\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "gone.txt"), []byte("bye\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "old.txt"), []byte("moved\n"), 0o644))
+
+	diffs, err := Parse(`diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ one
+-two
++TWO
+ three
+diff --git a/sub/new.txt b/sub/new.txt
+new file mode 100755
+--- /dev/null
++++ b/sub/new.txt
+@@ -0,0 +1 @@
++new
+diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+--- a/gone.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-bye
+diff --git a/old.txt b/new.txt
+similarity index 100%
+rename from old.txt
+rename to new.txt
+`)
+	require.NoError(t, err)
+
+	t.Run("rejected", func(t *testing.T) {
+		bad, err := Parse(`--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-nope
++NOPE
+--- a/missing.txt
++++ b/missing.txt
+@@ -1 +1 @@
+-a
++b
+`)
+		require.NoError(t, err)
+		err = ApplyDir(root, append(diffs, bad...), ApplyOpts{})
+		require.ErrorContains(t, err, "missing.txt: no such file")
+		require.ErrorContains(t, err, "a.txt: hunk #1 @@ -1,1 +1,1 @@")
+		var rejectErr *RejectError
+		require.True(t, errors.As(err, &rejectErr))
+
+		// nothing was applied
+		dt, err := os.ReadFile(filepath.Join(root, "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "one\ntwo\nthree\n", string(dt))
+		require.FileExists(t, filepath.Join(root, "gone.txt"))
+	})
+
+	require.NoError(t, ApplyDir(root, diffs, ApplyOpts{}))
+
+	dt, err := os.ReadFile(filepath.Join(root, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "one\nTWO\nthree\n", string(dt))
+	fi, err := os.Stat(filepath.Join(root, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	dt, err = os.ReadFile(filepath.Join(root, "sub/new.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "new\n", string(dt))
+	fi, err = os.Stat(filepath.Join(root, "sub/new.txt"))
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o755), fi.Mode().Perm())
+
+	require.NoFileExists(t, filepath.Join(root, "gone.txt"))
+	require.NoFileExists(t, filepath.Join(root, "old.txt"))
+	dt, err = os.ReadFile(filepath.Join(root, "new.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "moved\n", string(dt))
+}
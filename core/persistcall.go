@@ -0,0 +1,328 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/dagger/dagger/engine/cache/callindex"
+)
+
+// The return types of the function calls recorded in the call index.
+var persistedReturnTypes = []string{"Directory", "File", "Container"}
+
+// The types of results that are isolated per-client, and so can't be loaded
+// from a recorded ID by another client.
+var clientIsolatedTypes = []string{"Secret", "Socket"}
+
+func (fn *ModuleFunction) persisted() bool {
+	retType := fn.metadata.ReturnType
+	return retType != nil &&
+		retType.Kind == TypeDefKindObject &&
+		slices.Contains(persistedReturnTypes, retType.AsObject.Value.Name)
+}
+
+// loadPersistedCall returns the result of the current call recorded in the
+// call index, if there is one that still loads against the current schema.
+func (fn *ModuleFunction) loadPersistedCall(ctx context.Context, idx *callindex.Index, srv *dagql.Server) (dagql.Typed, bool) {
+	callDigest := dagql.CurrentID(ctx).Digest()
+	ent, ok, err := idx.Get(callDigest)
+	if err != nil {
+		bklog.G(ctx).WithError(err).Warn("failed to get recorded function call")
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var resultID call.ID
+	if err := resultID.Decode(ent.ResultID); err != nil {
+		bklog.G(ctx).WithError(err).Warn("failed to decode recorded function call result")
+		return nil, false
+	}
+	schemaDigest, err := idSchemaDigest(srv, &resultID)
+	if err != nil || schemaDigest != ent.SchemaDigest {
+		// the API the result was built with changed since it was recorded
+		bklog.G(ctx).WithError(err).Debug("recorded function call result is outdated")
+		if err := idx.Delete(callDigest); err != nil {
+			bklog.G(ctx).WithError(err).Warn("failed to delete recorded function call")
+		}
+		return nil, false
+	}
+
+	res, err := srv.Load(ctx, &resultID)
+	if err != nil {
+		// the result may depend on something this client can't load, so just
+		// call the function
+		bklog.G(ctx).WithError(err).Debug("failed to load recorded function call result")
+		return nil, false
+	}
+	idx.Touch(callDigest)
+	bklog.G(ctx).Debug("function call loaded from call index")
+	return res, true
+}
+
+// persistCall records the result of the current call in the call index, if
+// it can be reproduced from its ID by any client.
+func (fn *ModuleFunction) persistCall(ctx context.Context, idx *callindex.Index, srv *dagql.Server, result dagql.Typed) {
+	obj, ok := result.(dagql.Object)
+	if !ok {
+		return
+	}
+	resultID := obj.ID()
+	currentID := dagql.CurrentID(ctx)
+	if !reproducibleID(currentID, resultID) {
+		bklog.G(ctx).Debug("function call result is not reproducible, not recording it")
+		return
+	}
+
+	encoded, err := resultID.Encode()
+	if err != nil {
+		bklog.G(ctx).WithError(err).Warn("failed to encode function call result")
+		return
+	}
+	schemaDigest, err := idSchemaDigest(srv, resultID)
+	if err != nil {
+		bklog.G(ctx).WithError(err).Warn("failed to digest function call result schema")
+		return
+	}
+	// the engine's GC keeps the snapshots of the result while it's recorded,
+	// so that loading it doesn't call the function again anyway
+	records, err := resultRecords(ctx, result)
+	if err != nil {
+		bklog.G(ctx).WithError(err).Warn("failed to get function call result snapshots")
+		return
+	}
+	err = idx.Put(currentID.Digest(), callindex.Entry{
+		ResultID:     encoded,
+		SchemaDigest: schemaDigest,
+		Records:      records,
+	})
+	if err != nil {
+		bklog.G(ctx).WithError(err).Warn("failed to record function call")
+	}
+}
+
+// resultRecords returns the IDs of the buildkit cache records backing the
+// snapshots of a function call result, evaluating it if it wasn't already.
+func resultRecords(ctx context.Context, result dagql.Typed) ([]string, error) {
+	var records []string
+	addResult := func(res *buildkit.Result) error {
+		if res == nil {
+			return nil
+		}
+		ref, err := res.SingleRef()
+		if err != nil || ref == nil {
+			return err
+		}
+		cacheRef, err := ref.CacheRef(ctx)
+		if err != nil {
+			return err
+		}
+		records = append(records, cacheRef.ID())
+		return nil
+	}
+
+	if dir, ok := dagql.UnwrapAs[*Directory](result); ok {
+		if dir.Result != nil {
+			return []string{dir.Result.ID()}, nil
+		}
+		res, err := dir.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return records, addResult(res)
+	}
+	if file, ok := dagql.UnwrapAs[*File](result); ok {
+		if file.Result != nil {
+			return []string{file.Result.ID()}, nil
+		}
+		res, err := file.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return records, addResult(res)
+	}
+	if ctr, ok := dagql.UnwrapAs[*Container](result); ok {
+		res, err := ctr.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := addResult(res); err != nil {
+			return nil, err
+		}
+		bk, err := ctr.Query.Buildkit(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get buildkit client: %w", err)
+		}
+		for _, mnt := range ctr.Mounts {
+			if mnt.Source == nil || mnt.CacheVolumeID != "" || mnt.Tmpfs {
+				continue
+			}
+			res, err := bk.Solve(ctx, bkgw.SolveRequest{
+				Evaluate:   true,
+				Definition: mnt.Source,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate mount %s: %w", mnt.Target, err)
+			}
+			if err := addResult(res); err != nil {
+				return nil, err
+			}
+		}
+		return records, nil
+	}
+	return nil, fmt.Errorf("unexpected function call result %T", result)
+}
+
+// reproducibleID returns whether loading the result ID of a call from any
+// client reproduces the result. The parts of the result passed in by the
+// caller are covered by the call digest, so only the calls made by the
+// function are checked: they must not be scoped to the function's client or
+// to a single call, and must not call other modules, which may not be served
+// to the caller.
+func reproducibleID(callID, resultID *call.ID) bool {
+	callDAG, err := callID.ToProto()
+	if err != nil {
+		return false
+	}
+	resultDAG, err := resultID.ToProto()
+	if err != nil {
+		return false
+	}
+	for _, c := range resultDAG.CallsByDigest {
+		if slices.Contains(clientIsolatedTypes, c.Type.NamedType) {
+			return false
+		}
+	}
+
+	seen := map[digest.Digest]bool{}
+	var visit func(*call.ID) bool
+	var visitLit func(call.Literal) bool
+	visit = func(id *call.ID) bool {
+		if id == nil || seen[id.Digest()] {
+			return true
+		}
+		seen[id.Digest()] = true
+		if _, ok := callDAG.CallsByDigest[string(id.Digest())]; ok {
+			return true
+		}
+		if id.Module() != nil || strings.HasPrefix(id.Field(), "__") {
+			return false
+		}
+		// a custom digest is set by calls scoped to a client, session or
+		// single call, and by content hashes of host directories
+		if id.Nth() == 0 && id.WithDigest("").Digest() != id.Digest() {
+			return false
+		}
+		if !visit(id.Receiver()) {
+			return false
+		}
+		for _, arg := range id.Args() {
+			if !visitLit(arg.Value()) {
+				return false
+			}
+		}
+		return true
+	}
+	visitLit = func(lit call.Literal) bool {
+		switch x := lit.(type) {
+		case *call.LiteralID:
+			return visit(x.Value())
+		case *call.LiteralList:
+			ok := true
+			x.Range(func(_ int, lit call.Literal) error {
+				ok = ok && visitLit(lit)
+				return nil
+			})
+			return ok
+		case *call.LiteralObject:
+			ok := true
+			x.Range(func(_ int, _ string, lit call.Literal) error {
+				ok = ok && visitLit(lit)
+				return nil
+			})
+			return ok
+		}
+		return true
+	}
+	return visit(resultID)
+}
+
+// idSchemaDigest returns a digest of the signatures of the fields called by
+// the ID, so that a recorded ID can be checked to still be valid against the
+// schema of a later engine.
+func idSchemaDigest(srv *dagql.Server, id *call.ID) (digest.Digest, error) {
+	sigs := map[string]struct{}{}
+	seen := map[digest.Digest]bool{}
+	var visit func(*call.ID) error
+	var visitLit func(call.Literal) error
+	visit = func(id *call.ID) error {
+		if id == nil || seen[id.Digest()] {
+			return nil
+		}
+		seen[id.Digest()] = true
+
+		typeName := srv.Root().Type().Name()
+		if id.Receiver() != nil {
+			typeName = id.Receiver().Type().NamedType()
+		}
+		objType, ok := srv.ObjectType(typeName)
+		if !ok {
+			return fmt.Errorf("unknown type %q", typeName)
+		}
+		spec, ok := objType.FieldSpec(id.Field(), id.View())
+		if !ok {
+			return fmt.Errorf("unknown field %s.%s", typeName, id.Field())
+		}
+		def := spec.FieldDefinition()
+		args := make([]string, len(def.Arguments))
+		for i, arg := range def.Arguments {
+			args[i] = arg.Name + ": " + arg.Type.String()
+		}
+		sigs[fmt.Sprintf("%s.%s(%s): %s", typeName, def.Name, strings.Join(args, ", "), def.Type.String())] = struct{}{}
+
+		if err := visit(id.Receiver()); err != nil {
+			return err
+		}
+		for _, arg := range id.Args() {
+			if err := visitLit(arg.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	visitLit = func(lit call.Literal) error {
+		switch x := lit.(type) {
+		case *call.LiteralID:
+			return visit(x.Value())
+		case *call.LiteralList:
+			return x.Range(func(_ int, lit call.Literal) error {
+				return visitLit(lit)
+			})
+		case *call.LiteralObject:
+			return x.Range(func(_ int, _ string, lit call.Literal) error {
+				return visitLit(lit)
+			})
+		}
+		return nil
+	}
+	if err := visit(id); err != nil {
+		return "", err
+	}
+
+	sorted := make([]string, 0, len(sigs))
+	for sig := range sigs {
+		sorted = append(sorted, sig+"\n")
+	}
+	slices.Sort(sorted)
+	return dagql.HashFrom(sorted...), nil
+}
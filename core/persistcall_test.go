@@ -0,0 +1,72 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestReproducibleID(t *testing.T) {
+	dirType := ast.NonNullNamedType("Directory", nil)
+	strArg := func(name, val string) *call.Argument {
+		return call.NewArgument(name, call.NewLiteralString(val), false)
+	}
+	idArg := func(name string, id *call.ID) *call.Argument {
+		return call.NewArgument(name, call.NewLiteralID(id), false)
+	}
+
+	// a host directory passed in by the caller, with a content hash digest
+	hostDir := call.New().
+		Append(ast.NonNullNamedType("Host", nil), "host", "", nil, 0, "").
+		Append(dirType, "directory", "", nil, 0, dagql.HashFrom("content"), strArg("path", "."))
+	mod := call.NewModule(call.New().Append(ast.NonNullNamedType("Module", nil), "module", "", nil, 0, ""), "test", "ref", "pin")
+	callID := call.New().
+		Append(ast.NonNullNamedType("Test", nil), "test", "", mod, 0, "").
+		Append(dirType, "build", "", mod, 0, "", idArg("source", hostDir))
+
+	base := call.New().
+		Append(ast.NonNullNamedType("Container", nil), "container", "", nil, 0, "").
+		Append(ast.NonNullNamedType("Container", nil), "from", "", nil, 0, "", strArg("address", "alpine"))
+
+	t.Run("core calls", func(t *testing.T) {
+		resultID := base.
+			Append(ast.NonNullNamedType("Container", nil), "withDirectory", "", nil, 0, "", strArg("path", "/src"), idArg("source", hostDir)).
+			Append(dirType, "directory", "", nil, 0, "", strArg("path", "/out"))
+		require.True(t, reproducibleID(callID, resultID))
+	})
+
+	t.Run("function host", func(t *testing.T) {
+		fnHostDir := call.New().
+			Append(ast.NonNullNamedType("Host", nil), "host", "", nil, 0, "").
+			Append(dirType, "directory", "", nil, 0, dagql.HashFrom("other content"), strArg("path", "/tmp"))
+		resultID := base.
+			Append(ast.NonNullNamedType("Container", nil), "withDirectory", "", nil, 0, "", strArg("path", "/src"), idArg("source", fnHostDir))
+		require.False(t, reproducibleID(callID, resultID))
+	})
+
+	t.Run("module calls", func(t *testing.T) {
+		resultID := call.New().
+			Append(ast.NonNullNamedType("Dep", nil), "dep", "", mod, 0, "").
+			Append(dirType, "build", "", mod, 0, "")
+		require.False(t, reproducibleID(callID, resultID))
+	})
+
+	t.Run("hidden fields", func(t *testing.T) {
+		resultID := call.New().
+			Append(ast.NonNullNamedType("CacheVolume", nil), "cacheVolume", "", nil, 0, "", strArg("key", "cache")).
+			Append(dirType, "__snapshot", "", nil, 0, "", strArg("nonce", "abc"))
+		require.False(t, reproducibleID(callID, resultID))
+	})
+
+	t.Run("secrets", func(t *testing.T) {
+		secret := call.New().
+			Append(ast.NonNullNamedType("Secret", nil), "secret", "", nil, 0, "", strArg("uri", "env://TOKEN"))
+		resultID := base.
+			Append(ast.NonNullNamedType("Container", nil), "withSecretVariable", "", nil, 0, "", strArg("name", "TOKEN"), idArg("secret", secret))
+		require.False(t, reproducibleID(callID, resultID))
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/dagger/dagger/dagql/call"
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/dagger/dagger/engine/cache/callindex"
 	"github.com/dagger/dagger/engine/server/resource"
 )
 
@@ -90,14 +91,35 @@ type Server interface {
 	// The lease manager for the engine as a whole
 	LeaseManager() *leaseutil.Manager
 
+	// The persistent index of function call results for the engine as a
+	// whole, or nil if it's disabled
+	CallIndex() *callindex.Index
+
 	// Return all the cache entries in the local cache. No support for filtering yet.
 	EngineLocalCacheEntries(context.Context) (*EngineCacheEntrySet, error)
 
-	// Prune everything that is releasable in the local cache. No support for filtering yet.
-	PruneEngineLocalCacheEntries(context.Context) (*EngineCacheEntrySet, error)
+	// Prune the releasable entries of the local cache selected by the options.
+	PruneEngineLocalCacheEntries(context.Context, EngineCachePruneOpts) (*EngineCacheEntrySet, error)
 
 	// The default local cache policy to use for automatic local cache GC.
 	EngineLocalCachePolicy() *bkclient.PruneInfo
+
+	// Return the cache volumes in the local cache.
+	EngineCacheVolumes(context.Context) ([]*EngineCacheVolume, error)
+
+	// Delete the contents of a cache volume from the local cache.
+	DeleteCacheVolume(context.Context, *CacheVolume) error
+
+	// Mount a cache volume, creating it if it doesn't exist.
+	MountCacheVolume(context.Context, *CacheVolume, func(string) error) error
+
+	// Record a call in the session's index of recent calls, so that it can
+	// be explained if it wasn't cached.
+	RecordCall(ctx context.Context, id *call.ID, policy dagql.CachePolicy, cached bool)
+
+	// Explain why a call of the session, given by digest or encoded ID, was
+	// not cached.
+	ExplainCacheMiss(context.Context, string) (*EngineCacheMiss, error)
 }
 
 func NewRoot(srv Server) *Query {
@@ -3,6 +3,9 @@ package schema
 import (
 	"context"
 	"errors"
+	"fmt"
+
+	"github.com/moby/buildkit/identity"
 
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/dagql"
@@ -25,7 +28,23 @@ func (s *cacheSchema) Install() {
 			ArgDoc("key", `A string identifier to target this cache volume (e.g., "modules-cache").`),
 	}.Install(s.srv)
 
-	dagql.Fields[*core.CacheVolume]{}.Install(s.srv)
+	dagql.Fields[*core.CacheVolume]{
+		dagql.NodeFunc("snapshot", s.snapshot).
+			DoNotCache("The contents of the cache volume change while it's used.").
+			Doc(`Returns a copy of the current contents of the cache volume.`,
+				`Only the contents of the volume when mounted without a source directory are included.`),
+		dagql.NodeFunc("__snapshot", DagOpDirectoryWrapper(s.srv, s.snapshotDagOp, nil)).
+			Doc(`(Internal-only) A copy of the contents of the cache volume at the time of the given nonce.`),
+		dagql.Func("seed", s.seed).
+			DoNotCache("Mutates mutable state").
+			Doc(`Replaces the contents of the cache volume with the contents of a directory.`,
+				`The contents are used when the volume is mounted without a source directory.`).
+			ArgDoc("source", `The directory to copy into the cache volume.`),
+		dagql.Func("delete", s.delete).
+			DoNotCache("Mutates mutable state").
+			Doc(`Deletes the contents of the cache volume from the local cache.`,
+				`Containers currently using the volume keep their contents until they exit; later mounts start empty.`),
+	}.Install(s.srv)
 }
 
 func (s *cacheSchema) Dependencies() []SchemaResolvers {
@@ -102,3 +121,58 @@ func namespaceFromModule(m *core.Module) string {
 
 	return "mod(" + name + symbolic + ")"
 }
+
+func (s *cacheSchema) snapshot(ctx context.Context, parent dagql.Instance[*core.CacheVolume], args struct{}) (inst dagql.Instance[*core.Directory], _ error) {
+	err := s.srv.Select(ctx, parent, &inst, dagql.Selector{
+		Field: "__snapshot",
+		Args: []dagql.NamedInput{
+			{
+				Name:  "nonce",
+				Value: dagql.NewString(identity.NewID()),
+			},
+		},
+	})
+	return inst, err
+}
+
+type cacheSnapshotArgs struct {
+	Nonce string
+}
+
+func (s *cacheSchema) snapshotDagOp(ctx context.Context, parent dagql.Instance[*core.CacheVolume], args cacheSnapshotArgs) (inst dagql.Instance[*core.Directory], _ error) {
+	query, ok := s.srv.Root().(dagql.Instance[*core.Query])
+	if !ok {
+		return inst, fmt.Errorf("failed to get root query")
+	}
+	dir, err := parent.Self.Snapshot(ctx, query.Self)
+	if err != nil {
+		return inst, err
+	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, dir)
+}
+
+type cacheSeedArgs struct {
+	Source core.DirectoryID
+}
+
+func (s *cacheSchema) seed(ctx context.Context, parent *core.CacheVolume, args cacheSeedArgs) (dagql.Nullable[core.Void], error) {
+	void := dagql.Null[core.Void]()
+	query, ok := s.srv.Root().(dagql.Instance[*core.Query])
+	if !ok {
+		return void, fmt.Errorf("failed to get root query")
+	}
+	source, err := args.Source.Load(ctx, s.srv)
+	if err != nil {
+		return void, err
+	}
+	return void, parent.Seed(ctx, query.Self, source.Self)
+}
+
+func (s *cacheSchema) delete(ctx context.Context, parent *core.CacheVolume, args struct{}) (dagql.Nullable[core.Void], error) {
+	void := dagql.Null[core.Void]()
+	query, ok := s.srv.Root().(dagql.Instance[*core.Query])
+	if !ok {
+		return void, fmt.Errorf("failed to get root query")
+	}
+	return void, parent.Delete(ctx, query.Self)
+}
@@ -0,0 +1,283 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/compose"
+	"github.com/dagger/dagger/dagql"
+)
+
+type composeSchema struct {
+	srv *dagql.Server
+}
+
+var _ SchemaResolvers = &composeSchema{}
+
+func (s *composeSchema) Install() {
+	dagql.Fields[*core.Directory]{
+		dagql.NodeFunc("asComposeStack", s.asComposeStack).
+			Doc(`Load the services of a Compose file in this directory.`,
+				`Variables are interpolated from the .env file next to the Compose file, if any.`).
+			ArgDoc("file", `Path to the Compose file (e.g., "stack/compose.yaml").`),
+	}.Install(s.srv)
+
+	dagql.Fields[*core.ComposeStack]{
+		dagql.Func("serviceNames", s.serviceNames).
+			Doc(`The names of the services of the stack, in alphabetical order.`),
+
+		dagql.NodeFunc("service", s.service).
+			Doc(`Retrieves a service of the stack.`,
+				`The service is reachable by its name within the session, and starts the services it depends on first.`,
+				`Named volumes are mounted as cache volumes, and bind mounts from the directory of the Compose file.`).
+			ArgDoc("name", `The name of the service in the Compose file.`),
+	}.Install(s.srv)
+}
+
+func (s *composeSchema) asComposeStack(ctx context.Context, parent dagql.Instance[*core.Directory], args struct {
+	File string `default:"docker-compose.yml"`
+}) (*core.ComposeStack, error) {
+	return core.NewComposeStack(ctx, parent, args.File)
+}
+
+func (s *composeSchema) serviceNames(ctx context.Context, parent *core.ComposeStack, args struct{}) (dagql.Array[dagql.String], error) {
+	return dagql.NewStringArray(parent.Project.ServiceNames()...), nil
+}
+
+//nolint:gocyclo
+func (s *composeSchema) service(ctx context.Context, parent dagql.Instance[*core.ComposeStack], args struct {
+	Name string
+}) (inst dagql.Instance[*core.Service], _ error) {
+	stack := parent.Self
+	def, err := stack.ComposeService(args.Name)
+	if err != nil {
+		return inst, err
+	}
+
+	var ctr dagql.Instance[*core.Container]
+	if def.Build != nil {
+		buildctx, err := stack.ResolvePath(def.Build.Context)
+		if err != nil {
+			return inst, fmt.Errorf("service %q: %w", args.Name, err)
+		}
+		buildArgs := dagql.ArrayInput[dagql.InputObject[core.BuildArg]]{}
+		for _, name := range def.Build.Args.Sorted() {
+			buildArgs = append(buildArgs, dagql.InputObject[core.BuildArg]{
+				Value: core.BuildArg{Name: name, Value: def.Build.Args[name]},
+			})
+		}
+		dockerfile := def.Build.Dockerfile
+		if dockerfile == "" {
+			dockerfile = "Dockerfile"
+		}
+		err = s.srv.Select(ctx, stack.Dir, &ctr,
+			dagql.Selector{
+				Field: "directory",
+				Args:  []dagql.NamedInput{{Name: "path", Value: dagql.NewString(buildctx)}},
+			},
+			dagql.Selector{
+				Field: "dockerBuild",
+				Args: []dagql.NamedInput{
+					{Name: "dockerfile", Value: dagql.NewString(dockerfile)},
+					{Name: "buildArgs", Value: buildArgs},
+					{Name: "target", Value: dagql.NewString(def.Build.Target)},
+				},
+			},
+		)
+		if err != nil {
+			return inst, fmt.Errorf("service %q: build: %w", args.Name, err)
+		}
+	} else {
+		err := s.srv.Select(ctx, s.srv.Root(), &ctr,
+			dagql.Selector{Field: "container"},
+			dagql.Selector{
+				Field: "from",
+				Args:  []dagql.NamedInput{{Name: "address", Value: dagql.NewString(def.Image)}},
+			},
+		)
+		if err != nil {
+			return inst, fmt.Errorf("service %q: %w", args.Name, err)
+		}
+	}
+
+	var sels []dagql.Selector
+	if def.WorkingDir != "" {
+		sels = append(sels, dagql.Selector{
+			Field: "withWorkdir",
+			Args:  []dagql.NamedInput{{Name: "path", Value: dagql.NewString(def.WorkingDir)}},
+		})
+	}
+	if def.User != "" {
+		sels = append(sels, dagql.Selector{
+			Field: "withUser",
+			Args:  []dagql.NamedInput{{Name: "name", Value: dagql.NewString(def.User)}},
+		})
+	}
+	for _, name := range def.Environment.Sorted() {
+		sels = append(sels, dagql.Selector{
+			Field: "withEnvVariable",
+			Args: []dagql.NamedInput{
+				{Name: "name", Value: dagql.NewString(name)},
+				{Name: "value", Value: dagql.NewString(def.Environment[name])},
+			},
+		})
+	}
+	if def.Entrypoint != nil {
+		sels = append(sels, dagql.Selector{
+			Field: "withEntrypoint",
+			Args: []dagql.NamedInput{
+				{Name: "args", Value: dagql.ArrayInput[dagql.String](dagql.NewStringArray(def.Entrypoint...))},
+			},
+		})
+	}
+
+	for _, mnt := range def.Volumes {
+		sel, err := s.mountSelector(ctx, stack, args.Name, mnt)
+		if err != nil {
+			return inst, fmt.Errorf("service %q: volume %s: %w", args.Name, mnt.Target, err)
+		}
+		sels = append(sels, sel)
+	}
+
+	for _, port := range append(append([]compose.Port{}, def.Ports...), def.Expose...) {
+		protocol := core.NetworkProtocolTCP
+		if port.Protocol == "udp" {
+			protocol = core.NetworkProtocolUDP
+		}
+		sels = append(sels, dagql.Selector{
+			Field: "withExposedPort",
+			Args: []dagql.NamedInput{
+				{Name: "port", Value: dagql.NewInt(port.Target)},
+				{Name: "protocol", Value: protocol},
+			},
+		})
+	}
+
+	if hc := def.Healthcheck; hc != nil && !hc.Disable && len(hc.Test) > 0 {
+		hcArgs := []dagql.NamedInput{
+			{Name: "args", Value: dagql.ArrayInput[dagql.String](dagql.NewStringArray(hc.Test...))},
+		}
+		for _, arg := range []struct{ name, value string }{
+			{"interval", hc.Interval},
+			{"timeout", hc.Timeout},
+			{"startPeriod", hc.StartPeriod},
+		} {
+			if arg.value != "" {
+				hcArgs = append(hcArgs, dagql.NamedInput{Name: arg.name, Value: dagql.NewString(arg.value)})
+			}
+		}
+		if hc.Retries > 0 {
+			hcArgs = append(hcArgs, dagql.NamedInput{Name: "retries", Value: dagql.NewInt(hc.Retries)})
+		}
+		sels = append(sels, dagql.Selector{Field: "withHealthcheck", Args: hcArgs})
+	}
+
+	asServiceArgs := []dagql.NamedInput{
+		{Name: "useEntrypoint", Value: dagql.NewBoolean(true)},
+	}
+	if def.Command != nil {
+		asServiceArgs = append(asServiceArgs, dagql.NamedInput{
+			Name:  "args",
+			Value: dagql.ArrayInput[dagql.String](dagql.NewStringArray(def.Command...)),
+		})
+	}
+	sels = append(sels,
+		dagql.Selector{Field: "asService", Args: asServiceArgs},
+		dagql.Selector{
+			Field: "withHostname",
+			Args:  []dagql.NamedInput{{Name: "hostname", Value: dagql.NewString(args.Name)}},
+		},
+	)
+
+	for _, dep := range def.DependsOn.Sorted() {
+		var depInst dagql.Instance[*core.Service]
+		err := s.srv.Select(ctx, parent, &depInst, dagql.Selector{
+			Field: "service",
+			Args:  []dagql.NamedInput{{Name: "name", Value: dagql.NewString(dep)}},
+		})
+		if err != nil {
+			return inst, err
+		}
+		sels = append(sels, dagql.Selector{
+			Field: "withDependency",
+			Args: []dagql.NamedInput{
+				{Name: "service", Value: dagql.NewID[*core.Service](depInst.ID())},
+				{Name: "condition", Value: core.ComposeDependencyCondition(def.DependsOn[dep])},
+			},
+		})
+	}
+
+	if err := s.srv.Select(ctx, ctr, &inst, sels...); err != nil {
+		return inst, fmt.Errorf("service %q: %w", args.Name, err)
+	}
+	return inst, nil
+}
+
+// mountSelector returns the selector mounting a volume of a service: named
+// and anonymous volumes are cache volumes, and bind mounts are mounted from
+// the project directory.
+func (s *composeSchema) mountSelector(ctx context.Context, stack *core.ComposeStack, svcName string, mnt compose.Mount) (dagql.Selector, error) {
+	target := dagql.NamedInput{Name: "path", Value: dagql.NewString(mnt.Target)}
+	switch mnt.Type {
+	case compose.MountTmpfs:
+		return dagql.Selector{Field: "withMountedTemp", Args: []dagql.NamedInput{target}}, nil
+	case compose.MountBind:
+		src, err := stack.ResolvePath(mnt.Source)
+		if err != nil {
+			return dagql.Selector{}, err
+		}
+		bk, err := stack.Query.Buildkit(ctx)
+		if err != nil {
+			return dagql.Selector{}, fmt.Errorf("failed to get buildkit client: %w", err)
+		}
+		svcs, err := stack.Query.Services(ctx)
+		if err != nil {
+			return dagql.Selector{}, fmt.Errorf("failed to get services: %w", err)
+		}
+		info, err := stack.Dir.Self.Stat(ctx, bk, svcs, src)
+		if err != nil {
+			return dagql.Selector{}, err
+		}
+		if info.IsDir() {
+			var dir dagql.Instance[*core.Directory]
+			if err := s.srv.Select(ctx, stack.Dir, &dir, dagql.Selector{
+				Field: "directory",
+				Args:  []dagql.NamedInput{{Name: "path", Value: dagql.NewString(src)}},
+			}); err != nil {
+				return dagql.Selector{}, err
+			}
+			return dagql.Selector{
+				Field: "withMountedDirectory",
+				Args:  []dagql.NamedInput{target, {Name: "source", Value: dagql.NewID[*core.Directory](dir.ID())}},
+			}, nil
+		}
+		var file dagql.Instance[*core.File]
+		if err := s.srv.Select(ctx, stack.Dir, &file, dagql.Selector{
+			Field: "file",
+			Args:  []dagql.NamedInput{{Name: "path", Value: dagql.NewString(src)}},
+		}); err != nil {
+			return dagql.Selector{}, err
+		}
+		return dagql.Selector{
+			Field: "withMountedFile",
+			Args:  []dagql.NamedInput{target, {Name: "source", Value: dagql.NewID[*core.File](file.ID())}},
+		}, nil
+	default:
+		key := stack.Project.AnonymousVolumeName(svcName, mnt.Target)
+		if mnt.Source != "" {
+			key = stack.Project.VolumeName(mnt.Source)
+		}
+		var cache dagql.Instance[*core.CacheVolume]
+		if err := s.srv.Select(ctx, s.srv.Root(), &cache, dagql.Selector{
+			Field: "cacheVolume",
+			Args:  []dagql.NamedInput{{Name: "key", Value: dagql.NewString(key)}},
+		}); err != nil {
+			return dagql.Selector{}, err
+		}
+		return dagql.Selector{
+			Field: "withMountedCache",
+			Args:  []dagql.NamedInput{target, {Name: "cache", Value: dagql.NewID[*core.CacheVolume](cache.ID())}},
+		}, nil
+	}
+}
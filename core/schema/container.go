@@ -7,25 +7,21 @@ import (
 	"io/fs"
 	"os"
 	"path"
-	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/containerd/platforms"
 	"github.com/distribution/reference"
-	bkcache "github.com/moby/buildkit/cache"
-	bkclient "github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/client/llb/sourceresolver"
-	"github.com/moby/buildkit/exporter/containerimage/exptypes"
 	"github.com/moby/buildkit/frontend/dockerfile/shell"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/vektah/gqlparser/v2/ast"
 
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/dagger/dagger/engine/slog"
 )
@@ -61,7 +57,10 @@ func (s *containerSchema) Install() {
 			Doc(`Download a container image, and apply it to the container state. All previous state will be lost.`).
 			ArgDoc("address",
 				`Address of the container image to download, in standard OCI ref format. Example:"registry.dagger.io/engine:latest"`,
-			),
+			).
+			ArgDoc("verifyKey",
+				`PEM encoded public key to verify the image signature with.`,
+				`If set, the image must have a cosign signature made with the matching private key, or the download fails.`),
 		// FIXME: deprecate
 		dagql.Func("build", s.build).
 			Doc(`Initializes this container from a Dockerfile build.`).
@@ -395,6 +394,16 @@ func (s *containerSchema) Install() {
 			ArgDoc("redirectStderr",
 				`Like redirectStdout, but for standard error`).
 			ArgDoc("expect", `Exit codes this command is allowed to exit with without error`).
+			ArgDoc("expectExitCodes",
+				`Exact exit codes this command is allowed to exit with without error, instead of "expect". Example: [0, 2]`).
+			ArgDoc("expectStdoutRegex",
+				`Fail the command with an EXEC_ASSERTION_ERROR if its standard output doesn't match this regular expression (RE2 syntax). Example: "listening on port \\d+"`).
+			ArgDoc("expectStderrRegex",
+				`Like expectStdoutRegex, but for standard error`).
+			ArgDoc("expectStdoutNotContains",
+				`Fail the command with an EXEC_ASSERTION_ERROR if its standard output contains this string. Example: "panic:"`).
+			ArgDoc("expectStderrNotContains",
+				`Like expectStdoutNotContains, but for standard error`).
 			ArgDoc("experimentalPrivilegedNesting",
 				`Provides Dagger access to the executed command.`).
 			ArgDoc("insecureRootCapabilities",
@@ -406,7 +415,16 @@ func (s *containerSchema) Install() {
 			ArgDoc("noInit",
 				`Skip the automatic init process injected into containers by default.`,
 				`Only use this if you specifically need the command to be pid 1 in the container. Otherwise it may result in unexpected behavior. If you're not sure, you don't need this.`,
-			),
+			).
+			ArgDoc("limits",
+				`Resource limits enforced on the command with cgroups. Example: {cpus: 0.5, memoryBytes: 536870912, pids: 100}`).
+			ArgDoc("timeout",
+				`Kill the command if it runs for longer than this duration, failing with an EXEC_TIMEOUT_ERROR. Example: "10m"`,
+				`When retrying, each attempt has its own timeout.`).
+			ArgDoc("retry",
+				`Run the command again when it fails, up to the given number of attempts. Example: {attempts: 3, backoff: "1s", retryOnExitCodes: [1], retryOnStderrRegex: "connection reset"}`,
+				`Each attempt starts from the same filesystem: only changes to cache volumes are kept across attempts. Only the output of the last attempt is kept.`,
+				`To restore it, what the command may write to is copied before the first attempt: this is only the writable layer of the container with the overlayfs snapshotter, but would be the whole filesystem with other snapshotters, so retrying fails with them instead.`),
 		dagql.Func("withExec", s.withExec).
 			View(BeforeVersion("v0.13.0")).
 			Doc(`Retrieves this container after executing the specified command inside it.`).
@@ -486,6 +504,10 @@ func (s *containerSchema) Install() {
 			Doc(`The exit code of the last executed command`,
 				`Returns an error if no command was executed`),
 
+		dagql.Func("execMetrics", s.execMetrics).
+			Doc(`The resource usage of the last executed command`,
+				`Returns an error if no command was executed`),
+
 		dagql.Func("withAnnotation", s.withAnnotation).
 			Doc(`Retrieves this container plus the given OCI anotation.`).
 			ArgDoc("name", `The name of the annotation.`).
@@ -495,7 +517,12 @@ func (s *containerSchema) Install() {
 			Doc(`Retrieves this container minus the given OCI annotation.`).
 			ArgDoc("name", `The name of the annotation.`),
 
-		dagql.Func("publish", s.publish).
+		dagql.Func("withSourceDateEpoch", s.withSourceDateEpoch).
+			Doc(`Retrieves this container with the timestamps of its exported and published images clamped to the given time.`,
+				`Following the SOURCE_DATE_EPOCH specification, the image creation time, the history entries and the modification times of the files in the layers created by this container are set to the given time if they are more recent, so that building the same container always produces the same image digest.`).
+			ArgDoc("epoch", `Timestamp in seconds following Unix epoch (e.g., 1672531199).`),
+
+		dagql.NodeFunc("publish", s.publish).
 			DoNotCache("side effect on an external system (OCI registry)").
 			Doc(`Package the container state as an OCI image, and publish it to a registry`,
 				`Returns the fully qualified address of the published image, with digest`).
@@ -517,7 +544,18 @@ func (s *containerSchema) Install() {
 				`Use the specified media types for the published image's layers.`,
 				`Defaults to "OCI", which is compatible with most recent
 				registries, but "Docker" may be needed for older registries without OCI
-				support.`),
+				support.`).
+			ArgDoc("sbom",
+				`Attach a software bill of materials in the given format to the image of each platform.`,
+				`The packages are listed from the apk, dpkg and Python package metadata found in the container filesystem.`).
+			ArgDoc("provenance",
+				`Attach SLSA provenance to the image of each platform, recording the digest of the call that built it.`,
+				`The base images and git commits it was built from are listed as its resolved dependencies.`).
+			ArgDoc("signingKey",
+				`PEM encoded private key to sign the published image with.`,
+				`The signature is pushed to the registry next to the image in the cosign format, so it can be verified with "cosign verify --key".`).
+			ArgDoc("signingKeyPassword",
+				`Password to decrypt the signing key, if it is encrypted as generated by "cosign generate-key-pair".`),
 
 		dagql.Func("platform", s.platform).
 			Doc(`The platform this container executes and publishes as.`),
@@ -569,11 +607,61 @@ func (s *containerSchema) Install() {
 				container runtimes, but Docker may be needed for older runtimes without
 				OCI support.`),
 
+		dagql.NodeFunc("asOCILayout", DagOpDirectoryWrapper(s.srv, s.asOCILayout, nil)).
+			Doc(`Package the container state as an OCI image, and return it as an OCI image layout directory, with an "index.json" and the image blobs.`).
+			ArgDoc("platformVariants",
+				`Identifiers for other platform specific containers.`,
+				`Used for multi-platform images.`).
+			ArgDoc("forcedCompression",
+				`Force each layer of the image to use the specified compression algorithm.`,
+				`If this is unset, then if a layer already has a compressed blob in the
+				engine's cache, that will be used (this can result in a mix of
+				compression algorithms for different layers). If this is unset and a
+				layer has no compressed blob in the engine's cache, then it will be
+				compressed using Gzip.`).
+			ArgDoc("mediaTypes", `Use the specified media types for the image's layers.`,
+				`Defaults to OCI, which is largely compatible with most recent
+				container runtimes, but Docker may be needed for older runtimes without
+				OCI support.`),
+
+		dagql.NodeFunc("exportOCILayout", s.exportOCILayout).
+			DoNotCache("Writes to the local host.").
+			Doc(`Writes the container as an OCI image layout directory to the destination path on the host.`,
+				`It can also export platform variants.`).
+			ArgDoc("path",
+				`Host's destination directory (e.g., "./image").`,
+				`Path can be relative to the engine's workdir or absolute.`,
+				`Any existing contents of the directory are replaced, so that it only
+				holds the blobs of the exported images.`).
+			ArgDoc("platformVariants",
+				`Identifiers for other platform specific containers.`,
+				`Used for multi-platform image.`).
+			ArgDoc("forcedCompression",
+				`Force each layer of the exported image to use the specified compression algorithm.`,
+				`If this is unset, then if a layer already has a compressed blob in the
+				engine's cache, that will be used (this can result in a mix of
+				compression algorithms for different layers). If this is unset and a
+				layer has no compressed blob in the engine's cache, then it will be
+				compressed using Gzip.`).
+			ArgDoc("mediaTypes",
+				`Use the specified media types for the exported image's layers.`,
+				`Defaults to OCI, which is largely compatible with most recent
+				container runtimes, but Docker may be needed for older runtimes without
+				OCI support.`).
+			ArgDoc("expand",
+				`Replace "${VAR}" or "$VAR" in the value of path according to the current `+
+					`environment variables defined in the container (e.g. "/$VAR/foo").`),
+
 		dagql.Func("import", s.import_).
 			Doc(`Reads the container from an OCI tarball.`).
 			ArgDoc("source", `File to read the container from.`).
 			ArgDoc("tag", `Identifies the tag to import from the archive, if the archive bundles multiple tags.`),
 
+		dagql.Func("importOCILayout", s.importOCILayout).
+			Doc(`Reads the container from an OCI image layout directory.`).
+			ArgDoc("source", `Directory to read the container from, containing an "oci-layout" file, an "index.json" and the image blobs.`).
+			ArgDoc("tag", `Identifies the tag to import from the layout, if the layout bundles multiple tags.`),
+
 		dagql.Func("withRegistryAuth", s.withRegistryAuth).
 			Doc(`Attach credentials for future publishing to a registry. Use in combination with publish`).
 			ArgDoc("address", `The image address that needs authentication. Same format as "docker push". Example: "registry.dagger.io/dagger:latest"`).
@@ -598,6 +686,20 @@ func (s *containerSchema) Install() {
 			ArgDoc("description", `Port description. Example: "payment API endpoint"`).
 			ArgDoc("experimentalSkipHealthcheck", `Skip the health check when run as a service.`),
 
+		dagql.Func("withHealthcheck", s.withHealthcheck).
+			Doc(`Retrieves this container with a health check run when it is started as a service.`,
+				`The service is ready once the check succeeds, instead of once its exposed ports accept connections. Set exactly one of args, or httpPath and/or port.`).
+			ArgDoc("args", `Command to run in the service container. The service is healthy when it exits with status 0.`).
+			ArgDoc("httpPath", `Path to request with an HTTP GET on the port, or the first exposed TCP port. The service is healthy on a 2xx or 3xx response.`).
+			ArgDoc("port", `TCP port to check. Alone, the service is healthy once the port accepts connections.`).
+			ArgDoc("interval", `Time between two checks, as a duration string (e.g., "1s", "500ms").`).
+			ArgDoc("timeout", `Time after which a check fails, as a duration string.`).
+			ArgDoc("retries", `Number of consecutive failed checks after which the service is unhealthy and fails to start.`).
+			ArgDoc("startPeriod", `Time during which failed checks don't count towards retries, as a duration string.`),
+
+		dagql.Func("withoutHealthcheck", s.withoutHealthcheck).
+			Doc(`Retrieves this container without a health check, waiting for its exposed ports when started as a service.`),
+
 		dagql.Func("withoutExposedPort", s.withoutExposedPort).
 			Doc(`Unexpose a previously exposed port.`).
 			ArgDoc("port", `Port number to unexpose`).
@@ -703,7 +805,8 @@ func (s *containerSchema) container(ctx context.Context, parent *core.Query, arg
 }
 
 type containerFromArgs struct {
-	Address string
+	Address   string
+	VerifyKey dagql.Optional[core.SecretID]
 }
 
 func (s *containerSchema) from(ctx context.Context, parent dagql.Instance[*core.Container], args containerFromArgs) (inst dagql.Instance[*core.Container], _ error) {
@@ -721,6 +824,16 @@ func (s *containerSchema) from(ctx context.Context, parent dagql.Instance[*core.
 	refName = reference.TagNameOnly(refName)
 
 	if refName, isCanonical := refName.(reference.Canonical); isCanonical {
+		if args.VerifyKey.Valid {
+			key, err := secretPlaintext(ctx, s.srv, args.VerifyKey.Value)
+			if err != nil {
+				return inst, err
+			}
+			if err := parent.Self.VerifyImageSignature(ctx, refName, key); err != nil {
+				return inst, err
+			}
+		}
+
 		ctr, err := parent.Self.FromCanonicalRef(ctx, refName, nil)
 		if err != nil {
 			return inst, err
@@ -746,12 +859,16 @@ func (s *containerSchema) from(ctx context.Context, parent dagql.Instance[*core.
 		return inst, fmt.Errorf("failed to set digest on image %s: %w", refName.String(), err)
 	}
 
+	selectArgs := []dagql.NamedInput{
+		{Name: "address", Value: dagql.String(refName.String())},
+	}
+	if args.VerifyKey.Valid {
+		selectArgs = append(selectArgs, dagql.NamedInput{Name: "verifyKey", Value: args.VerifyKey})
+	}
 	err = s.srv.Select(ctx, parent, &inst,
 		dagql.Selector{
 			Field: "from",
-			Args: []dagql.NamedInput{
-				{Name: "address", Value: dagql.String(refName.String())},
-			},
+			Args:  selectArgs,
 		},
 	)
 	if err != nil {
@@ -915,6 +1032,10 @@ func (s *containerSchema) exitCode(ctx context.Context, parent *core.Container,
 	return parent.ExitCode(ctx)
 }
 
+func (s *containerSchema) execMetrics(ctx context.Context, parent *core.Container, _ struct{}) (*core.ExecMetrics, error) {
+	return parent.ExecMetrics(ctx)
+}
+
 func (s *containerSchema) stdoutLegacy(ctx context.Context, parent *core.Container, _ struct{}) (string, error) {
 	out, err := parent.Stdout(ctx)
 	if errors.Is(err, core.ErrNoCommand) {
@@ -1290,24 +1411,67 @@ func (s *containerSchema) withoutAnnotation(ctx context.Context, parent *core.Co
 	return parent.WithoutAnnotation(ctx, args.Name)
 }
 
+type containerWithSourceDateEpochArgs struct {
+	Epoch int
+}
+
+func (s *containerSchema) withSourceDateEpoch(ctx context.Context, parent *core.Container, args containerWithSourceDateEpochArgs) (*core.Container, error) {
+	return parent.WithSourceDateEpoch(ctx, int64(args.Epoch))
+}
+
 type containerPublishArgs struct {
-	Address           dagql.String
-	PlatformVariants  []core.ContainerID `default:"[]"`
-	ForcedCompression dagql.Optional[core.ImageLayerCompression]
-	MediaTypes        core.ImageMediaTypes `default:"OCIMediaTypes"`
+	Address            dagql.String
+	PlatformVariants   []core.ContainerID `default:"[]"`
+	ForcedCompression  dagql.Optional[core.ImageLayerCompression]
+	MediaTypes         core.ImageMediaTypes            `default:"OCIMediaTypes"`
+	SBOM               dagql.Optional[core.SBOMFormat] `name:"sbom"`
+	Provenance         bool                            `default:"false"`
+	SigningKey         dagql.Optional[core.SecretID]
+	SigningKeyPassword dagql.Optional[core.SecretID]
 }
 
-func (s *containerSchema) publish(ctx context.Context, parent *core.Container, args containerPublishArgs) (dagql.String, error) {
+func (s *containerSchema) publish(ctx context.Context, parent dagql.Instance[*core.Container], args containerPublishArgs) (dagql.String, error) {
 	variants, err := dagql.LoadIDs(ctx, s.srv, args.PlatformVariants)
 	if err != nil {
 		return "", err
 	}
-	ref, err := parent.Publish(
+	attestations := core.ImageAttestationOpts{
+		SBOM: args.SBOM.Value,
+	}
+	if args.Provenance {
+		calls := []*call.ID{parent.ID()}
+		for _, variant := range args.PlatformVariants {
+			calls = append(calls, variant.ID())
+		}
+		for _, id := range calls {
+			prov, err := core.NewImageProvenance(ctx, s.srv, id)
+			if err != nil {
+				return "", fmt.Errorf("failed to resolve provenance: %w", err)
+			}
+			attestations.Provenance = append(attestations.Provenance, prov)
+		}
+	}
+	var signing core.ImageSigningOpts
+	if args.SigningKey.Valid {
+		signing.Key, err = secretPlaintext(ctx, s.srv, args.SigningKey.Value)
+		if err != nil {
+			return "", err
+		}
+	}
+	if args.SigningKeyPassword.Valid {
+		signing.Password, err = secretPlaintext(ctx, s.srv, args.SigningKeyPassword.Value)
+		if err != nil {
+			return "", err
+		}
+	}
+	ref, err := parent.Self.Publish(
 		ctx,
 		args.Address.String(),
 		variants,
 		args.ForcedCompression.Value,
 		args.MediaTypes,
+		attestations,
+		signing,
 	)
 	if err != nil {
 		return "", err
@@ -1799,123 +1963,63 @@ func (s *containerSchema) asTarball(
 	if err != nil {
 		return inst, err
 	}
-
-	bk, err := parent.Self.Query.Buildkit(ctx)
-	if err != nil {
-		return inst, fmt.Errorf("failed to get buildkit client: %w", err)
-	}
-	svcs, err := parent.Self.Query.Services(ctx)
+	f, err := parent.Self.AsTarball(ctx, platformVariants, args.ForcedCompression.Value, args.MediaTypes)
 	if err != nil {
-		return inst, fmt.Errorf("failed to get services: %w", err)
-	}
-	engineHostPlatform := parent.Self.Query.Platform()
-
-	if args.MediaTypes == "" {
-		args.MediaTypes = core.OCIMediaTypes
-	}
-
-	opts := map[string]string{
-		"tar":                           strconv.FormatBool(true),
-		string(exptypes.OptKeyOCITypes): strconv.FormatBool(args.MediaTypes == core.OCIMediaTypes),
-	}
-	if args.ForcedCompression.Value != "" {
-		opts[string(exptypes.OptKeyLayerCompression)] = strings.ToLower(string(args.ForcedCompression.Value))
-		opts[string(exptypes.OptKeyForceCompression)] = strconv.FormatBool(true)
+		return inst, err
 	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, f)
+}
 
-	inputByPlatform := map[string]buildkit.ContainerExport{}
-	services := core.ServiceBindings{}
-
-	variants := append([]*core.Container{parent.Self}, platformVariants...)
-	for _, variant := range variants {
-		if variant.FS == nil {
-			continue
-		}
-		st, err := variant.FSState()
-		if err != nil {
-			return inst, err
-		}
-
-		platformSpec := variant.Platform.Spec()
-		def, err := st.Marshal(ctx, llb.Platform(platformSpec))
-		if err != nil {
-			return inst, err
-		}
-
-		platformString := platforms.Format(variant.Platform.Spec())
-		if _, ok := inputByPlatform[platformString]; ok {
-			return inst, fmt.Errorf("duplicate platform %q", platformString)
-		}
-		inputByPlatform[platformString] = buildkit.ContainerExport{
-			Definition: def.ToPB(),
-			Config:     variant.Config,
-		}
-
-		if len(variants) == 1 {
-			// single platform case
-			for _, annotation := range variant.Annotations {
-				opts[exptypes.AnnotationManifestKey(nil, annotation.Key)] = annotation.Value
-				opts[exptypes.AnnotationManifestDescriptorKey(nil, annotation.Key)] = annotation.Value
-			}
-		} else {
-			// multi platform case
-			for _, annotation := range variant.Annotations {
-				opts[exptypes.AnnotationManifestKey(&platformSpec, annotation.Key)] = annotation.Value
-				opts[exptypes.AnnotationManifestDescriptorKey(&platformSpec, annotation.Key)] = annotation.Value
-			}
-		}
-
-		services.Merge(variant.Services)
+func (s *containerSchema) asOCILayout(
+	ctx context.Context,
+	parent dagql.Instance[*core.Container],
+	args containerAsTarballArgs,
+) (inst dagql.Instance[*core.Directory], rerr error) {
+	platformVariants, err := dagql.LoadIDs(ctx, s.srv, args.PlatformVariants)
+	if err != nil {
+		return inst, err
 	}
-	if len(inputByPlatform) == 0 {
-		return inst, errors.New("no containers to export")
+	dir, err := parent.Self.AsOCILayout(ctx, platformVariants, args.ForcedCompression.Value, args.MediaTypes)
+	if err != nil {
+		return inst, err
 	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, dir)
+}
 
-	detach, _, err := svcs.StartBindings(ctx, services)
+func (s *containerSchema) exportOCILayout(ctx context.Context, parent dagql.Instance[*core.Container], args containerExportArgs) (dagql.String, error) {
+	path, err := expandEnvVar(ctx, parent.Self, args.Path, args.Expand)
 	if err != nil {
-		return inst, err
+		return "", err
 	}
-	defer detach()
 
-	op, ok := core.DagOpFromContext[core.FSDagOp](ctx)
-	if !ok {
-		return inst, fmt.Errorf("no dagop")
+	selectArgs := []dagql.NamedInput{
+		{Name: "platformVariants", Value: dagql.ArrayInput[core.ContainerID](args.PlatformVariants)},
+		{Name: "mediaTypes", Value: args.MediaTypes},
 	}
-	bkref, err := op.CreateRef(ctx, nil,
-		bkcache.CachePolicyRetain,
-		bkcache.WithRecordType(bkclient.UsageRecordTypeRegular),
-		bkcache.WithDescription(op.Name()))
-	if err != nil {
-		return inst, err
+	if args.ForcedCompression.Valid {
+		selectArgs = append(selectArgs, dagql.NamedInput{Name: "forcedCompression", Value: args.ForcedCompression.Value})
 	}
-	defer func() {
-		if rerr != nil && bkref != nil {
-			bkref.Release(context.WithoutCancel(ctx))
-		}
-	}()
-	err = op.Mount(ctx, bkref, func(out string) error {
-		err = bk.ContainerImageToTarball(ctx, engineHostPlatform.Spec(), filepath.Join(out, op.Path), inputByPlatform, opts)
-		if err != nil {
-			return fmt.Errorf("container image to tarball file conversion failed: %w", err)
-		}
-		return nil
+	var dir dagql.Instance[*core.Directory]
+	err = s.srv.Select(ctx, parent, &dir, dagql.Selector{
+		Field: "asOCILayout",
+		Args:  selectArgs,
 	})
 	if err != nil {
-		return inst, fmt.Errorf("container image to tarball file conversion failed: %w", err)
+		return "", err
 	}
 
-	f := core.NewFile(parent.Self.Query, nil, op.Path, parent.Self.Query.Platform(), nil)
-	snap, err := bkref.Commit(ctx)
+	if err := dir.Self.Export(ctx, path, false); err != nil {
+		return "", err
+	}
+	bk, err := parent.Self.Query.Buildkit(ctx)
 	if err != nil {
-		return inst, err
+		return "", fmt.Errorf("failed to get buildkit: %w", err)
 	}
-	bkref = nil
-	f.Result = snap
-	fileInst, err := dagql.NewInstanceForCurrentID(ctx, s.srv, parent, f)
+	stat, err := bk.StatCallerHostPath(ctx, path, true)
 	if err != nil {
-		return inst, err
+		return "", err
 	}
-	return fileInst, nil
+	return dagql.String(stat.Path), err
 }
 
 type containerImportArgs struct {
@@ -1940,6 +2044,19 @@ func (s *containerSchema) import_(ctx context.Context, parent *core.Container, a
 	)
 }
 
+type containerImportOCILayoutArgs struct {
+	Source core.DirectoryID
+	Tag    string `default:""`
+}
+
+func (s *containerSchema) importOCILayout(ctx context.Context, parent *core.Container, args containerImportOCILayoutArgs) (*core.Container, error) {
+	source, err := args.Source.Load(ctx, s.srv)
+	if err != nil {
+		return nil, err
+	}
+	return parent.ImportOCILayout(ctx, source.Self, args.Tag)
+}
+
 type containerWithRegistryAuthArgs struct {
 	Address  string
 	Username string
@@ -2022,6 +2139,45 @@ func (s *containerSchema) withExposedPort(ctx context.Context, parent *core.Cont
 	})
 }
 
+type containerWithHealthcheckArgs struct {
+	Args        []string `default:"[]"`
+	HTTPPath    string   `name:"httpPath" default:""`
+	Port        int      `default:"0"`
+	Interval    string   `default:"1s"`
+	Timeout     string   `default:"10s"`
+	Retries     int      `default:"30"`
+	StartPeriod string   `default:"0s"`
+}
+
+func (s *containerSchema) withHealthcheck(ctx context.Context, parent *core.Container, args containerWithHealthcheckArgs) (*core.Container, error) {
+	hc := core.Healthcheck{
+		Args:     args.Args,
+		HTTPPath: args.HTTPPath,
+		Port:     args.Port,
+		Retries:  args.Retries,
+	}
+	for _, d := range []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"interval", args.Interval, &hc.Interval},
+		{"timeout", args.Timeout, &hc.Timeout},
+		{"startPeriod", args.StartPeriod, &hc.StartPeriod},
+	} {
+		var err error
+		*d.dest, err = time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", d.name, err)
+		}
+	}
+	return parent.WithHealthcheck(hc)
+}
+
+func (s *containerSchema) withoutHealthcheck(ctx context.Context, parent *core.Container, args struct{}) (*core.Container, error) {
+	return parent.WithoutHealthcheck(), nil
+}
+
 type containerWithoutExposedPortArgs struct {
 	Port     int
 	Protocol core.NetworkProtocol `default:"TCP"`
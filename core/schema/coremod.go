@@ -48,6 +48,7 @@ func (m *CoreMod) Install(ctx context.Context, dag *dagql.Server) error {
 		&cacheSchema{dag},
 		&secretSchema{dag},
 		&serviceSchema{dag},
+		&composeSchema{dag},
 		&hostSchema{dag},
 		&httpSchema{dag},
 		&platformSchema{dag},
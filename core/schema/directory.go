@@ -11,6 +11,7 @@ import (
 	"strings"
 
 	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/patch"
 	"github.com/dagger/dagger/dagql"
 	"github.com/moby/patternmatcher/ignorefile"
 )
@@ -48,6 +49,13 @@ func (s *directorySchema) Install() {
 			View(AllVersion). // glob returns different results in different versions
 			Doc(`Returns a list of files and directories that matche the given pattern.`).
 			ArgDoc("pattern", `Pattern to match (e.g., "*.md").`),
+		dagql.Func("search", s.search).
+			Doc(`Searches the contents of the files in this directory, returning each match.`,
+				`Binary files and symbolic links are skipped.`).
+			ArgDoc("pattern", `The regular expression to search for, in RE2 syntax (e.g., "TODO|FIXME").`).
+			ArgDoc("literal", `Interpret the pattern as a literal string instead of a regular expression.`).
+			ArgDoc("paths", `If set, only search the paths matching one of these glob patterns (e.g., ["src/", "*.go"]).`).
+			ArgDoc("multiline", `Allow matches to span multiple lines.`),
 		dagql.Func("digest", s.digest).
 			Doc(
 				`Return the directory's digest.
@@ -101,6 +109,22 @@ func (s *directorySchema) Install() {
 		dagql.Func("diff", s.diff).
 			Doc(`Return the difference between this directory and an another directory. The difference is encoded as a directory.`).
 			ArgDoc("other", `The directory to compare against`),
+		dagql.Func("changes", s.changes).
+			Doc(`Return the file changes from this directory to another directory.`,
+				`Unlike diff, the changes include removed and renamed files, along with a unified diff of each file.`).
+			ArgDoc("other", `The directory after the changes.`),
+		dagql.NodeFunc("withPatch", DagOpDirectoryWrapper(s.srv, s.withPatch, s.patchPath)).
+			Doc(`Retrieves this directory with a patch applied to it.`,
+				`The patch is a unified diff, such as the output of "git diff" or "git format-patch". If any hunk can't be applied, no change is made and the rejected hunks are listed in the error.`).
+			ArgDoc("patch", `The patch to apply.`).
+			ArgDoc("strict", `Require each hunk to apply at exactly the line numbers in its header.`).
+			ArgDoc("fuzz", `The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.`),
+		dagql.NodeFunc("withPatchFile", DagOpDirectoryWrapper(s.srv, s.withPatchFile, s.patchPath)).
+			Doc(`Retrieves this directory with the patch in the given file applied to it.`,
+				`The patch is a unified diff, such as the output of "git diff" or "git format-patch". If any hunk can't be applied, no change is made and the rejected hunks are listed in the error.`).
+			ArgDoc("patch", `The file containing the patch to apply.`).
+			ArgDoc("strict", `Require each hunk to apply at exactly the line numbers in its header.`).
+			ArgDoc("fuzz", `The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.`),
 		dagql.Func("export", s.export).
 			View(AllVersion).
 			DoNotCache("Writes to the local host.").
@@ -144,6 +168,23 @@ func (s *directorySchema) Install() {
 			guarantees when using this option. It should only be used when
 			absolutely necessary and only with trusted commands.`),
 	}.Install(s.srv)
+
+	core.ChangeKinds.Install(s.srv)
+	dagql.Fields[core.ChangesetEntry]{}.Install(s.srv)
+	dagql.Fields[*core.Changeset]{
+		dagql.Func("addedPaths", s.changesetAddedPaths).
+			Doc(`The paths of the files that were created.`),
+		dagql.Func("modifiedPaths", s.changesetModifiedPaths).
+			Doc(`The paths of the files whose contents or permissions were changed.`),
+		dagql.Func("removedPaths", s.changesetRemovedPaths).
+			Doc(`The paths of the files that were deleted.`),
+		dagql.Func("renamedPaths", s.changesetRenamedPaths).
+			Doc(`The new paths of the files that were moved without changing their contents.`),
+		dagql.Func("entries", s.changesetEntries).
+			Doc(`The changes made to each file, sorted by path.`),
+		dagql.Func("asPatch", s.changesetAsPatch).
+			Doc(`Returns the changes as a patch file in the format of "git diff", which can be applied with Directory.withPatchFile.`),
+	}.Install(s.srv)
 }
 
 type directoryPipelineArgs struct {
@@ -246,6 +287,68 @@ func (s *directorySchema) glob(ctx context.Context, parent *core.Directory, args
 	return parent.Glob(ctx, args.Pattern)
 }
 
+func (s *directorySchema) patchPath(ctx context.Context, dir dagql.Instance[*core.Directory]) (string, error) {
+	return dir.Self.Dir, nil
+}
+
+type directoryWithPatchArgs struct {
+	Patch  string
+	Strict bool `default:"false"`
+	Fuzz   int  `default:"0"`
+}
+
+func (s *directorySchema) withPatch(ctx context.Context, parent dagql.Instance[*core.Directory], args directoryWithPatchArgs) (inst dagql.Instance[*core.Directory], _ error) {
+	if args.Fuzz < 0 {
+		return inst, fmt.Errorf("fuzz must not be negative, got %d", args.Fuzz)
+	}
+	dir, err := parent.Self.WithPatch(ctx, args.Patch, patch.ApplyOpts{
+		Strict: args.Strict,
+		Fuzz:   args.Fuzz,
+	})
+	if err != nil {
+		return inst, err
+	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, dir)
+}
+
+type directoryWithPatchFileArgs struct {
+	Patch  core.FileID
+	Strict bool `default:"false"`
+	Fuzz   int  `default:"0"`
+}
+
+func (s *directorySchema) withPatchFile(ctx context.Context, parent dagql.Instance[*core.Directory], args directoryWithPatchFileArgs) (inst dagql.Instance[*core.Directory], _ error) {
+	patchFile, err := args.Patch.Load(ctx, s.srv)
+	if err != nil {
+		return inst, err
+	}
+	contents, err := patchFile.Self.Contents(ctx)
+	if err != nil {
+		return inst, err
+	}
+	return s.withPatch(ctx, parent, directoryWithPatchArgs{
+		Patch:  string(contents),
+		Strict: args.Strict,
+		Fuzz:   args.Fuzz,
+	})
+}
+
+type directorySearchArgs struct {
+	Pattern   string
+	Literal   bool     `default:"false"`
+	Paths     []string `default:"[]"`
+	Multiline bool     `default:"false"`
+}
+
+func (s *directorySchema) search(ctx context.Context, parent *core.Directory, args directorySearchArgs) ([]core.SearchResult, error) {
+	return parent.Search(ctx, core.SearchOpts{
+		Pattern:   args.Pattern,
+		Literal:   args.Literal,
+		Paths:     args.Paths,
+		Multiline: args.Multiline,
+	})
+}
+
 func (s *directorySchema) digest(ctx context.Context, parent *core.Directory, args struct{}) (dagql.String, error) {
 	digest, err := parent.Digest(ctx)
 	if err != nil {
@@ -341,6 +444,38 @@ func (s *directorySchema) diff(ctx context.Context, parent *core.Directory, args
 	return parent.Diff(ctx, dir.Self)
 }
 
+func (s *directorySchema) changes(ctx context.Context, parent *core.Directory, args diffArgs) (*core.Changeset, error) {
+	dir, err := args.Other.Load(ctx, s.srv)
+	if err != nil {
+		return nil, err
+	}
+	return parent.Changes(ctx, dir.Self)
+}
+
+func (s *directorySchema) changesetAddedPaths(ctx context.Context, parent *core.Changeset, args struct{}) (dagql.Array[dagql.String], error) {
+	return dagql.NewStringArray(parent.Paths(core.ChangeKindAdded)...), nil
+}
+
+func (s *directorySchema) changesetModifiedPaths(ctx context.Context, parent *core.Changeset, args struct{}) (dagql.Array[dagql.String], error) {
+	return dagql.NewStringArray(parent.Paths(core.ChangeKindModified)...), nil
+}
+
+func (s *directorySchema) changesetRemovedPaths(ctx context.Context, parent *core.Changeset, args struct{}) (dagql.Array[dagql.String], error) {
+	return dagql.NewStringArray(parent.Paths(core.ChangeKindRemoved)...), nil
+}
+
+func (s *directorySchema) changesetRenamedPaths(ctx context.Context, parent *core.Changeset, args struct{}) (dagql.Array[dagql.String], error) {
+	return dagql.NewStringArray(parent.Paths(core.ChangeKindRenamed)...), nil
+}
+
+func (s *directorySchema) changesetEntries(ctx context.Context, parent *core.Changeset, args struct{}) ([]core.ChangesetEntry, error) {
+	return parent.Entries, nil
+}
+
+func (s *directorySchema) changesetAsPatch(ctx context.Context, parent *core.Changeset, args struct{}) (*core.File, error) {
+	return core.NewFileWithContents(ctx, parent.Query, "changes.patch", []byte(parent.Patch()), 0o644, nil, parent.Query.Platform())
+}
+
 type dirExportArgs struct {
 	Path string
 	Wipe bool `default:"false"`
@@ -3,6 +3,8 @@ package schema
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"time"
 
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/dagql"
@@ -24,12 +26,31 @@ func (s *engineSchema) Install() {
 	dagql.Fields[*core.Engine]{
 		dagql.Func("localCache", s.localCache).
 			Doc("The local (on-disk) cache for the Dagger engine"),
+		dagql.FuncWithCacheKey("cacheVolumes", s.cacheVolumes, dagql.CachePerCall).
+			Doc("The cache volumes in the local cache of the Dagger engine"),
+		dagql.FuncWithCacheKey("cacheMiss", s.cacheMiss, dagql.CachePerCall).
+			Doc("Explain why a call was not cached",
+				"Only calls of the current session are explained: a call is compared to the most similar call of the same field made before it in the session. The most recent misses are kept until the session ends.").
+			ArgDoc("call", "The digest of the call, as recorded in its telemetry, or its encoded ID."),
 	}.Install(s.srv)
 
 	dagql.Fields[*core.EngineCache]{
 		dagql.NodeFuncWithCacheKey("entrySet", s.cacheEntrySet, dagql.CachePerCall).
 			Doc("The current set of entries in the cache"),
 		dagql.Func("prune", s.cachePrune).
+			View(AllVersion).
+			DoNotCache("Mutates mutable state").
+			Doc("Prune the cache of releaseable entries, returning the pruned entries",
+				"Entries in use are never pruned. When filters are given, only the entries matching all of them are pruned.").
+			ArgDoc("olderThan", `Only prune entries created at least this long ago, as a duration string. Example: "168h"`).
+			ArgDoc("unusedFor", `Only prune entries that haven't been used for at least this long, as a duration string. Example: "72h"`).
+			ArgDoc("descriptionRegex", `Only prune entries whose description matches this regular expression (RE2 syntax).`).
+			ArgDoc("recordTypes", `Only prune entries of these record types. Example: ["exec.cachemount"]`).
+			ArgDoc("minSizeBytes", `Only prune entries using at least this much disk space.`).
+			ArgDoc("entryIDs", `Only prune the entries with these IDs, as returned by entryID.`).
+			ArgDoc("dryRun", `Return the entries that would be pruned without pruning them.`),
+		dagql.Func("prune", s.cachePruneLegacy).
+			View(BeforeVersion("v0.18.4")).
 			DoNotCache("Mutates mutable state").
 			Doc("Prune the cache of releaseable entries"),
 	}.Install(s.srv)
@@ -40,6 +61,13 @@ func (s *engineSchema) Install() {
 	}.Install(s.srv)
 
 	dagql.Fields[*core.EngineCacheEntry]{}.Install(s.srv)
+
+	dagql.Fields[*core.EngineCacheMiss]{}.Install(s.srv)
+
+	dagql.Fields[*core.EngineCacheVolume]{
+		dagql.Func("cacheVolume", s.cacheVolumeVolume).
+			Doc("The cache volume, which can be mounted, snapshotted, seeded or deleted"),
+	}.Install(s.srv)
 }
 
 func (s *engineSchema) engine(ctx context.Context, parent *core.Query, args struct{}) (*core.Engine, error) {
@@ -63,6 +91,34 @@ func (s *engineSchema) localCache(ctx context.Context, parent *core.Engine, args
 	}, nil
 }
 
+func (s *engineSchema) cacheVolumes(ctx context.Context, parent *core.Engine, args struct{}) (dagql.Array[*core.EngineCacheVolume], error) {
+	if err := parent.Query.RequireMainClient(ctx); err != nil {
+		return nil, err
+	}
+	vols, err := parent.Query.EngineCacheVolumes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cache volumes: %w", err)
+	}
+	return vols, nil
+}
+
+func (s *engineSchema) cacheMiss(ctx context.Context, parent *core.Engine, args struct {
+	Call string
+}) (*core.EngineCacheMiss, error) {
+	if err := parent.Query.RequireMainClient(ctx); err != nil {
+		return nil, err
+	}
+	miss, err := parent.Query.ExplainCacheMiss(ctx, args.Call)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain cache miss: %w", err)
+	}
+	return miss, nil
+}
+
+func (s *engineSchema) cacheVolumeVolume(ctx context.Context, parent *core.EngineCacheVolume, args struct{}) (*core.CacheVolume, error) {
+	return core.NewCache(parent.Keys...), nil
+}
+
 func (s *engineSchema) cacheEntrySet(ctx context.Context, parent dagql.Instance[*core.EngineCache], args struct {
 	Key string `default:""`
 }) (inst dagql.Instance[*core.EngineCacheEntrySet], _ error) {
@@ -93,13 +149,76 @@ func (s *engineSchema) cacheEntrySet(ctx context.Context, parent dagql.Instance[
 	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, entrySet)
 }
 
-func (s *engineSchema) cachePrune(ctx context.Context, parent *core.EngineCache, args struct{}) (dagql.Nullable[core.Void], error) {
+type cachePruneArgs struct {
+	OlderThan        string   `default:""`
+	UnusedFor        string   `default:""`
+	DescriptionRegex string   `default:""`
+	RecordTypes      []string `default:"[]"`
+	MinSizeBytes     int      `default:"0"`
+	EntryIDs         []string `name:"entryIDs" default:"[]"`
+	DryRun           bool     `default:"false"`
+}
+
+func (args cachePruneArgs) opts() (opts core.EngineCachePruneOpts, err error) {
+	for _, dur := range []struct {
+		name string
+		val  string
+		dest *time.Duration
+	}{
+		{"olderThan", args.OlderThan, &opts.OlderThan},
+		{"unusedFor", args.UnusedFor, &opts.UnusedFor},
+	} {
+		if dur.val == "" {
+			continue
+		}
+		*dur.dest, err = time.ParseDuration(dur.val)
+		if err != nil {
+			return opts, fmt.Errorf("invalid %s: %w", dur.name, err)
+		}
+		if *dur.dest <= 0 {
+			return opts, fmt.Errorf("invalid %s: must be positive, got %s", dur.name, dur.val)
+		}
+	}
+	if args.DescriptionRegex != "" {
+		opts.DescriptionRegex, err = regexp.Compile(args.DescriptionRegex)
+		if err != nil {
+			return opts, fmt.Errorf("invalid descriptionRegex: %w", err)
+		}
+	}
+	if args.MinSizeBytes < 0 {
+		return opts, fmt.Errorf("invalid minSizeBytes: must not be negative, got %d", args.MinSizeBytes)
+	}
+	opts.RecordTypes = args.RecordTypes
+	opts.MinSizeBytes = args.MinSizeBytes
+	opts.EntryIDs = args.EntryIDs
+	opts.DryRun = args.DryRun
+	return opts, nil
+}
+
+func (s *engineSchema) cachePrune(ctx context.Context, parent *core.EngineCache, args cachePruneArgs) (*core.EngineCacheEntrySet, error) {
+	if err := parent.Query.RequireMainClient(ctx); err != nil {
+		return nil, err
+	}
+
+	opts, err := args.opts()
+	if err != nil {
+		return nil, err
+	}
+	set, err := parent.Query.PruneEngineLocalCacheEntries(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune cache entries: %w", err)
+	}
+
+	return set, nil
+}
+
+func (s *engineSchema) cachePruneLegacy(ctx context.Context, parent *core.EngineCache, args struct{}) (dagql.Nullable[core.Void], error) {
 	void := dagql.Null[core.Void]()
 	if err := parent.Query.RequireMainClient(ctx); err != nil {
 		return void, err
 	}
 
-	_, err := parent.Query.PruneEngineLocalCacheEntries(ctx)
+	_, err := parent.Query.PruneEngineLocalCacheEntries(ctx, core.EngineCachePruneOpts{})
 	if err != nil {
 		return void, fmt.Errorf("failed to prune cache entries: %w", err)
 	}
@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/core/patch"
 	"github.com/dagger/dagger/dagql"
 )
 
@@ -25,6 +26,12 @@ func (s *fileSchema) Install() {
 			Doc(`Retrieves the size of the file, in bytes.`),
 		dagql.Func("name", s.name).
 			Doc(`Retrieves the name of the file.`),
+		dagql.Func("search", s.search).
+			Doc(`Searches the contents of the file, returning each match.`,
+				`A binary file has no matches.`).
+			ArgDoc("pattern", `The regular expression to search for, in RE2 syntax (e.g., "TODO|FIXME").`).
+			ArgDoc("literal", `Interpret the pattern as a literal string instead of a regular expression.`).
+			ArgDoc("multiline", `Allow matches to span multiple lines.`),
 		dagql.Func("digest", s.digest).
 			Doc(
 				`Return the file's digest.
@@ -50,6 +57,24 @@ func (s *fileSchema) Install() {
 			Doc(`Retrieves this file with its created/modified timestamps set to the given time.`).
 			ArgDoc("timestamp", `Timestamp to set dir/files in.`,
 				`Formatted in seconds following Unix epoch (e.g., 1672531199).`),
+		dagql.NodeFunc("withReplaced", DagOpFileWrapper(s.srv, s.withReplaced, s.editPath)).
+			Doc(`Retrieves this file with occurrences of a string replaced.`,
+				`Unless all or firstFrom is set, it is an error for the search string to be found more than once.`).
+			ArgDoc("search", `The text to search for.`).
+			ArgDoc("replacement", `The text to replace the search string with.`).
+			ArgDoc("all", `Replace all occurrences of the search string.`).
+			ArgDoc("firstFrom", `Replace only the first occurrence found at or after this line number, starting at 1.`),
+		dagql.NodeFunc("withReplacedLines", DagOpFileWrapper(s.srv, s.withReplacedLines, s.editPath)).
+			Doc(`Retrieves this file with a range of lines replaced.`).
+			ArgDoc("start", `The first line to replace, starting at 1.`).
+			ArgDoc("end", `The last line to replace, inclusive.`).
+			ArgDoc("contents", `The text to replace the lines with. An empty string deletes the lines.`),
+		dagql.NodeFunc("withPatch", DagOpFileWrapper(s.srv, s.withPatch, s.editPath)).
+			Doc(`Retrieves this file with a unified diff applied to it.`,
+				`The patch must change exactly one file; its file names are ignored.`).
+			ArgDoc("patch", `The unified diff to apply, e.g. the output of "diff -u" or "git diff".`).
+			ArgDoc("strict", `Require each hunk to apply at exactly the line numbers in its header.`).
+			ArgDoc("fuzz", `The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.`),
 	}.Install(s.srv)
 }
 
@@ -75,6 +100,84 @@ func (s *fileSchema) name(ctx context.Context, file *core.File, args struct{}) (
 	return dagql.NewString(filepath.Base(file.File)), nil
 }
 
+func (s *fileSchema) editPath(ctx context.Context, file dagql.Instance[*core.File]) (string, error) {
+	return filepath.Base(file.Self.File), nil
+}
+
+type fileWithReplacedArgs struct {
+	Search      string
+	Replacement string
+	All         bool `default:"false"`
+	FirstFrom   dagql.Optional[dagql.Int]
+}
+
+func (s *fileSchema) withReplaced(ctx context.Context, parent dagql.Instance[*core.File], args fileWithReplacedArgs) (inst dagql.Instance[*core.File], _ error) {
+	var firstFrom *int
+	if args.FirstFrom.Valid {
+		n := args.FirstFrom.Value.Int()
+		firstFrom = &n
+	}
+	f, err := parent.Self.WithEditedContents(ctx, func(contents []byte) ([]byte, error) {
+		return core.ReplaceContents(contents, args.Search, args.Replacement, args.All, firstFrom)
+	})
+	if err != nil {
+		return inst, err
+	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, f)
+}
+
+type fileWithReplacedLinesArgs struct {
+	Start    int
+	End      int
+	Contents string
+}
+
+func (s *fileSchema) withReplacedLines(ctx context.Context, parent dagql.Instance[*core.File], args fileWithReplacedLinesArgs) (inst dagql.Instance[*core.File], _ error) {
+	f, err := parent.Self.WithEditedContents(ctx, func(contents []byte) ([]byte, error) {
+		return core.ReplaceLines(contents, args.Start, args.End, args.Contents)
+	})
+	if err != nil {
+		return inst, err
+	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, f)
+}
+
+type fileWithPatchArgs struct {
+	Patch  string
+	Strict bool `default:"false"`
+	Fuzz   int  `default:"0"`
+}
+
+func (s *fileSchema) withPatch(ctx context.Context, parent dagql.Instance[*core.File], args fileWithPatchArgs) (inst dagql.Instance[*core.File], _ error) {
+	if args.Fuzz < 0 {
+		return inst, fmt.Errorf("fuzz must not be negative, got %d", args.Fuzz)
+	}
+	f, err := parent.Self.WithEditedContents(ctx, func(contents []byte) ([]byte, error) {
+		return core.PatchContents(contents, args.Patch, patch.ApplyOpts{
+			Strict: args.Strict,
+			Fuzz:   args.Fuzz,
+		})
+	})
+	if err != nil {
+		return inst, err
+	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, f)
+}
+
+type fileSearchArgs struct {
+	Pattern   string
+	Literal   bool `default:"false"`
+	Multiline bool `default:"false"`
+}
+
+func (s *fileSchema) search(ctx context.Context, file *core.File, args fileSearchArgs) ([]core.SearchResult, error) {
+	return file.Search(ctx, core.SearchOpts{
+		Pattern:   args.Pattern,
+		Literal:   args.Literal,
+		Multiline: args.Multiline,
+	})
+}
+
 type fileDigestArgs struct {
 	ExcludeMetadata bool `default:"false"`
 }
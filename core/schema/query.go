@@ -37,6 +37,8 @@ func (s *querySchema) Install() {
 	core.NetworkProtocols.Install(s.srv)
 	core.ImageLayerCompressions.Install(s.srv)
 	core.ImageMediaTypesEnum.Install(s.srv)
+	core.SBOMFormats.Install(s.srv)
+	core.ServiceDependencyConditions.Install(s.srv)
 	core.CacheSharingModes.Install(s.srv)
 	core.TypeDefKinds.Install(s.srv)
 	core.ModuleSourceKindEnum.Install(s.srv)
@@ -45,11 +47,17 @@ func (s *querySchema) Install() {
 	dagql.MustInputSpec(PipelineLabel{}).Install(s.srv)
 	dagql.MustInputSpec(core.PortForward{}).Install(s.srv)
 	dagql.MustInputSpec(core.BuildArg{}).Install(s.srv)
+	dagql.MustInputSpec(core.ExecLimits{}).Install(s.srv)
+	dagql.MustInputSpec(core.ExecRetry{}).Install(s.srv)
 
 	dagql.Fields[EnvVariable]{}.Install(s.srv)
 
 	dagql.Fields[core.Port]{}.Install(s.srv)
 
+	dagql.Fields[*core.ExecMetrics]{}.Install(s.srv)
+
+	dagql.Fields[core.SearchResult]{}.Install(s.srv)
+
 	dagql.Fields[Label]{}.Install(s.srv)
 
 	dagql.Fields[*core.Query]{
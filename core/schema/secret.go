@@ -39,6 +39,11 @@ func (s *secretSchema) Install() {
 			Sensitive().
 			DoNotCache("Do not include plaintext secret in the cache.").
 			Doc(`The value of this secret.`),
+		dagql.NodeFunc("refresh", s.refresh).
+			DoNotCache("Secrets must be resolved again from their provider.").
+			Doc(`Resolves this secret again from its provider, bypassing any cached value.`,
+				`Subsequent uses of the secret see the new value, which picks up rotated credentials in long-running sessions.`,
+				`This is a no-op for secrets created with setSecret.`),
 	}.Install(s.srv)
 }
 
@@ -176,3 +181,29 @@ func (s *secretSchema) plaintext(ctx context.Context, secret dagql.Instance[*cor
 
 	return dagql.NewString(string(plaintext)), nil
 }
+
+func (s *secretSchema) refresh(ctx context.Context, secret dagql.Instance[*core.Secret], args struct{}) (dagql.ID[*core.Secret], error) {
+	if secret.Self.URI != "" {
+		secretStore, err := secret.Self.Query.Secrets(ctx)
+		if err != nil {
+			return dagql.ID[*core.Secret]{}, fmt.Errorf("failed to get secret store: %w", err)
+		}
+		if _, err := secretStore.RefreshSecretPlaintext(ctx, secret.ID().Digest()); err != nil {
+			return dagql.ID[*core.Secret]{}, err
+		}
+	}
+	return dagql.NewID[*core.Secret](secret.ID()), nil
+}
+
+// secretPlaintext loads the secret and returns its plaintext.
+func secretPlaintext(ctx context.Context, srv *dagql.Server, id core.SecretID) ([]byte, error) {
+	secret, err := id.Load(ctx, srv)
+	if err != nil {
+		return nil, err
+	}
+	secretStore, err := secret.Self.Query.Secrets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret store: %w", err)
+	}
+	return secretStore.GetSecretPlaintext(ctx, secret.ID().Digest())
+}
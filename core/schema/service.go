@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime/debug"
+	"time"
 
 	"github.com/dagger/dagger/core"
 	"github.com/dagger/dagger/dagql"
@@ -97,6 +98,14 @@ func (s *serviceSchema) Install() {
 			Doc(`Configures a hostname which can be used by clients within the session to reach this container.`).
 			ArgDoc("hostname", `The hostname to use.`),
 
+		dagql.NodeFunc("withDependency", s.withDependency).
+			Doc(`Configures a service to start before this service, and to stop along with it.`,
+				`Dependencies start in the order they are added, each once the previous one meets its condition.`,
+				`A dependency is reachable at its hostname within the session.`).
+			ArgDoc("service", `The service to start first.`).
+			ArgDoc("condition", `The condition the dependency must meet before this service starts.`,
+				`Use COMPLETED for jobs like database migrations, which must exit successfully.`),
+
 		dagql.NodeFunc("ports", s.ports).
 			DoNotCache("A tunnel service's ports can change each time it is restarted.").
 			Doc(`Retrieves the list of ports provided by the service.`),
@@ -121,6 +130,34 @@ func (s *serviceSchema) Install() {
 			ArgDoc("ports", `List of frontend/backend port mappings to forward.`,
 				`Frontend is the port accepting traffic on the host, backend is the service port.`),
 
+		dagql.NodeFunc("logs", s.logs).
+			DoNotCache("The logs change while the service runs.").
+			Doc(`Retrieves the combined standard output and standard error of the service, in the order they were written.`,
+				`The service must be running or have run in the session. Only the most recent output is kept.`).
+			ArgDoc("waitForExit", `Wait for the service to exit before returning its logs, instead of returning the output so far.`,
+				`The logs are not streamed while waiting: use "since" to poll for new lines instead.`).
+			ArgDoc("since", `Only return the lines written since the given time, either an RFC 3339 timestamp (e.g., "2006-01-02T15:04:05Z") or a duration before now (e.g., "5m").`).
+			ArgDoc("tail", `Only return the given number of last lines. All lines are returned if 0.`),
+
+		dagql.NodeFunc("stdout", s.stdout).
+			DoNotCache("The logs change while the service runs.").
+			Doc(`The standard output of the service so far.`,
+				`The service must be running or have run in the session. Only the most recent output is kept.`),
+
+		dagql.NodeFunc("stderr", s.stderr).
+			DoNotCache("The logs change while the service runs.").
+			Doc(`The standard error of the service so far.`,
+				`The service must be running or have run in the session. Only the most recent output is kept.`),
+
+		dagql.NodeFunc("snapshot", s.snapshot).
+			Doc(`Gracefully stops the service and captures its state as a container, starting it first if it's not running.`,
+				`The container's root filesystem includes the changes made by the service and the contents of its cache volumes, which are no longer mounted.`,
+				`The snapshot is cached like any other result, so later runs can start from the warmed-up state.`),
+
+		// hidden from external clients via the __ prefix
+		dagql.NodeFunc("__snapshotRootfs", DagOpDirectoryWrapper(s.srv, s.snapshotRootfs, nil)).
+			Doc(`(Internal-only) The root filesystem of a snapshot of the service.`),
+
 		dagql.NodeFunc("stop", s.stop).
 			DoNotCache("Imperatively mutates runtime state.").
 			Doc(`Stop the service.`).
@@ -236,6 +273,19 @@ func (s *serviceSchema) withHostname(ctx context.Context, parent dagql.Instance[
 	return parent.Self.WithHostname(args.Hostname), nil
 }
 
+type serviceWithDependencyArgs struct {
+	Service   core.ServiceID
+	Condition core.ServiceDependencyCondition `default:"HEALTHY"`
+}
+
+func (s *serviceSchema) withDependency(ctx context.Context, parent dagql.Instance[*core.Service], args serviceWithDependencyArgs) (*core.Service, error) {
+	dep, err := args.Service.Load(ctx, s.srv)
+	if err != nil {
+		return nil, err
+	}
+	return parent.Self.WithDependency(dep.ID(), dep.Self, args.Condition)
+}
+
 func (s *serviceSchema) ports(ctx context.Context, parent dagql.Instance[*core.Service], args struct{}) (dagql.Array[core.Port], error) {
 	return parent.Self.Ports(ctx, parent.ID())
 }
@@ -268,6 +318,69 @@ func (s *serviceSchema) start(ctx context.Context, parent dagql.Instance[*core.S
 	return dagql.NewID[*core.Service](parent.ID()), nil
 }
 
+func (s *serviceSchema) snapshot(ctx context.Context, parent dagql.Instance[*core.Service], args struct{}) (*core.Container, error) {
+	var rootfs dagql.Instance[*core.Directory]
+	if err := s.srv.Select(ctx, parent, &rootfs, dagql.Selector{Field: "__snapshotRootfs"}); err != nil {
+		return nil, err
+	}
+	return parent.Self.SnapshotContainer(ctx, rootfs.Self)
+}
+
+func (s *serviceSchema) snapshotRootfs(ctx context.Context, parent dagql.Instance[*core.Service], args struct{}) (inst dagql.Instance[*core.Directory], _ error) {
+	dir, err := parent.Self.Snapshot(ctx, parent.ID())
+	if err != nil {
+		return inst, err
+	}
+	return dagql.NewInstanceForCurrentID(ctx, s.srv, parent, dir)
+}
+
+type serviceLogsArgs struct {
+	WaitForExit bool   `default:"false"`
+	Since       string `default:""`
+	Tail        int    `default:"0"`
+}
+
+func (s *serviceSchema) logs(ctx context.Context, parent dagql.Instance[*core.Service], args serviceLogsArgs) (dagql.String, error) {
+	if args.Tail < 0 {
+		return "", fmt.Errorf("tail must not be negative")
+	}
+	opts := core.ServiceLogsOpts{
+		Stdout: true,
+		Stderr: true,
+		Tail:   args.Tail,
+	}
+	if args.Since != "" {
+		if since, err := time.Parse(time.RFC3339, args.Since); err == nil {
+			opts.Since = since
+		} else if ago, err := time.ParseDuration(args.Since); err == nil {
+			opts.Since = time.Now().Add(-ago)
+		} else {
+			return "", fmt.Errorf("invalid since %q: must be an RFC 3339 timestamp or a duration", args.Since)
+		}
+	}
+	logs, err := parent.Self.Logs(ctx, parent.ID(), args.WaitForExit, opts)
+	if err != nil {
+		return "", err
+	}
+	return dagql.NewString(logs), nil
+}
+
+func (s *serviceSchema) stdout(ctx context.Context, parent dagql.Instance[*core.Service], args struct{}) (dagql.String, error) {
+	logs, err := parent.Self.Logs(ctx, parent.ID(), false, core.ServiceLogsOpts{Stdout: true})
+	if err != nil {
+		return "", err
+	}
+	return dagql.NewString(logs), nil
+}
+
+func (s *serviceSchema) stderr(ctx context.Context, parent dagql.Instance[*core.Service], args struct{}) (dagql.String, error) {
+	logs, err := parent.Self.Logs(ctx, parent.ID(), false, core.ServiceLogsOpts{Stderr: true})
+	if err != nil {
+		return "", err
+	}
+	return dagql.NewString(logs), nil
+}
+
 type serviceStopArgs struct {
 	Kill bool `default:"false"`
 }
@@ -321,13 +434,15 @@ func (s *serviceSchema) up(ctx context.Context, svc dagql.Instance[*core.Service
 	slog := slog.SpanLogger(ctx, InstrumentationLibrary)
 
 	for _, port := range runningSvc.Ports {
-		slog.Info(
-			"tunnel started",
+		attrs := []any{
 			"port", port.Port,
 			"protocol", port.Protocol.Network(),
-			"http_url", fmt.Sprintf("http://%s:%d", "localhost", port.Port),
-			"description", *port.Description,
-		)
+		}
+		if port.Protocol == core.NetworkProtocolTCP {
+			attrs = append(attrs, "http_url", fmt.Sprintf("http://%s:%d", "localhost", port.Port))
+		}
+		attrs = append(attrs, "description", *port.Description)
+		slog.Info("tunnel started", attrs...)
 	}
 
 	// wait for the request to be canceled
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/moby/patternmatcher"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+// SearchResult is a single match of a content search.
+type SearchResult struct {
+	FilePath    string `field:"true" doc:"The path to the file that matched."`
+	LineNumber  int    `field:"true" doc:"The first line that matched, starting at 1."`
+	Column      int    `field:"true" doc:"The byte offset of the match within its first line, starting at 1."`
+	MatchedText string `field:"true" doc:"The text that matched the pattern."`
+}
+
+func (SearchResult) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "SearchResult",
+		NonNull:   true,
+	}
+}
+
+func (SearchResult) TypeDescription() string {
+	return "A match of a content search in a file."
+}
+
+// SearchOpts configures a content search.
+type SearchOpts struct {
+	// The regular expression (RE2 syntax) to search for.
+	Pattern string
+	// Interpret the pattern as a literal string instead of a regular
+	// expression.
+	Literal bool
+	// Glob patterns restricting the searched paths.
+	Paths []string
+	// Allow matches to span multiple lines.
+	Multiline bool
+}
+
+func (opts SearchOpts) regexp() (*regexp.Regexp, error) {
+	pattern := opts.Pattern
+	if pattern == "" {
+		return nil, errors.New("search pattern must not be empty")
+	}
+	if opts.Literal {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.Multiline {
+		pattern = "(?m)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid search pattern: %w", err)
+	}
+	return re, nil
+}
+
+// binarySniffLen is how much of a file is inspected to detect binary content,
+// which is skipped from searches.
+const binarySniffLen = 8000
+
+// searchReader returns the matches of re in the content of r, reported as
+// being in filePath.
+func searchReader(r io.Reader, filePath string, re *regexp.Regexp, multiline bool) ([]SearchResult, error) {
+	br := bufio.NewReaderSize(r, binarySniffLen)
+	head, err := br.Peek(binarySniffLen)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	if bytes.IndexByte(head, 0) != -1 {
+		return nil, nil
+	}
+
+	var results []SearchResult
+	if multiline {
+		content, err := io.ReadAll(io.LimitReader(br, buildkit.MaxFileContentsSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(content) > buildkit.MaxFileContentsSize {
+			return nil, fmt.Errorf("%s: file size exceeds limit %d", filePath, buildkit.MaxFileContentsSize)
+		}
+		for _, loc := range re.FindAllIndex(content, -1) {
+			lineStart := bytes.LastIndexByte(content[:loc[0]], '\n') + 1
+			results = append(results, SearchResult{
+				FilePath:    filePath,
+				LineNumber:  bytes.Count(content[:loc[0]], []byte("\n")) + 1,
+				Column:      loc[0] - lineStart + 1,
+				MatchedText: string(content[loc[0]:loc[1]]),
+			})
+		}
+		return results, nil
+	}
+
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, 64*1024), buildkit.MaxFileContentsSize)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Bytes()
+		for _, loc := range re.FindAllIndex(line, -1) {
+			results = append(results, SearchResult{
+				FilePath:    filePath,
+				LineNumber:  lineNumber,
+				Column:      loc[0] + 1,
+				MatchedText: string(line[loc[0]:loc[1]]),
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return results, nil
+}
+
+// searchDir searches the regular files below root, skipping symlinks and
+// binary files. Paths in results are relative to root.
+func searchDir(ctx context.Context, root string, opts SearchOpts) ([]SearchResult, error) {
+	re, err := opts.regexp()
+	if err != nil {
+		return nil, err
+	}
+
+	var pm *patternmatcher.PatternMatcher
+	if len(opts.Paths) > 0 {
+		pm, err = patternmatcher.New(opts.Paths)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := []SearchResult{}
+	err = filepath.WalkDir(root, func(fullPath string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, fullPath)
+		if err != nil {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath)
+		if pm != nil {
+			match, err := pm.MatchesOrParentMatches(relPath)
+			if err != nil {
+				return err
+			}
+			if !match {
+				return nil
+			}
+		}
+
+		f, err := os.Open(fullPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fileResults, err := searchReader(f, relPath, re, opts.Multiline)
+		if err != nil {
+			return err
+		}
+		results = append(results, fileResults...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Search returns the matches of the given search in the files of the
+// directory.
+func (dir *Directory) Search(ctx context.Context, opts SearchOpts) ([]SearchResult, error) {
+	var results []SearchResult
+	err := dir.mount(ctx, func(root string) error {
+		src, err := fs.RootPath(root, dir.Dir)
+		if err != nil {
+			return err
+		}
+		results, err = searchDir(ctx, src, opts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Search returns the matches of the given search in the contents of the file.
+func (file *File) Search(ctx context.Context, opts SearchOpts) ([]SearchResult, error) {
+	re, err := opts.regexp()
+	if err != nil {
+		return nil, err
+	}
+	r, err := file.Open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	results, err := searchReader(r, filepath.Base(file.File), re, opts.Multiline)
+	if err != nil {
+		return nil, err
+	}
+	if results == nil {
+		results = []SearchResult{}
+	}
+	return results, nil
+}
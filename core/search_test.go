@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchDir(t *testing.T) {
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o644))
+	}
+	write("main.go", "package main\n\n// TODO: fix\nfunc main() {}\n")
+	write("pkg/util.go", "package pkg\n\nfunc a() {} // TODO a.b\n")
+	write("docs/readme.md", "TODO docs\n")
+	write("bin/blob", "TODO\x00binary")
+	require.NoError(t, os.Symlink("main.go", filepath.Join(root, "link.go")))
+
+	ctx := context.Background()
+
+	results, err := searchDir(ctx, root, SearchOpts{Pattern: `TODO\W`})
+	require.NoError(t, err)
+	require.Equal(t, []SearchResult{
+		{FilePath: "docs/readme.md", LineNumber: 1, Column: 1, MatchedText: "TODO "},
+		{FilePath: "main.go", LineNumber: 3, Column: 4, MatchedText: "TODO:"},
+		{FilePath: "pkg/util.go", LineNumber: 3, Column: 16, MatchedText: "TODO "},
+	}, results)
+
+	results, err = searchDir(ctx, root, SearchOpts{Pattern: "a.b", Literal: true, Paths: []string{"pkg/"}})
+	require.NoError(t, err)
+	require.Equal(t, []SearchResult{
+		{FilePath: "pkg/util.go", LineNumber: 3, Column: 21, MatchedText: "a.b"},
+	}, results)
+
+	results, err = searchDir(ctx, root, SearchOpts{Pattern: "fix\n^func", Multiline: true, Paths: []string{"*.go"}})
+	require.NoError(t, err)
+	require.Equal(t, []SearchResult{
+		{FilePath: "main.go", LineNumber: 3, Column: 10, MatchedText: "fix\nfunc"},
+	}, results)
+
+	results, err = searchDir(ctx, root, SearchOpts{Pattern: "nope"})
+	require.NoError(t, err)
+	require.Empty(t, results)
+
+	_, err = searchDir(ctx, root, SearchOpts{Pattern: "("})
+	require.ErrorContains(t, err, "invalid search pattern")
+}
@@ -70,7 +70,7 @@ func (store *SecretStore) AddSecret(secret dagql.Instance[*Secret]) error {
 	}
 
 	if secret.Self.URI != "" {
-		_, _, err := secretprovider.ResolverForID(secret.Self.URI)
+		_, _, err := secretprovider.ParseID(secret.Self.URI)
 		if err != nil {
 			return err
 		}
@@ -136,6 +136,17 @@ func (store *SecretStore) GetSecretNameOrURI(idDgst digest.Digest) (string, bool
 }
 
 func (store *SecretStore) GetSecretPlaintext(ctx context.Context, idDgst digest.Digest) ([]byte, error) {
+	return store.getSecretPlaintext(ctx, idDgst, false)
+}
+
+// RefreshSecretPlaintext resolves the secret again from its provider,
+// bypassing any plaintext cached by the client, so that subsequent lookups
+// return the rotated value.
+func (store *SecretStore) RefreshSecretPlaintext(ctx context.Context, idDgst digest.Digest) ([]byte, error) {
+	return store.getSecretPlaintext(ctx, idDgst, true)
+}
+
+func (store *SecretStore) getSecretPlaintext(ctx context.Context, idDgst digest.Digest, refresh bool) ([]byte, error) {
 	store.mu.RLock()
 	defer store.mu.RUnlock()
 	secret, ok := store.secrets[idDgst]
@@ -160,9 +171,15 @@ func (store *SecretStore) GetSecretPlaintext(ctx context.Context, idDgst digest.
 		return nil, status.Errorf(codes.Internal, "failed to get buildkit session: was nil")
 	}
 
-	resp, err := secrets.NewSecretsClient(caller.Conn()).GetSecret(ctx, &secrets.GetSecretRequest{
+	req := &secrets.GetSecretRequest{
 		ID: secret.Self.URI,
-	})
+	}
+	if refresh {
+		req.Annotations = map[string]string{
+			secretprovider.RefreshAnnotation: "true",
+		}
+	}
+	resp, err := secrets.NewSecretsClient(caller.Conn()).GetSecret(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"log/slog"
 	"net"
+	"path"
 	"slices"
 	"strconv"
 	"strings"
@@ -54,6 +55,9 @@ type Service struct {
 
 	// The sockets on the host to reverse tunnel
 	HostSockets []*Socket
+
+	// Dependencies are the services started before this service, in order.
+	Dependencies []ServiceDependency
 }
 
 func (*Service) Type() *ast.Type {
@@ -79,6 +83,7 @@ func (svc *Service) Clone() *Service {
 	}
 	cp.TunnelPorts = cloneSlice(cp.TunnelPorts)
 	cp.HostSockets = cloneSlice(cp.HostSockets)
+	cp.Dependencies = cloneSlice(cp.Dependencies)
 	return &cp
 }
 
@@ -206,6 +211,31 @@ func (svc *Service) StartAndTrack(ctx context.Context, id *call.ID) error {
 	return err
 }
 
+// Logs returns the output of the service, which must be running or have run
+// in the session. If waitForExit is set, it waits for the service to exit
+// first.
+func (svc *Service) Logs(ctx context.Context, id *call.ID, waitForExit bool, opts ServiceLogsOpts) (string, error) {
+	if svc.Container == nil {
+		return "", errors.New("only container services have logs")
+	}
+	svcs, err := svc.Query.Services(ctx)
+	if err != nil {
+		return "", err
+	}
+	logs, err := svcs.Logs(ctx, id, false)
+	if err != nil {
+		return "", err
+	}
+	if waitForExit {
+		select {
+		case <-logs.Done():
+		case <-ctx.Done():
+			return "", context.Cause(ctx)
+		}
+	}
+	return logs.Text(opts), nil
+}
+
 func (svc *Service) Stop(ctx context.Context, id *call.ID, kill bool) error {
 	svcs, err := svc.Query.Services(ctx)
 	if err != nil {
@@ -222,9 +252,11 @@ func (svc *Service) Start(
 	forwardStdout func(io.Reader),
 	forwardStderr func(io.Reader),
 ) (running *RunningService, err error) {
+	ctx, started := takeServiceStarted(ctx)
+	ctx, dependency := takeServiceDependency(ctx)
 	switch {
 	case svc.Container != nil:
-		return svc.startContainer(ctx, id, interactive, forwardStdin, forwardStdout, forwardStderr)
+		return svc.startContainer(ctx, id, interactive, forwardStdin, forwardStdout, forwardStderr, started, dependency)
 	case svc.TunnelUpstream != nil:
 		return svc.startTunnel(ctx)
 	case len(svc.HostSockets) > 0:
@@ -242,6 +274,8 @@ func (svc *Service) startContainer(
 	forwardStdin func(io.Writer, bkgw.ContainerProcess),
 	forwardStdout func(io.Reader),
 	forwardStderr func(io.Reader),
+	started func(),
+	dependency bool,
 ) (running *RunningService, rerr error) {
 	dig := id.Digest()
 
@@ -288,15 +322,26 @@ func (svc *Service) startContainer(
 			AllowedLLMModules: clientMetadata.AllowedLLMModules,
 		}
 	}
+	// a service exiting is reported to its clients rather than retried
+	execMD.Retry = nil
 
 	svcs, err := svc.Query.Services(ctx)
 	if err != nil {
 		return nil, err
 	}
-	detachDeps, _, err := svcs.StartBindings(ctx, ctr.Services)
+	detachDependencies, err := svcs.StartDependencies(ctx, svc.Dependencies)
+	if err != nil {
+		return nil, fmt.Errorf("start service dependencies: %w", err)
+	}
+	detachBindings, _, err := svcs.StartBindings(ctx, ctr.Services)
 	if err != nil {
+		detachDependencies()
 		return nil, fmt.Errorf("start dependent services: %w", err)
 	}
+	detachDeps := func() {
+		detachBindings()
+		detachDependencies()
+	}
 
 	defer func() {
 		if err != nil {
@@ -410,11 +455,6 @@ func (svc *Service) startContainer(
 		}
 	}()
 
-	checked := make(chan error, 1)
-	go func() {
-		checked <- newHealth(bk, gc, fullHost, ctr.Ports).Check(ctx)
-	}()
-
 	env := append([]string{}, execOp.Meta.Env...)
 	env = append(env, telemetry.PropagationEnv(ctx)...)
 
@@ -432,6 +472,9 @@ func (svc *Service) startContainer(
 		stderrClient, stderrCtr = io.Pipe()
 	}
 
+	// record the output for Service.logs, in addition to forwarding it
+	logs := NewServiceLogs(ServiceLogsLimit)
+
 	svcProc, err := gc.Start(execCtx, bkgw.StartRequest{
 		Args:         execOp.Meta.Args,
 		Env:          env,
@@ -440,13 +483,33 @@ func (svc *Service) startContainer(
 		SecretEnv:    execOp.Secretenv,
 		Tty:          interactive,
 		Stdin:        stdinCtr,
-		Stdout:       stdoutCtr,
-		Stderr:       stderrCtr,
+		Stdout:       teeOutput(logs.Stdout(), stdoutCtr),
+		Stderr:       teeOutput(logs.Stderr(), stderrCtr),
 		SecurityMode: execOp.Security,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("start container: %w", err)
 	}
+	started()
+
+	// health checks run once the service started, since command checks
+	// exec in the service container
+	var health interface {
+		Check(context.Context) error
+	} = newHealth(bk, gc, fullHost, ctr.Ports)
+	if ctr.Healthcheck != nil {
+		health = newCustomHealth(bk, gc, fullHost, ctr.Ports, *ctr.Healthcheck, bkgw.StartRequest{
+			Env:          env,
+			Cwd:          execOp.Meta.Cwd,
+			User:         execOp.Meta.User,
+			SecretEnv:    execOp.Secretenv,
+			SecurityMode: execOp.Security,
+		})
+	}
+	checked := make(chan error, 1)
+	go func() {
+		checked <- health.Check(ctx)
+	}()
 
 	if forwardStdin != nil {
 		forwardStdin(stdinClient, svcProc)
@@ -462,6 +525,9 @@ func (svc *Service) startContainer(
 
 	var exitErr error
 	exited := make(chan struct{})
+	// procExited is closed once the process exited, before the container is
+	// released
+	procExited := make(chan struct{})
 	go func() {
 		defer func() {
 			if stdinClient != nil {
@@ -477,6 +543,8 @@ func (svc *Service) startContainer(
 		}()
 
 		exitErr = svcProc.Wait()
+		close(procExited)
+		logs.Close()
 		slog.Info("service exited", "err", exitErr)
 
 		// show the exit status; doing so won't fail anything, and is
@@ -528,27 +596,119 @@ func (svc *Service) startContainer(
 		}
 	}
 
+	// the snapshot includes cache volumes, but not the inputs and secrets
+	// mounted in the container
+	var snapshotExcludes []string
+	for _, m := range execOp.Mounts {
+		if m.Dest == pb.RootMount || m.MountType == pb.MountType_CACHE {
+			continue
+		}
+		snapshotExcludes = append(snapshotExcludes, strings.TrimPrefix(path.Clean(m.Dest), "/"))
+	}
+	// the root filesystem is copied once the process exited, before the
+	// container is released, so the copy doesn't count towards the grace
+	// period of the process
+	snapshotSvc := func(ctx context.Context, dest string) error {
+		copied := make(chan error, 1)
+		err := bk.OnContainerExit(gc, func(rootfs string) {
+			copied <- copyServiceRootFS(ctx, rootfs, dest, snapshotExcludes)
+		})
+		if err != nil {
+			return fmt.Errorf("service has exited: %w", err)
+		}
+
+		stopped.Store(true)
+		signal := func(sig syscall.Signal) error {
+			err := svcProc.Signal(ctx, sig)
+			if err != nil {
+				select {
+				case <-procExited:
+					// exited in the meantime
+					return nil
+				default:
+				}
+				return fmt.Errorf("signal: %w", err)
+			}
+			return nil
+		}
+		if err := signal(syscall.SIGTERM); err != nil {
+			return err
+		}
+		cause := errors.New("service did not terminate")
+		graceCtx, cancel := context.WithTimeoutCause(ctx, TerminateGracePeriod, cause)
+		defer cancel()
+		select {
+		case <-procExited:
+		case <-graceCtx.Done():
+			if context.Cause(graceCtx) != cause {
+				return context.Cause(ctx)
+			}
+			// service didn't terminate within the grace period, so force it
+			// to stop
+			if err := signal(syscall.SIGKILL); err != nil {
+				return err
+			}
+			select {
+			case <-procExited:
+			case <-ctx.Done():
+				return context.Cause(ctx)
+			}
+		}
+
+		select {
+		case err := <-copied:
+			return err
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+
+	runningSvc := &RunningService{
+		Service:  svc,
+		Host:     fullHost,
+		Ports:    ctr.Ports,
+		Stop:     stopSvc,
+		Wait:     waitSvc,
+		Logs:     logs,
+		Snapshot: snapshotSvc,
+	}
+
 	select {
 	case err := <-checked:
 		if err != nil {
+			// don't leave an unhealthy service running
+			if stopErr := stopSvc(ctx, true); stopErr != nil {
+				slog.Warn("failed to stop unhealthy service", "err", stopErr)
+			}
 			return nil, fmt.Errorf("health check errored: %w", err)
 		}
 
-		return &RunningService{
-			Service: svc,
-			Host:    fullHost,
-			Ports:   ctr.Ports,
-			Stop:    stopSvc,
-			Wait:    waitSvc,
-		}, nil
+		return runningSvc, nil
 	case <-exited:
 		if exitErr != nil {
 			return nil, fmt.Errorf("exited: %w", exitErr)
 		}
+		if dependency && len(ctr.Ports) == 0 && ctr.Healthcheck == nil {
+			// nothing to check; the dependency is a job that already
+			// completed, e.g. one waited on until it completes
+			return runningSvc, nil
+		}
 		return nil, fmt.Errorf("service exited before healthcheck")
 	}
 }
 
+// teeOutput returns a writer writing to both the recorded output and the
+// forwarded output, if any.
+func teeOutput(recorded io.WriteCloser, forwarded io.WriteCloser) io.WriteCloser {
+	if forwarded == nil {
+		return recorded
+	}
+	return struct {
+		io.Writer
+		io.Closer
+	}{io.MultiWriter(recorded, forwarded), forwarded}
+}
+
 func (svc *Service) startTunnel(ctx context.Context) (running *RunningService, rerr error) {
 	svcCtx, stop := context.WithCancelCause(context.WithoutCancel(ctx))
 	defer func() {
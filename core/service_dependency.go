@@ -0,0 +1,210 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/network"
+)
+
+type ServiceDependencyCondition string
+
+var ServiceDependencyConditions = dagql.NewEnum[ServiceDependencyCondition]()
+
+var (
+	ServiceDependencyStarted = ServiceDependencyConditions.Register("STARTED",
+		`The dependency has started, regardless of its health checks.`)
+	ServiceDependencyHealthy = ServiceDependencyConditions.Register("HEALTHY",
+		`The dependency has started and its health checks passed.`)
+	ServiceDependencyCompleted = ServiceDependencyConditions.Register("COMPLETED",
+		`The dependency has run to completion and exited successfully.`)
+)
+
+func (cond ServiceDependencyCondition) Type() *ast.Type {
+	return &ast.Type{
+		NamedType: "ServiceDependencyCondition",
+		NonNull:   true,
+	}
+}
+
+func (cond ServiceDependencyCondition) TypeDescription() string {
+	return "The condition a service dependency must meet before the service depending on it starts."
+}
+
+func (cond ServiceDependencyCondition) Decoder() dagql.InputDecoder {
+	return ServiceDependencyConditions
+}
+
+func (cond ServiceDependencyCondition) ToLiteral() call.Literal {
+	return ServiceDependencyConditions.Literal(cond)
+}
+
+// ServiceDependency is a service started before the service depending on it,
+// and stopped along with it.
+type ServiceDependency struct {
+	ID        *call.ID
+	Service   *Service
+	Condition ServiceDependencyCondition
+}
+
+func (dep ServiceDependency) name() string {
+	if dep.Service.CustomHostname != "" {
+		return dep.Service.CustomHostname
+	}
+	return network.HostHash(dep.ID.Digest())
+}
+
+// WithDependency returns the service with the given service started before
+// it, once the condition is met.
+func (svc *Service) WithDependency(id *call.ID, dep *Service, cond ServiceDependencyCondition) (*Service, error) {
+	if svc.Container == nil {
+		return nil, errors.New("only container services can have dependencies")
+	}
+	svc = svc.Clone()
+	svc.Dependencies = append(svc.Dependencies, ServiceDependency{
+		ID:        id,
+		Service:   dep,
+		Condition: cond,
+	})
+	return svc, nil
+}
+
+type serviceStartedKey struct{}
+
+// contextWithServiceStarted returns a context for starting a service which
+// calls fn once the service's process has started, possibly before its health
+// checks pass.
+func contextWithServiceStarted(ctx context.Context, fn func()) context.Context {
+	return context.WithValue(ctx, serviceStartedKey{}, fn)
+}
+
+// takeServiceStarted returns the function to call once the service started,
+// and a context without it so that the services it starts in turn don't call
+// it.
+func takeServiceStarted(ctx context.Context) (context.Context, func()) {
+	fn, ok := ctx.Value(serviceStartedKey{}).(func())
+	if !ok {
+		return ctx, func() {}
+	}
+	return context.WithValue(ctx, serviceStartedKey{}, nil), fn
+}
+
+type serviceDependencyKey struct{}
+
+// contextWithServiceDependency returns a context for starting a service as a
+// dependency of another one, which may be a job that completes before it's
+// checked.
+func contextWithServiceDependency(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serviceDependencyKey{}, true)
+}
+
+// takeServiceDependency returns whether the service is started as a
+// dependency, and a context without it so that the services it starts in turn
+// aren't.
+func takeServiceDependency(ctx context.Context) (context.Context, bool) {
+	dependency, _ := ctx.Value(serviceDependencyKey{}).(bool)
+	if !dependency {
+		return ctx, false
+	}
+	return context.WithValue(ctx, serviceDependencyKey{}, false), true
+}
+
+// StartDependencies starts each of the dependencies in order, waiting for each
+// to meet its condition before starting the next one, and returns a function
+// that will detach from all of them after 10 seconds.
+func (ss *Services) StartDependencies(ctx context.Context, deps []ServiceDependency) (_ func(), err error) {
+	var mu sync.Mutex
+	var running []*RunningService
+	var detached bool
+	attach := func(svc *RunningService) {
+		mu.Lock()
+		defer mu.Unlock()
+		if detached {
+			// started in the background after detaching
+			go ss.Detach(ctx, svc)
+			return
+		}
+		running = append(running, svc)
+	}
+
+	detachOnce := sync.Once{}
+	detach := func() {
+		detachOnce.Do(func() {
+			mu.Lock()
+			detached = true
+			svcs := running
+			mu.Unlock()
+			go func() {
+				<-time.After(DetachGracePeriod)
+				for _, svc := range svcs {
+					ss.Detach(ctx, svc)
+				}
+			}()
+		})
+	}
+
+	for _, dep := range deps {
+		if err := ss.startDependency(ctx, dep, attach); err != nil {
+			detach()
+			return nil, fmt.Errorf("start dependency %s: %w", dep.name(), err)
+		}
+	}
+	return detach, nil
+}
+
+func (ss *Services) startDependency(ctx context.Context, dep ServiceDependency, attach func(*RunningService)) error {
+	ctx = contextWithServiceDependency(ctx)
+	switch dep.Condition {
+	case ServiceDependencyStarted:
+		started := make(chan struct{})
+		startedOnce := sync.Once{}
+		startCtx := contextWithServiceStarted(ctx, func() {
+			startedOnce.Do(func() { close(started) })
+		})
+		done := make(chan error, 1)
+		go func() {
+			running, err := ss.Start(startCtx, dep.ID, dep.Service, false)
+			if err == nil {
+				attach(running)
+			}
+			done <- err
+		}()
+		select {
+		case <-started:
+			return nil
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	case ServiceDependencyHealthy:
+		running, err := ss.Start(ctx, dep.ID, dep.Service, false)
+		if err != nil {
+			return err
+		}
+		attach(running)
+		return nil
+	case ServiceDependencyCompleted:
+		running, err := ss.Start(ctx, dep.ID, dep.Service, false)
+		if err != nil {
+			return err
+		}
+		attach(running)
+		if running.Wait == nil {
+			return errors.New("service does not run to completion")
+		}
+		if err := running.Wait(ctx); err != nil {
+			return fmt.Errorf("did not complete successfully: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown dependency condition %q", dep.Condition)
+	}
+}
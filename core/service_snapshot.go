@@ -0,0 +1,123 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	bkcache "github.com/moby/buildkit/cache"
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/bklog"
+	fscopy "github.com/tonistiigi/fsutil/copy"
+
+	"github.com/dagger/dagger/dagql/call"
+)
+
+// Snapshot gracefully stops the service, starting it first if it's not
+// running, and returns its root filesystem including the contents of the
+// cache volumes mounted in it.
+//
+// It must be called within a FSDagOp.
+func (svc *Service) Snapshot(ctx context.Context, id *call.ID) (_ *Directory, rerr error) {
+	if svc.Container == nil {
+		return nil, errors.New("only container services can be snapshotted")
+	}
+
+	op, ok := DagOpFromContext[FSDagOp](ctx)
+	if !ok {
+		return nil, fmt.Errorf("no dagop")
+	}
+
+	svcs, err := svc.Query.Services(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get services: %w", err)
+	}
+	running, err := svcs.Start(ctx, id, svc, false)
+	if err != nil {
+		return nil, fmt.Errorf("start service: %w", err)
+	}
+	// no-op once the snapshot stopped the service
+	defer svcs.Detach(ctx, running)
+
+	bkref, err := op.CreateRef(ctx, nil,
+		bkcache.CachePolicyRetain,
+		bkcache.WithRecordType(bkclient.UsageRecordTypeRegular),
+		bkcache.WithDescription(op.Name()))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr != nil && bkref != nil {
+			bkref.Release(context.WithoutCancel(ctx))
+		}
+	}()
+	err = op.Mount(ctx, bkref, func(out string) error {
+		return svcs.Snapshot(ctx, running, out)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("snapshot service: %w", err)
+	}
+
+	snap, err := bkref.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	bkref = nil
+
+	dir := NewDirectory(svc.Query, nil, "/", svc.Container.Platform, nil)
+	dir.Result = snap
+	return dir, nil
+}
+
+// SnapshotContainer returns the container of the service with its root
+// filesystem replaced by a snapshot. Cache volumes are no longer mounted,
+// since their contents are part of the snapshot.
+func (svc *Service) SnapshotContainer(ctx context.Context, rootfs *Directory) (*Container, error) {
+	if svc.Container == nil {
+		return nil, errors.New("only container services can be snapshotted")
+	}
+	ctr, err := svc.Container.WithRootFS(ctx, rootfs)
+	if err != nil {
+		return nil, err
+	}
+	ctr.Mounts = slices.DeleteFunc(ctr.Mounts, func(mnt ContainerMount) bool {
+		return mnt.CacheVolumeID != ""
+	})
+	ctr.Meta = nil
+	return ctr, nil
+}
+
+// Snapshot gracefully stops the running service and copies its root
+// filesystem to dest once it exits.
+func (ss *Services) Snapshot(ctx context.Context, running *RunningService, dest string) error {
+	if running.Snapshot == nil {
+		return fmt.Errorf("service %s cannot be snapshotted", running.Host)
+	}
+	if err := running.Snapshot(ctx, dest); err != nil {
+		return err
+	}
+	ss.forget(running)
+	return nil
+}
+
+// copyServiceRootFS copies the root filesystem of a service container to
+// dest, skipping the excluded paths.
+func copyServiceRootFS(ctx context.Context, rootfs, dest string, excludes []string) error {
+	err := fscopy.Copy(ctx,
+		rootfs, "/",
+		dest, "/",
+		func(ci *fscopy.CopyInfo) {
+			ci.ExcludePatterns = excludes
+			ci.CopyDirContents = true
+		},
+		fscopy.WithXAttrErrorHandler(func(dst, src, key string, err error) error {
+			bklog.G(ctx).Debugf("xattr error during service snapshot copy: %v", err)
+			return nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("copy root filesystem: %w", err)
+	}
+	return nil
+}
@@ -1,9 +1,11 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"sync"
 	"time"
 
@@ -36,7 +38,10 @@ type Services struct {
 	starting map[ServiceKey]*sync.WaitGroup
 	running  map[ServiceKey]*RunningService
 	bindings map[ServiceKey]int
-	l        sync.Mutex
+	// logs keeps the logs of the last run of each service, including after it
+	// stopped, until its session ends.
+	logs map[ServiceKey]*ServiceLogs
+	l    sync.Mutex
 }
 
 // RunningService represents a service that is actively running.
@@ -67,6 +72,14 @@ type RunningService struct {
 
 	// Block until the service has exited or the provided context is canceled.
 	Wait func(ctx context.Context) error
+
+	// Logs holds the most recent output of the service, if it has any.
+	Logs *ServiceLogs
+
+	// Snapshot gracefully stops the service and copies its root filesystem,
+	// with the contents of its cache volumes, to dest once it exits. It is
+	// only set for container services.
+	Snapshot func(ctx context.Context, dest string) error
 }
 
 // ServiceKey is a unique identifier for a service.
@@ -82,6 +95,7 @@ func NewServices() *Services {
 		starting: map[ServiceKey]*sync.WaitGroup{},
 		running:  map[ServiceKey]*RunningService{},
 		bindings: map[ServiceKey]int{},
+		logs:     map[ServiceKey]*ServiceLogs{},
 	}
 }
 
@@ -196,6 +210,9 @@ dance:
 	delete(ss.starting, key)
 	ss.running[key] = running
 	ss.bindings[key] = 1
+	if running.Logs != nil {
+		ss.logs[key] = running.Logs
+	}
 	ss.l.Unlock()
 
 	_ = stop // leave it running
@@ -203,6 +220,39 @@ dance:
 	return running, nil
 }
 
+// Logs returns the logs of the given service, which is running or has run in
+// the session. If the service is starting, it waits for it first.
+func (ss *Services) Logs(ctx context.Context, id *call.ID, clientSpecific bool) (*ServiceLogs, error) {
+	clientMetadata, err := engine.ClientMetadataFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dig := id.Digest()
+	key := ServiceKey{
+		Digest:    dig,
+		SessionID: clientMetadata.SessionID,
+	}
+	if clientSpecific {
+		key.ClientID = clientMetadata.ClientID
+	}
+
+	ss.l.Lock()
+	starting, isStarting := ss.starting[key]
+	ss.l.Unlock()
+	if isStarting {
+		starting.Wait()
+	}
+
+	ss.l.Lock()
+	logs, found := ss.logs[key]
+	ss.l.Unlock()
+	if !found {
+		return nil, fmt.Errorf("service %s has not been started", network.HostHash(dig))
+	}
+	return logs, nil
+}
+
 // StartBindings starts each of the bound services in parallel and returns a
 // function that will detach from all of them after 10 seconds.
 func (ss *Services) StartBindings(ctx context.Context, bindings ServiceBindings) (_ func(), _ []*RunningService, err error) {
@@ -299,6 +349,11 @@ func (ss *Services) StopSessionServices(ctx context.Context, sessionID string) e
 			svcs = append(svcs, svc)
 		}
 	}
+	for key := range ss.logs {
+		if key.SessionID == sessionID {
+			delete(ss.logs, key)
+		}
+	}
 	ss.l.Unlock()
 
 	eg := new(errgroup.Group)
@@ -355,12 +410,7 @@ func (ss *Services) stop(ctx context.Context, running *RunningService, force boo
 	if err != nil {
 		return fmt.Errorf("stop: %w", err)
 	}
-
-	ss.l.Lock()
-	delete(ss.bindings, running.Key)
-	delete(ss.running, running.Key)
-	ss.l.Unlock()
-
+	ss.forget(running)
 	return nil
 }
 
@@ -376,10 +426,199 @@ func (ss *Services) stopGraceful(ctx context.Context, running *RunningService, t
 	if err != nil {
 		return err
 	}
+	ss.forget(running)
+	return nil
+}
 
+// forget removes a service that was stopped.
+func (ss *Services) forget(running *RunningService) {
 	ss.l.Lock()
 	delete(ss.bindings, running.Key)
 	delete(ss.running, running.Key)
 	ss.l.Unlock()
+}
+
+// ServiceLogsLimit is the amount of output kept for each service, beyond
+// which the oldest lines are dropped. Each line counts for its text and
+// ServiceLogLineOverhead.
+const ServiceLogsLimit = 1 << 20
+
+// ServiceLogLineOverhead is the size counted for each line in addition to its
+// text, so that many short lines are bounded too.
+const ServiceLogLineOverhead = 64
+
+// serviceLogLineLimit is the length after which a line without a newline is
+// split.
+const serviceLogLineLimit = 64 << 10
+
+type serviceLogStream int
+
+const (
+	serviceLogStdout serviceLogStream = iota
+	serviceLogStderr
+)
+
+type serviceLogLine struct {
+	time   time.Time
+	stream serviceLogStream
+	text   string
+}
+
+// size is the memory counted for the line towards the limit.
+func (line serviceLogLine) size() int {
+	return len(line.text) + ServiceLogLineOverhead
+}
+
+// ServiceLogs keeps the most recent output lines of a service in a ring
+// buffer, bounded in size.
+type ServiceLogs struct {
+	mu sync.Mutex
+
+	// lines is a ring buffer of count lines starting at head
+	lines []serviceLogLine
+	head  int
+	count int
+
+	size  int
+	limit int
+
+	// pending is the incomplete last line of each stream
+	pending [2][]byte
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewServiceLogs(limit int) *ServiceLogs {
+	return &ServiceLogs{
+		limit: limit,
+		done:  make(chan struct{}),
+	}
+}
+
+// Stdout returns a writer recording the standard output of the service.
+func (logs *ServiceLogs) Stdout() io.WriteCloser {
+	return serviceLogWriter{logs, serviceLogStdout}
+}
+
+// Stderr returns a writer recording the standard error of the service.
+func (logs *ServiceLogs) Stderr() io.WriteCloser {
+	return serviceLogWriter{logs, serviceLogStderr}
+}
+
+// Close records the incomplete last lines once the service exited.
+func (logs *ServiceLogs) Close() error {
+	logs.closeOnce.Do(func() {
+		logs.mu.Lock()
+		now := time.Now()
+		for stream, pending := range logs.pending {
+			if len(pending) > 0 {
+				logs.push(serviceLogLine{now, serviceLogStream(stream), string(pending)})
+				logs.pending[stream] = nil
+			}
+		}
+		logs.mu.Unlock()
+		close(logs.done)
+	})
+	return nil
+}
+
+// Done is closed once the service exited.
+func (logs *ServiceLogs) Done() <-chan struct{} {
+	return logs.done
+}
+
+func (logs *ServiceLogs) write(stream serviceLogStream, p []byte) {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+	now := time.Now()
+	pending := append(logs.pending[stream], p...)
+	for {
+		i := bytes.IndexByte(pending, '\n')
+		if i < 0 {
+			break
+		}
+		logs.push(serviceLogLine{now, stream, string(pending[:i])})
+		pending = pending[i+1:]
+	}
+	for len(pending) > serviceLogLineLimit {
+		logs.push(serviceLogLine{now, stream, string(pending[:serviceLogLineLimit])})
+		pending = pending[serviceLogLineLimit:]
+	}
+	logs.pending[stream] = append([]byte(nil), pending...)
+}
+
+func (logs *ServiceLogs) push(line serviceLogLine) {
+	if logs.count == len(logs.lines) {
+		// grow the ring, unrolling it
+		grown := make([]serviceLogLine, max(16, 2*len(logs.lines)))
+		for i := range logs.count {
+			grown[i] = logs.lines[(logs.head+i)%len(logs.lines)]
+		}
+		logs.lines = grown
+		logs.head = 0
+	}
+	logs.lines[(logs.head+logs.count)%len(logs.lines)] = line
+	logs.count++
+	logs.size += line.size()
+
+	// always keep the last line
+	for logs.size > logs.limit && logs.count > 1 {
+		logs.size -= logs.lines[logs.head].size()
+		logs.lines[logs.head] = serviceLogLine{}
+		logs.head = (logs.head + 1) % len(logs.lines)
+		logs.count--
+	}
+}
+
+// ServiceLogsOpts selects the lines of ServiceLogs.Text.
+type ServiceLogsOpts struct {
+	// Stdout and Stderr select the streams to include.
+	Stdout bool
+	Stderr bool
+
+	// Since excludes the lines written before, if set.
+	Since time.Time
+
+	// Tail only includes the given number of last lines, if positive.
+	Tail int
+}
+
+// Text returns the recorded lines, in the order they were written.
+func (logs *ServiceLogs) Text(opts ServiceLogsOpts) string {
+	logs.mu.Lock()
+	defer logs.mu.Unlock()
+
+	var lines []string
+	for i := range logs.count {
+		line := logs.lines[(logs.head+i)%len(logs.lines)]
+		switch {
+		case line.stream == serviceLogStdout && !opts.Stdout,
+			line.stream == serviceLogStderr && !opts.Stderr,
+			line.time.Before(opts.Since):
+			continue
+		}
+		lines = append(lines, line.text)
+	}
+	if opts.Tail > 0 && len(lines) > opts.Tail {
+		lines = lines[len(lines)-opts.Tail:]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+type serviceLogWriter struct {
+	logs   *ServiceLogs
+	stream serviceLogStream
+}
+
+func (w serviceLogWriter) Write(p []byte) (int, error) {
+	w.logs.write(w.stream, p)
+	return len(p), nil
+}
+
+func (w serviceLogWriter) Close() error {
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"runtime"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -115,6 +116,85 @@ func TestServicesStartSad(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestServicesLogs(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	ctx = engine.ContextWithClientMetadata(ctx, &engine.ClientMetadata{
+		ClientID:  "fake-client",
+		SessionID: "fake-session",
+	})
+
+	services := core.NewServices()
+
+	stub := newStartable("fake")
+
+	_, err := services.Logs(ctx, stub.ID(), false)
+	require.Error(t, err)
+
+	expected := stub.Succeed()
+	expected.Logs = core.NewServiceLogs(core.ServiceLogsLimit)
+	expected.Stop = func(context.Context, bool) error { return nil }
+	_, err = expected.Logs.Stdout().Write([]byte("hello\n"))
+	require.NoError(t, err)
+
+	_, err = services.Start(ctx, stub.ID(), stub, false)
+	require.NoError(t, err)
+
+	logs, err := services.Logs(ctx, stub.ID(), false)
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", logs.Text(core.ServiceLogsOpts{Stdout: true}))
+
+	// logs are dropped with the session
+	require.NoError(t, services.StopSessionServices(ctx, "fake-session"))
+	_, err = services.Logs(ctx, stub.ID(), false)
+	require.Error(t, err)
+}
+
+func TestServiceLogs(t *testing.T) {
+	t.Parallel()
+
+	// room for three short lines
+	logs := core.NewServiceLogs(3*core.ServiceLogLineOverhead + 12)
+	all := core.ServiceLogsOpts{Stdout: true, Stderr: true}
+
+	write := func(w io.Writer, s string) {
+		_, err := w.Write([]byte(s))
+		require.NoError(t, err)
+	}
+	write(logs.Stdout(), "one\ntw")
+	write(logs.Stderr(), "err\n")
+	write(logs.Stdout(), "o\n")
+	require.Equal(t, "one\nerr\ntwo\n", logs.Text(all))
+	require.Equal(t, "one\ntwo\n", logs.Text(core.ServiceLogsOpts{Stdout: true}))
+	require.Equal(t, "err\n", logs.Text(core.ServiceLogsOpts{Stderr: true}))
+	require.Equal(t, "err\ntwo\n", logs.Text(core.ServiceLogsOpts{Stdout: true, Stderr: true, Tail: 2}))
+	require.Empty(t, logs.Text(core.ServiceLogsOpts{Stdout: true, Stderr: true, Since: time.Now().Add(time.Minute)}))
+
+	// the oldest lines are dropped beyond the limit
+	for _, line := range []string{"three\n", "four\n", "five\n", "six\n"} {
+		write(logs.Stdout(), line)
+	}
+	require.Equal(t, "four\nfive\nsix\n", logs.Text(all))
+
+	// an incomplete last line is kept once the service exits
+	write(logs.Stderr(), "bye")
+	require.Equal(t, "four\nfive\nsix\n", logs.Text(all))
+	select {
+	case <-logs.Done():
+		t.Fatal("logs done before close")
+	default:
+	}
+	require.NoError(t, logs.Close())
+	<-logs.Done()
+	require.Equal(t, "five\nsix\nbye\n", logs.Text(all))
+
+	// empty lines count towards the limit too
+	logs = core.NewServiceLogs(10 * core.ServiceLogLineOverhead)
+	write(logs.Stdout(), strings.Repeat("\n", 1000))
+	require.Equal(t, strings.Repeat("\n", 10), logs.Text(all))
+}
+
 func TestServicesStartConcurrentHappy(t *testing.T) {
 	t.Parallel()
 
@@ -63,6 +63,10 @@ func AroundFunc(ctx context.Context, self dagql.Object, id *call.ID) (context.Co
 		attrs = append(attrs, attribute.StringSlice(telemetry.DagInputsAttr, inputs))
 	}
 
+	if policy := dagql.CurrentCachePolicy(ctx); policy != "" {
+		attrs = append(attrs, attribute.String(telemetry.CachePolicyAttr, string(policy)))
+	}
+
 	if dagql.IsInternal(ctx) {
 		attrs = append(attrs, attribute.Bool(telemetry.UIInternalAttr, true))
 	}
@@ -77,6 +81,9 @@ func AroundFunc(ctx context.Context, self dagql.Object, id *call.ID) (context.Co
 			return nil
 		})
 		recordStatus(ctx, res, span, cached, err, id)
+		if err == nil {
+			recordCacheMiss(ctx, span, cached, id)
+		}
 		logResult(ctx, res, self, id)
 		collectEffects(ctx, res, span, self)
 	}
@@ -123,6 +130,39 @@ func recordStatus(ctx context.Context, res dagql.Typed, span trace.Span, cached
 	}
 }
 
+// recordCacheMiss records the call in the session's index of recent calls, to
+// be explained if it wasn't cached. Explaining a miss means comparing the call
+// to previous ones, so it's only done when the miss is queried; only misses
+// explained by the cache policy of the call are recorded on its span.
+func recordCacheMiss(ctx context.Context, span trace.Span, cached bool, id *call.ID) {
+	srv := dagql.CurrentDagqlServer(ctx)
+	if srv == nil {
+		return
+	}
+	query, ok := srv.Root().(dagql.Instance[*Query])
+	if !ok {
+		return
+	}
+	policy := dagql.CurrentCachePolicy(ctx)
+	query.Self.RecordCall(ctx, id, policy, cached)
+	if cached {
+		return
+	}
+	var desc string
+	switch policy {
+	case dagql.CachePolicyPerCall:
+		desc = id.Field() + " runs again every time it's called"
+	case dagql.CachePolicyDoNotCache:
+		desc = id.Field() + " is never cached"
+	default:
+		return
+	}
+	span.SetAttributes(
+		attribute.String(telemetry.CacheMissReasonAttr, CacheMissCachePolicy),
+		attribute.String(telemetry.CacheMissDescriptionAttr, desc),
+	)
+}
+
 // logResult prints the result of a call to the span's stdout.
 func logResult(ctx context.Context, res dagql.Typed, self dagql.Object, id *call.ID) {
 	stdio := telemetry.SpanStdio(ctx, InstrumentationLibrary)
@@ -15,6 +15,33 @@ const (
 	XXH3 digest.Algorithm = "xxh3"
 )
 
+// CachePolicy names how the cache key of a call was scoped, which explains why
+// a call may not be cached where it otherwise would be.
+type CachePolicy string
+
+const (
+	// The call is cached per client, see CachePerClient.
+	CachePolicyPerClient CachePolicy = "CachePerClient"
+	// The call is cached per session, see CachePerSession.
+	CachePolicyPerSession CachePolicy = "CachePerSession"
+	// The call always runs, see CachePerCall.
+	CachePolicyPerCall CachePolicy = "CachePerCall"
+	// The result of the call is never cached, see Field.DoNotCache.
+	CachePolicyDoNotCache CachePolicy = "DoNotCache"
+)
+
+type cachePolicyCtx struct{}
+
+func cachePolicyToContext(ctx context.Context, policy CachePolicy) context.Context {
+	return context.WithValue(ctx, cachePolicyCtx{}, policy)
+}
+
+// CurrentCachePolicy returns the cache policy of the current call, if any.
+func CurrentCachePolicy(ctx context.Context) CachePolicy {
+	val, _ := ctx.Value(cachePolicyCtx{}).(CachePolicy)
+	return val
+}
+
 // CachePerClient is a CacheKeyFunc that scopes the cache key to the client by mixing in the client ID to the original digest of the operation.
 // It should be used when the operation should be run for each client, but not more than once for a given client.
 // Canonical examples include loading client filesystem data or referencing client-side sockets/ports.
@@ -43,6 +70,7 @@ func CachePerClientObject[A any](
 	}
 
 	cacheCfg.Digest = HashFrom(cacheCfg.Digest.String(), clientMD.ClientID)
+	cacheCfg.Policy = CachePolicyPerClient
 	return &cacheCfg, nil
 }
 
@@ -63,6 +91,7 @@ func CachePerSession[P Typed, A any](
 	}
 
 	cacheCfg.Digest = HashFrom(cacheCfg.Digest.String(), clientMD.SessionID)
+	cacheCfg.Policy = CachePolicyPerSession
 	return &cacheCfg, nil
 }
 
@@ -78,6 +107,7 @@ func CachePerCall[P Typed, A any](
 ) (*CacheConfig, error) {
 	randID := identity.NewID()
 	cacheCfg.Digest = HashFrom(randID)
+	cacheCfg.Policy = CachePolicyPerCall
 	return &cacheCfg, nil
 }
 
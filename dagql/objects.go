@@ -357,11 +357,11 @@ func NoopDone(res Typed, cached bool, rerr error) {}
 
 // Select calls the field on the instance specified by the selector
 func (r Instance[T]) Select(ctx context.Context, s *Server, sel Selector) (Typed, *call.ID, error) {
-	inputArgs, newID, doNotCache, err := r.preselect(ctx, s, sel)
+	inputArgs, newID, policy, err := r.preselect(ctx, s, sel)
 	if err != nil {
 		return nil, nil, err
 	}
-	return r.call(ctx, s, newID, inputArgs, doNotCache)
+	return r.call(ctx, s, newID, inputArgs, policy)
 }
 
 func (r Instance[T]) ReturnType(ctx context.Context, s *Server, sel Selector) (Typed, *call.ID, error) {
@@ -376,11 +376,11 @@ func (r Instance[T]) ReturnType(ctx context.Context, s *Server, sel Selector) (T
 	return returnType, newID, nil
 }
 
-func (r Instance[T]) preselect(ctx context.Context, s *Server, sel Selector) (map[string]Input, *call.ID, bool, error) {
+func (r Instance[T]) preselect(ctx context.Context, s *Server, sel Selector) (map[string]Input, *call.ID, CachePolicy, error) {
 	view := sel.View
 	field, ok := r.Class.Field(sel.Field, view)
 	if !ok {
-		return nil, nil, false, fmt.Errorf("Select: %s has no such field: %q", r.Class.TypeName(), sel.Field)
+		return nil, nil, "", fmt.Errorf("Select: %s has no such field: %q", r.Class.TypeName(), sel.Field)
 	}
 	if field.ViewFilter == nil {
 		// fields in the global view shouldn't attach the current view to the
@@ -415,7 +415,7 @@ func (r Instance[T]) preselect(ctx context.Context, s *Server, sel Selector) (ma
 
 		case argSpec.Type.Type().NonNull:
 			// error out if the arg is missing but required
-			return nil, nil, false, fmt.Errorf("missing required argument: %q", argSpec.Name)
+			return nil, nil, "", fmt.Errorf("missing required argument: %q", argSpec.Name)
 		}
 	}
 	// TODO: it's better DX if it matches schema order
@@ -438,7 +438,10 @@ func (r Instance[T]) preselect(ctx context.Context, s *Server, sel Selector) (ma
 		idArgs...,
 	)
 
-	doNotCache := field.CacheSpec.DoNotCache != ""
+	var policy CachePolicy
+	if field.CacheSpec.DoNotCache != "" {
+		policy = CachePolicyDoNotCache
+	}
 	if field.CacheSpec.GetCacheConfig != nil {
 		origDgst := newID.Digest()
 
@@ -448,7 +451,7 @@ func (r Instance[T]) preselect(ctx context.Context, s *Server, sel Selector) (ma
 			Digest: origDgst,
 		})
 		if err != nil {
-			return nil, nil, false, fmt.Errorf("failed to compute cache key for %s.%s: %w", r.Type().Name(), sel.Field, err)
+			return nil, nil, "", fmt.Errorf("failed to compute cache key for %s.%s: %w", r.Type().Name(), sel.Field, err)
 		}
 
 		if len(cacheCfg.UpdatedArgs) > 0 {
@@ -484,9 +487,12 @@ func (r Instance[T]) preselect(ctx context.Context, s *Server, sel Selector) (ma
 		if cacheCfg.Digest != origDgst {
 			newID = newID.WithDigest(cacheCfg.Digest)
 		}
+		if policy == "" {
+			policy = cacheCfg.Policy
+		}
 	}
 
-	return inputArgs, newID, doNotCache, nil
+	return inputArgs, newID, policy, nil
 }
 
 // Call calls the field on the instance specified by the ID.
@@ -528,8 +534,11 @@ func (r Instance[T]) Call(ctx context.Context, s *Server, newID *call.ID) (Typed
 		}
 	}
 
-	doNotCache := field.CacheSpec.DoNotCache != ""
-	return r.call(ctx, s, newID, inputArgs, doNotCache)
+	var policy CachePolicy
+	if field.CacheSpec.DoNotCache != "" {
+		policy = CachePolicyDoNotCache
+	}
+	return r.call(ctx, s, newID, inputArgs, policy)
 }
 
 func (r Instance[T]) call(
@@ -537,10 +546,12 @@ func (r Instance[T]) call(
 	s *Server,
 	newID *call.ID,
 	inputArgs map[string]Input,
-	doNotCache bool,
+	policy CachePolicy,
 ) (Typed, *call.ID, error) {
 	ctx = idToContext(ctx, newID)
 	ctx = srvToContext(ctx, s)
+	ctx = cachePolicyToContext(ctx, policy)
+	doNotCache := policy == CachePolicyDoNotCache
 	callCacheKey := newID.Digest()
 	if doNotCache {
 		callCacheKey = ""
@@ -1012,6 +1023,10 @@ type GetCacheConfigFunc[T Typed, A any] func(context.Context, Instance[T], A, Ca
 type CacheConfig struct {
 	Digest      digest.Digest
 	UpdatedArgs map[string]Input
+
+	// Policy names the scope the digest was limited to, if any, so that cache
+	// misses can be explained.
+	Policy CachePolicy
 }
 
 // Field defines a field of an Object type.
@@ -0,0 +1,124 @@
+package buildkit
+
+import (
+	"context"
+	"encoding/json"
+
+	bkcache "github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/solver/llbsolver/mounts"
+	"github.com/moby/buildkit/util/bklog"
+)
+
+const (
+	// the metadata key of the cache volume a cache mount record belongs to
+	cacheVolumeMetadataKey   = "dagger.cachevolume"
+	cacheVolumeMetadataIndex = cacheVolumeMetadataKey + ":"
+
+	// the metadata key buildkit indexes cache mount records by, which is
+	// cleared once the cache mount is pruned
+	cacheDirMetadataKey = "cache-dir"
+)
+
+// CacheVolumeMetadata describes a cache volume mounted in a container. It's
+// recorded on the cache mount records of the volume, since its keys can't be
+// recovered from the volume ID.
+type CacheVolumeMetadata struct {
+	// The ID of the cache mount, which is a checksum of the keys.
+	ID string `json:"id"`
+
+	Keys        []string `json:"keys"`
+	SharingMode string   `json:"sharingMode"`
+
+	// The name of the module the volume was mounted by, if any.
+	Module string `json:"module,omitempty"`
+}
+
+// SetCacheVolumeMetadata records the cache volume a cache mount record belongs
+// to.
+func SetCacheVolumeMetadata(md bkcache.RefMetadata, vol CacheVolumeMetadata) error {
+	bs, err := json.Marshal(vol)
+	if err != nil {
+		return err
+	}
+	return md.SetString(cacheVolumeMetadataKey, string(bs), cacheVolumeMetadataIndex+vol.ID)
+}
+
+// GetCacheVolumeMetadata returns the cache volume a cache mount record belongs
+// to, if it's been recorded.
+func GetCacheVolumeMetadata(md bkcache.RefMetadata) (CacheVolumeMetadata, bool) {
+	var vol CacheVolumeMetadata
+	bs := md.GetString(cacheVolumeMetadataKey)
+	if bs == "" {
+		return vol, false
+	}
+	if err := json.Unmarshal([]byte(bs), &vol); err != nil {
+		return vol, false
+	}
+	return vol, true
+}
+
+// ListCacheVolumes returns the cache volumes of the cache mount records in the
+// store that haven't been pruned, by record ID.
+func ListCacheVolumes(ctx context.Context, store bkcache.MetadataStore) (map[string]CacheVolumeMetadata, error) {
+	mds, err := store.Search(ctx, cacheVolumeMetadataIndex, true)
+	if err != nil {
+		return nil, err
+	}
+	vols := make(map[string]CacheVolumeMetadata, len(mds))
+	for _, md := range mds {
+		if md.GetString(cacheDirMetadataKey) == "" {
+			continue
+		}
+		vol, ok := GetCacheVolumeMetadata(md)
+		if !ok {
+			bklog.G(ctx).Warnf("invalid cache volume metadata on %s", md.ID())
+			continue
+		}
+		vols[md.ID()] = vol
+	}
+	return vols, nil
+}
+
+// SearchCacheVolume returns the cache mount records of the cache volume with
+// the given ID, including the ones initialized from a source directory.
+func SearchCacheVolume(ctx context.Context, store bkcache.MetadataStore, id string) ([]mounts.CacheRefMetadata, error) {
+	var results []mounts.CacheRefMetadata
+	seen := map[string]struct{}{}
+	for _, nested := range []bool{false, true} {
+		mds, err := mounts.SearchCacheDir(ctx, store, id, nested)
+		if err != nil {
+			return nil, err
+		}
+		for _, md := range mds {
+			if _, ok := seen[md.ID()]; ok {
+				continue
+			}
+			seen[md.ID()] = struct{}{}
+			results = append(results, md)
+		}
+	}
+	return results, nil
+}
+
+// labelCacheVolumes records the cache volumes mounted in the container on
+// their cache mount records, so they can be listed by their keys.
+func (w *Worker) labelCacheVolumes(ctx context.Context, _ *execState) error {
+	if w.execMD == nil || w.workerCache == nil {
+		return nil
+	}
+	for id, vol := range w.execMD.CacheVolumes {
+		vol.ID = id
+		mds, err := SearchCacheVolume(ctx, w.workerCache, id)
+		if err != nil {
+			// only used for listing, so don't fail the exec
+			bklog.G(ctx).WithError(err).Warnf("failed to search cache volume %s", id)
+			continue
+		}
+		for _, md := range mds {
+			if err := SetCacheVolumeMetadata(md, vol); err != nil {
+				bklog.G(ctx).WithError(err).Warnf("failed to label cache volume %s", id)
+			}
+		}
+	}
+	return nil
+}
@@ -31,6 +31,7 @@ import (
 
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/session"
+	"github.com/dagger/dagger/network"
 )
 
 const (
@@ -365,6 +366,25 @@ func RunInNetNS[T any](
 	return runInNetNS(ctx, runState, fn)
 }
 
+// OnContainerExit registers a function to call with the path of the
+// container's root filesystem on the host once its process exits. The root
+// filesystem and the container's mounts beneath it stay mounted until the
+// function returns.
+func (c *Client) OnContainerExit(ns Namespaced, fn func(rootfsPath string)) error {
+	if ns == nil {
+		return errors.New("namespace is nil")
+	}
+
+	c.Worker.mu.Lock()
+	defer c.Worker.mu.Unlock()
+	runState, ok := c.Worker.running[ns.NamespaceID()]
+	if !ok {
+		return fmt.Errorf("container %s not found in running state", ns.NamespaceID())
+	}
+	runState.exitHooks = append(runState.exitHooks, fn)
+	return nil
+}
+
 // CombinedResult returns a buildkit result with all the refs solved by this client so far.
 // This is useful for constructing a result for upstream remote caching.
 func (c *Client) CombinedResult(ctx context.Context) (*Result, error) {
@@ -563,8 +583,19 @@ func (c *Client) ListenHostToContainer(
 
 			connsL.Lock()
 			conn, found := conns[connID]
+			if found && res.GetClose() {
+				delete(conns, connID)
+			}
 			connsL.Unlock()
 
+			if res.GetClose() {
+				// the host closed the conn, e.g. an idle UDP conn
+				if found {
+					conn.Close()
+				}
+				continue
+			}
+
 			if !found {
 				conn, err := c.Dialer.Dial(proto, upstream)
 				if err != nil {
@@ -580,7 +611,8 @@ func (c *Client) ListenHostToContainer(
 				go func() {
 					defer wg.Done()
 
-					data := make([]byte, 32*1024)
+					// large enough to read a whole UDP datagram at once
+					data := make([]byte, network.MaxDatagramSize)
 					for {
 						n, err := conn.Read(data)
 						if err != nil {
@@ -8,21 +8,36 @@ import (
 	"path"
 	"strings"
 
+	"github.com/containerd/containerd/remotes"
 	"github.com/containerd/platforms"
 	bkcache "github.com/moby/buildkit/cache"
 	bkclient "github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/exporter/containerimage/exptypes"
 	bkgw "github.com/moby/buildkit/frontend/gateway/client"
+	gatewayapi "github.com/moby/buildkit/frontend/gateway/pb"
+	bksession "github.com/moby/buildkit/session"
 	bksolverpb "github.com/moby/buildkit/solver/pb"
 	solverresult "github.com/moby/buildkit/solver/result"
+	"github.com/moby/buildkit/util/resolver"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/dagger/dagger/engine"
 )
 
 type ContainerExport struct {
-	Definition *bksolverpb.Definition
-	Config     specs.ImageConfig
+	Definition   *bksolverpb.Definition
+	Config       specs.ImageConfig
+	Attestations []ContainerAttestation
+}
+
+// ContainerAttestation is an in-toto attestation attached to an exported
+// image in an attestation manifest.
+type ContainerAttestation struct {
+	PredicateType string
+	Predicate     []byte
+	// Reason is why the attestation is attached, such as "sbom" or
+	// "provenance".
+	Reason string
 }
 
 func (c *Client) PublishContainerImage(
@@ -62,6 +77,17 @@ func (c *Client) PublishContainerImage(
 	return resp, nil
 }
 
+// RegistryResolver returns a resolver for the repository of the given image
+// reference, authenticating with the registry credentials of the client. A
+// resolver with push access can also pull.
+func (c *Client) RegistryResolver(ref string, push bool) remotes.Resolver {
+	scope := "pull"
+	if push {
+		scope = "push"
+	}
+	return resolver.DefaultPool.GetResolver(c.Worker.RegistryHosts, ref, scope, c.SessionManager, bksession.NewGroup(c.ID()))
+}
+
 func (c *Client) ExportContainerImage(
 	ctx context.Context,
 	inputByPlatform map[string]ContainerExport,
@@ -232,5 +258,32 @@ func (c *Client) getContainerResult(
 		combinedResult.AddMeta(exptypes.ExporterPlatformsKey, platformBytes)
 	}
 
+	ps, err := exptypes.ParsePlatforms(combinedResult.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	for platformString, input := range inputByPlatform {
+		// attestations are keyed by the platform ID used by the exporter,
+		// which is normalized from the image config for single platforms
+		key := platformString
+		if len(inputByPlatform) == 1 {
+			key = ps.Platforms[0].ID
+		}
+		for _, att := range input.Attestations {
+			combinedResult.AddAttestation(key, solverresult.Attestation[bkcache.ImmutableRef]{
+				Kind: gatewayapi.AttestationKindInToto,
+				Metadata: map[string][]byte{
+					solverresult.AttestationReasonKey: []byte(att.Reason),
+				},
+				ContentFunc: func() ([]byte, error) {
+					return att.Predicate, nil
+				},
+				InToto: solverresult.InTotoAttestation{
+					PredicateType: att.PredicateType,
+				},
+			})
+		}
+	}
+
 	return combinedResult, nil
 }
@@ -1,5 +1,11 @@
 package buildkit
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
 // ExecError is an error that occurred while executing an `Op_Exec`.
 type ExecError struct {
 	original error
@@ -18,11 +24,51 @@ func (e *ExecError) Unwrap() error {
 }
 
 func (e *ExecError) Extensions() map[string]any {
-	return map[string]any{
+	ext := map[string]any{
 		"_type":    "EXEC_ERROR",
 		"cmd":      e.Cmd,
 		"exitCode": e.ExitCode,
 		"stdout":   e.Stdout,
 		"stderr":   e.Stderr,
 	}
+	var timeoutErr *ExecTimeoutError
+	if errors.As(e.original, &timeoutErr) {
+		ext["_type"] = "EXEC_TIMEOUT_ERROR"
+		ext["timeout"] = timeoutErr.Timeout.String()
+	}
+	var assertionErr *ExecAssertionError
+	if errors.As(e.original, &assertionErr) {
+		ext["_type"] = "EXEC_ASSERTION_ERROR"
+		ext["assertion"] = assertionErr.Stream + " " + assertionErr.Assertion
+		ext["snippet"] = assertionErr.Snippet
+	}
+	return ext
+}
+
+// ExecTimeoutError is the cause of an `Op_Exec` being killed for running
+// longer than its timeout.
+type ExecTimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *ExecTimeoutError) Error() string {
+	return fmt.Sprintf("exec timed out after %s", e.Timeout)
+}
+
+// ExecAssertionError is the cause of an `Op_Exec` failing because its output
+// didn't satisfy an assertion.
+type ExecAssertionError struct {
+	// The output stream the assertion applies to, stdout or stderr.
+	Stream string
+	// The expectation that wasn't met, e.g. `to match regex "ok"`.
+	Assertion string
+	// The part of the output relevant to the assertion.
+	Snippet string
+}
+
+func (e *ExecAssertionError) Error() string {
+	if e.Snippet == "" {
+		return fmt.Sprintf("expected %s %s, got no output", e.Stream, e.Assertion)
+	}
+	return fmt.Sprintf("expected %s %s, got:\n%s", e.Stream, e.Assertion, e.Snippet)
 }
@@ -6,6 +6,7 @@ https://github.com/moby/buildkit/blob/08180a774253a8199ebdb629d21cd9f378a14419/e
 */
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -14,13 +15,16 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/containerd/console"
 	runc "github.com/containerd/go-runc"
 	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/engine/buildkit/resources"
 	"github.com/dagger/dagger/engine/server/resource"
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/executor"
@@ -102,6 +106,21 @@ type ExecutionMetadata struct {
 	// If true, skip injecting dagger-init into the container.
 	NoInit bool
 
+	// Resource limits applied to the exec's cgroup, if any.
+	Limits *ExecLimits
+
+	// If set, kill the exec once it runs for longer than this.
+	Timeout time.Duration
+
+	// If set, run the exec again when it fails, according to this policy.
+	Retry *ExecRetry
+
+	// If set, fail the exec when its output doesn't satisfy these assertions.
+	OutputAssertions *ExecOutputAssertions
+
+	// The cache volumes mounted in the container, by cache mount ID.
+	CacheVolumes map[string]CacheVolumeMetadata
+
 	// list of remote modules allowed to access LLM APIs
 	// any value of "all" bypasses restrictions, a nil slice imposes them
 	AllowedLLMModules []string
@@ -113,6 +132,131 @@ type ExecutionMetadata struct {
 
 const executionMetadataKey = "dagger.executionMetadata"
 
+// ExecLimits are resource limits enforced on an exec with cgroups. Zero
+// values are unlimited.
+type ExecLimits struct {
+	// Number of CPUs, possibly fractional.
+	CPUs float64
+	// Maximum memory usage in bytes, beyond which the exec is OOM killed.
+	MemoryBytes int64
+	// Maximum number of processes and threads.
+	Pids int64
+}
+
+// String returns a stable representation of the limits, suitable for cache
+// keys.
+func (limits ExecLimits) String() string {
+	return fmt.Sprintf("cpus=%s,memory=%d,pids=%d",
+		strconv.FormatFloat(limits.CPUs, 'f', -1, 64), limits.MemoryBytes, limits.Pids)
+}
+
+// maxRetryBackoff is the delay between exec retries beyond which the backoff
+// stops growing.
+const maxRetryBackoff = time.Minute
+
+// ExecRetry is the policy for running a failed exec again. The writable
+// mounts of the exec, except cache mounts, are restored before each retry.
+type ExecRetry struct {
+	// Maximum number of attempts, including the first one.
+	Attempts int
+	// Delay before the first retry, doubled after each retry until it
+	// reaches maxRetryBackoff.
+	Backoff time.Duration
+	// If set, only retry when the exec exits with one of these codes.
+	ExitCodes []int
+	// If set, only retry when the stderr of the exec matches this regexp.
+	StderrRegex string
+	// The paths of the cache mounts, whose changes are kept across attempts.
+	CacheMountPaths []string
+}
+
+// ExecOutputAssertions are checked against the output of an exec that exited
+// with an allowed code. Empty assertions are skipped.
+type ExecOutputAssertions struct {
+	StdoutRegex       string
+	StderrRegex       string
+	StdoutNotContains string
+	StderrNotContains string
+}
+
+// String returns a stable representation of the assertions, suitable for
+// cache keys.
+func (assertions ExecOutputAssertions) String() string {
+	return fmt.Sprintf("stdoutRegex=%q,stderrRegex=%q,stdoutNotContains=%q,stderrNotContains=%q",
+		assertions.StdoutRegex, assertions.StderrRegex,
+		assertions.StdoutNotContains, assertions.StderrNotContains)
+}
+
+// Check returns an *ExecAssertionError for the first assertion the output
+// doesn't satisfy.
+func (assertions ExecOutputAssertions) Check(stdout, stderr []byte) error {
+	for _, stream := range []struct {
+		name        string
+		out         []byte
+		regex       string
+		notContains string
+	}{
+		{"stdout", stdout, assertions.StdoutRegex, assertions.StdoutNotContains},
+		{"stderr", stderr, assertions.StderrRegex, assertions.StderrNotContains},
+	} {
+		if stream.regex != "" {
+			re, err := regexp.Compile(stream.regex)
+			if err != nil {
+				return fmt.Errorf("invalid %s regex: %w", stream.name, err)
+			}
+			if !re.Match(stream.out) {
+				return &ExecAssertionError{
+					Stream:    stream.name,
+					Assertion: fmt.Sprintf("to match regex %q", stream.regex),
+					Snippet:   outputTail(stream.out),
+				}
+			}
+		}
+		if stream.notContains != "" {
+			if i := bytes.Index(stream.out, []byte(stream.notContains)); i >= 0 {
+				return &ExecAssertionError{
+					Stream:    stream.name,
+					Assertion: fmt.Sprintf("not to contain %q", stream.notContains),
+					Snippet:   outputAround(stream.out, i),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// assertionSnippetLines is the number of output lines included in an
+// assertion error.
+const assertionSnippetLines = 10
+
+// outputTail returns the last lines of the output.
+func outputTail(out []byte) string {
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) > assertionSnippetLines {
+		lines = lines[len(lines)-assertionSnippetLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// outputAround returns the lines of the output around the given offset.
+func outputAround(out []byte, offset int) string {
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	// the offset may be in the trimmed trailing newlines
+	line := min(bytes.Count(out[:offset], []byte("\n")), len(lines)-1)
+	start := max(line-assertionSnippetLines/2, 0)
+	end := min(start+assertionSnippetLines, len(lines))
+	return strings.Join(lines[start:end], "\n")
+}
+
+// ExecMetrics is the resource usage of a finished exec, written as JSON to
+// the meta mount.
+type ExecMetrics struct {
+	resources.Usage
+
+	// Wall-clock duration of the exec in microseconds.
+	DurationMicroseconds int64 `json:"durationMicroseconds"`
+}
+
 func executionMetadataFromVtx(vtx solver.Vertex) (*ExecutionMetadata, bool, error) {
 	if vtx == nil {
 		return nil, false, nil
@@ -179,6 +323,7 @@ func (w *Worker) Run(
 		w.generateBaseSpec,
 		w.filterEnvs,
 		w.setupRootfs,
+		w.labelCacheVolumes,
 		w.setUserGroup,
 		w.setExitCodePath,
 		w.setupStdio,
@@ -186,9 +331,11 @@ func (w *Worker) Run(
 		w.setupSecretScrubbing,
 		w.setProxyEnvs,
 		w.enableGPU,
+		w.setupResourceLimits,
 		w.createCWD,
 		w.setupNestedClient,
 		w.installCACerts,
+		w.backupForRetry,
 		w.runContainer,
 	)
 }
@@ -204,8 +351,16 @@ func (w *Worker) run(
 	defer func() {
 		w.mu.Lock()
 		delete(w.running, state.id)
+		exitHooks := state.exitHooks
+		state.exitHooks = nil
 		w.mu.Unlock()
 
+		if state.rootfsPath != "" {
+			for _, fn := range exitHooks {
+				fn(state.rootfsPath)
+			}
+		}
+
 		close(state.done)
 		if err := state.cleanups.Run(); err != nil {
 			bklog.G(ctx).Errorf("executor run failed to cleanup: %v", err)
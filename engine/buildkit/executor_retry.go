@@ -0,0 +1,252 @@
+package buildkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/mount"
+	"github.com/containerd/continuity/fs"
+)
+
+// retryBackup is a copy of a writable directory or file of the container,
+// taken before the first attempt of a retried exec.
+type retryBackup struct {
+	// path is the upper directory of an overlay mount, or the source of a
+	// read-write bind mount
+	path string
+	// backup is where path is copied to, or empty for a directory that was
+	// empty and only needs to be cleared again
+	backup string
+	isDir  bool
+}
+
+// redirectFile is an output file in the root filesystem of the container,
+// reopened once the filesystem is restored for a retry.
+type redirectFile struct {
+	*os.File
+	open func() (*os.File, error)
+}
+
+func (f *redirectFile) reopen() error {
+	file, err := f.open()
+	if err != nil {
+		return err
+	}
+	f.File = file
+	return nil
+}
+
+// backupForRetry copies the writable mounts of a retried exec, except cache
+// mounts, once the container is set up, so that every attempt starts from the
+// same filesystem.
+//
+// Only what the exec may have changed is copied: the upper directory of
+// overlay mounts and read-write bind mounted files. Read-write bind mounted
+// directories must be empty, since they would have to be copied whole, e.g.
+// the whole root filesystem with the native snapshotter.
+func (w *Worker) backupForRetry(_ context.Context, state *execState) error {
+	if w.execMD == nil || w.execMD.Retry == nil || w.execMD.Retry.Attempts < 2 {
+		return nil
+	}
+	cacheMounts := map[string]bool{}
+	for _, p := range w.execMD.Retry.CacheMountPaths {
+		cacheMounts[filepath.Clean(p)] = true
+	}
+
+	var writable []writableMount
+	seen := map[string]bool{}
+	add := func(target string, mnt mount.Mount) {
+		if wm, ok := writableMountPath(target, mnt); ok && !seen[wm.path] {
+			seen[wm.path] = true
+			writable = append(writable, wm)
+		}
+	}
+	for _, mnt := range state.rootMounts {
+		add("/", mnt)
+	}
+	for _, mnt := range state.nonRootMounts {
+		if !cacheMounts[filepath.Clean(mnt.Target)] {
+			add(mnt.Target, mnt)
+		}
+	}
+
+	backupDir, err := os.MkdirTemp(w.executorRoot, state.id+"-retry-")
+	if err != nil {
+		return fmt.Errorf("create retry backup dir: %w", err)
+	}
+	state.cleanups.Add("remove retry backups", func() error {
+		return os.RemoveAll(backupDir)
+	})
+
+	state.retryBackups = []retryBackup{}
+	for i, wm := range writable {
+		p := wm.path
+		fi, err := os.Stat(p)
+		if err != nil {
+			return fmt.Errorf("stat %s for retry backup: %w", p, err)
+		}
+		backup := retryBackup{
+			path:   p,
+			backup: filepath.Join(backupDir, strconv.Itoa(i)),
+			isDir:  fi.IsDir(),
+		}
+		switch {
+		case !backup.isDir:
+			err = copyFileContents(backup.backup, backup.path)
+		case !wm.upper:
+			var empty bool
+			empty, err = isEmptyDir(p)
+			if err == nil && !empty {
+				return fmt.Errorf("cannot retry exec: writable mount %s is not an overlay and would have to be copied whole to be restored between attempts; retries require the overlayfs snapshotter", wm.target)
+			}
+			backup.backup = ""
+		default:
+			err = fs.CopyDir(backup.backup, backup.path)
+		}
+		if err != nil {
+			return fmt.Errorf("back up %s for retry: %w", p, err)
+		}
+		state.retryBackups = append(state.retryBackups, backup)
+	}
+	return nil
+}
+
+// restoreForRetry unmounts the root filesystem of the container, restores
+// its writable mounts from their backups and mounts it again.
+func (w *Worker) restoreForRetry(state *execState) error {
+	// nothing may be left open in the root filesystem to unmount it
+	for _, f := range state.redirectFiles {
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("close %s: %w", f.Name(), err)
+		}
+	}
+	for i := len(state.nonRootMounts) - 1; i >= 0; i-- {
+		mnt := state.nonRootMounts[i]
+		dstPath, err := fs.RootPath(state.rootfsPath, mnt.Target)
+		if err != nil {
+			return fmt.Errorf("mount %s points to invalid target: %w", mnt.Target, err)
+		}
+		if err := mount.Unmount(dstPath, 0); err != nil {
+			return fmt.Errorf("unmount %s: %w", mnt.Target, err)
+		}
+	}
+	if err := mount.Unmount(state.rootfsPath, 0); err != nil {
+		return fmt.Errorf("unmount rootfs: %w", err)
+	}
+
+	for _, backup := range state.retryBackups {
+		if err := backup.restore(); err != nil {
+			return fmt.Errorf("restore %s: %w", backup.path, err)
+		}
+	}
+
+	if err := mount.All(state.rootMounts, state.rootfsPath); err != nil {
+		return fmt.Errorf("mount rootfs: %w", err)
+	}
+	for _, mnt := range state.nonRootMounts {
+		if err := mnt.Mount(state.rootfsPath); err != nil {
+			return fmt.Errorf("mount to rootfs %s: %w", mnt.Target, err)
+		}
+	}
+	for _, f := range state.redirectFiles {
+		if err := f.reopen(); err != nil {
+			return fmt.Errorf("reopen redirect file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (backup retryBackup) restore() error {
+	if !backup.isDir {
+		return copyFileContents(backup.path, backup.backup)
+	}
+	entries, err := os.ReadDir(backup.path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(backup.path, entry.Name())); err != nil {
+			return err
+		}
+	}
+	if backup.backup == "" {
+		return nil
+	}
+	return fs.CopyDir(backup.path, backup.backup)
+}
+
+// writableMount is where the writes to a mount of the container go.
+type writableMount struct {
+	// target is where the mount is in the container
+	target string
+	path   string
+	// upper is whether path is the upper directory of an overlay mount,
+	// which only holds what was written to the mount
+	upper bool
+}
+
+// writableMountPath returns where the writes to a mount go: the upper
+// directory of overlay mounts, or the source of read-write bind mounts. Other
+// mounts, like tmpfs, start empty again once remounted.
+func writableMountPath(target string, mnt mount.Mount) (writableMount, bool) {
+	var upperdir string
+	var readonly, bind bool
+	for _, opt := range mnt.Options {
+		switch {
+		case opt == "ro":
+			readonly = true
+		case opt == "bind" || opt == "rbind":
+			bind = true
+		case strings.HasPrefix(opt, "upperdir="):
+			upperdir = strings.TrimPrefix(opt, "upperdir=")
+		}
+	}
+	switch {
+	case readonly:
+		return writableMount{}, false
+	case mnt.Type == "overlay":
+		return writableMount{target: target, path: upperdir, upper: true}, upperdir != ""
+	case bind || mnt.Type == "bind":
+		return writableMount{target: target, path: mnt.Source}, true
+	default:
+		return writableMount{}, false
+	}
+}
+
+// isEmptyDir returns whether the directory at path has no entries.
+func isEmptyDir(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	_, err = f.Readdirnames(1)
+	if errors.Is(err, io.EOF) {
+		return true, nil
+	}
+	return false, err
+}
+
+// copyFileContents replaces the contents of dst with the contents of src.
+func copyFileContents(dst, src string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
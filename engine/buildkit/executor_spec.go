@@ -13,6 +13,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -57,6 +59,9 @@ const (
 	DaggerRedirectStderrEnv  = "_DAGGER_REDIRECT_STDERR"
 	DaggerHostnameAliasesEnv = "_DAGGER_HOSTNAME_ALIASES"
 	DaggerNoInitEnv          = "_DAGGER_NOINIT"
+	DaggerExecLimitsEnv      = "_DAGGER_EXEC_LIMITS"
+	DaggerExecTimeoutEnv     = "_DAGGER_EXEC_TIMEOUT"
+	DaggerExecAssertionsEnv  = "_DAGGER_EXEC_ASSERTIONS"
 
 	DaggerSessionPortEnv  = "DAGGER_SESSION_PORT"
 	DaggerSessionTokenEnv = "DAGGER_SESSION_TOKEN"
@@ -66,6 +71,9 @@ const (
 
 	buildkitQemuEmulatorMountPoint = "/dev/.buildkit_qemu_emulator"
 
+	// the period over which the CPU quota of an exec applies
+	cpuPeriod = 100_000 // microseconds
+
 	cgroupSampleInterval     = 3 * time.Second
 	finalCgroupSampleTimeout = 3 * time.Second
 
@@ -80,6 +88,9 @@ var removeEnvs = map[string]struct{}{
 	DaggerRedirectStderrEnv:  {},
 	DaggerHostnameAliasesEnv: {},
 	DaggerNoInitEnv:          {},
+	DaggerExecLimitsEnv:      {},
+	DaggerExecTimeoutEnv:     {},
+	DaggerExecAssertionsEnv:  {},
 }
 
 type execState struct {
@@ -102,12 +113,30 @@ type execState struct {
 	metaMount        *specs.Mount
 	origEnvMap       map[string]string
 
+	// outputFiles capture the output of the process; they're emptied before
+	// a retry.
+	outputFiles []*os.File
+	// redirectFiles capture the output of the process in the root
+	// filesystem; they're reopened once it's restored for a retry.
+	redirectFiles []*redirectFile
+
+	// rootMounts and nonRootMounts are mounted to rootfsPath, in order.
+	rootMounts    []mount.Mount
+	nonRootMounts []mount.Mount
+	// retryBackups are the contents of the writable mounts before the first
+	// attempt, restored before each retry; nil if the exec isn't retried.
+	retryBackups []retryBackup
+
 	startedOnce *sync.Once
 	startedCh   chan<- struct{}
 
 	doneErr error
 	done    chan struct{}
 
+	// exitHooks are called with the root filesystem once the process exits,
+	// before it's unmounted; guarded by the worker's mutex.
+	exitHooks []func(rootfsPath string)
+
 	netNSJobs chan func()
 }
 
@@ -422,6 +451,7 @@ func (w *Worker) setupRootfs(ctx context.Context, state *execState) error {
 	if err := mount.All(rootMnts, state.rootfsPath); err != nil {
 		return fmt.Errorf("mount rootfs: %w", err)
 	}
+	state.rootMounts = rootMnts
 	state.cleanups.Add("unmount rootfs", func() error {
 		return mount.Unmount(state.rootfsPath, 0)
 	})
@@ -508,6 +538,7 @@ func (w *Worker) setupRootfs(ctx context.Context, state *execState) error {
 		if err := mnt.Mount(state.rootfsPath); err != nil {
 			return fmt.Errorf("mount to rootfs %s: %w", mnt.Target, err)
 		}
+		state.nonRootMounts = append(state.nonRootMounts, mnt)
 		state.cleanups.Add("unmount from rootfs "+mnt.Target, func() error {
 			return mount.Unmount(dstPath, 0)
 		})
@@ -584,6 +615,7 @@ func (w *Worker) setupStdio(_ context.Context, state *execState) error {
 	}
 	state.cleanups.Add("close container stdout file", stdoutFile.Close)
 	stdoutWriters = append(stdoutWriters, stdoutFile)
+	state.outputFiles = append(state.outputFiles, stdoutFile)
 
 	var stderrWriters []io.Writer
 	if state.procInfo.Stderr != nil {
@@ -596,6 +628,7 @@ func (w *Worker) setupStdio(_ context.Context, state *execState) error {
 	}
 	state.cleanups.Add("close container stderr file", stderrFile.Close)
 	stderrWriters = append(stderrWriters, stderrFile)
+	state.outputFiles = append(state.outputFiles, stderrFile)
 
 	if w.execMD != nil && (w.execMD.RedirectStdoutPath != "" || w.execMD.RedirectStderrPath != "") {
 		ctrFS, err := containerfs.NewContainerFS(state.spec, nil)
@@ -611,34 +644,39 @@ func (w *Worker) setupStdio(_ context.Context, state *execState) error {
 			ctrCwd = filepath.Join("/", ctrCwd)
 		}
 
-		redirectStdoutPath := w.execMD.RedirectStdoutPath
-		if redirectStdoutPath != "" {
-			if !path.IsAbs(redirectStdoutPath) {
-				redirectStdoutPath = filepath.Join(ctrCwd, redirectStdoutPath)
+		openRedirectFile := func(name, p string) (*redirectFile, error) {
+			if !path.IsAbs(p) {
+				p = filepath.Join(ctrCwd, p)
 			}
-			redirectStdoutFile, err := ctrFS.OpenFile(redirectStdoutPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+			f, err := ctrFS.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
 			if err != nil {
-				return fmt.Errorf("open redirect stdout file: %w", err)
+				return nil, fmt.Errorf("open redirect %s file: %w", name, err)
 			}
-			state.cleanups.Add("close redirect stdout file", redirectStdoutFile.Close)
-			if err := redirectStdoutFile.Chown(int(state.spec.Process.User.UID), int(state.spec.Process.User.GID)); err != nil {
-				return fmt.Errorf("chown redirect stdout file: %w", err)
+			rf := &redirectFile{File: f, open: func() (*os.File, error) {
+				return ctrFS.OpenFile(p, os.O_WRONLY|os.O_TRUNC, 0o600)
+			}}
+			state.cleanups.Add("close redirect "+name+" file", func() error {
+				return rf.Close()
+			})
+			if err := f.Chown(int(state.spec.Process.User.UID), int(state.spec.Process.User.GID)); err != nil {
+				return nil, fmt.Errorf("chown redirect %s file: %w", name, err)
 			}
-			stdoutWriters = append(stdoutWriters, redirectStdoutFile)
+			state.redirectFiles = append(state.redirectFiles, rf)
+			return rf, nil
 		}
 
-		redirectStderrPath := w.execMD.RedirectStderrPath
-		if redirectStderrPath != "" {
-			if !path.IsAbs(redirectStderrPath) {
-				redirectStderrPath = filepath.Join(ctrCwd, redirectStderrPath)
-			}
-			redirectStderrFile, err := ctrFS.OpenFile(redirectStderrPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if w.execMD.RedirectStdoutPath != "" {
+			redirectStdoutFile, err := openRedirectFile("stdout", w.execMD.RedirectStdoutPath)
 			if err != nil {
-				return fmt.Errorf("open redirect stderr file: %w", err)
+				return err
 			}
-			state.cleanups.Add("close redirect stderr file", redirectStderrFile.Close)
-			if err := redirectStderrFile.Chown(int(state.spec.Process.User.UID), int(state.spec.Process.User.GID)); err != nil {
-				return fmt.Errorf("chown redirect stderr file: %w", err)
+			stdoutWriters = append(stdoutWriters, redirectStdoutFile)
+		}
+
+		if w.execMD.RedirectStderrPath != "" {
+			redirectStderrFile, err := openRedirectFile("stderr", w.execMD.RedirectStderrPath)
+			if err != nil {
+				return err
 			}
 			stderrWriters = append(stderrWriters, redirectStderrFile)
 		}
@@ -887,6 +925,45 @@ func (w *Worker) enableGPU(_ context.Context, state *execState) error {
 	return nil
 }
 
+func (w *Worker) setupResourceLimits(_ context.Context, state *execState) error {
+	if w.execMD == nil || w.execMD.Limits == nil {
+		return nil
+	}
+	limits := w.execMD.Limits
+
+	if state.spec.Linux == nil {
+		state.spec.Linux = &specs.Linux{}
+	}
+	if state.spec.Linux.Resources == nil {
+		state.spec.Linux.Resources = &specs.LinuxResources{}
+	}
+	res := state.spec.Linux.Resources
+
+	if limits.CPUs > 0 {
+		if res.CPU == nil {
+			res.CPU = &specs.LinuxCPU{}
+		}
+		period := uint64(cpuPeriod)
+		quota := int64(limits.CPUs * cpuPeriod)
+		res.CPU.Period = &period
+		res.CPU.Quota = &quota
+	}
+	if limits.MemoryBytes > 0 {
+		if res.Memory == nil {
+			res.Memory = &specs.LinuxMemory{}
+		}
+		limit := limits.MemoryBytes
+		// don't let the exec swap beyond its memory limit
+		swap := limits.MemoryBytes
+		res.Memory.Limit = &limit
+		res.Memory.Swap = &swap
+	}
+	if limits.Pids > 0 {
+		res.Pids = &specs.LinuxPids{Limit: limits.Pids}
+	}
+	return nil
+}
+
 func (w *Worker) createCWD(_ context.Context, state *execState) error {
 	newp, err := fs.RootPath(state.rootfsPath, state.procInfo.Meta.Cwd)
 	if err != nil {
@@ -1199,5 +1276,181 @@ func (w *Worker) runContainer(ctx context.Context, state *execState) (rerr error
 		return err
 	}
 
-	return exitError(ctx, state.exitCodePath, w.callWithIO(ctx, state.procInfo, startedCallback, killer, runcCall), state.procInfo.Meta.ValidExitCodes)
+	var timeoutErr *ExecTimeoutError
+	for attempt := 1; ; attempt++ {
+		startTime := time.Now()
+		timeoutErr, err = w.runAttempt(ctx, state, startedCallback, killer, runcCall)
+		w.writeExecMetrics(ctx, state, time.Since(startTime))
+
+		delay, retry := w.retryDelay(ctx, state, attempt, err, timeoutErr != nil)
+		if !retry {
+			break
+		}
+		if err := w.waitForRetry(ctx, state, attempt, delay, err); err != nil {
+			return err
+		}
+	}
+	if timeoutErr != nil {
+		// a killed exec fails even if its exit code is otherwise allowed, with
+		// the timeout as the cause
+		ctx, cancel := context.WithCancelCause(ctx)
+		cancel(timeoutErr)
+		return exitError(ctx, state.exitCodePath, err, nil)
+	}
+	if err := exitError(ctx, state.exitCodePath, err, state.procInfo.Meta.ValidExitCodes); err != nil {
+		return err
+	}
+	return w.checkOutputAssertions(state)
+}
+
+// checkOutputAssertions checks the output of an exec that exited with an
+// allowed code against its assertions.
+func (w *Worker) checkOutputAssertions(state *execState) error {
+	if w.execMD == nil || w.execMD.OutputAssertions == nil || state.metaMount == nil {
+		return nil
+	}
+	stdout, err := os.ReadFile(filepath.Join(state.metaMount.Source, MetaMountStdoutPath))
+	if err != nil {
+		return fmt.Errorf("read stdout to check assertions: %w", err)
+	}
+	stderr, err := os.ReadFile(filepath.Join(state.metaMount.Source, MetaMountStderrPath))
+	if err != nil {
+		return fmt.Errorf("read stderr to check assertions: %w", err)
+	}
+	return w.execMD.OutputAssertions.Check(stdout, stderr)
+}
+
+// runAttempt runs the container once, killing it if it runs for longer than
+// the exec timeout, in which case the timeout error is returned along with the
+// error of the run.
+func (w *Worker) runAttempt(
+	ctx context.Context,
+	state *execState,
+	started func(),
+	killer procKiller,
+	call runcCall,
+) (*ExecTimeoutError, error) {
+	if w.execMD == nil || w.execMD.Timeout <= 0 {
+		return nil, w.callWithIO(ctx, state.procInfo, started, killer, call)
+	}
+	timeoutErr := &ExecTimeoutError{Timeout: w.execMD.Timeout}
+	ctx, cancel := context.WithTimeoutCause(ctx, w.execMD.Timeout, timeoutErr)
+	defer cancel()
+	err := w.callWithIO(ctx, state.procInfo, started, killer, call)
+	if errors.Is(context.Cause(ctx), timeoutErr) {
+		return timeoutErr, err
+	}
+	return nil, err
+}
+
+// retryDelay returns whether the failed attempt of the exec should be
+// retried according to its retry policy, and how long to wait before doing
+// so.
+func (w *Worker) retryDelay(ctx context.Context, state *execState, attempt int, err error, timedOut bool) (time.Duration, bool) {
+	if err == nil || ctx.Err() != nil || w.execMD == nil || w.execMD.Retry == nil || state.retryBackups == nil {
+		return 0, false
+	}
+	retry := w.execMD.Retry
+	if attempt >= retry.Attempts {
+		return 0, false
+	}
+
+	var runcExitError *runc.ExitError
+	if !errors.As(err, &runcExitError) {
+		// the container failed to run at all
+		return 0, false
+	}
+	exitCode := runcExitError.Status
+	if !timedOut && slices.Contains(state.procInfo.Meta.ValidExitCodes, exitCode) {
+		return 0, false
+	}
+	if len(retry.ExitCodes) > 0 && !slices.Contains(retry.ExitCodes, exitCode) {
+		return 0, false
+	}
+	if retry.StderrRegex != "" {
+		re, err := regexp.Compile(retry.StderrRegex)
+		if err != nil {
+			bklog.G(ctx).WithError(err).Error("invalid retry stderr regex")
+			return 0, false
+		}
+		if state.metaMount == nil {
+			return 0, false
+		}
+		stderr, err := os.ReadFile(filepath.Join(state.metaMount.Source, MetaMountStderrPath))
+		if err != nil {
+			bklog.G(ctx).WithError(err).Error("failed to read stderr to check for retry")
+			return 0, false
+		}
+		if !re.Match(stderr) {
+			return 0, false
+		}
+	}
+
+	delay := retry.Backoff
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return delay, true
+}
+
+// waitForRetry prepares the container to run again after a failed attempt.
+func (w *Worker) waitForRetry(ctx context.Context, state *execState, attempt int, delay time.Duration, attemptErr error) (rerr error) {
+	ctx, span := Tracer(ctx).Start(ctx, fmt.Sprintf("retry %d/%d", attempt+1, w.execMD.Retry.Attempts),
+		trace.WithAttributes(attribute.String("exec.retry.error", attemptErr.Error())))
+	defer telemetry.End(span, func() error { return rerr })
+
+	// the container is kept around after it exits, so it has to be deleted to
+	// reuse its ID
+	if err := w.runc.Delete(ctx, state.id, &runc.DeleteOpts{Force: true}); err != nil {
+		return fmt.Errorf("delete container for retry: %w", err)
+	}
+	if seeker, ok := state.procInfo.Stdin.(io.Seeker); ok {
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewind stdin for retry: %w", err)
+		}
+	}
+	for _, f := range state.outputFiles {
+		if err := f.Truncate(0); err != nil {
+			return fmt.Errorf("truncate %s for retry: %w", f.Name(), err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewind %s for retry: %w", f.Name(), err)
+		}
+	}
+	if err := w.restoreForRetry(state); err != nil {
+		return fmt.Errorf("restore filesystem for retry: %w", err)
+	}
+
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// writeExecMetrics records the resource usage of the exited container to the
+// meta mount. The cgroup is still around at this point since runc is run with
+// --keep.
+func (w *Worker) writeExecMetrics(ctx context.Context, state *execState, duration time.Duration) {
+	if state.metaMount == nil {
+		return
+	}
+	metrics := ExecMetrics{DurationMicroseconds: duration.Microseconds()}
+	if cgroupPath := state.spec.Linux.CgroupsPath; cgroupPath != "" {
+		usage, err := resources.ReadUsage(cgroupPath)
+		if err != nil {
+			bklog.G(ctx).WithError(err).Error("failed to read exec resource usage")
+		}
+		metrics.Usage = usage
+	}
+	bs, err := json.Marshal(metrics)
+	if err != nil {
+		bklog.G(ctx).WithError(err).Error("failed to marshal exec metrics")
+		return
+	}
+	metricsPath := filepath.Join(state.metaMount.Source, MetaMountMetricsPath)
+	if err := os.WriteFile(metricsPath, bs, 0o600); err != nil {
+		bklog.G(ctx).Errorf("failed to write exec metrics to %s: %v", metricsPath, err)
+	}
 }
@@ -59,6 +59,7 @@ const (
 	MetaMountStdoutPath   = "stdout"
 	MetaMountStderrPath   = "stderr"
 	MetaMountClientIDPath = "clientID"
+	MetaMountMetricsPath  = "metrics"
 )
 
 type Result = solverresult.Result[*ref]
@@ -0,0 +1,79 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Usage is the total resource usage of the tasks in a cgroup.
+type Usage struct {
+	// CPU time used in microseconds.
+	CPUTimeMicroseconds int64 `json:"cpuTimeMicroseconds"`
+
+	// Peak memory usage in bytes.
+	MemoryPeakBytes int64 `json:"memoryPeakBytes"`
+
+	// Bytes read from and written to disk, not including the page cache.
+	IOReadBytes  int64 `json:"ioReadBytes"`
+	IOWriteBytes int64 `json:"ioWriteBytes"`
+}
+
+// ReadUsage reads the total resource usage of the cgroup at the given path in
+// the cgroup namespace. Statistics the cgroup doesn't report are left as 0.
+func ReadUsage(cgroupNSSubpath string) (Usage, error) {
+	cgroupPath := filepath.Join(defaultMountpoint, cgroupNSSubpath)
+	var usage Usage
+
+	bs, err := readStatFile(filepath.Join(cgroupPath, cpuStatFile))
+	if err != nil {
+		return usage, err
+	}
+	for key, value := range flatKeyValuesInt64(bs) {
+		if key == cpuUsageKey {
+			usage.CPUTimeMicroseconds = value
+		}
+	}
+
+	bs, err = readStatFile(filepath.Join(cgroupPath, memoryPeakFile))
+	if err != nil {
+		return usage, err
+	}
+	if len(bs) > 0 {
+		usage.MemoryPeakBytes, err = singleValue(bs)
+		if err != nil {
+			return usage, fmt.Errorf("error converting %s to int64: %w", memoryPeakFile, err)
+		}
+	}
+
+	bs, err = readStatFile(filepath.Join(cgroupPath, ioStatFile))
+	if err != nil {
+		return usage, err
+	}
+	for _, kvs := range nestedKeyValuesInt64(bs) {
+		for k, v := range kvs {
+			switch k {
+			case ioReadBytes:
+				usage.IOReadBytes += v
+			case ioWriteBytes:
+				usage.IOWriteBytes += v
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// readStatFile reads a cgroup statistics file, returning no content if the
+// cgroup doesn't report it.
+func readStatFile(path string) ([]byte, error) {
+	bs, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return bs, nil
+}
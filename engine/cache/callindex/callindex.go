@@ -0,0 +1,259 @@
+package callindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/dagger/dagger/engine"
+)
+
+// The bucket entries are stored in. The suffix is the version of the index
+// format, which must be bumped whenever the encoding of entries or of the IDs
+// they record changes incompatibly; buckets of other versions are dropped on
+// open.
+const (
+	bucketPrefix = "calls-"
+	bucketName   = bucketPrefix + "v1"
+)
+
+// Index is a persistent index of dagql call digests to the IDs of their
+// results, so calls can be cached across engine restarts. The snapshots
+// backing the results are persisted by buildkit, and kept by the engine's GC
+// as long as the entries recording them, so loading a recorded ID is cheap.
+type Index struct {
+	db *bolt.DB
+
+	// the times entries were last used since the index was last flushed, so
+	// that using an entry doesn't write to disk
+	touchedMu sync.Mutex
+	touched   map[digest.Digest]time.Time
+}
+
+// Entry is the result of a call recorded in the index.
+type Entry struct {
+	// The encoded ID of the result.
+	ResultID string `json:"resultID"`
+
+	// The version of the engine that recorded the entry.
+	EngineVersion string `json:"engineVersion"`
+
+	// A digest of the schema of the fields called by the result ID, which
+	// must match the current schema for the entry to be used.
+	SchemaDigest digest.Digest `json:"schemaDigest"`
+
+	// The IDs of the buildkit cache records backing the result.
+	Records []string `json:"records,omitempty"`
+
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+}
+
+// Open opens the index at the given path, creating it if it doesn't exist.
+func Open(path string) (*Index, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open call index: %w", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		var stale [][]byte
+		err := tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			if string(name) != bucketName {
+				stale = append(stale, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range stale {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		_, err = tx.CreateBucketIfNotExists([]byte(bucketName))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize call index: %w", err)
+	}
+	return &Index{db: db, touched: map[digest.Digest]time.Time{}}, nil
+}
+
+func (idx *Index) Close() error {
+	return errors.Join(idx.Flush(), idx.db.Close())
+}
+
+// Get returns the entry recorded for the call with the given digest. Entries
+// recorded by a newer engine are ignored, since they may call fields this
+// engine doesn't know about.
+func (idx *Index) Get(callDigest digest.Digest) (*Entry, bool, error) {
+	var ent *Entry
+	err := idx.db.View(func(tx *bolt.Tx) error {
+		bs := tx.Bucket([]byte(bucketName)).Get([]byte(callDigest))
+		if bs == nil {
+			return nil
+		}
+		ent = &Entry{}
+		return json.Unmarshal(bs, ent)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get call %s: %w", callDigest, err)
+	}
+	if ent == nil {
+		return nil, false, nil
+	}
+	if !engine.CheckMaxVersionCompatibility(ent.EngineVersion, engine.Version) {
+		return nil, false, nil
+	}
+	return ent, true, nil
+}
+
+// Put records the result of the call with the given digest, replacing any
+// previous entry.
+func (idx *Index) Put(callDigest digest.Digest, ent Entry) error {
+	if ent.EngineVersion == "" {
+		ent.EngineVersion = engine.Version
+	}
+	now := time.Now()
+	if ent.CreatedAt.IsZero() {
+		ent.CreatedAt = now
+	}
+	if ent.LastUsedAt.IsZero() {
+		ent.LastUsedAt = now
+	}
+	bs, err := json.Marshal(ent)
+	if err != nil {
+		return err
+	}
+	err = idx.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucketName)).Put([]byte(callDigest), bs)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put call %s: %w", callDigest, err)
+	}
+	return nil
+}
+
+// Touch updates the time the entry of the call with the given digest was last
+// used, if there is one. The update is only written by the next Flush.
+func (idx *Index) Touch(callDigest digest.Digest) {
+	idx.touchedMu.Lock()
+	defer idx.touchedMu.Unlock()
+	idx.touched[callDigest] = time.Now()
+}
+
+// Flush writes the times entries were last used since the last flush.
+func (idx *Index) Flush() error {
+	return idx.db.Update(idx.flush)
+}
+
+func (idx *Index) flush(tx *bolt.Tx) error {
+	idx.touchedMu.Lock()
+	touched := idx.touched
+	idx.touched = map[digest.Digest]time.Time{}
+	idx.touchedMu.Unlock()
+
+	b := tx.Bucket([]byte(bucketName))
+	for callDigest, lastUsedAt := range touched {
+		bs := b.Get([]byte(callDigest))
+		if bs == nil {
+			continue
+		}
+		var ent Entry
+		if err := json.Unmarshal(bs, &ent); err != nil {
+			return err
+		}
+		if !lastUsedAt.After(ent.LastUsedAt) {
+			continue
+		}
+		ent.LastUsedAt = lastUsedAt
+		bs, err := json.Marshal(ent)
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(callDigest), bs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Records returns the IDs of the buildkit cache records backing the results
+// of all the entries, which must not be pruned.
+func (idx *Index) Records() (map[string]bool, error) {
+	records := map[string]bool{}
+	err := idx.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucketName)).ForEach(func(_, v []byte) error {
+			var ent Entry
+			if err := json.Unmarshal(v, &ent); err != nil {
+				// invalid entries are removed by the next prune
+				return nil
+			}
+			for _, id := range ent.Records {
+				records[id] = true
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list call records: %w", err)
+	}
+	return records, nil
+}
+
+// Delete removes the entry of the call with the given digest.
+func (idx *Index) Delete(callDigest digest.Digest) error {
+	idx.touchedMu.Lock()
+	delete(idx.touched, callDigest)
+	idx.touchedMu.Unlock()
+
+	err := idx.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(bucketName)).Delete([]byte(callDigest))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete call %s: %w", callDigest, err)
+	}
+	return nil
+}
+
+// Prune removes the entries last used before the given time, returning the
+// number of entries removed. The times entries were last used are flushed
+// first.
+func (idx *Index) Prune(before time.Time) (int, error) {
+	var pruned int
+	err := idx.db.Update(func(tx *bolt.Tx) error {
+		if err := idx.flush(tx); err != nil {
+			return err
+		}
+		b := tx.Bucket([]byte(bucketName))
+		var stale [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var ent Entry
+			if err := json.Unmarshal(v, &ent); err != nil || ent.LastUsedAt.Before(before) {
+				stale = append(stale, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		pruned = len(stale)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune call index: %w", err)
+	}
+	return pruned, nil
+}
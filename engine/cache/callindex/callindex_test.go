@@ -0,0 +1,144 @@
+package callindex
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calls.db")
+	idx, err := Open(path)
+	require.NoError(t, err)
+
+	call := digest.FromString("call")
+	_, ok, err := idx.Get(call)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, idx.Put(call, Entry{ResultID: "result", SchemaDigest: digest.FromString("schema")}))
+	ent, ok, err := idx.Get(call)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "result", ent.ResultID)
+	require.Equal(t, digest.FromString("schema"), ent.SchemaDigest)
+	require.False(t, ent.CreatedAt.IsZero())
+
+	// entries survive reopening the index
+	require.NoError(t, idx.Close())
+	idx, err = Open(path)
+	require.NoError(t, err)
+	defer idx.Close()
+	_, ok, err = idx.Get(call)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, idx.Delete(call))
+	_, ok, err = idx.Get(call)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestIndexNewerEngine(t *testing.T) {
+	idx, err := Open(filepath.Join(t.TempDir(), "calls.db"))
+	require.NoError(t, err)
+	defer idx.Close()
+
+	call := digest.FromString("call")
+	require.NoError(t, idx.Put(call, Entry{ResultID: "result", EngineVersion: "v999.0.0"}))
+	_, ok, err := idx.Get(call)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestIndexPrune(t *testing.T) {
+	idx, err := Open(filepath.Join(t.TempDir(), "calls.db"))
+	require.NoError(t, err)
+	defer idx.Close()
+
+	now := time.Now()
+	old := digest.FromString("old")
+	recent := digest.FromString("recent")
+	require.NoError(t, idx.Put(old, Entry{ResultID: "old", LastUsedAt: now.Add(-48 * time.Hour)}))
+	require.NoError(t, idx.Put(recent, Entry{ResultID: "recent", LastUsedAt: now.Add(-48 * time.Hour)}))
+	idx.Touch(recent)
+
+	pruned, err := idx.Prune(now.Add(-24 * time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, pruned)
+
+	_, ok, err := idx.Get(old)
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, ok, err = idx.Get(recent)
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestIndexFlush(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calls.db")
+	idx, err := Open(path)
+	require.NoError(t, err)
+
+	call := digest.FromString("call")
+	lastUsedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	require.NoError(t, idx.Put(call, Entry{ResultID: "result", LastUsedAt: lastUsedAt}))
+
+	idx.Touch(call)
+	ent, _, err := idx.Get(call)
+	require.NoError(t, err)
+	require.True(t, ent.LastUsedAt.Equal(lastUsedAt))
+
+	// touched entries are written on close
+	require.NoError(t, idx.Close())
+	idx, err = Open(path)
+	require.NoError(t, err)
+	defer idx.Close()
+	ent, _, err = idx.Get(call)
+	require.NoError(t, err)
+	require.True(t, ent.LastUsedAt.After(lastUsedAt))
+}
+
+func TestIndexRecords(t *testing.T) {
+	idx, err := Open(filepath.Join(t.TempDir(), "calls.db"))
+	require.NoError(t, err)
+	defer idx.Close()
+
+	require.NoError(t, idx.Put(digest.FromString("a"), Entry{ResultID: "a", Records: []string{"rec1", "rec2"}}))
+	require.NoError(t, idx.Put(digest.FromString("b"), Entry{ResultID: "b", Records: []string{"rec2", "rec3"}}))
+	records, err := idx.Records()
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"rec1": true, "rec2": true, "rec3": true}, records)
+
+	require.NoError(t, idx.Delete(digest.FromString("a")))
+	records, err = idx.Records()
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"rec2": true, "rec3": true}, records)
+}
+
+func TestIndexDropsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calls.db")
+	db, err := bolt.Open(path, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucket([]byte(bucketPrefix + "v0"))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("call"), []byte("{}"))
+	}))
+	require.NoError(t, db.Close())
+
+	idx, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, idx.db.View(func(tx *bolt.Tx) error {
+		require.Nil(t, tx.Bucket([]byte(bucketPrefix+"v0")))
+		require.NotNil(t, tx.Bucket([]byte(bucketName)))
+		return nil
+	}))
+	require.NoError(t, idx.Close())
+}
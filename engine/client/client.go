@@ -344,11 +344,16 @@ func (c *Client) startSession(ctx context.Context) (rerr error) {
 	clientMetadata := c.clientMetadata()
 	c.internalCtx = engine.ContextWithClientMetadata(c.internalCtx, &clientMetadata)
 
+	secretProvider, err := secretprovider.NewSecretProvider()
+	if err != nil {
+		return fmt.Errorf("secret provider: %w", err)
+	}
+
 	attachables := []bksession.Attachable{
 		// sockets
 		SocketProvider{EnableHostNetworkAccess: !c.DisableHostRW},
 		// secrets
-		secretprovider.NewSecretProvider(),
+		secretProvider,
 		// registry auth
 		authprovider.NewDockerAuthProvider(config.LoadDefaultConfigFile(os.Stderr), nil),
 		// host=>container networking
@@ -0,0 +1,157 @@
+package secretprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RefreshAnnotation is set on secret requests that must bypass any cached
+	// plaintext and resolve the secret again from its provider.
+	RefreshAnnotation = "dagger.io/secret.refresh"
+
+	// CacheTTLEnv configures how long resolved secrets are cached by the
+	// client, as a comma separated list of scheme=duration pairs, e.g.
+	// "vault=5m,op=1m". A duration without a scheme applies to every scheme.
+	CacheTTLEnv = "DAGGER_SECRET_CACHE_TTL"
+)
+
+var (
+	cacheTTLsMu sync.RWMutex
+	cacheTTLs   = map[string]time.Duration{}
+)
+
+// SetCacheTTL sets the default duration for which secrets resolved with the
+// given scheme are cached, overridable by CacheTTLEnv. A zero TTL disables
+// caching, which is the default.
+func SetCacheTTL(scheme string, ttl time.Duration) {
+	cacheTTLsMu.Lock()
+	defer cacheTTLsMu.Unlock()
+	if ttl <= 0 {
+		delete(cacheTTLs, scheme)
+		return
+	}
+	cacheTTLs[scheme] = ttl
+}
+
+// ParseCacheTTLs parses a CacheTTLEnv value. The default TTL for schemes that
+// aren't listed is returned with an empty scheme key.
+func ParseCacheTTLs(s string) (map[string]time.Duration, error) {
+	ttls := map[string]time.Duration{}
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scheme, durStr, ok := strings.Cut(entry, "=")
+		if !ok {
+			scheme, durStr = "", entry
+		}
+		scheme = strings.TrimSpace(scheme)
+		if scheme != "" {
+			if err := validateScheme(scheme); err != nil {
+				return nil, fmt.Errorf("invalid secret cache ttl %q: %w", entry, err)
+			}
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(durStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret cache ttl %q: %w", entry, err)
+		}
+		if ttl < 0 {
+			return nil, fmt.Errorf("invalid secret cache ttl %q: negative duration", entry)
+		}
+		ttls[scheme] = ttl
+	}
+	return ttls, nil
+}
+
+type cachedPlaintext struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+// secretCache caches resolved plaintexts by secret URI for a per-scheme TTL,
+// so that repeated uses of a secret don't hit the provider every time.
+type secretCache struct {
+	ttls map[string]time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedPlaintext
+}
+
+func newSecretCache() (*secretCache, error) {
+	ttls := map[string]time.Duration{}
+	cacheTTLsMu.RLock()
+	for scheme, ttl := range cacheTTLs {
+		ttls[scheme] = ttl
+	}
+	cacheTTLsMu.RUnlock()
+
+	if v, ok := os.LookupEnv(CacheTTLEnv); ok {
+		envTTLs, err := ParseCacheTTLs(v)
+		if err != nil {
+			return nil, err
+		}
+		for scheme, ttl := range envTTLs {
+			ttls[scheme] = ttl
+		}
+	}
+
+	return &secretCache{
+		ttls:    ttls,
+		entries: map[string]cachedPlaintext{},
+	}, nil
+}
+
+func (c *secretCache) ttl(scheme string) time.Duration {
+	if ttl, ok := c.ttls[scheme]; ok {
+		return ttl
+	}
+	return c.ttls[""]
+}
+
+func (c *secretCache) get(id string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, id)
+		return nil, false
+	}
+	return entry.data, true
+}
+
+func (c *secretCache) set(id, scheme string, data []byte) {
+	ttl := c.ttl(scheme)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ttl <= 0 {
+		delete(c.entries, id)
+		return
+	}
+	c.entries[id] = cachedPlaintext{
+		data:      data,
+		expiresAt: time.Now().Add(ttl),
+	}
+}
+
+type refreshKey struct{}
+
+// WithRefresh returns a context indicating that resolvers must bypass any
+// cache of their own and look up the secret from its source.
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+// IsRefresh returns whether resolvers must bypass their own cache.
+func IsRefresh(ctx context.Context) bool {
+	v, _ := ctx.Value(refreshKey{}).(bool)
+	return v
+}
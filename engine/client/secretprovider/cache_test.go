@@ -0,0 +1,71 @@
+package secretprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCacheTTLs(t *testing.T) {
+	ttls, err := ParseCacheTTLs("vault=5m, op=30s,1m")
+	require.NoError(t, err)
+	require.Equal(t, map[string]time.Duration{
+		"vault": 5 * time.Minute,
+		"op":    30 * time.Second,
+		"":      time.Minute,
+	}, ttls)
+
+	_, err = ParseCacheTTLs("vault=forever")
+	require.ErrorContains(t, err, `invalid secret cache ttl "vault=forever"`)
+	_, err = ParseCacheTTLs("Bad_Scheme=1m")
+	require.ErrorContains(t, err, `invalid secret cache ttl "Bad_Scheme=1m"`)
+}
+
+func TestSecretProviderCache(t *testing.T) {
+	var calls int
+	Register("test-counter", func(ctx context.Context, key string) ([]byte, error) {
+		calls++
+		if IsRefresh(ctx) {
+			return []byte("refreshed"), nil
+		}
+		return []byte("value"), nil
+	})
+	t.Cleanup(func() {
+		resolversMu.Lock()
+		delete(resolvers, "test-counter")
+		resolversMu.Unlock()
+	})
+
+	t.Setenv(CacheTTLEnv, "test-counter=1h")
+	sp, err := NewSecretProvider()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	get := func(annotations map[string]string) string {
+		resp, err := sp.GetSecret(ctx, &secrets.GetSecretRequest{
+			ID:          "test-counter://foo",
+			Annotations: annotations,
+		})
+		require.NoError(t, err)
+		return string(resp.Data)
+	}
+
+	require.Equal(t, "value", get(nil))
+	require.Equal(t, "value", get(nil))
+	require.Equal(t, 1, calls)
+
+	require.Equal(t, "refreshed", get(map[string]string{RefreshAnnotation: "true"}))
+	require.Equal(t, 2, calls)
+	require.Equal(t, "refreshed", get(nil))
+	require.Equal(t, 2, calls)
+
+	t.Setenv(CacheTTLEnv, "")
+	sp, err = NewSecretProvider()
+	require.NoError(t, err)
+	get(nil)
+	get(nil)
+	require.Equal(t, 4, calls)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/moby/buildkit/session/secrets"
 	"google.golang.org/grpc"
@@ -14,13 +16,82 @@ import (
 
 type SecretResolver func(context.Context, string) ([]byte, error)
 
-var resolvers = map[string]SecretResolver{
-	"env":       envProvider,
-	"file":      fileProvider,
-	"cmd":       cmdProvider,
-	"op":        opProvider,
-	"vault":     vaultProvider,
-	"libsecret": libsecretProvider,
+var (
+	resolversMu sync.RWMutex
+	resolvers   = map[string]SecretResolver{
+		"env":       envProvider,
+		"file":      fileProvider,
+		"cmd":       cmdProvider,
+		"op":        opProvider,
+		"vault":     vaultProvider,
+		"libsecret": libsecretProvider,
+		"sops":      sopsProvider,
+	}
+)
+
+// Register makes a secret resolver available for URIs with the given scheme,
+// e.g. "aws" for "aws://path/to/secret".
+//
+// Register panics if the scheme is invalid, the resolver is nil, or a
+// resolver is already registered for the scheme.
+func Register(scheme string, resolver SecretResolver) {
+	if err := validateScheme(scheme); err != nil {
+		panic(fmt.Sprintf("secretprovider: register %q: %v", scheme, err))
+	}
+	if resolver == nil {
+		panic(fmt.Sprintf("secretprovider: register %q: nil resolver", scheme))
+	}
+
+	resolversMu.Lock()
+	defer resolversMu.Unlock()
+	if _, exists := resolvers[scheme]; exists {
+		panic(fmt.Sprintf("secretprovider: register %q: scheme already registered", scheme))
+	}
+	resolvers[scheme] = resolver
+}
+
+// Schemes returns the sorted list of registered secret provider schemes.
+func Schemes() []string {
+	resolversMu.RLock()
+	defer resolversMu.RUnlock()
+	schemes := make([]string, 0, len(resolvers))
+	for scheme := range resolvers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+func validateScheme(scheme string) error {
+	if scheme == "" {
+		return errors.New("empty scheme")
+	}
+	for i, r := range scheme {
+		switch {
+		case r >= 'a' && r <= 'z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.'):
+		default:
+			return fmt.Errorf("invalid character %q in scheme", r)
+		}
+	}
+	return nil
+}
+
+// ParseID splits a secret URI into its scheme and the provider-specific
+// remainder, without checking that the scheme is registered.
+//
+// This is useful to validate URIs in a process that doesn't resolve secrets
+// itself (such as the engine), since resolvers may be registered only on the
+// client.
+func ParseID(id string) (scheme string, pathWithQuery string, _ error) {
+	scheme, pathWithQuery, ok := strings.Cut(id, "://")
+	if !ok {
+		return "", "", fmt.Errorf("parse %q: malformed id", id)
+	}
+	if err := validateScheme(scheme); err != nil {
+		return "", "", fmt.Errorf("parse %q: %w", id, err)
+	}
+	return scheme, pathWithQuery, nil
 }
 
 func ResolverForID(id string) (SecretResolver, string, error) {
@@ -29,7 +100,9 @@ func ResolverForID(id string) (SecretResolver, string, error) {
 		return nil, "", fmt.Errorf("parse %q: malformed id", id)
 	}
 
+	resolversMu.RLock()
 	resolver, ok := resolvers[scheme]
+	resolversMu.RUnlock()
 	if !ok {
 		return nil, "", fmt.Errorf("unsupported secret provider: %q", scheme)
 	}
@@ -37,10 +110,15 @@ func ResolverForID(id string) (SecretResolver, string, error) {
 }
 
 type SecretProvider struct {
+	cache *secretCache
 }
 
-func NewSecretProvider() SecretProvider {
-	return SecretProvider{}
+func NewSecretProvider() (SecretProvider, error) {
+	cache, err := newSecretCache()
+	if err != nil {
+		return SecretProvider{}, err
+	}
+	return SecretProvider{cache: cache}, nil
 }
 
 func (sp SecretProvider) Register(server *grpc.Server) {
@@ -52,6 +130,18 @@ func (sp SecretProvider) GetSecret(ctx context.Context, req *secrets.GetSecretRe
 	if err != nil {
 		return nil, err
 	}
+	scheme, _, _ := strings.Cut(req.ID, "://")
+
+	refresh := req.Annotations[RefreshAnnotation] == "true"
+	if refresh {
+		ctx = WithRefresh(ctx)
+	} else if sp.cache != nil {
+		if plaintext, ok := sp.cache.get(req.ID); ok {
+			return &secrets.GetSecretResponse{
+				Data: plaintext,
+			}, nil
+		}
+	}
 
 	plaintext, err := resolver(ctx, u)
 	if err != nil {
@@ -60,6 +150,9 @@ func (sp SecretProvider) GetSecret(ctx context.Context, req *secrets.GetSecretRe
 		}
 		return nil, err
 	}
+	if sp.cache != nil {
+		sp.cache.set(req.ID, scheme, plaintext)
+	}
 
 	return &secrets.GetSecretResponse{
 		Data: plaintext,
@@ -0,0 +1,383 @@
+package secretprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dagger/dagger/engine/client/pathutil"
+)
+
+// sopsProvider decrypts a single value from a SOPS-encrypted YAML or JSON
+// file.
+// https://github.com/getsops/sops
+//
+// Format:
+// - sops://<path>#<key.path>
+//
+// Files encrypted for age recipients are decrypted in-process, using the
+// identities from SOPS_AGE_KEY, SOPS_AGE_KEY_FILE or the default sops key
+// file. Otherwise (e.g. for PGP or cloud KMS keys), the `sops` CLI is used if
+// present.
+func sopsProvider(ctx context.Context, key string) ([]byte, error) {
+	filePath, keyPath, err := parseSopsKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	filePath, err = pathutil.ExpandHomeDir(homeDir, filePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sops file %q: %w", filePath, err)
+	}
+
+	plaintext, nativeErr := sopsDecryptAge(data, keyPath)
+	if nativeErr == nil {
+		return plaintext, nil
+	}
+	if !errors.Is(nativeErr, errSopsNoAgeKey) {
+		return nil, fmt.Errorf("sops file %q: %w", filePath, nativeErr)
+	}
+
+	if _, err := exec.LookPath("sops"); err == nil {
+		return sopsCLIProvider(ctx, filePath, keyPath)
+	}
+	return nil, fmt.Errorf("sops file %q: %w, and `sops` binary is not present", filePath, nativeErr)
+}
+
+func parseSopsKey(key string) (filePath string, keyPath []string, _ error) {
+	filePath, fragment, _ := strings.Cut(key, "#")
+	if filePath == "" {
+		return "", nil, fmt.Errorf("invalid sops secret %q: missing file path", key)
+	}
+	if fragment == "" {
+		return "", nil, fmt.Errorf("invalid sops secret %q: missing key path, e.g. %q", key, "sops://"+filePath+"#path.to.key")
+	}
+	keyPath = strings.Split(fragment, ".")
+	for _, part := range keyPath {
+		if part == "" {
+			return "", nil, fmt.Errorf("invalid sops secret %q: empty key path component", key)
+		}
+	}
+	return filePath, keyPath, nil
+}
+
+func sopsCLIProvider(ctx context.Context, filePath string, keyPath []string) ([]byte, error) {
+	var extract strings.Builder
+	for _, part := range keyPath {
+		if _, err := strconv.Atoi(part); err == nil {
+			fmt.Fprintf(&extract, "[%s]", part)
+		} else {
+			fmt.Fprintf(&extract, "[%q]", part)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "sops", "--decrypt", "--extract", extract.String(), filePath)
+	cmd.Env = os.Environ()
+
+	plaintext, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("unable to decrypt sops file %q: %w: %s", filePath, err, bytes.TrimSpace(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("unable to decrypt sops file %q: %w", filePath, err)
+	}
+	return plaintext, nil
+}
+
+var errSopsNoAgeKey = errors.New("no matching age identity to decrypt the data key")
+
+// sopsEncryptedValue matches values encrypted by sops, see
+// https://github.com/getsops/sops/blob/main/aes/cipher.go
+var sopsEncryptedValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	LastModified     string `yaml:"lastmodified"`
+	MAC              string `yaml:"mac"`
+	MACOnlyEncrypted bool   `yaml:"mac_only_encrypted"`
+}
+
+func sopsDecryptAge(data []byte, keyPath []string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("parse: the document is not a map")
+	}
+	root := doc.Content[0]
+
+	var file struct {
+		Sops *sopsMetadata `yaml:"sops"`
+	}
+	if err := root.Decode(&file); err != nil {
+		return nil, fmt.Errorf("parse metadata: %w", err)
+	}
+	if file.Sops == nil {
+		return nil, errors.New("missing sops metadata, is the file encrypted with sops?")
+	}
+
+	// walk to the requested value; list indices are part of the key path
+	cur := root
+	for i, part := range keyPath {
+		for cur.Kind == yaml.AliasNode {
+			cur = cur.Alias
+		}
+		var next *yaml.Node
+		switch cur.Kind {
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(cur.Content); j += 2 {
+				if cur.Content[j].Value == part {
+					next = cur.Content[j+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(cur.Content) {
+				return nil, fmt.Errorf("invalid list index %q at %q", part, strings.Join(keyPath[:i], "."))
+			}
+			next = cur.Content[idx]
+		}
+		if next == nil {
+			return nil, fmt.Errorf("key %q not found", strings.Join(keyPath[:i+1], "."))
+		}
+		cur = next
+	}
+	for cur.Kind == yaml.AliasNode {
+		cur = cur.Alias
+	}
+	if cur.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("key %q is not a scalar value", strings.Join(keyPath, "."))
+	}
+
+	dataKey, err := sopsAgeDataKey(file.Sops)
+	if err != nil {
+		return nil, err
+	}
+
+	// like `sops decrypt`, decrypt the whole document to check its MAC, so
+	// that values can't be tampered with, removed or moved around
+	values := map[*yaml.Node][]byte{}
+	hash := sha512.New()
+	var walk func(node *yaml.Node, adPath []string) error
+	walk = func(node *yaml.Node, adPath []string) error {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				if node == root && key == "sops" {
+					continue
+				}
+				if err := walk(node.Content[i+1], append(adPath[:len(adPath):len(adPath)], key)); err != nil {
+					return err
+				}
+			}
+		case yaml.SequenceNode:
+			for _, item := range node.Content {
+				if err := walk(item, adPath); err != nil {
+					return err
+				}
+			}
+		case yaml.AliasNode:
+			return walk(node.Alias, adPath)
+		case yaml.ScalarNode:
+			value, macValue, encrypted, err := sopsScalarValue(node, dataKey, adPath)
+			if err != nil {
+				return fmt.Errorf("%s: %w", strings.Join(adPath, "."), err)
+			}
+			values[node] = value
+			if encrypted || !file.Sops.MACOnlyEncrypted {
+				hash.Write(macValue)
+			}
+		}
+		return nil
+	}
+	if err := walk(root, nil); err != nil {
+		return nil, err
+	}
+	if err := sopsVerifyMAC(file.Sops, dataKey, fmt.Sprintf("%X", hash.Sum(nil))); err != nil {
+		return nil, err
+	}
+	return values[cur], nil
+}
+
+// sopsScalarValue returns the value of a scalar, decrypted if needed, and the
+// bytes it contributes to the MAC.
+func sopsScalarValue(node *yaml.Node, dataKey []byte, adPath []string) (value, macValue []byte, encrypted bool, _ error) {
+	if match := sopsEncryptedValue.FindStringSubmatch(node.Value); match != nil {
+		plaintext, err := sopsDecryptValue(dataKey, match, strings.Join(adPath, ":")+":")
+		if err != nil {
+			return nil, nil, false, err
+		}
+		return plaintext, plaintext, true, nil
+	}
+
+	// unencrypted scalar (e.g. matching unencrypted_suffix), converted to
+	// bytes for the MAC the same way as sops
+	var v any
+	if err := node.Decode(&v); err != nil {
+		return nil, nil, false, err
+	}
+	value = []byte(fmt.Sprint(v))
+	switch v := v.(type) {
+	case nil:
+		return []byte{}, []byte{}, false, nil
+	case bool:
+		if v {
+			return value, []byte("True"), false, nil
+		}
+		return value, []byte("False"), false, nil
+	case float64:
+		return value, []byte(strconv.FormatFloat(v, 'f', -1, 64)), false, nil
+	default:
+		return value, value, false, nil
+	}
+}
+
+// sopsVerifyMAC checks the MAC of the document, encrypted with the time it was
+// last modified as additional data.
+func sopsVerifyMAC(md *sopsMetadata, dataKey []byte, mac string) error {
+	if md.MAC == "" {
+		return errors.New("missing sops MAC")
+	}
+	match := sopsEncryptedValue.FindStringSubmatch(md.MAC)
+	if match == nil {
+		return errors.New("invalid sops MAC")
+	}
+	lastModified, err := time.Parse(time.RFC3339, md.LastModified)
+	if err != nil {
+		return fmt.Errorf("invalid sops lastmodified: %w", err)
+	}
+	expected, err := sopsDecryptValue(dataKey, match, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("sops MAC: %w", err)
+	}
+	if string(expected) != mac {
+		return errors.New("sops MAC mismatch, the file was modified after it was encrypted")
+	}
+	return nil
+}
+
+func sopsDecryptValue(dataKey []byte, match []string, additionalData string) ([]byte, error) {
+	encData, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("decode data: %w", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(match[2])
+	if err != nil {
+		return nil, fmt.Errorf("decode iv: %w", err)
+	}
+	tag, err := base64.StdEncoding.DecodeString(match[3])
+	if err != nil {
+		return nil, fmt.Errorf("decode tag: %w", err)
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(encData, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, fmt.Errorf("decrypt value: %w", err)
+	}
+	return plaintext, nil
+}
+
+func sopsAgeDataKey(md *sopsMetadata) ([]byte, error) {
+	if len(md.Age) == 0 {
+		return nil, errSopsNoAgeKey
+	}
+	identities, err := sopsAgeIdentities()
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		return nil, errSopsNoAgeKey
+	}
+
+	for _, stanza := range md.Age {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(stanza.Enc))), identities...)
+		if err != nil {
+			var noMatch *age.NoIdentityMatchError
+			if errors.As(err, &noMatch) {
+				continue
+			}
+			return nil, fmt.Errorf("decrypt data key for %q: %w", stanza.Recipient, err)
+		}
+		return io.ReadAll(r)
+	}
+	return nil, errSopsNoAgeKey
+}
+
+// sopsAgeIdentities loads age identities from the same locations as sops.
+func sopsAgeIdentities() ([]age.Identity, error) {
+	var identities []age.Identity
+
+	if keys := os.Getenv("SOPS_AGE_KEY"); keys != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(keys))
+		if err != nil {
+			return nil, fmt.Errorf("parse SOPS_AGE_KEY: %w", err)
+		}
+		identities = append(identities, ids...)
+	}
+
+	keyFile := os.Getenv("SOPS_AGE_KEY_FILE")
+	if keyFile == "" {
+		configDir := os.Getenv("XDG_CONFIG_HOME")
+		if configDir == "" {
+			var err error
+			configDir, err = os.UserConfigDir()
+			if err != nil {
+				return identities, nil
+			}
+		}
+		keyFile = filepath.Join(configDir, "sops", "age", "keys.txt")
+	}
+	f, err := os.Open(keyFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return identities, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	ids, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %q: %w", keyFile, err)
+	}
+	return append(identities, ids...), nil
+}
@@ -0,0 +1,172 @@
+package secretprovider
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/stretchr/testify/require"
+)
+
+// sopsEncrypt encrypts a value the same way sops does.
+func sopsEncrypt(t *testing.T, dataKey []byte, value string, additionalData string) string {
+	t.Helper()
+	iv := make([]byte, 32)
+	_, err := rand.Read(iv)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(dataKey)
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	require.NoError(t, err)
+	out := gcm.Seal(nil, iv, []byte(value), []byte(additionalData))
+	encData, tag := out[:len(out)-gcm.Overhead()], out[len(out)-gcm.Overhead():]
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:str]",
+		base64.StdEncoding.EncodeToString(encData),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag))
+}
+
+func ageEncryptArmored(t *testing.T, recipient age.Recipient, data []byte) string {
+	t.Helper()
+	var buf bytes.Buffer
+	aw := armor.NewWriter(&buf)
+	w, err := age.Encrypt(aw, recipient)
+	require.NoError(t, err)
+	_, err = w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, aw.Close())
+	return buf.String()
+}
+
+func TestSopsProvider(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	dataKey := make([]byte, 32)
+	_, err = rand.Read(dataKey)
+	require.NoError(t, err)
+
+	// the MAC covers every value in order, encrypted with the last
+	// modification time as additional data
+	const lastModified = "2024-05-01T10:00:00Z"
+	mac := sha512.Sum512([]byte("hunter2" + "admin" + "tok-0"))
+	sopsDoc := func(user string) string {
+		return fmt.Sprintf(`db:
+    password: %s
+    user_unencrypted: %s
+tokens:
+    - %s
+sops:
+    age:
+        - recipient: %s
+          enc: |
+%s
+    lastmodified: "%s"
+    mac: %s
+    version: 3.9.0
+`,
+			sopsEncrypt(t, dataKey, "hunter2", "db:password:"),
+			user,
+			sopsEncrypt(t, dataKey, "tok-0", "tokens:"),
+			identity.Recipient().String(),
+			indent(ageEncryptArmored(t, identity.Recipient(), dataKey), "            "),
+			lastModified,
+			sopsEncrypt(t, dataKey, fmt.Sprintf("%X", mac), lastModified),
+		)
+	}
+
+	dir := t.TempDir()
+	secretsPath := filepath.Join(dir, "secrets.yaml")
+	require.NoError(t, os.WriteFile(secretsPath, []byte(sopsDoc("admin")), 0o600))
+	tamperedPath := filepath.Join(dir, "tampered.yaml")
+	require.NoError(t, os.WriteFile(tamperedPath, []byte(sopsDoc("root")), 0o600))
+
+	t.Setenv("SOPS_AGE_KEY", identity.String())
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(dir, "missing.txt"))
+
+	ctx := context.Background()
+
+	plaintext, err := sopsProvider(ctx, secretsPath+"#db.password")
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(plaintext))
+
+	plaintext, err = sopsProvider(ctx, secretsPath+"#tokens.0")
+	require.NoError(t, err)
+	require.Equal(t, "tok-0", string(plaintext))
+
+	plaintext, err = sopsProvider(ctx, secretsPath+"#db.user_unencrypted")
+	require.NoError(t, err)
+	require.Equal(t, "admin", string(plaintext))
+
+	_, err = sopsProvider(ctx, secretsPath+"#db.nope")
+	require.ErrorContains(t, err, `key "db.nope" not found`)
+
+	_, err = sopsProvider(ctx, secretsPath+"#db")
+	require.ErrorContains(t, err, "not a scalar value")
+
+	_, err = sopsProvider(ctx, secretsPath)
+	require.ErrorContains(t, err, "missing key path")
+
+	_, err = sopsProvider(ctx, tamperedPath+"#db.password")
+	require.ErrorContains(t, err, "MAC mismatch")
+
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	t.Setenv("SOPS_AGE_KEY", other.String())
+	t.Setenv("PATH", dir)
+	_, err = sopsProvider(ctx, secretsPath+"#db.password")
+	require.ErrorIs(t, err, errSopsNoAgeKey)
+}
+
+func TestRegister(t *testing.T) {
+	Register("test-static", func(_ context.Context, key string) ([]byte, error) {
+		return []byte("value of " + key), nil
+	})
+	t.Cleanup(func() {
+		resolversMu.Lock()
+		delete(resolvers, "test-static")
+		resolversMu.Unlock()
+	})
+
+	require.Contains(t, Schemes(), "test-static")
+
+	resolver, key, err := ResolverForID("test-static://foo")
+	require.NoError(t, err)
+	plaintext, err := resolver(context.Background(), key)
+	require.NoError(t, err)
+	require.Equal(t, "value of foo", string(plaintext))
+
+	require.Panics(t, func() {
+		Register("test-static", envProvider)
+	})
+	require.Panics(t, func() {
+		Register("Bad_Scheme", envProvider)
+	})
+
+	_, _, err = ResolverForID("unknown://foo")
+	require.ErrorContains(t, err, `unsupported secret provider: "unknown"`)
+	scheme, key, err := ParseID("unknown://foo")
+	require.NoError(t, err)
+	require.Equal(t, "unknown", scheme)
+	require.Equal(t, "foo", key)
+}
+
+func indent(s, prefix string) string {
+	var buf bytes.Buffer
+	for _, line := range bytes.Split(bytes.TrimRight([]byte(s), "\n"), []byte("\n")) {
+		buf.WriteString(prefix)
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
@@ -60,7 +60,7 @@ func vaultProvider(ctx context.Context, pathWithQuery string) ([]byte, error) {
 	secretPath := keyParts[0]
 	secretField := keyParts[1]
 
-	if existing, ok := vaultCache[key]; !ok || hasExpired(existing) {
+	if existing, ok := vaultCache[key]; !ok || hasExpired(existing) || IsRefresh(ctx) {
 		// check if client is initialized
 		if vaultClient == nil {
 			err := vaultConfigureClient(ctx)
@@ -8,6 +8,7 @@ import (
 	"os"
 
 	"github.com/dagger/dagger/engine"
+	"github.com/dagger/dagger/network"
 	"github.com/moby/buildkit/session/sshforward"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -105,7 +106,11 @@ func (p *socketProxy) ForwardAgent(stream sshforward.SSH_ForwardAgentServer) err
 		return fmt.Errorf("dialer failed: %w", err)
 	}
 
-	err = sshforward.Copy(context.TODO(), conn, stream, nil)
+	if network.IsPacketNetwork(p.network) {
+		err = network.CopyDatagrams(context.TODO(), conn, stream, nil)
+	} else {
+		err = sshforward.Copy(context.TODO(), conn, stream, nil)
+	}
 	if err != nil {
 		return fmt.Errorf("copy failed: %w", err)
 	}
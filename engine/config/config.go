@@ -2,7 +2,9 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/invopop/jsonschema"
@@ -29,6 +31,9 @@ type Config struct {
 
 	// Security allows configuring various security settings for the engine.
 	Security *Security `json:"security,omitempty"`
+
+	// Cache configures how the engine caches the results of calls.
+	Cache *CacheConfig `json:"cache,omitempty"`
 }
 
 type LogLevel string
@@ -92,6 +97,54 @@ type GCConfig struct {
 	// an automatic default will be generated from the top-level disk space
 	// parameters.
 	Policies []GCPolicy `json:"policies,omitempty"`
+
+	// Rules are named policies for specific cache records, such as the cache
+	// volumes of a toolchain. Each record is governed by the first rule
+	// matching it, and records matched by a rule are left alone by Policies.
+	Rules []GCRule `json:"rules,omitempty"`
+}
+
+type GCRule struct {
+	// Name identifies the rule in the engine logs.
+	Name string `json:"name"`
+
+	// CacheVolumes matches cache volumes by key, as glob patterns (e.g.
+	// "go-build-*"). Patterns are matched against the key given to
+	// cacheVolume, as well as the namespaced key.
+	CacheVolumes []string `json:"cacheVolumes,omitempty"`
+
+	// Modules matches cache volumes mounted by the functions of these
+	// modules, by module name.
+	Modules []string `json:"modules,omitempty"`
+
+	// RecordTypes matches cache records by type (e.g. "regular",
+	// "exec.cachemount", "source.local").
+	RecordTypes []string `json:"recordTypes,omitempty"`
+
+	// KeepDuration specifies the minimum amount of time to keep records
+	// matching this rule since they were last used.
+	KeepDuration Duration `json:"keepDuration,omitempty"`
+
+	// GCSpace is the amount of space to allow for the records matching this
+	// rule.
+	GCSpace
+}
+
+// Validate returns an error if the rule doesn't match anything or has an
+// invalid pattern.
+func (rule GCRule) Validate() error {
+	if rule.Name == "" {
+		return errors.New("rule has no name")
+	}
+	if len(rule.CacheVolumes) == 0 && len(rule.Modules) == 0 && len(rule.RecordTypes) == 0 {
+		return fmt.Errorf("rule %q matches nothing: set cacheVolumes, modules or recordTypes", rule.Name)
+	}
+	for _, pattern := range rule.CacheVolumes {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("rule %q: invalid cache volume pattern %q: %w", rule.Name, pattern, err)
+		}
+	}
+	return nil
 }
 
 type GCPolicy struct {
@@ -202,6 +255,22 @@ func (duration Duration) JSONSchema() *jsonschema.Schema {
 	}
 }
 
+type CacheConfig struct {
+	// PersistFunctionCalls controls whether the results of module function
+	// calls returning a Directory, File or Container are recorded on disk, so
+	// that the calls are cached across sessions and engine restarts - it is
+	// disabled by default.
+	//
+	// Only results that can be reproduced from their ID by any client are
+	// recorded, so functions are still called when they return something
+	// derived from secrets, sockets, or the host of the function.
+	PersistFunctionCalls *bool `json:"persistFunctionCalls,omitempty"`
+
+	// KeepDuration specifies how long to keep recorded function calls since
+	// they were last used - it defaults to 7 days.
+	KeepDuration Duration `json:"keepDuration,omitempty"`
+}
+
 type Security struct {
 	// InsecureRootCapabilities controls whether the argument of the same name
 	// is permitted in Container.withExec - it is allowed by default.
@@ -0,0 +1,474 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+	"github.com/dagger/dagger/dagql/call/callpbv1"
+)
+
+// How many of the most recent calls of each field a call missing the cache is
+// compared to.
+const recentCallsPerField = 32
+
+// How many of the most recent cache misses are kept, to be explained.
+const recentCacheMisses = 10000
+
+// recentCalls indexes the most recent calls of each field in a session, and
+// its most recent cache misses. A cache miss is only explained when it's
+// queried, by comparing it to the most similar call made before it.
+type recentCalls struct {
+	mu sync.Mutex
+
+	// the most recent calls of each type and field, least recent first
+	byField  map[string][]*recentCall
+	byDigest map[string]*recentCall
+	seq      uint64
+
+	misses      map[string]*recentMiss
+	missDigests []string // least recent first
+}
+
+type recentCall struct {
+	field  string
+	id     *call.ID
+	policy dagql.CachePolicy
+	time   time.Time
+	// seq orders the calls, so that a miss is only compared to the calls
+	// made before it
+	seq uint64
+}
+
+// recentMiss is a call that missed the cache.
+type recentMiss struct {
+	call *recentCall
+	// lastCalled is when the same call was made before, if it was
+	lastCalled time.Time
+	// explained is the explanation of the miss, once it was queried
+	explained *core.EngineCacheMiss
+}
+
+func newRecentCalls() *recentCalls {
+	return &recentCalls{
+		byField:  map[string][]*recentCall{},
+		byDigest: map[string]*recentCall{},
+		misses:   map[string]*recentMiss{},
+	}
+}
+
+// record indexes a call, and keeps it to be explained if it wasn't cached.
+func (rc *recentCalls) record(id *call.ID, policy dagql.CachePolicy, cached bool) {
+	now := time.Now()
+	dgst := id.Digest().String()
+	field := idTypeField(id)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.seq++
+	c := &recentCall{field: field, id: id, policy: policy, time: now, seq: rc.seq}
+
+	prev, seen := rc.byDigest[dgst]
+	if !cached {
+		miss := &recentMiss{call: c}
+		if seen {
+			miss.lastCalled = prev.time
+		}
+		if _, ok := rc.misses[dgst]; !ok {
+			rc.missDigests = append(rc.missDigests, dgst)
+		}
+		rc.misses[dgst] = miss
+		if len(rc.missDigests) > recentCacheMisses {
+			delete(rc.misses, rc.missDigests[0])
+			rc.missDigests = rc.missDigests[1:]
+		}
+	}
+
+	if seen {
+		rc.byField[field] = slices.DeleteFunc(rc.byField[field], func(c *recentCall) bool {
+			return c == prev
+		})
+	}
+	rc.byDigest[dgst] = c
+	rc.byField[field] = append(rc.byField[field], c)
+	if calls := rc.byField[field]; len(calls) > recentCallsPerField {
+		delete(rc.byDigest, calls[0].id.Digest().String())
+		rc.byField[field] = slices.Delete(calls, 0, 1)
+	}
+}
+
+// clear drops all the recorded calls.
+func (rc *recentCalls) clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.byField = map[string][]*recentCall{}
+	rc.byDigest = map[string]*recentCall{}
+	rc.misses = map[string]*recentMiss{}
+	rc.missDigests = nil
+}
+
+// miss explains a recent cache miss of the call with the given digest.
+func (rc *recentCalls) miss(dgst string) (*core.EngineCacheMiss, bool) {
+	rc.mu.Lock()
+	miss, ok := rc.misses[dgst]
+	var candidates []*recentCall
+	if ok && miss.explained == nil {
+		for _, c := range rc.byField[miss.call.field] {
+			if c.seq < miss.call.seq {
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	rc.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	if miss.explained != nil {
+		return miss.explained, true
+	}
+
+	explained := rc.explain(miss.call.id, miss.call.policy, miss.lastCalled, candidates)
+	rc.mu.Lock()
+	miss.explained = explained
+	rc.mu.Unlock()
+	return explained, true
+}
+
+// explain explains why a call was not cached, by comparing it to the most
+// similar of the given calls of the same field. If the same call was made
+// before, at lastCalled, it's explained as having expired from the cache.
+func (rc *recentCalls) explain(id *call.ID, policy dagql.CachePolicy, lastCalled time.Time, candidates []*recentCall) *core.EngineCacheMiss {
+	dgst := id.Digest().String()
+	miss := &core.EngineCacheMiss{Call: dgst}
+
+	dag, err := id.ToProto()
+	if err != nil {
+		miss.Reason = core.CacheMissUnknown
+		miss.Description = fmt.Sprintf("failed to read the call: %s", err)
+		return miss
+	}
+	calls := recordedCalls{}
+	calls.addDAG(dag)
+	target := calls[dgst]
+	target.policy = policy
+	display := calls.display(target.call)
+
+	switch {
+	case policy != "":
+		miss.Reason = core.CacheMissCachePolicy
+		miss.ChangedCall = display
+		miss.Description = policyDescription(display, policy)
+		return miss
+	case !lastCalled.IsZero():
+		miss.Reason = core.CacheMissExpired
+		miss.ChangedCall = display
+		miss.PreviousCall = dgst
+		miss.Description = fmt.Sprintf("%s was last called at %s, and its result has expired from the cache since", display, lastCalled.Format(time.RFC3339))
+		return miss
+	}
+
+	prev := nearestPrevious(target.call, calls, candidates)
+	if prev == nil {
+		miss.Reason = core.CacheMissFirstSeen
+		miss.ChangedCall = display
+		miss.Description = fmt.Sprintf("%s was called for the first time", calls.typeField(target.call))
+		return miss
+	}
+	prevDgst := prev.id.Digest().String()
+	miss.PreviousCall = prevDgst
+
+	prevDAG, err := prev.id.ToProto()
+	if err == nil {
+		calls.addDAG(prevDAG)
+		rc.mu.Lock()
+		for d, c := range calls {
+			if recent, ok := rc.byDigest[d]; ok && d != dgst {
+				c.policy = recent.policy
+			}
+		}
+		rc.mu.Unlock()
+		if cause := calls.diff(dgst, prevDgst); cause != nil {
+			miss.Reason = cause.Reason
+			miss.Description = cause.Description
+			miss.ChangedCall = cause.ChangedCall
+			miss.Argument = cause.Argument
+			return miss
+		}
+	}
+	miss.Reason = core.CacheMissUnknown
+	miss.ChangedCall = display
+	miss.Description = fmt.Sprintf("%s differs from the previous call %s in a way that can't be explained", display, prevDgst)
+	return miss
+}
+
+// nearestPrevious returns the recent call sharing the most inputs and
+// arguments with the target, preferring the most recent one. Calls the
+// target depends on are never considered.
+func nearestPrevious(target *callpbv1.Call, targetCalls recordedCalls, candidates []*recentCall) *recentCall {
+	targetArgs := map[string]*callpbv1.Literal{}
+	for _, arg := range target.Args {
+		targetArgs[arg.Name] = arg.Value
+	}
+
+	var nearest *recentCall
+	var nearestScore int
+	for _, c := range candidates {
+		if _, ok := targetCalls[c.id.Digest().String()]; ok {
+			continue
+		}
+		var score int
+		pb := c.id.Call()
+		if pb.ReceiverDigest == target.ReceiverDigest {
+			score++
+		}
+		for _, arg := range pb.Args {
+			if lit, ok := targetArgs[arg.Name]; ok && proto.Equal(lit, arg.Value) {
+				score++
+			}
+		}
+		if nearest == nil || score > nearestScore || (score == nearestScore && c.time.After(nearest.time)) {
+			nearest = c
+			nearestScore = score
+		}
+	}
+	return nearest
+}
+
+// idTypeField returns the type and field of a call, e.g. Container.withExec.
+func idTypeField(id *call.ID) string {
+	typeName := "Query"
+	if id.Receiver() != nil {
+		typeName = id.Receiver().Type().NamedType()
+	}
+	return typeName + "." + id.Field()
+}
+
+// recordedCall is a call being compared, or one of its inputs.
+type recordedCall struct {
+	call   *callpbv1.Call
+	policy dagql.CachePolicy
+}
+
+// recordedCalls are the calls being compared and their inputs, by digest.
+type recordedCalls map[string]*recordedCall
+
+func (calls recordedCalls) addDAG(dag *callpbv1.DAG) {
+	for dgst, c := range dag.CallsByDigest {
+		if _, ok := calls[dgst]; !ok {
+			calls[dgst] = &recordedCall{call: c}
+		}
+	}
+}
+
+// Explain why a call of the current session, given by digest or encoded ID,
+// was not cached. Calls given by digest must be among the most recent misses.
+func (srv *Server) ExplainCacheMiss(ctx context.Context, callRef string) (*core.EngineCacheMiss, error) {
+	client, err := srv.clientFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	recent := client.daggerSession.recentCalls
+
+	var id call.ID
+	if err := id.Decode(callRef); err == nil {
+		if miss, ok := recent.miss(id.Digest().String()); ok {
+			return miss, nil
+		}
+		recent.mu.Lock()
+		candidates := slices.Clone(recent.byField[idTypeField(&id)])
+		recent.mu.Unlock()
+		return recent.explain(&id, "", time.Time{}, candidates), nil
+	}
+	miss, ok := recent.miss(callRef)
+	if !ok {
+		return nil, fmt.Errorf("no cache miss of call %s was recorded recently", callRef)
+	}
+	return miss, nil
+}
+
+// Record a call of the current session, so that it can be explained if it
+// wasn't cached.
+func (srv *Server) RecordCall(ctx context.Context, id *call.ID, policy dagql.CachePolicy, cached bool) {
+	client, err := srv.clientFromContext(ctx)
+	if err != nil {
+		return
+	}
+	client.daggerSession.recentCalls.record(id, policy, cached)
+}
+
+func policyDescription(display string, policy dagql.CachePolicy) string {
+	switch policy {
+	case dagql.CachePolicyPerClient:
+		return fmt.Sprintf("%s is cached per client, so it runs again for every new client", display)
+	case dagql.CachePolicyPerSession:
+		return fmt.Sprintf("%s is cached per session, so it runs again for every new session", display)
+	case dagql.CachePolicyPerCall:
+		return fmt.Sprintf("%s runs again every time it's called", display)
+	case dagql.CachePolicyDoNotCache:
+		return fmt.Sprintf("%s is never cached", display)
+	default:
+		return fmt.Sprintf("%s has cache policy %s", display, policy)
+	}
+}
+
+// diff returns the innermost difference between two calls that explains why
+// they have different digests, if it can be found.
+func (calls recordedCalls) diff(aDgst, bDgst string) *core.EngineCacheMiss {
+	if aDgst == bDgst {
+		return nil
+	}
+	a, aok := calls[aDgst]
+	b, bok := calls[bDgst]
+	if !aok || !bok || calls.typeField(a.call) != calls.typeField(b.call) {
+		return nil
+	}
+
+	if a.call.ReceiverDigest != b.call.ReceiverDigest {
+		if cause := calls.diff(a.call.ReceiverDigest, b.call.ReceiverDigest); cause != nil {
+			return cause
+		}
+		display := calls.display(a.call)
+		return &core.EngineCacheMiss{
+			Reason:      core.CacheMissArgumentChanged,
+			ChangedCall: display,
+			Description: fmt.Sprintf("%s was called on a different object than before", display),
+		}
+	}
+
+	aArgs := map[string]*callpbv1.Literal{}
+	for _, arg := range a.call.Args {
+		aArgs[arg.Name] = arg.Value
+	}
+	bArgs := map[string]*callpbv1.Literal{}
+	for _, arg := range b.call.Args {
+		bArgs[arg.Name] = arg.Value
+	}
+	var names []string
+	for name := range aArgs {
+		names = append(names, name)
+	}
+	for name := range bArgs {
+		if _, ok := aArgs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		aLit, bLit := aArgs[name], bArgs[name]
+		if aLit != nil && bLit != nil && proto.Equal(aLit, bLit) {
+			continue
+		}
+		aID, aIsID := aLit.GetValue().(*callpbv1.Literal_CallDigest)
+		bID, bIsID := bLit.GetValue().(*callpbv1.Literal_CallDigest)
+		if aIsID && bIsID {
+			if cause := calls.diff(aID.CallDigest, bID.CallDigest); cause != nil {
+				return cause
+			}
+		}
+		// the previous call may be another client's, so only the values of
+		// this call are shown
+		display := calls.display(a.call)
+		var desc string
+		switch {
+		case bLit == nil:
+			desc = fmt.Sprintf("argument %q of %s was set for the first time, to %s", name, display, calls.displayLiteral(aLit))
+		case aLit == nil:
+			desc = fmt.Sprintf("argument %q of %s was unset", name, display)
+		default:
+			desc = fmt.Sprintf("argument %q of %s changed to %s", name, display, calls.displayLiteral(aLit))
+		}
+		return &core.EngineCacheMiss{
+			Reason:      core.CacheMissArgumentChanged,
+			ChangedCall: display,
+			Argument:    name,
+			Description: desc,
+		}
+	}
+
+	// the calls are the same, so the digests were customized, either by the
+	// cache policy of the call or to the content it returned
+	display := calls.display(a.call)
+	if a.policy != "" {
+		return &core.EngineCacheMiss{
+			Reason:      core.CacheMissCachePolicy,
+			ChangedCall: display,
+			Description: policyDescription(display, a.policy),
+		}
+	}
+	return &core.EngineCacheMiss{
+		Reason:      core.CacheMissContentChanged,
+		ChangedCall: display,
+		Description: fmt.Sprintf("the content returned by %s changed", display),
+	}
+}
+
+// typeField returns the type and field of a call, e.g. Container.withExec.
+func (calls recordedCalls) typeField(c *callpbv1.Call) string {
+	typeName := "Query"
+	if recv, ok := calls[c.ReceiverDigest]; ok {
+		typeName = recv.call.Type.NamedType
+	} else if c.ReceiverDigest != "" {
+		typeName = "?"
+	}
+	return typeName + "." + c.Field
+}
+
+// display returns a short representation of a call, with its arguments.
+func (calls recordedCalls) display(c *callpbv1.Call) string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = arg.Name + ": " + calls.displayLiteral(arg.Value)
+	}
+	return calls.typeField(c) + "(" + strings.Join(args, ", ") + ")"
+}
+
+const maxLiteralDisplayLen = 80
+
+func (calls recordedCalls) displayLiteral(lit *callpbv1.Literal) string {
+	var s string
+	switch v := lit.GetValue().(type) {
+	case *callpbv1.Literal_CallDigest:
+		if rc, ok := calls[v.CallDigest]; ok {
+			s = calls.typeField(rc.call) + "(...)"
+		} else {
+			s = v.CallDigest
+		}
+	case *callpbv1.Literal_Null:
+		s = "null"
+	case *callpbv1.Literal_Bool:
+		s = strconv.FormatBool(v.Bool)
+	case *callpbv1.Literal_Enum:
+		s = v.Enum
+	case *callpbv1.Literal_Int:
+		s = strconv.FormatInt(v.Int, 10)
+	case *callpbv1.Literal_Float:
+		s = strconv.FormatFloat(v.Float, 'g', -1, 64)
+	case *callpbv1.Literal_String_:
+		s = strconv.Quote(v.String_)
+	case *callpbv1.Literal_List:
+		elems := make([]string, len(v.List.Values))
+		for i, elem := range v.List.Values {
+			elems[i] = calls.displayLiteral(elem)
+		}
+		s = "[" + strings.Join(elems, ", ") + "]"
+	case *callpbv1.Literal_Object:
+		fields := make([]string, len(v.Object.Values))
+		for i, field := range v.Object.Values {
+			fields[i] = field.Name + ": " + calls.displayLiteral(field.Value)
+		}
+		s = "{" + strings.Join(fields, ", ") + "}"
+	}
+	if len(s) > maxLiteralDisplayLen {
+		s = s[:maxLiteralDisplayLen] + "..."
+	}
+	return s
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/dagql"
+	"github.com/dagger/dagger/dagql/call"
+)
+
+func TestRecentCallsCacheMiss(t *testing.T) {
+	ctrType := ast.NonNullNamedType("Container", nil)
+	dirType := ast.NonNullNamedType("Directory", nil)
+	strArg := func(name, val string) *call.Argument {
+		return call.NewArgument(name, call.NewLiteralString(val), false)
+	}
+	listArg := func(name string, vals ...string) *call.Argument {
+		lits := make([]call.Literal, len(vals))
+		for i, val := range vals {
+			lits[i] = call.NewLiteralString(val)
+		}
+		return call.NewArgument(name, call.NewLiteralList(lits...), false)
+	}
+	idArg := func(name string, id *call.ID) *call.Argument {
+		return call.NewArgument(name, call.NewLiteralID(id), false)
+	}
+
+	alpine := call.New().
+		Append(ctrType, "container", "", nil, 0, "").
+		Append(ctrType, "from", "", nil, 0, "", strArg("address", "alpine"))
+	withExec := func(ctr *call.ID, args ...string) *call.ID {
+		return ctr.Append(ctrType, "withExec", "", nil, 0, "", listArg("args", args...))
+	}
+	// recordMiss records a call that missed the cache, and explains it
+	recordMiss := func(t *testing.T, calls *recentCalls, id *call.ID, policy dagql.CachePolicy) *core.EngineCacheMiss {
+		calls.record(id, policy, false)
+		miss, ok := calls.miss(id.Digest().String())
+		require.True(t, ok)
+		return miss
+	}
+
+	t.Run("first seen", func(t *testing.T) {
+		calls := newRecentCalls()
+		miss := recordMiss(t, calls, withExec(alpine, "echo", "hi"), "")
+		require.Equal(t, core.CacheMissFirstSeen, miss.Reason)
+		require.Equal(t, `Container.withExec(args: ["echo", "hi"])`, miss.ChangedCall)
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		calls := newRecentCalls()
+		id := withExec(alpine, "echo", "hi")
+		calls.record(id, "", true)
+		_, ok := calls.miss(id.Digest().String())
+		require.False(t, ok)
+	})
+
+	t.Run("argument changed", func(t *testing.T) {
+		calls := newRecentCalls()
+		prev := withExec(alpine, "echo", "hi")
+		calls.record(prev, "", false)
+		miss := recordMiss(t, calls, withExec(alpine, "echo", "bye"), "")
+		require.Equal(t, core.CacheMissArgumentChanged, miss.Reason)
+		require.Equal(t, prev.Digest().String(), miss.PreviousCall)
+		require.Equal(t, "args", miss.Argument)
+		require.Contains(t, miss.Description, `changed to ["echo", "bye"]`)
+		// the previous call may be another client's
+		require.NotContains(t, miss.Description, "hi")
+	})
+
+	t.Run("nested argument changed", func(t *testing.T) {
+		calls := newRecentCalls()
+		calls.record(withExec(withExec(alpine, "apk", "add", "git"), "make"), "", false)
+		miss := recordMiss(t, calls, withExec(withExec(alpine, "apk", "add", "curl"), "make"), "")
+		require.Equal(t, core.CacheMissArgumentChanged, miss.Reason)
+		require.Equal(t, `Container.withExec(args: ["apk", "add", "curl"])`, miss.ChangedCall)
+	})
+
+	t.Run("not compared to its inputs", func(t *testing.T) {
+		calls := newRecentCalls()
+		apk := withExec(alpine, "apk", "add", "git")
+		calls.record(apk, "", false)
+		miss := recordMiss(t, calls, withExec(apk, "make"), "")
+		require.Equal(t, core.CacheMissFirstSeen, miss.Reason)
+	})
+
+	t.Run("content changed", func(t *testing.T) {
+		host := call.New().Append(ast.NonNullNamedType("Host", nil), "host", "", nil, 0, "")
+		// host directories are referred to by a content hash digest
+		hostDir := func(content string) *call.ID {
+			return host.Append(dirType, "directory", "", nil, 0, dagql.HashFrom(content), strArg("path", "."))
+		}
+		build := func(src *call.ID) *call.ID {
+			return withExec(alpine.Append(ctrType, "withDirectory", "", nil, 0, "", strArg("path", "/src"), idArg("source", src)), "make")
+		}
+
+		calls := newRecentCalls()
+		calls.record(build(hostDir("v1")), "", false)
+		miss := recordMiss(t, calls, build(hostDir("v2")), "")
+		require.Equal(t, core.CacheMissContentChanged, miss.Reason)
+		require.Equal(t, `Host.directory(path: ".")`, miss.ChangedCall)
+	})
+
+	t.Run("cache policy", func(t *testing.T) {
+		secret := func(nonce string) *call.ID {
+			return call.New().Append(ast.NonNullNamedType("Secret", nil), "setSecret", "", nil, 0, dagql.HashFrom(nonce), strArg("name", "token"))
+		}
+		withSecret := func(secret *call.ID) *call.ID {
+			return withExec(alpine.Append(ctrType, "withSecretVariable", "", nil, 0, "", strArg("name", "TOKEN"), idArg("secret", secret)), "deploy")
+		}
+
+		calls := newRecentCalls()
+		calls.record(secret("a"), dagql.CachePolicyPerCall, false)
+		calls.record(withSecret(secret("a")), "", false)
+		miss := recordMiss(t, calls, secret("b"), dagql.CachePolicyPerCall)
+		require.Equal(t, core.CacheMissCachePolicy, miss.Reason)
+		require.Contains(t, miss.Description, "runs again every time")
+
+		miss = recordMiss(t, calls, withSecret(secret("b")), "")
+		require.Equal(t, core.CacheMissCachePolicy, miss.Reason)
+		require.Equal(t, `Query.setSecret(name: "token")`, miss.ChangedCall)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		calls := newRecentCalls()
+		id := withExec(alpine, "echo", "hi")
+		calls.record(id, "", false)
+		miss := recordMiss(t, calls, id, "")
+		require.Equal(t, core.CacheMissExpired, miss.Reason)
+		require.Equal(t, id.Digest().String(), miss.PreviousCall)
+
+		// the most recent misses can be explained again
+		recorded, ok := calls.miss(id.Digest().String())
+		require.True(t, ok)
+		require.Equal(t, miss, recorded)
+	})
+
+	t.Run("compared to calls before it", func(t *testing.T) {
+		calls := newRecentCalls()
+		prev := withExec(alpine, "echo", "hi")
+		id := withExec(alpine, "echo", "bye")
+		calls.record(prev, "", false)
+		calls.record(id, "", false)
+		calls.record(withExec(alpine, "echo", "bye", "again"), "", false)
+		miss, ok := calls.miss(id.Digest().String())
+		require.True(t, ok)
+		require.Equal(t, prev.Digest().String(), miss.PreviousCall)
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		calls := newRecentCalls()
+		id := withExec(alpine, "echo", "hi")
+		calls.record(id, "", false)
+		calls.clear()
+		_, ok := calls.miss(id.Digest().String())
+		require.False(t, ok)
+		require.Empty(t, calls.byDigest)
+	})
+
+	t.Run("bounded", func(t *testing.T) {
+		calls := newRecentCalls()
+		for i := range recentCallsPerField + 1 {
+			calls.record(withExec(alpine, "echo", strconv.Itoa(i)), "", false)
+		}
+		require.Len(t, calls.byField["Container.withExec"], recentCallsPerField)
+		require.Len(t, calls.byDigest, recentCallsPerField)
+		_, ok := calls.byDigest[withExec(alpine, "echo", "0").Digest().String()]
+		require.False(t, ok)
+	})
+}
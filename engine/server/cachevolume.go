@@ -0,0 +1,119 @@
+package server
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/snapshot"
+	solverpb "github.com/moby/buildkit/solver/pb"
+
+	"github.com/dagger/dagger/core"
+	"github.com/dagger/dagger/engine/buildkit"
+)
+
+// Return the cache volumes in the local cache, aggregating the cache mount
+// records of each volume.
+func (srv *Server) EngineCacheVolumes(ctx context.Context) ([]*core.EngineCacheVolume, error) {
+	vols, err := buildkit.ListCacheVolumes(ctx, srv.workerCache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cache volumes: %w", err)
+	}
+	if len(vols) == 0 {
+		return nil, nil
+	}
+	du, err := srv.baseWorker.DiskUsage(ctx, bkclient.DiskUsageInfo{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk usage from worker: %w", err)
+	}
+
+	byID := map[string]*core.EngineCacheVolume{}
+	for _, r := range du {
+		vol, ok := vols[r.ID]
+		if !ok {
+			continue
+		}
+		ent := engineCacheEntry(r)
+		cv, ok := byID[vol.ID]
+		if !ok {
+			cv = &core.EngineCacheVolume{Keys: vol.Keys}
+			byID[vol.ID] = cv
+		}
+		cv.EntryIDs = append(cv.EntryIDs, ent.EntryID)
+		cv.DiskSpaceBytes += ent.DiskSpaceBytes
+		cv.ActivelyUsed = cv.ActivelyUsed || ent.ActivelyUsed
+		if cv.SharingMode == "" || ent.MostRecentUseTimeUnixNano > cv.MostRecentUseTimeUnixNano {
+			cv.MostRecentUseTimeUnixNano = ent.MostRecentUseTimeUnixNano
+			cv.SharingMode = core.CacheSharingMode(vol.SharingMode)
+		}
+	}
+
+	list := make([]*core.EngineCacheVolume, 0, len(byID))
+	for _, cv := range byID {
+		list = append(list, cv)
+	}
+	slices.SortFunc(list, func(a, b *core.EngineCacheVolume) int {
+		return cmp.Compare(strings.Join(a.Keys, "\x00"), strings.Join(b.Keys, "\x00"))
+	})
+	return list, nil
+}
+
+// Delete the cache mount records of a cache volume. Records in use are removed
+// once released; until then, new mounts of the volume start empty.
+func (srv *Server) DeleteCacheVolume(ctx context.Context, cache *core.CacheVolume) error {
+	id := cache.Sum()
+	// buildkit searches the records initialized from a source directory
+	// separately from the others
+	for _, nested := range []bool{false, true} {
+		if err := srv.baseWorker.PruneCacheMounts(ctx, map[string]bool{id: nested}); err != nil {
+			return fmt.Errorf("failed to delete cache volume: %w", err)
+		}
+	}
+	return nil
+}
+
+// Mount a cache volume in shared mode, creating it if it doesn't exist. Only
+// the contents of the volume not initialized from a source directory are
+// mounted.
+func (srv *Server) MountCacheVolume(ctx context.Context, cache *core.CacheVolume, f func(string) error) error {
+	id := cache.Sum()
+	ref, err := srv.workerMountManager.MountableCache(ctx, &solverpb.Mount{
+		CacheOpt: &solverpb.CacheOpt{
+			ID:      id,
+			Sharing: solverpb.CacheSharingOpt_SHARED,
+		},
+	}, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get cache volume: %w", err)
+	}
+	defer ref.Release(context.WithoutCancel(ctx))
+
+	vol := buildkit.CacheVolumeMetadata{
+		ID:          id,
+		Keys:        cache.Keys,
+		SharingMode: string(core.CacheSharingModeShared),
+	}
+	if prev, ok := buildkit.GetCacheVolumeMetadata(ref); ok {
+		// keep describing how containers use the volume
+		vol.SharingMode = prev.SharingMode
+		vol.Module = prev.Module
+	}
+	if err := buildkit.SetCacheVolumeMetadata(ref, vol); err != nil {
+		return fmt.Errorf("failed to label cache volume: %w", err)
+	}
+
+	mountable, err := ref.Mount(ctx, false, nil)
+	if err != nil {
+		return fmt.Errorf("failed to mount cache volume: %w", err)
+	}
+	lm := snapshot.LocalMounter(mountable)
+	defer lm.Unmount()
+	dir, err := lm.Mount()
+	if err != nil {
+		return fmt.Errorf("failed to mount cache volume: %w", err)
+	}
+	return f(dir)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/dagger/dagger/engine/config"
 	bkclient "github.com/moby/buildkit/client"
@@ -27,27 +28,49 @@ func (srv *Server) EngineLocalCacheEntries(ctx context.Context) (*core.EngineCac
 		return nil, fmt.Errorf("failed to get disk usage from worker: %w", err)
 	}
 
-	set := &core.EngineCacheEntrySet{}
+	var entries []*core.EngineCacheEntry
 	for _, r := range du {
-		cacheEnt := &core.EngineCacheEntry{
-			Description:         r.Description,
-			DiskSpaceBytes:      int(r.Size),
-			ActivelyUsed:        r.InUse,
-			CreatedTimeUnixNano: int(r.CreatedAt.UnixNano()),
+		entries = append(entries, engineCacheEntry(r))
+	}
+	return engineCacheEntrySet(entries), nil
+}
+
+// Prune the releasable entries of the local cache selected by the options.
+func (srv *Server) PruneEngineLocalCacheEntries(ctx context.Context, opts core.EngineCachePruneOpts) (*core.EngineCacheEntrySet, error) {
+	pruneInfo := bkclient.PruneInfo{All: true}
+
+	// buildkit can only filter on a few string fields, so select the entries
+	// here and prune them by ID
+	var selected map[string]*core.EngineCacheEntry
+	if opts.Filtered() || opts.DryRun {
+		du, err := srv.baseWorker.DiskUsage(ctx, bkclient.DiskUsageInfo{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get disk usage from worker: %w", err)
+		}
+		now := time.Now()
+		selected = map[string]*core.EngineCacheEntry{}
+		var selectedList []*core.EngineCacheEntry
+		for _, r := range du {
+			if r.InUse {
+				continue
+			}
+			ent := engineCacheEntry(r)
+			if !opts.Matches(ent, now) {
+				continue
+			}
+			selected[r.ID] = ent
+			selectedList = append(selectedList, ent)
+			pruneInfo.Filter = append(pruneInfo.Filter, "id=="+r.ID)
+		}
+		if opts.DryRun {
+			return engineCacheEntrySet(selectedList), nil
 		}
-		if r.LastUsedAt != nil {
-			cacheEnt.MostRecentUseTimeUnixNano = int(r.LastUsedAt.UnixNano())
+		if len(selected) == 0 {
+			// pruning with an empty filter would prune everything
+			return &core.EngineCacheEntrySet{}, nil
 		}
-		set.EntriesList = append(set.EntriesList, cacheEnt)
-		set.DiskSpaceBytes += int(r.Size)
 	}
-	set.EntryCount = len(set.EntriesList)
 
-	return set, nil
-}
-
-// Prune everything that is releasable in the local cache. No support for filtering yet.
-func (srv *Server) PruneEngineLocalCacheEntries(ctx context.Context) (*core.EngineCacheEntrySet, error) {
 	srv.daggerSessionsMu.RLock()
 	cancelLeases := len(srv.daggerSessions) == 0
 	srv.daggerSessionsMu.RUnlock()
@@ -66,7 +89,7 @@ func (srv *Server) PruneEngineLocalCacheEntries(ctx context.Context) (*core.Engi
 		}
 	}()
 
-	err := srv.baseWorker.Prune(ctx, ch, bkclient.PruneInfo{All: true})
+	err := srv.baseWorker.Prune(ctx, ch, pruneInfo)
 	if err != nil {
 		return nil, fmt.Errorf("worker failed to prune local cache: %w", err)
 	}
@@ -85,34 +108,62 @@ func (srv *Server) PruneEngineLocalCacheEntries(ctx context.Context) (*core.Engi
 		}
 	}
 
-	set := &core.EngineCacheEntrySet{}
+	entries := make([]*core.EngineCacheEntry, 0, len(pruned))
 	for _, r := range pruned {
-		// buildkit's Prune doesn't set RecordType currently, so can't include kind here
-		ent := &core.EngineCacheEntry{
-			Description:         r.Description,
-			DiskSpaceBytes:      int(r.Size),
-			CreatedTimeUnixNano: int(r.CreatedAt.UnixNano()),
-			ActivelyUsed:        r.InUse,
-		}
-		if r.LastUsedAt != nil {
-			ent.MostRecentUseTimeUnixNano = int(r.LastUsedAt.UnixNano())
+		if ent, ok := selected[r.ID]; ok {
+			// buildkit's Prune doesn't set RecordType currently, so prefer the
+			// entry from the disk usage
+			entries = append(entries, ent)
+			continue
 		}
-		set.EntriesList = append(set.EntriesList, ent)
-		set.DiskSpaceBytes += int(r.Size)
+		entries = append(entries, engineCacheEntry(&r))
 	}
-	set.EntryCount = len(set.EntriesList)
+	return engineCacheEntrySet(entries), nil
+}
 
-	return set, nil
+func engineCacheEntry(r *bkclient.UsageInfo) *core.EngineCacheEntry {
+	ent := &core.EngineCacheEntry{
+		EntryID:             r.ID,
+		RecordType:          string(r.RecordType),
+		Description:         r.Description,
+		DiskSpaceBytes:      int(r.Size),
+		CreatedTimeUnixNano: int(r.CreatedAt.UnixNano()),
+		ActivelyUsed:        r.InUse,
+	}
+	if r.LastUsedAt != nil {
+		ent.MostRecentUseTimeUnixNano = int(r.LastUsedAt.UnixNano())
+	}
+	return ent
+}
+
+func engineCacheEntrySet(entries []*core.EngineCacheEntry) *core.EngineCacheEntrySet {
+	set := &core.EngineCacheEntrySet{EntriesList: entries}
+	for _, ent := range entries {
+		set.DiskSpaceBytes += ent.DiskSpaceBytes
+	}
+	set.EntryCount = len(entries)
+	return set
 }
 
 func (srv *Server) gc() {
 	srv.gcmu.Lock()
 	defer srv.gcmu.Unlock()
 
+	// recorded function calls are pruned first, so that the snapshots they
+	// keep can be pruned below
+	if srv.callIndex != nil {
+		n, err := srv.callIndex.Prune(time.Now().Add(-srv.callIndexKeepDuration))
+		if err != nil {
+			bklog.G(context.TODO()).Errorf("call index gc error: %+v", err)
+		} else if n > 0 {
+			bklog.G(context.TODO()).Debugf("gc cleaned up %d recorded function calls", n)
+		}
+	}
+
+	var size, selectedSize int64
 	ch := make(chan bkclient.UsageInfo)
 	eg, ctx := errgroup.WithContext(context.TODO())
 
-	var size int64
 	eg.Go(func() error {
 		for ui := range ch {
 			size += ui.Size
@@ -122,6 +173,13 @@ func (srv *Server) gc() {
 
 	eg.Go(func() error {
 		defer close(ch)
+		if len(srv.gcRules) > 0 || srv.callIndex != nil {
+			// the global policies are applied by gcByID, to the records
+			// not governed by a rule or kept by the call index
+			var err error
+			selectedSize, err = srv.gcByID(ctx)
+			return err
+		}
 		if policy := srv.baseWorker.GCPolicy(); len(policy) > 0 {
 			return srv.baseWorker.Prune(ctx, ch, policy...)
 		}
@@ -132,10 +190,12 @@ func (srv *Server) gc() {
 	if err != nil {
 		bklog.G(ctx).Errorf("gc error: %+v", err)
 	}
+	size += selectedSize
 	if size > 0 {
 		bklog.G(ctx).Debugf("gc cleaned up %d bytes", size)
 		go srv.throttledReleaseUnreferenced()
 	}
+
 }
 
 func getGCPolicy(cfg config.Config, bkcfg bkconfig.GCConfig, root string) []bkclient.PruneInfo {
@@ -245,6 +305,10 @@ func convertBkSpaceFromConfig(cfg bkconfig.GCConfig) config.GCSpace {
 	return space
 }
 
+// how long to keep recorded function calls since they were last used, unless
+// configured otherwise
+const defaultCallIndexKeepDuration = 7 * 24 * time.Hour
+
 const (
 	diskSpaceReservePercentage int64 = 10
 	diskSpaceReserveBytes      int64 = 10 * 1e9 // 10GB
@@ -0,0 +1,332 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/filters"
+	bkclient "github.com/moby/buildkit/client"
+	bkconfig "github.com/moby/buildkit/cmd/buildkitd/config"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/disk"
+
+	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/dagger/dagger/engine/config"
+)
+
+// gcRule is a named GC rule from the engine config, with its disk space
+// parameters resolved to bytes.
+type gcRule struct {
+	config.GCRule
+
+	keepDuration  time.Duration
+	reservedSpace int64
+	maxUsedSpace  int64
+	minFreeSpace  int64
+}
+
+func getGCRules(cfg config.Config, bkcfg bkconfig.GCConfig, root string) ([]gcRule, error) {
+	if cfg.GC.Enabled != nil && !*cfg.GC.Enabled {
+		return nil, nil
+	}
+	if bkcfg.GC != nil && !*bkcfg.GC {
+		return nil, nil
+	}
+
+	dstat, _ := disk.GetDiskStat(root)
+
+	rules := make([]gcRule, 0, len(cfg.GC.Rules))
+	for _, rule := range cfg.GC.Rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid gc rule: %w", err)
+		}
+		rules = append(rules, gcRule{
+			GCRule:        rule,
+			keepDuration:  rule.KeepDuration.Duration,
+			reservedSpace: rule.ReservedSpace.AsBytes(dstat),
+			maxUsedSpace:  rule.MaxUsedSpace.AsBytes(dstat),
+			minFreeSpace:  rule.MinFreeSpace.AsBytes(dstat),
+		})
+	}
+	return rules, nil
+}
+
+// matches returns whether the rule matches a cache record, given the cache
+// volume it belongs to, if any.
+func (rule gcRule) matches(r *bkclient.UsageInfo, vol *buildkit.CacheVolumeMetadata) bool {
+	if len(rule.RecordTypes) > 0 && !slices.Contains(rule.RecordTypes, string(r.RecordType)) {
+		return false
+	}
+	if len(rule.CacheVolumes) > 0 && (vol == nil || !slices.ContainsFunc(vol.Keys, rule.matchesCacheVolumeKey)) {
+		return false
+	}
+	if len(rule.Modules) > 0 && (vol == nil || !slices.Contains(rule.Modules, vol.Module)) {
+		return false
+	}
+	return true
+}
+
+func (rule gcRule) matchesCacheVolumeKey(key string) bool {
+	for _, pattern := range rule.CacheVolumes {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, unnamespacedCacheVolumeKey(key)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unnamespacedCacheVolumeKey returns a cache volume key without the namespace
+// of the client or module it was created by.
+func unnamespacedCacheVolumeKey(key string) string {
+	if rest, ok := strings.CutPrefix(key, "mainClient:"); ok {
+		return rest
+	}
+	if strings.HasPrefix(key, "mod(") {
+		if _, rest, ok := strings.Cut(key, "):"); ok {
+			return rest
+		}
+	}
+	return key
+}
+
+// prunable returns the records governed by the rule that should be pruned,
+// least recently used first, given the available disk space.
+func (rule gcRule) prunable(records []*bkclient.UsageInfo, available int64, now time.Time) []*bkclient.UsageInfo {
+	var total int64
+	for _, r := range records {
+		total += r.Size
+	}
+	return rule.selectPrunable(records, total, available, now)
+}
+
+// selectPrunable returns the candidates that should be pruned, least recently
+// used first, given the total size of the records counted against the space
+// budget of the rule and the available disk space.
+func (rule gcRule) selectPrunable(candidates []*bkclient.UsageInfo, total, available int64, now time.Time) []*bkclient.UsageInfo {
+	candidates = slices.DeleteFunc(slices.Clone(candidates), func(r *bkclient.UsageInfo) bool {
+		if r.InUse || r.Shared {
+			return true
+		}
+		return rule.keepDuration > 0 && lastUsedAt(r).After(now.Add(-rule.keepDuration))
+	})
+	slices.SortStableFunc(candidates, func(a, b *bkclient.UsageInfo) int {
+		return lastUsedAt(a).Compare(lastUsedAt(b))
+	})
+
+	// without a space budget, everything older than the keep duration goes
+	budgeted := rule.maxUsedSpace > 0 || rule.minFreeSpace > 0
+
+	var pruned []*bkclient.UsageInfo
+	for _, r := range candidates {
+		if budgeted {
+			overMax := rule.maxUsedSpace > 0 && total > rule.maxUsedSpace
+			underFree := rule.minFreeSpace > 0 && available < rule.minFreeSpace
+			if !overMax && !underFree {
+				break
+			}
+		}
+		if total-r.Size < rule.reservedSpace {
+			continue
+		}
+		pruned = append(pruned, r)
+		total -= r.Size
+		available += r.Size
+	}
+	return pruned
+}
+
+// policyRule returns a rule applying a global GC policy, so that the policy
+// can be applied to the records not governed by any rule.
+func policyRule(policy bkclient.PruneInfo) (gcRule, filters.Filter, error) {
+	filter, err := filters.ParseAll(policy.Filter...)
+	if err != nil {
+		return gcRule{}, nil, fmt.Errorf("invalid gc policy filters %v: %w", policy.Filter, err)
+	}
+	return gcRule{
+		keepDuration:  policy.KeepDuration,
+		reservedSpace: policy.ReservedSpace,
+		maxUsedSpace:  policy.MaxUsedSpace,
+		minFreeSpace:  policy.MinFreeSpace,
+	}, filter, nil
+}
+
+// policyPrunable returns the records that a global GC policy should prune,
+// given the records not governed by any rule, as buildkit would select them
+// if there were no other records.
+func policyPrunable(policy bkclient.PruneInfo, records []*bkclient.UsageInfo, available int64, now time.Time) ([]*bkclient.UsageInfo, error) {
+	rule, filter, err := policyRule(policy)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	var candidates []*bkclient.UsageInfo
+	for _, r := range records {
+		if !r.Shared {
+			total += r.Size
+		}
+		if !policy.All && (r.RecordType == bkclient.UsageRecordTypeInternal || r.RecordType == bkclient.UsageRecordTypeFrontend) {
+			continue
+		}
+		if !filter.Match(adaptUsageInfo(r)) {
+			continue
+		}
+		candidates = append(candidates, r)
+	}
+	return rule.selectPrunable(candidates, total, available, now), nil
+}
+
+// adaptUsageInfo matches records with the fields buildkit supports in prune
+// filters.
+func adaptUsageInfo(r *bkclient.UsageInfo) filters.Adaptor {
+	return filters.AdapterFunc(func(fieldpath []string) (string, bool) {
+		if len(fieldpath) == 0 {
+			return "", false
+		}
+		switch fieldpath[0] {
+		case "id":
+			return r.ID, r.ID != ""
+		case "parents":
+			return strings.Join(r.Parents, ";"), len(r.Parents) > 0
+		case "description":
+			return r.Description, r.Description != ""
+		case "inuse":
+			return "", r.InUse
+		case "mutable":
+			return "", r.Mutable
+		case "immutable":
+			return "", !r.Mutable
+		case "type":
+			return string(r.RecordType), r.RecordType != ""
+		case "shared":
+			return "", r.Shared
+		case "private":
+			return "", !r.Shared
+		}
+		return "", false
+	})
+}
+
+func lastUsedAt(r *bkclient.UsageInfo) time.Time {
+	if r.LastUsedAt != nil {
+		return *r.LastUsedAt
+	}
+	return r.CreatedAt
+}
+
+// gcByID prunes the records governed by the GC rules, then applies the global
+// GC policies to the other records, returning the bytes pruned. The records
+// backing the results recorded in the call index are kept, like records in
+// use.
+//
+// buildkit counts every record against the disk space parameters of a
+// policy, so the records to prune are selected here and pruned by ID, the
+// same way as for the rules.
+func (srv *Server) gcByID(ctx context.Context) (int64, error) {
+	du, err := srv.baseWorker.DiskUsage(ctx, bkclient.DiskUsageInfo{})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get disk usage from worker: %w", err)
+	}
+	vols, err := buildkit.ListCacheVolumes(ctx, srv.workerCache)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list cache volumes: %w", err)
+	}
+	if srv.callIndex != nil {
+		kept, err := srv.callIndex.Records()
+		if err != nil {
+			return 0, err
+		}
+		for _, r := range du {
+			if kept[r.ID] {
+				r.InUse = true
+			}
+		}
+	}
+
+	var ungoverned []*bkclient.UsageInfo
+	byRule := make([][]*bkclient.UsageInfo, len(srv.gcRules))
+	for _, r := range du {
+		if r.RecordType == bkclient.UsageRecordTypeInternal || r.RecordType == bkclient.UsageRecordTypeFrontend {
+			ungoverned = append(ungoverned, r)
+			continue
+		}
+		var vol *buildkit.CacheVolumeMetadata
+		if v, ok := vols[r.ID]; ok {
+			vol = &v
+		}
+		governed := false
+		for i, rule := range srv.gcRules {
+			if rule.matches(r, vol) {
+				byRule[i] = append(byRule[i], r)
+				governed = true
+				break
+			}
+		}
+		if !governed {
+			ungoverned = append(ungoverned, r)
+		}
+	}
+
+	dstat, _ := disk.GetDiskStat(srv.rootDir)
+	available := dstat.Available
+	now := time.Now()
+	var filter []string
+	for i, rule := range srv.gcRules {
+		pruned := rule.prunable(byRule[i], available, now)
+		if len(pruned) == 0 {
+			continue
+		}
+		bklog.G(ctx).Debugf("gc rule %q pruning %d records", rule.Name, len(pruned))
+		for _, r := range pruned {
+			filter = append(filter, "id=="+r.ID)
+			available += r.Size
+		}
+	}
+
+	// like buildkit, apply each policy to what the previous ones left
+	for _, policy := range srv.baseWorker.GCPolicy() {
+		pruned, err := policyPrunable(policy, ungoverned, available, now)
+		if err != nil {
+			return 0, err
+		}
+		prunedIDs := make(map[string]bool, len(pruned))
+		for _, r := range pruned {
+			filter = append(filter, "id=="+r.ID)
+			available += r.Size
+			prunedIDs[r.ID] = true
+		}
+		ungoverned = slices.DeleteFunc(ungoverned, func(r *bkclient.UsageInfo) bool {
+			return prunedIDs[r.ID]
+		})
+	}
+
+	if len(filter) == 0 {
+		// pruning with an empty filter would prune everything
+		return 0, nil
+	}
+
+	wg := &sync.WaitGroup{}
+	ch := make(chan bkclient.UsageInfo, 32)
+	var size int64
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for r := range ch {
+			size += r.Size
+		}
+	}()
+	err = srv.baseWorker.Prune(ctx, ch, bkclient.PruneInfo{All: true, Filter: filter})
+	close(ch)
+	wg.Wait()
+	if err != nil {
+		return size, fmt.Errorf("failed to prune selected records: %w", err)
+	}
+	return size, nil
+}
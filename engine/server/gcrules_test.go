@@ -0,0 +1,151 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	bkclient "github.com/moby/buildkit/client"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dagger/dagger/engine/buildkit"
+	"github.com/dagger/dagger/engine/config"
+)
+
+func TestGCRuleMatches(t *testing.T) {
+	toolchain := gcRule{GCRule: config.GCRule{
+		Name:         "toolchains",
+		CacheVolumes: []string{"go-*"},
+	}}
+	ciModule := gcRule{GCRule: config.GCRule{
+		Name:        "ci",
+		Modules:     []string{"ci"},
+		RecordTypes: []string{string(bkclient.UsageRecordTypeCacheMount)},
+	}}
+	snapshots := gcRule{GCRule: config.GCRule{
+		Name:        "snapshots",
+		RecordTypes: []string{string(bkclient.UsageRecordTypeRegular)},
+	}}
+
+	cacheMount := &bkclient.UsageInfo{RecordType: bkclient.UsageRecordTypeCacheMount}
+	regular := &bkclient.UsageInfo{RecordType: bkclient.UsageRecordTypeRegular}
+
+	for _, tc := range []struct {
+		name   string
+		rule   gcRule
+		record *bkclient.UsageInfo
+		vol    *buildkit.CacheVolumeMetadata
+		match  bool
+	}{
+		{"main client key", toolchain, cacheMount, &buildkit.CacheVolumeMetadata{Keys: []string{"mainClient:go-mod"}}, true},
+		{"module key", toolchain, cacheMount, &buildkit.CacheVolumeMetadata{Keys: []string{"mod(ci./ci):go-build"}}, true},
+		{"namespaced pattern", toolchain, cacheMount, &buildkit.CacheVolumeMetadata{Keys: []string{"go-mod"}}, true},
+		{"other key", toolchain, cacheMount, &buildkit.CacheVolumeMetadata{Keys: []string{"mainClient:node_modules"}}, false},
+		{"not a volume", toolchain, cacheMount, nil, false},
+		{"module", ciModule, cacheMount, &buildkit.CacheVolumeMetadata{Module: "ci"}, true},
+		{"other module", ciModule, cacheMount, &buildkit.CacheVolumeMetadata{Module: "docs"}, false},
+		{"module wrong type", ciModule, regular, &buildkit.CacheVolumeMetadata{Module: "ci"}, false},
+		{"record type", snapshots, regular, nil, true},
+		{"other record type", snapshots, cacheMount, nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.match, tc.rule.matches(tc.record, tc.vol))
+		})
+	}
+}
+
+func TestGCRulePrunable(t *testing.T) {
+	now := time.Now()
+	record := func(id string, size int64, lastUsed time.Duration) *bkclient.UsageInfo {
+		at := now.Add(-lastUsed)
+		return &bkclient.UsageInfo{ID: id, Size: size, LastUsedAt: &at}
+	}
+	ids := func(records []*bkclient.UsageInfo) []string {
+		var ids []string
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+	records := []*bkclient.UsageInfo{
+		record("new", 100, time.Hour),
+		record("old", 100, 72*time.Hour),
+		record("oldest", 100, 96*time.Hour),
+		record("recent", 100, 24*time.Hour),
+	}
+
+	t.Run("keep duration", func(t *testing.T) {
+		rule := gcRule{keepDuration: 48 * time.Hour}
+		require.Equal(t, []string{"oldest", "old"}, ids(rule.prunable(records, 0, now)))
+	})
+
+	t.Run("max used space", func(t *testing.T) {
+		rule := gcRule{maxUsedSpace: 250}
+		require.Equal(t, []string{"oldest", "old"}, ids(rule.prunable(records, 0, now)))
+	})
+
+	t.Run("min free space", func(t *testing.T) {
+		rule := gcRule{minFreeSpace: 1000}
+		require.Equal(t, []string{"oldest"}, ids(rule.prunable(records, 900, now)))
+	})
+
+	t.Run("reserved space", func(t *testing.T) {
+		rule := gcRule{reservedSpace: 300}
+		require.Equal(t, []string{"oldest"}, ids(rule.prunable(records, 0, now)))
+	})
+
+	t.Run("in use", func(t *testing.T) {
+		inUse := record("inuse", 100, 100*time.Hour)
+		inUse.InUse = true
+		rule := gcRule{keepDuration: 48 * time.Hour}
+		require.Equal(t, []string{"oldest", "old"}, ids(rule.prunable(append([]*bkclient.UsageInfo{inUse}, records...), 0, now)))
+	})
+}
+
+func TestGCPolicyPrunable(t *testing.T) {
+	now := time.Now()
+	record := func(id string, typ bkclient.UsageRecordType, size int64, lastUsed time.Duration) *bkclient.UsageInfo {
+		at := now.Add(-lastUsed)
+		return &bkclient.UsageInfo{ID: id, RecordType: typ, Size: size, LastUsedAt: &at}
+	}
+	ids := func(records []*bkclient.UsageInfo) []string {
+		var ids []string
+		for _, r := range records {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+	// the records not governed by a rule: the space used by governed records
+	// is not counted against the policies
+	records := []*bkclient.UsageInfo{
+		record("local", bkclient.UsageRecordTypeLocalSource, 100, 72*time.Hour),
+		record("regular", bkclient.UsageRecordTypeRegular, 100, 96*time.Hour),
+		record("frontend", bkclient.UsageRecordTypeFrontend, 100, 120*time.Hour),
+		record("recent", bkclient.UsageRecordTypeRegular, 100, time.Hour),
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		pruned, err := policyPrunable(bkclient.PruneInfo{
+			Filter:       []string{"type==source.local", "type==exec.cachemount"},
+			KeepDuration: 48 * time.Hour,
+		}, records, 0, now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"local"}, ids(pruned))
+	})
+
+	t.Run("max used space", func(t *testing.T) {
+		pruned, err := policyPrunable(bkclient.PruneInfo{MaxUsedSpace: 250}, records, 0, now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"regular", "local"}, ids(pruned))
+	})
+
+	t.Run("all", func(t *testing.T) {
+		pruned, err := policyPrunable(bkclient.PruneInfo{All: true, MaxUsedSpace: 250}, records, 0, now)
+		require.NoError(t, err)
+		require.Equal(t, []string{"frontend", "regular"}, ids(pruned))
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		_, err := policyPrunable(bkclient.PruneInfo{Filter: []string{"type=="}}, records, 0, now)
+		require.Error(t, err)
+	})
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/containerd/platforms"
 	"github.com/dagger/dagger/dagql"
 	"github.com/dagger/dagger/engine/cache"
+	"github.com/dagger/dagger/engine/cache/callindex"
 	"github.com/dagger/dagger/engine/config"
 	controlapi "github.com/moby/buildkit/api/services/control"
 	apitypes "github.com/moby/buildkit/api/types"
@@ -97,6 +98,7 @@ type Server struct {
 
 	rootDir           string
 	solverCacheDBPath string
+	callIndexDBPath   string
 
 	workerRootDir         string
 	snapshotterRootDir    string
@@ -115,8 +117,10 @@ type Server struct {
 	worker                *buildkit.Worker
 	workerCacheMetaDB     *metadata.Store
 	workerCache           bkcache.Manager
+	workerMountManager    *mounts.MountManager
 	workerSourceManager   *source.Manager
 	workerDefaultGCPolicy *bkclient.PruneInfo
+	gcRules               []gcRule
 
 	bkSessionManager *bksession.Manager
 
@@ -171,7 +175,9 @@ type Server struct {
 	//
 	// dagql cache
 	//
-	baseDagqlCache cache.Cache[digest.Digest, dagql.Typed]
+	baseDagqlCache        cache.Cache[digest.Digest, dagql.Typed]
+	callIndex             *callindex.Index
+	callIndexKeepDuration time.Duration
 
 	//
 	// session+client state
@@ -229,6 +235,7 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 		return nil, err
 	}
 	srv.solverCacheDBPath = filepath.Join(srv.rootDir, "cache.db")
+	srv.callIndexDBPath = filepath.Join(srv.rootDir, "calls.db")
 
 	srv.workerRootDir = filepath.Join(srv.rootDir, "worker")
 	if err := os.MkdirAll(srv.workerRootDir, 0700); err != nil {
@@ -290,6 +297,17 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 
 	srv.registryHosts = resolver.NewRegistryConfig(bkcfg.Registries)
 
+	if cfg.Cache != nil && cfg.Cache.PersistFunctionCalls != nil && *cfg.Cache.PersistFunctionCalls {
+		srv.callIndex, err = callindex.Open(srv.callIndexDBPath)
+		if err != nil {
+			return nil, err
+		}
+		srv.callIndexKeepDuration = cfg.Cache.KeepDuration.Duration
+		if srv.callIndexKeepDuration <= 0 {
+			srv.callIndexKeepDuration = defaultCallIndexKeepDuration
+		}
+	}
+
 	if slog.Default().Enabled(ctx, slog.LevelExtraDebug) {
 		srv.buildkitLogSink = os.Stderr
 	}
@@ -425,6 +443,10 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 	srv.workerCache = srv.baseWorker.CacheMgr
 	srv.workerSourceManager = srv.baseWorker.SourceManager
 	srv.workerDefaultGCPolicy = getDefaultGCPolicy(*cfg, ociCfg.GCConfig, srv.rootDir)
+	srv.gcRules, err = getGCRules(*cfg, ociCfg.GCConfig, srv.rootDir)
+	if err != nil {
+		return nil, err
+	}
 
 	logrus.Infof("found worker %q, labels=%v, platforms=%v", workerID, baseLabels, FormatPlatforms(srv.enabledPlatforms))
 	archutil.WarnIfUnsupported(srv.enabledPlatforms)
@@ -521,11 +543,12 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 	if cacheServiceURL == "" {
 		cacheServiceURL = daggerCacheServiceURL
 	}
+	srv.workerMountManager = mounts.NewMountManager("dagger-cache", srv.workerCache, srv.bkSessionManager)
 	srv.SolverCache, err = daggercache.NewManager(ctx, daggercache.ManagerConfig{
 		KeyStore:     srv.solverCacheDB,
 		ResultStore:  bkworker.NewCacheResultStorage(baseWorkerController),
 		Worker:       srv.baseWorker,
-		MountManager: mounts.NewMountManager("dagger-cache", srv.workerCache, srv.bkSessionManager),
+		MountManager: srv.workerMountManager,
 		ServiceURL:   cacheServiceURL,
 		Token:        cacheServiceToken,
 		EngineID:     opts.Name,
@@ -574,6 +597,9 @@ func NewServer(ctx context.Context, opts *NewServerOpts) (*Server, error) {
 
 func (srv *Server) Close() error {
 	err := srv.baseWorker.Close()
+	if srv.callIndex != nil {
+		err = errors.Join(err, srv.callIndex.Close())
+	}
 
 	// note this *could* cause a panic in Session if it was still running, so
 	// the server should be shutdown first
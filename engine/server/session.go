@@ -48,6 +48,7 @@ import (
 	"github.com/dagger/dagger/engine"
 	"github.com/dagger/dagger/engine/buildkit"
 	"github.com/dagger/dagger/engine/cache/cachemanager"
+	"github.com/dagger/dagger/engine/cache/callindex"
 	"github.com/dagger/dagger/engine/server/resource"
 	"github.com/dagger/dagger/engine/slog"
 	enginetel "github.com/dagger/dagger/engine/telemetry"
@@ -91,6 +92,9 @@ type daggerSession struct {
 
 	dagqlCache *dagql.SessionCache
 
+	// the recent calls of the session, to explain its cache misses
+	recentCalls *recentCalls
+
 	interactive        bool
 	interactiveCommand []string
 
@@ -249,6 +253,7 @@ func (srv *Server) initializeDaggerSession(
 	sess.refs = map[buildkit.Reference]struct{}{}
 	sess.containers = map[bkgw.Container]struct{}{}
 	sess.dagqlCache = dagql.NewSessionCache(srv.baseDagqlCache)
+	sess.recentCalls = newRecentCalls()
 	sess.telemetryPubSub = srv.telemetryPubSub
 	sess.interactive = clientMetadata.Interactive
 	sess.interactiveCommand = clientMetadata.InteractiveCommand
@@ -392,6 +397,8 @@ func (srv *Server) removeDaggerSession(ctx context.Context, sess *daggerSession)
 	sess.refs = nil
 	sess.refsMu.Unlock()
 
+	sess.recentCalls.clear()
+
 	// cleanup analytics and telemetry
 	errs = errors.Join(errs, sess.analytics.Close())
 
@@ -1468,6 +1475,12 @@ func (srv *Server) LeaseManager() *leaseutil.Manager {
 	return srv.leaseManager
 }
 
+// The persistent index of function call results for the engine as a whole, or
+// nil if it's disabled.
+func (srv *Server) CallIndex() *callindex.Index {
+	return srv.callIndex
+}
+
 type httpError struct {
 	error
 	code int
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/dagger/dagger/engine/slog"
+	"github.com/dagger/dagger/network"
 	"github.com/moby/buildkit/util/grpcerrors"
 	"google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -39,12 +40,19 @@ func (s TunnelListenerAttachable) Listen(srv TunnelListener_ListenServer) error
 		return err
 	}
 
-	l, err := net.Listen(req.GetProtocol(), req.GetAddr())
+	l, err := network.Listen(req.GetProtocol(), req.GetAddr())
 	if err != nil {
 		return err
 	}
 	defer l.Close()
 
+	// each read of a packet connection is a whole datagram
+	readSize := 1024
+	isPacket := network.IsPacketNetwork(req.GetProtocol())
+	if isPacket {
+		readSize = network.MaxDatagramSize
+	}
+
 	err = srv.Send(&ListenResponse{
 		Addr: l.Addr().String(),
 	})
@@ -93,11 +101,26 @@ func (s TunnelListenerAttachable) Listen(srv TunnelListener_ListenServer) error
 			go func() {
 				for {
 					// Read data from the connection
-					data := make([]byte, 1024)
+					data := make([]byte, readSize)
 					n, err := conn.Read(data)
 					if err != nil {
 						if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
 							// conn closed
+							if isPacket {
+								// idle; let the other end release its conn too
+								connsL.Lock()
+								delete(conns, connID)
+								connsL.Unlock()
+								sendL.Lock()
+								err = srv.Send(&ListenResponse{
+									ConnId: connID,
+									Close:  true,
+								})
+								sendL.Unlock()
+								if err != nil {
+									slog.Warn("send close error", "error", err)
+								}
+							}
 							return
 						}
 
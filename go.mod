@@ -17,6 +17,7 @@ replace (
 )
 
 require (
+	filippo.io/age v1.2.1
 	github.com/1password/onepassword-sdk-go v0.2.1
 	github.com/99designs/gqlgen v0.17.70
 	github.com/Khan/genqlient v0.8.0
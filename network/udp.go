@@ -0,0 +1,291 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/buildkit/session/sshforward"
+	"golang.org/x/sync/errgroup"
+)
+
+// UDPIdleTimeout is how long a connection accepted from a packet listener is
+// kept open without any traffic.
+const UDPIdleTimeout = time.Minute
+
+// MaxDatagramSize is the size of the largest UDP datagram.
+const MaxDatagramSize = 64 * 1024
+
+// packetQueueSize is the number of datagrams queued for a connection before
+// new ones are dropped.
+const packetQueueSize = 64
+
+// IsPacketNetwork returns whether the network is a datagram network.
+func IsPacketNetwork(network string) bool {
+	return strings.HasPrefix(network, "udp")
+}
+
+// CopyDatagrams copies datagrams between a packet connection and a stream of
+// messages until either ends, like sshforward.Copy, except that each message
+// holds exactly one whole datagram.
+//
+// sshforward.Copy reads up to 32KiB at a time, which truncates larger
+// datagrams.
+func CopyDatagrams(ctx context.Context, conn io.ReadWriteCloser, stream sshforward.Stream, closeStream func() error) error {
+	defer conn.Close()
+	eg, ctx := errgroup.WithContext(ctx)
+
+	eg.Go(func() error {
+		// datagram connections can't be half closed, so stop reading too
+		defer conn.Close()
+		for {
+			var msg sshforward.BytesMessage
+			if err := stream.RecvMsg(&msg); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return err
+			}
+			if err := context.Cause(ctx); err != nil {
+				return err
+			}
+			if _, err := conn.Write(msg.Data); err != nil {
+				return err
+			}
+		}
+	})
+
+	eg.Go(func() error {
+		defer conn.Close()
+		buf := make([]byte, MaxDatagramSize)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+					if closeStream != nil {
+						closeStream()
+					}
+					return nil
+				}
+				return err
+			}
+			if err := context.Cause(ctx); err != nil {
+				return err
+			}
+			// the message is marshaled before SendMsg returns, so the buffer
+			// can be reused
+			if err := stream.SendMsg(&sshforward.BytesMessage{Data: buf[:n]}); err != nil {
+				return err
+			}
+		}
+	})
+
+	return eg.Wait()
+}
+
+// Listen listens on the network address like net.Listen, additionally
+// supporting UDP by accepting a connection for each remote address.
+func Listen(network, addr string) (net.Listener, error) {
+	if !IsPacketNetwork(network) {
+		return net.Listen(network, addr)
+	}
+	pc, err := net.ListenPacket(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return NewPacketListener(pc, UDPIdleTimeout), nil
+}
+
+// PacketListener adapts a packet connection, such as a UDP socket, to a
+// net.Listener accepting a connection for each remote address it receives
+// datagrams from.
+//
+// Each Read of an accepted connection returns a single datagram and each Write
+// sends a single datagram. Connections are closed after being idle for a
+// while, since datagrams have no notion of connection end.
+type PacketListener struct {
+	pc   net.PacketConn
+	idle time.Duration
+
+	conns  map[string]*packetConn
+	connsL sync.Mutex
+
+	accept    chan *packetConn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ net.Listener = (*PacketListener)(nil)
+
+// NewPacketListener returns a listener accepting connections from the
+// datagrams received by the packet connection.
+func NewPacketListener(pc net.PacketConn, idle time.Duration) *PacketListener {
+	l := &PacketListener{
+		pc:     pc,
+		idle:   idle,
+		conns:  map[string]*packetConn{},
+		accept: make(chan *packetConn),
+		closed: make(chan struct{}),
+	}
+	go l.serve()
+	return l
+}
+
+func (l *PacketListener) serve() {
+	defer l.Close()
+	buf := make([]byte, MaxDatagramSize)
+	for {
+		n, addr, err := l.pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		data := append([]byte(nil), buf[:n]...)
+
+		l.connsL.Lock()
+		conn, found := l.conns[addr.String()]
+		if !found {
+			conn = l.newConn(addr)
+			l.conns[addr.String()] = conn
+		}
+		l.connsL.Unlock()
+
+		if !found {
+			select {
+			case l.accept <- conn:
+			case <-l.closed:
+				return
+			}
+		}
+		conn.deliver(data)
+	}
+}
+
+func (l *PacketListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.accept:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *PacketListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		err = l.pc.Close()
+
+		l.connsL.Lock()
+		conns := make([]*packetConn, 0, len(l.conns))
+		for _, conn := range l.conns {
+			conns = append(conns, conn)
+		}
+		l.connsL.Unlock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+	})
+	return err
+}
+
+func (l *PacketListener) Addr() net.Addr {
+	return l.pc.LocalAddr()
+}
+
+func (l *PacketListener) newConn(remote net.Addr) *packetConn {
+	conn := &packetConn{
+		l:        l,
+		remote:   remote,
+		incoming: make(chan []byte, packetQueueSize),
+		closed:   make(chan struct{}),
+	}
+	conn.idle = time.AfterFunc(l.idle, func() { conn.Close() })
+	return conn
+}
+
+func (l *PacketListener) forget(conn *packetConn) {
+	l.connsL.Lock()
+	defer l.connsL.Unlock()
+	if l.conns[conn.remote.String()] == conn {
+		delete(l.conns, conn.remote.String())
+	}
+}
+
+// errDeadlinesUnsupported is returned when setting deadlines on connections
+// accepted from a packet listener.
+var errDeadlinesUnsupported = errors.New("deadlines are not supported on packet connections")
+
+type packetConn struct {
+	l      *PacketListener
+	remote net.Addr
+	idle   *time.Timer
+
+	incoming  chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+var _ net.Conn = (*packetConn)(nil)
+
+func (conn *packetConn) deliver(data []byte) {
+	select {
+	case conn.incoming <- data:
+		conn.idle.Reset(conn.l.idle)
+	case <-conn.closed:
+	default:
+		// the reader is falling behind; drop the datagram like a full socket
+		// buffer would
+	}
+}
+
+func (conn *packetConn) Read(b []byte) (int, error) {
+	select {
+	case data := <-conn.incoming:
+		return copy(b, data), nil
+	case <-conn.closed:
+		return 0, io.EOF
+	}
+}
+
+func (conn *packetConn) Write(b []byte) (int, error) {
+	select {
+	case <-conn.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	conn.idle.Reset(conn.l.idle)
+	return conn.l.pc.WriteTo(b, conn.remote)
+}
+
+func (conn *packetConn) Close() error {
+	conn.closeOnce.Do(func() {
+		conn.idle.Stop()
+		close(conn.closed)
+		conn.l.forget(conn)
+	})
+	return nil
+}
+
+func (conn *packetConn) LocalAddr() net.Addr {
+	return conn.l.pc.LocalAddr()
+}
+
+func (conn *packetConn) RemoteAddr() net.Addr {
+	return conn.remote
+}
+
+func (conn *packetConn) SetDeadline(time.Time) error {
+	return errDeadlinesUnsupported
+}
+
+func (conn *packetConn) SetReadDeadline(time.Time) error {
+	return errDeadlinesUnsupported
+}
+
+func (conn *packetConn) SetWriteDeadline(time.Time) error {
+	return errDeadlinesUnsupported
+}
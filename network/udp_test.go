@@ -0,0 +1,157 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/session/sshforward"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacketListener(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l := NewPacketListener(pc, 200*time.Millisecond)
+	t.Cleanup(func() { l.Close() })
+
+	dial := func() net.Conn {
+		client, err := net.Dial("udp", l.Addr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+	client1, client2 := dial(), dial()
+
+	_, err = client1.Write([]byte("ping 1"))
+	require.NoError(t, err)
+	conn1, err := l.Accept()
+	require.NoError(t, err)
+	require.Equal(t, client1.LocalAddr().String(), conn1.RemoteAddr().String())
+
+	_, err = client2.Write([]byte("ping 2"))
+	require.NoError(t, err)
+	conn2, err := l.Accept()
+	require.NoError(t, err)
+	require.Equal(t, client2.LocalAddr().String(), conn2.RemoteAddr().String())
+
+	// each read is a single datagram, from the connection's remote address
+	_, err = client1.Write([]byte("again"))
+	require.NoError(t, err)
+	buf := make([]byte, MaxDatagramSize)
+	for _, expected := range []string{"ping 1", "again"} {
+		n, err := conn1.Read(buf)
+		require.NoError(t, err)
+		require.Equal(t, expected, string(buf[:n]))
+	}
+	n, err := conn2.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping 2", string(buf[:n]))
+
+	// writes go back to the remote address
+	_, err = conn2.Write([]byte("pong 2"))
+	require.NoError(t, err)
+	require.NoError(t, client2.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, err = client2.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "pong 2", string(buf[:n]))
+
+	// idle connections are closed, and a new one is accepted on new traffic
+	_, err = conn1.Read(buf)
+	require.ErrorIs(t, err, io.EOF)
+	_, err = client1.Write([]byte("back"))
+	require.NoError(t, err)
+	conn1, err = l.Accept()
+	require.NoError(t, err)
+	n, err = conn1.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "back", string(buf[:n]))
+
+	require.NoError(t, l.Close())
+	_, err = l.Accept()
+	require.ErrorIs(t, err, net.ErrClosed)
+	_, err = conn1.Read(buf)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestListen(t *testing.T) {
+	l, err := Listen("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.IsType(t, &PacketListener{}, l)
+	require.NoError(t, l.Close())
+
+	l, err = Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	require.IsType(t, &net.TCPListener{}, l)
+	require.NoError(t, l.Close())
+}
+
+// messageStream is a stream of messages backed by channels.
+type messageStream struct {
+	recv chan []byte
+	sent chan []byte
+}
+
+func (s *messageStream) SendMsg(m any) error {
+	s.sent <- append([]byte(nil), m.(*sshforward.BytesMessage).Data...)
+	return nil
+}
+
+func (s *messageStream) RecvMsg(m any) error {
+	data, ok := <-s.recv
+	if !ok {
+		return io.EOF
+	}
+	m.(*sshforward.BytesMessage).Data = data
+	return nil
+}
+
+func TestCopyDatagrams(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { pc.Close() })
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	require.NoError(t, err)
+
+	stream := &messageStream{
+		recv: make(chan []byte),
+		sent: make(chan []byte, 1),
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- CopyDatagrams(context.Background(), conn, stream, nil)
+	}()
+
+	// larger than the 32KiB read by sshforward.Copy
+	large := bytes.Repeat([]byte("x"), 40*1024)
+
+	// each message is sent as a single datagram
+	stream.recv <- large
+	buf := make([]byte, MaxDatagramSize)
+	require.NoError(t, pc.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, addr, err := pc.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Equal(t, large, buf[:n])
+
+	// each datagram is received as a single message
+	_, err = pc.WriteTo(large, addr)
+	require.NoError(t, err)
+	select {
+	case msg := <-stream.sent:
+		require.Equal(t, large, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the datagram")
+	}
+
+	// the end of the stream closes the connection
+	close(stream.recv)
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the copy to end")
+	}
+}
@@ -162,6 +162,24 @@ func LoadCacheVolumeFromID(id dagger.CacheVolumeID) *dagger.CacheVolume {
 	return client.LoadCacheVolumeFromID(id)
 }
 
+// Load a ChangesetEntry from its ID.
+func LoadChangesetEntryFromID(id dagger.ChangesetEntryID) *dagger.ChangesetEntry {
+	client := initClient()
+	return client.LoadChangesetEntryFromID(id)
+}
+
+// Load a Changeset from its ID.
+func LoadChangesetFromID(id dagger.ChangesetID) *dagger.Changeset {
+	client := initClient()
+	return client.LoadChangesetFromID(id)
+}
+
+// Load a ComposeStack from its ID.
+func LoadComposeStackFromID(id dagger.ComposeStackID) *dagger.ComposeStack {
+	client := initClient()
+	return client.LoadComposeStackFromID(id)
+}
+
 // Load a Container from its ID.
 func LoadContainerFromID(id dagger.ContainerID) *dagger.Container {
 	client := initClient()
@@ -198,6 +216,18 @@ func LoadEngineCacheFromID(id dagger.EngineCacheID) *dagger.EngineCache {
 	return client.LoadEngineCacheFromID(id)
 }
 
+// Load a EngineCacheMiss from its ID.
+func LoadEngineCacheMissFromID(id dagger.EngineCacheMissID) *dagger.EngineCacheMiss {
+	client := initClient()
+	return client.LoadEngineCacheMissFromID(id)
+}
+
+// Load a EngineCacheVolume from its ID.
+func LoadEngineCacheVolumeFromID(id dagger.EngineCacheVolumeID) *dagger.EngineCacheVolume {
+	client := initClient()
+	return client.LoadEngineCacheVolumeFromID(id)
+}
+
 // Load a Engine from its ID.
 func LoadEngineFromID(id dagger.EngineID) *dagger.Engine {
 	client := initClient()
@@ -240,6 +270,12 @@ func LoadErrorValueFromID(id dagger.ErrorValueID) *dagger.ErrorValue {
 	return client.LoadErrorValueFromID(id)
 }
 
+// Load a ExecMetrics from its ID.
+func LoadExecMetricsFromID(id dagger.ExecMetricsID) *dagger.ExecMetrics {
+	client := initClient()
+	return client.LoadExecMetricsFromID(id)
+}
+
 // Load a FieldTypeDef from its ID.
 func LoadFieldTypeDefFromID(id dagger.FieldTypeDefID) *dagger.FieldTypeDef {
 	client := initClient()
@@ -378,6 +414,12 @@ func LoadScalarTypeDefFromID(id dagger.ScalarTypeDefID) *dagger.ScalarTypeDef {
 	return client.LoadScalarTypeDefFromID(id)
 }
 
+// Load a SearchResult from its ID.
+func LoadSearchResultFromID(id dagger.SearchResultID) *dagger.SearchResult {
+	client := initClient()
+	return client.LoadSearchResultFromID(id)
+}
+
 // Load a Secret from its ID.
 func LoadSecretFromID(id dagger.SecretID) *dagger.Secret {
 	client := initClient()
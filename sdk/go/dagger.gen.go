@@ -104,6 +104,15 @@ type BindingID string
 // The `CacheVolumeID` scalar type represents an identifier for an object of type CacheVolume.
 type CacheVolumeID string
 
+// The `ChangesetEntryID` scalar type represents an identifier for an object of type ChangesetEntry.
+type ChangesetEntryID string
+
+// The `ChangesetID` scalar type represents an identifier for an object of type Changeset.
+type ChangesetID string
+
+// The `ComposeStackID` scalar type represents an identifier for an object of type ComposeStack.
+type ComposeStackID string
+
 // The `ContainerID` scalar type represents an identifier for an object of type Container.
 type ContainerID string
 
@@ -122,6 +131,12 @@ type EngineCacheEntrySetID string
 // The `EngineCacheID` scalar type represents an identifier for an object of type EngineCache.
 type EngineCacheID string
 
+// The `EngineCacheMissID` scalar type represents an identifier for an object of type EngineCacheMiss.
+type EngineCacheMissID string
+
+// The `EngineCacheVolumeID` scalar type represents an identifier for an object of type EngineCacheVolume.
+type EngineCacheVolumeID string
+
 // The `EngineID` scalar type represents an identifier for an object of type Engine.
 type EngineID string
 
@@ -143,6 +158,9 @@ type ErrorID string
 // The `ErrorValueID` scalar type represents an identifier for an object of type ErrorValue.
 type ErrorValueID string
 
+// The `ExecMetricsID` scalar type represents an identifier for an object of type ExecMetrics.
+type ExecMetricsID string
+
 // The `FieldTypeDefID` scalar type represents an identifier for an object of type FieldTypeDef.
 type FieldTypeDefID string
 
@@ -220,6 +238,9 @@ type SDKConfigID string
 // The `ScalarTypeDefID` scalar type represents an identifier for an object of type ScalarTypeDef.
 type ScalarTypeDefID string
 
+// The `SearchResultID` scalar type represents an identifier for an object of type SearchResult.
+type SearchResultID string
+
 // The `SecretID` scalar type represents an identifier for an object of type Secret.
 type SecretID string
 
@@ -252,6 +273,33 @@ type BuildArg struct {
 	Value string `json:"value"`
 }
 
+// Resource limits enforced on a command. A limit of zero is no limit.
+type ExecLimits struct {
+	// Number of CPUs the command may use, possibly fractional (e.g., 0.5).
+	Cpus float64 `json:"cpus"`
+
+	// Memory the command may use in bytes, beyond which it is killed.
+	MemoryBytes int `json:"memoryBytes"`
+
+	// Number of processes and threads the command may run at once.
+	Pids int `json:"pids"`
+}
+
+// A policy for running a failed command again.
+type ExecRetry struct {
+	// Maximum number of times to run the command, including the first attempt.
+	Attempts int `json:"attempts"`
+
+	// Delay before the first retry as a duration string, doubled after each retry until it reaches a minute. Defaults to retrying immediately.
+	Backoff string `json:"backoff"`
+
+	// Only retry when the command exits with one of these codes.
+	RetryOnExitCodes []int `json:"retryOnExitCodes,omitempty"`
+
+	// Only retry when the standard error of the command matches this regular expression (RE2 syntax).
+	RetryOnStderrRegex string `json:"retryOnStderrRegex"`
+}
+
 // Key value object that represents a pipeline label.
 type PipelineLabel struct {
 	// Label name.
@@ -299,6 +347,33 @@ func (r *Binding) AsCacheVolume() *CacheVolume {
 	}
 }
 
+// Retrieve the binding value, as type Changeset
+func (r *Binding) AsChangeset() *Changeset {
+	q := r.query.Select("asChangeset")
+
+	return &Changeset{
+		query: q,
+	}
+}
+
+// Retrieve the binding value, as type ChangesetEntry
+func (r *Binding) AsChangesetEntry() *ChangesetEntry {
+	q := r.query.Select("asChangesetEntry")
+
+	return &ChangesetEntry{
+		query: q,
+	}
+}
+
+// Retrieve the binding value, as type ComposeStack
+func (r *Binding) AsComposeStack() *ComposeStack {
+	q := r.query.Select("asComposeStack")
+
+	return &ComposeStack{
+		query: q,
+	}
+}
+
 // Retrieve the binding value, as type Container
 func (r *Binding) AsContainer() *Container {
 	q := r.query.Select("asContainer")
@@ -317,6 +392,24 @@ func (r *Binding) AsDirectory() *Directory {
 	}
 }
 
+// Retrieve the binding value, as type EngineCacheMiss
+func (r *Binding) AsEngineCacheMiss() *EngineCacheMiss {
+	q := r.query.Select("asEngineCacheMiss")
+
+	return &EngineCacheMiss{
+		query: q,
+	}
+}
+
+// Retrieve the binding value, as type EngineCacheVolume
+func (r *Binding) AsEngineCacheVolume() *EngineCacheVolume {
+	q := r.query.Select("asEngineCacheVolume")
+
+	return &EngineCacheVolume{
+		query: q,
+	}
+}
+
 // Retrieve the binding value, as type Env
 func (r *Binding) AsEnv() *Env {
 	q := r.query.Select("asEnv")
@@ -525,7 +618,9 @@ func (r *Binding) TypeName(ctx context.Context) (string, error) {
 type CacheVolume struct {
 	query *querybuilder.Selection
 
-	id *CacheVolumeID
+	delete *Void
+	id     *CacheVolumeID
+	seed   *Void
 }
 
 func (r *CacheVolume) WithGraphQLQuery(q *querybuilder.Selection) *CacheVolume {
@@ -534,6 +629,18 @@ func (r *CacheVolume) WithGraphQLQuery(q *querybuilder.Selection) *CacheVolume {
 	}
 }
 
+// Deletes the contents of the cache volume from the local cache.
+//
+// Containers currently using the volume keep their contents until they exit; later mounts start empty.
+func (r *CacheVolume) Delete(ctx context.Context) error {
+	if r.delete != nil {
+		return nil
+	}
+	q := r.query.Select("delete")
+
+	return q.Execute(ctx)
+}
+
 // A unique identifier for this CacheVolume.
 func (r *CacheVolume) ID(ctx context.Context) (CacheVolumeID, error) {
 	if r.id != nil {
@@ -574,232 +681,620 @@ func (r *CacheVolume) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
-// An OCI-compatible container, also known as a Docker container.
-type Container struct {
-	query *querybuilder.Selection
+// Replaces the contents of the cache volume with the contents of a directory.
+//
+// The contents are used when the volume is mounted without a source directory.
+func (r *CacheVolume) Seed(ctx context.Context, source *Directory) error {
+	assertNotNil("source", source)
+	if r.seed != nil {
+		return nil
+	}
+	q := r.query.Select("seed")
+	q = q.Arg("source", source)
 
-	envVariable *string
-	exitCode    *int
-	export      *string
-	id          *ContainerID
-	imageRef    *string
-	label       *string
-	platform    *Platform
-	publish     *string
-	stderr      *string
-	stdout      *string
-	sync        *ContainerID
-	up          *Void
-	user        *string
-	workdir     *string
+	return q.Execute(ctx)
 }
-type WithContainerFunc func(r *Container) *Container
 
-// With calls the provided function with current Container.
+// Returns a copy of the current contents of the cache volume.
 //
-// This is useful for reusability and readability by not breaking the calling chain.
-func (r *Container) With(f WithContainerFunc) *Container {
-	return f(r)
-}
+// Only the contents of the volume when mounted without a source directory are included.
+func (r *CacheVolume) Snapshot() *Directory {
+	q := r.query.Select("snapshot")
 
-func (r *Container) WithGraphQLQuery(q *querybuilder.Selection) *Container {
-	return &Container{
+	return &Directory{
 		query: q,
 	}
 }
 
-// ContainerAsServiceOpts contains options for Container.AsService
-type ContainerAsServiceOpts struct {
-	// Command to run instead of the container's default command (e.g., ["go", "run", "main.go"]).
-	//
-	// If empty, the container's default command is used.
-	Args []string
-	// If the container has an entrypoint, prepend it to the args.
-	UseEntrypoint bool
-	// Provides Dagger access to the executed command.
-	ExperimentalPrivilegedNesting bool
-	// Execute the command with all root capabilities. This is similar to running a command with "sudo" or executing "docker run" with the "--privileged" flag. Containerization does not provide any security guarantees when using this option. It should only be used when absolutely necessary and only with trusted commands.
-	InsecureRootCapabilities bool
-	// Replace "${VAR}" or "$VAR" in the args according to the current environment variables defined in the container (e.g. "/$VAR/foo").
-	Expand bool
-	// If set, skip the automatic init process injected into containers by default.
-	//
-	// This should only be used if the user requires that their exec process be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
-	NoInit bool
-}
+// The file changes between two directories.
+type Changeset struct {
+	query *querybuilder.Selection
 
-// Turn the container into a Service.
-//
-// Be sure to set any exposed ports before this conversion.
-func (r *Container) AsService(opts ...ContainerAsServiceOpts) *Service {
-	q := r.query.Select("asService")
-	for i := len(opts) - 1; i >= 0; i-- {
-		// `args` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Args) {
-			q = q.Arg("args", opts[i].Args)
-		}
-		// `useEntrypoint` optional argument
-		if !querybuilder.IsZeroValue(opts[i].UseEntrypoint) {
-			q = q.Arg("useEntrypoint", opts[i].UseEntrypoint)
-		}
-		// `experimentalPrivilegedNesting` optional argument
-		if !querybuilder.IsZeroValue(opts[i].ExperimentalPrivilegedNesting) {
-			q = q.Arg("experimentalPrivilegedNesting", opts[i].ExperimentalPrivilegedNesting)
-		}
-		// `insecureRootCapabilities` optional argument
-		if !querybuilder.IsZeroValue(opts[i].InsecureRootCapabilities) {
-			q = q.Arg("insecureRootCapabilities", opts[i].InsecureRootCapabilities)
-		}
-		// `expand` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Expand) {
-			q = q.Arg("expand", opts[i].Expand)
-		}
-		// `noInit` optional argument
-		if !querybuilder.IsZeroValue(opts[i].NoInit) {
-			q = q.Arg("noInit", opts[i].NoInit)
-		}
-	}
+	id *ChangesetID
+}
 
-	return &Service{
+func (r *Changeset) WithGraphQLQuery(q *querybuilder.Selection) *Changeset {
+	return &Changeset{
 		query: q,
 	}
 }
 
-// ContainerAsTarballOpts contains options for Container.AsTarball
-type ContainerAsTarballOpts struct {
-	// Identifiers for other platform specific containers.
-	//
-	// Used for multi-platform images.
-	PlatformVariants []*Container
-	// Force each layer of the image to use the specified compression algorithm.
-	//
-	// If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
-	ForcedCompression ImageLayerCompression
-	// Use the specified media types for the image's layers.
-	//
-	// Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
-	//
-	// Default: OCIMediaTypes
-	MediaTypes ImageMediaTypes
+// The paths of the files that were created.
+func (r *Changeset) AddedPaths(ctx context.Context) ([]string, error) {
+	q := r.query.Select("addedPaths")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
 }
 
-// Package the container state as an OCI image, and return it as a tar archive
-func (r *Container) AsTarball(opts ...ContainerAsTarballOpts) *File {
-	q := r.query.Select("asTarball")
-	for i := len(opts) - 1; i >= 0; i-- {
-		// `platformVariants` optional argument
-		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
-			q = q.Arg("platformVariants", opts[i].PlatformVariants)
-		}
-		// `forcedCompression` optional argument
-		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
-			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
-		}
-		// `mediaTypes` optional argument
-		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
-			q = q.Arg("mediaTypes", opts[i].MediaTypes)
-		}
-	}
+// Returns the changes as a patch file in the format of "git diff", which can be applied with Directory.withPatchFile.
+func (r *Changeset) AsPatch() *File {
+	q := r.query.Select("asPatch")
 
 	return &File{
 		query: q,
 	}
 }
 
-// ContainerBuildOpts contains options for Container.Build
-type ContainerBuildOpts struct {
-	// Path to the Dockerfile to use.
-	//
-	// Default: "Dockerfile"
-	Dockerfile string
-	// Target build stage to build.
-	Target string
-	// Additional build arguments.
-	BuildArgs []BuildArg
-	// Secrets to pass to the build.
-	//
-	// They will be mounted at /run/secrets/[secret-name] in the build container
-	//
-	// They can be accessed in the Dockerfile using the "secret" mount type and mount path /run/secrets/[secret-name], e.g. RUN --mount=type=secret,id=my-secret curl [http://example.com?token=$(cat /run/secrets/my-secret)](http://example.com?token=$(cat /run/secrets/my-secret))
-	Secrets []*Secret
-	// If set, skip the automatic init process injected into containers created by RUN statements.
-	//
-	// This should only be used if the user requires that their exec processes be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
-	NoInit bool
-}
+// The changes made to each file, sorted by path.
+func (r *Changeset) Entries(ctx context.Context) ([]ChangesetEntry, error) {
+	q := r.query.Select("entries")
 
-// Initializes this container from a Dockerfile build.
-func (r *Container) Build(context *Directory, opts ...ContainerBuildOpts) *Container {
-	assertNotNil("context", context)
-	q := r.query.Select("build")
-	for i := len(opts) - 1; i >= 0; i-- {
-		// `dockerfile` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Dockerfile) {
-			q = q.Arg("dockerfile", opts[i].Dockerfile)
-		}
-		// `target` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Target) {
-			q = q.Arg("target", opts[i].Target)
-		}
-		// `buildArgs` optional argument
-		if !querybuilder.IsZeroValue(opts[i].BuildArgs) {
-			q = q.Arg("buildArgs", opts[i].BuildArgs)
-		}
-		// `secrets` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Secrets) {
-			q = q.Arg("secrets", opts[i].Secrets)
-		}
-		// `noInit` optional argument
-		if !querybuilder.IsZeroValue(opts[i].NoInit) {
-			q = q.Arg("noInit", opts[i].NoInit)
-		}
-	}
-	q = q.Arg("context", context)
+	q = q.Select("id")
 
-	return &Container{
-		query: q,
+	type entries struct {
+		Id ChangesetEntryID
 	}
-}
 
-// Return the container's default arguments.
-func (r *Container) DefaultArgs(ctx context.Context) ([]string, error) {
-	q := r.query.Select("defaultArgs")
+	convert := func(fields []entries) []ChangesetEntry {
+		out := []ChangesetEntry{}
 
-	var response []string
+		for i := range fields {
+			val := ChangesetEntry{id: &fields[i].Id}
+			val.query = q.Root().Select("loadChangesetEntryFromID").Arg("id", fields[i].Id)
+			out = append(out, val)
+		}
 
-	q = q.Bind(&response)
-	return response, q.Execute(ctx)
-}
+		return out
+	}
+	var response []entries
 
-// ContainerDirectoryOpts contains options for Container.Directory
-type ContainerDirectoryOpts struct {
-	// Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo").
-	Expand bool
-}
+	q = q.Bind(&response)
 
-// Retrieve a directory from the container's root filesystem
-//
-// Mounts are included.
-func (r *Container) Directory(path string, opts ...ContainerDirectoryOpts) *Directory {
-	q := r.query.Select("directory")
-	for i := len(opts) - 1; i >= 0; i-- {
-		// `expand` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Expand) {
-			q = q.Arg("expand", opts[i].Expand)
-		}
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
 	}
-	q = q.Arg("path", path)
 
-	return &Directory{
-		query: q,
-	}
+	return convert(response), nil
 }
 
-// Return the container's OCI entrypoint.
-func (r *Container) Entrypoint(ctx context.Context) ([]string, error) {
-	q := r.query.Select("entrypoint")
+// A unique identifier for this Changeset.
+func (r *Changeset) ID(ctx context.Context) (ChangesetID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
 
-	var response []string
+	var response ChangesetID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *Changeset) XXX_GraphQLType() string {
+	return "Changeset"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *Changeset) XXX_GraphQLIDType() string {
+	return "ChangesetID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *Changeset) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *Changeset) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The paths of the files whose contents or permissions were changed.
+func (r *Changeset) ModifiedPaths(ctx context.Context) ([]string, error) {
+	q := r.query.Select("modifiedPaths")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The paths of the files that were deleted.
+func (r *Changeset) RemovedPaths(ctx context.Context) ([]string, error) {
+	q := r.query.Select("removedPaths")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The new paths of the files that were moved without changing their contents.
+func (r *Changeset) RenamedPaths(ctx context.Context) ([]string, error) {
+	q := r.query.Select("renamedPaths")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A change made to a file.
+type ChangesetEntry struct {
+	query *querybuilder.Selection
+
+	diff    *string
+	id      *ChangesetEntryID
+	kind    *ChangeKind
+	oldPath *string
+	path    *string
+}
+
+func (r *ChangesetEntry) WithGraphQLQuery(q *querybuilder.Selection) *ChangesetEntry {
+	return &ChangesetEntry{
+		query: q,
+	}
+}
+
+// The unified diff of the file, in the format of git diff.
+func (r *ChangesetEntry) Diff(ctx context.Context) (string, error) {
+	if r.diff != nil {
+		return *r.diff, nil
+	}
+	q := r.query.Select("diff")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this ChangesetEntry.
+func (r *ChangesetEntry) ID(ctx context.Context) (ChangesetEntryID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response ChangesetEntryID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *ChangesetEntry) XXX_GraphQLType() string {
+	return "ChangesetEntry"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *ChangesetEntry) XXX_GraphQLIDType() string {
+	return "ChangesetEntryID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *ChangesetEntry) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *ChangesetEntry) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The kind of change.
+func (r *ChangesetEntry) Kind(ctx context.Context) (ChangeKind, error) {
+	if r.kind != nil {
+		return *r.kind, nil
+	}
+	q := r.query.Select("kind")
+
+	var response ChangeKind
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The path of the file before the change, if it was renamed.
+func (r *ChangesetEntry) OldPath(ctx context.Context) (string, error) {
+	if r.oldPath != nil {
+		return *r.oldPath, nil
+	}
+	q := r.query.Select("oldPath")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The path of the file after the change, or before it if it was removed.
+func (r *ChangesetEntry) Path(ctx context.Context) (string, error) {
+	if r.path != nil {
+		return *r.path, nil
+	}
+	q := r.query.Select("path")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The services defined by a Compose file.
+type ComposeStack struct {
+	query *querybuilder.Selection
+
+	id *ComposeStackID
+}
+
+func (r *ComposeStack) WithGraphQLQuery(q *querybuilder.Selection) *ComposeStack {
+	return &ComposeStack{
+		query: q,
+	}
+}
+
+// A unique identifier for this ComposeStack.
+func (r *ComposeStack) ID(ctx context.Context) (ComposeStackID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response ComposeStackID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *ComposeStack) XXX_GraphQLType() string {
+	return "ComposeStack"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *ComposeStack) XXX_GraphQLIDType() string {
+	return "ComposeStackID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *ComposeStack) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *ComposeStack) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// Retrieves a service of the stack.
+//
+// The service is reachable by its name within the session, and starts the services it depends on first.
+//
+// Named volumes are mounted as cache volumes, and bind mounts from the directory of the Compose file.
+func (r *ComposeStack) Service(name string) *Service {
+	q := r.query.Select("service")
+	q = q.Arg("name", name)
+
+	return &Service{
+		query: q,
+	}
+}
+
+// The names of the services of the stack, in alphabetical order.
+func (r *ComposeStack) ServiceNames(ctx context.Context) ([]string, error) {
+	q := r.query.Select("serviceNames")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// An OCI-compatible container, also known as a Docker container.
+type Container struct {
+	query *querybuilder.Selection
+
+	envVariable     *string
+	exitCode        *int
+	export          *string
+	exportOCILayout *string
+	id              *ContainerID
+	imageRef        *string
+	label           *string
+	platform        *Platform
+	publish         *string
+	stderr          *string
+	stdout          *string
+	sync            *ContainerID
+	up              *Void
+	user            *string
+	workdir         *string
+}
+type WithContainerFunc func(r *Container) *Container
+
+// With calls the provided function with current Container.
+//
+// This is useful for reusability and readability by not breaking the calling chain.
+func (r *Container) With(f WithContainerFunc) *Container {
+	return f(r)
+}
+
+func (r *Container) WithGraphQLQuery(q *querybuilder.Selection) *Container {
+	return &Container{
+		query: q,
+	}
+}
+
+// ContainerAsOCILayoutOpts contains options for Container.AsOCILayout
+type ContainerAsOCILayoutOpts struct {
+	// Identifiers for other platform specific containers.
+	//
+	// Used for multi-platform images.
+	PlatformVariants []*Container
+	// Force each layer of the image to use the specified compression algorithm.
+	//
+	// If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Use the specified media types for the image's layers.
+	//
+	// Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+	//
+	// Default: OCIMediaTypes
+	MediaTypes ImageMediaTypes
+}
+
+// Package the container state as an OCI image, and return it as an OCI image layout directory, with an "index.json" and the image blobs.
+func (r *Container) AsOCILayout(opts ...ContainerAsOCILayoutOpts) *Directory {
+	q := r.query.Select("asOCILayout")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+	}
+
+	return &Directory{
+		query: q,
+	}
+}
+
+// ContainerAsServiceOpts contains options for Container.AsService
+type ContainerAsServiceOpts struct {
+	// Command to run instead of the container's default command (e.g., ["go", "run", "main.go"]).
+	//
+	// If empty, the container's default command is used.
+	Args []string
+	// If the container has an entrypoint, prepend it to the args.
+	UseEntrypoint bool
+	// Provides Dagger access to the executed command.
+	ExperimentalPrivilegedNesting bool
+	// Execute the command with all root capabilities. This is similar to running a command with "sudo" or executing "docker run" with the "--privileged" flag. Containerization does not provide any security guarantees when using this option. It should only be used when absolutely necessary and only with trusted commands.
+	InsecureRootCapabilities bool
+	// Replace "${VAR}" or "$VAR" in the args according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+	Expand bool
+	// If set, skip the automatic init process injected into containers by default.
+	//
+	// This should only be used if the user requires that their exec process be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
+	NoInit bool
+}
+
+// Turn the container into a Service.
+//
+// Be sure to set any exposed ports before this conversion.
+func (r *Container) AsService(opts ...ContainerAsServiceOpts) *Service {
+	q := r.query.Select("asService")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `args` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Args) {
+			q = q.Arg("args", opts[i].Args)
+		}
+		// `useEntrypoint` optional argument
+		if !querybuilder.IsZeroValue(opts[i].UseEntrypoint) {
+			q = q.Arg("useEntrypoint", opts[i].UseEntrypoint)
+		}
+		// `experimentalPrivilegedNesting` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExperimentalPrivilegedNesting) {
+			q = q.Arg("experimentalPrivilegedNesting", opts[i].ExperimentalPrivilegedNesting)
+		}
+		// `insecureRootCapabilities` optional argument
+		if !querybuilder.IsZeroValue(opts[i].InsecureRootCapabilities) {
+			q = q.Arg("insecureRootCapabilities", opts[i].InsecureRootCapabilities)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+		// `noInit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].NoInit) {
+			q = q.Arg("noInit", opts[i].NoInit)
+		}
+	}
+
+	return &Service{
+		query: q,
+	}
+}
+
+// ContainerAsTarballOpts contains options for Container.AsTarball
+type ContainerAsTarballOpts struct {
+	// Identifiers for other platform specific containers.
+	//
+	// Used for multi-platform images.
+	PlatformVariants []*Container
+	// Force each layer of the image to use the specified compression algorithm.
+	//
+	// If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Use the specified media types for the image's layers.
+	//
+	// Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+	//
+	// Default: OCIMediaTypes
+	MediaTypes ImageMediaTypes
+}
+
+// Package the container state as an OCI image, and return it as a tar archive
+func (r *Container) AsTarball(opts ...ContainerAsTarballOpts) *File {
+	q := r.query.Select("asTarball")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+	}
+
+	return &File{
+		query: q,
+	}
+}
+
+// ContainerBuildOpts contains options for Container.Build
+type ContainerBuildOpts struct {
+	// Path to the Dockerfile to use.
+	//
+	// Default: "Dockerfile"
+	Dockerfile string
+	// Target build stage to build.
+	Target string
+	// Additional build arguments.
+	BuildArgs []BuildArg
+	// Secrets to pass to the build.
+	//
+	// They will be mounted at /run/secrets/[secret-name] in the build container
+	//
+	// They can be accessed in the Dockerfile using the "secret" mount type and mount path /run/secrets/[secret-name], e.g. RUN --mount=type=secret,id=my-secret curl [http://example.com?token=$(cat /run/secrets/my-secret)](http://example.com?token=$(cat /run/secrets/my-secret))
+	Secrets []*Secret
+	// If set, skip the automatic init process injected into containers created by RUN statements.
+	//
+	// This should only be used if the user requires that their exec processes be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
+	NoInit bool
+}
+
+// Initializes this container from a Dockerfile build.
+func (r *Container) Build(context *Directory, opts ...ContainerBuildOpts) *Container {
+	assertNotNil("context", context)
+	q := r.query.Select("build")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `dockerfile` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Dockerfile) {
+			q = q.Arg("dockerfile", opts[i].Dockerfile)
+		}
+		// `target` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Target) {
+			q = q.Arg("target", opts[i].Target)
+		}
+		// `buildArgs` optional argument
+		if !querybuilder.IsZeroValue(opts[i].BuildArgs) {
+			q = q.Arg("buildArgs", opts[i].BuildArgs)
+		}
+		// `secrets` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Secrets) {
+			q = q.Arg("secrets", opts[i].Secrets)
+		}
+		// `noInit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].NoInit) {
+			q = q.Arg("noInit", opts[i].NoInit)
+		}
+	}
+	q = q.Arg("context", context)
+
+	return &Container{
+		query: q,
+	}
+}
+
+// Return the container's default arguments.
+func (r *Container) DefaultArgs(ctx context.Context) ([]string, error) {
+	q := r.query.Select("defaultArgs")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// ContainerDirectoryOpts contains options for Container.Directory
+type ContainerDirectoryOpts struct {
+	// Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+	Expand bool
+}
+
+// Retrieve a directory from the container's root filesystem
+//
+// Mounts are included.
+func (r *Container) Directory(path string, opts ...ContainerDirectoryOpts) *Directory {
+	q := r.query.Select("directory")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Directory{
+		query: q,
+	}
+}
+
+// Return the container's OCI entrypoint.
+func (r *Container) Entrypoint(ctx context.Context) ([]string, error) {
+	q := r.query.Select("entrypoint")
+
+	var response []string
 
 	q = q.Bind(&response)
 	return response, q.Execute(ctx)
@@ -852,6 +1347,17 @@ func (r *Container) EnvVariables(ctx context.Context) ([]EnvVariable, error) {
 	return convert(response), nil
 }
 
+// The resource usage of the last executed command
+//
+// Returns an error if no command was executed
+func (r *Container) ExecMetrics() *ExecMetrics {
+	q := r.query.Select("execMetrics")
+
+	return &ExecMetrics{
+		query: q,
+	}
+}
+
 // The exit code of the last executed command
 //
 // Returns an error if no command was executed
@@ -948,6 +1454,60 @@ func (r *Container) Export(ctx context.Context, path string, opts ...ContainerEx
 	return response, q.Execute(ctx)
 }
 
+// ContainerExportOCILayoutOpts contains options for Container.ExportOCILayout
+type ContainerExportOCILayoutOpts struct {
+	// Identifiers for other platform specific containers.
+	//
+	// Used for multi-platform image.
+	PlatformVariants []*Container
+	// Force each layer of the exported image to use the specified compression algorithm.
+	//
+	// If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+	ForcedCompression ImageLayerCompression
+	// Use the specified media types for the exported image's layers.
+	//
+	// Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+	//
+	// Default: OCIMediaTypes
+	MediaTypes ImageMediaTypes
+	// Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+	Expand bool
+}
+
+// Writes the container as an OCI image layout directory to the destination path on the host.
+//
+// It can also export platform variants.
+func (r *Container) ExportOCILayout(ctx context.Context, path string, opts ...ContainerExportOCILayoutOpts) (string, error) {
+	if r.exportOCILayout != nil {
+		return *r.exportOCILayout, nil
+	}
+	q := r.query.Select("exportOCILayout")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `platformVariants` optional argument
+		if !querybuilder.IsZeroValue(opts[i].PlatformVariants) {
+			q = q.Arg("platformVariants", opts[i].PlatformVariants)
+		}
+		// `forcedCompression` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ForcedCompression) {
+			q = q.Arg("forcedCompression", opts[i].ForcedCompression)
+		}
+		// `mediaTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
+			q = q.Arg("mediaTypes", opts[i].MediaTypes)
+		}
+		// `expand` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Expand) {
+			q = q.Arg("expand", opts[i].Expand)
+		}
+	}
+	q = q.Arg("path", path)
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // Retrieves the list of exposed ports.
 //
 // This includes ports already exposed by the image, even if not explicitly added with dagger.
@@ -1007,9 +1567,23 @@ func (r *Container) File(path string, opts ...ContainerFileOpts) *File {
 	}
 }
 
+// ContainerFromOpts contains options for Container.From
+type ContainerFromOpts struct {
+	// PEM encoded public key to verify the image signature with.
+	//
+	// If set, the image must have a cosign signature made with the matching private key, or the download fails.
+	VerifyKey *Secret
+}
+
 // Download a container image, and apply it to the container state. All previous state will be lost.
-func (r *Container) From(address string) *Container {
+func (r *Container) From(address string, opts ...ContainerFromOpts) *Container {
 	q := r.query.Select("from")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `verifyKey` optional argument
+		if !querybuilder.IsZeroValue(opts[i].VerifyKey) {
+			q = q.Arg("verifyKey", opts[i].VerifyKey)
+		}
+	}
 	q = q.Arg("address", address)
 
 	return &Container{
@@ -1093,6 +1667,29 @@ func (r *Container) Import(source *File, opts ...ContainerImportOpts) *Container
 	}
 }
 
+// ContainerImportOCILayoutOpts contains options for Container.ImportOCILayout
+type ContainerImportOCILayoutOpts struct {
+	// Identifies the tag to import from the layout, if the layout bundles multiple tags.
+	Tag string
+}
+
+// Reads the container from an OCI image layout directory.
+func (r *Container) ImportOCILayout(source *Directory, opts ...ContainerImportOCILayoutOpts) *Container {
+	assertNotNil("source", source)
+	q := r.query.Select("importOCILayout")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `tag` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Tag) {
+			q = q.Arg("tag", opts[i].Tag)
+		}
+	}
+	q = q.Arg("source", source)
+
+	return &Container{
+		query: q,
+	}
+}
+
 // Retrieves the value of the specified label.
 func (r *Container) Label(ctx context.Context, name string) (string, error) {
 	if r.label != nil {
@@ -1179,6 +1776,20 @@ type ContainerPublishOpts struct {
 	//
 	// Default: OCIMediaTypes
 	MediaTypes ImageMediaTypes
+	// Attach a software bill of materials in the given format to the image of each platform.
+	//
+	// The packages are listed from the apk, dpkg and Python package metadata found in the container filesystem.
+	Sbom SBOMFormat
+	// Attach SLSA provenance to the image of each platform, recording the digest of the call that built it.
+	//
+	// The base images and git commits it was built from are listed as its resolved dependencies.
+	Provenance bool
+	// PEM encoded private key to sign the published image with.
+	//
+	// The signature is pushed to the registry next to the image in the cosign format, so it can be verified with "cosign verify --key".
+	SigningKey *Secret
+	// Password to decrypt the signing key, if it is encrypted as generated by "cosign generate-key-pair".
+	SigningKeyPassword *Secret
 }
 
 // Package the container state as an OCI image, and publish it to a registry
@@ -1202,6 +1813,22 @@ func (r *Container) Publish(ctx context.Context, address string, opts ...Contain
 		if !querybuilder.IsZeroValue(opts[i].MediaTypes) {
 			q = q.Arg("mediaTypes", opts[i].MediaTypes)
 		}
+		// `sbom` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Sbom) {
+			q = q.Arg("sbom", opts[i].Sbom)
+		}
+		// `provenance` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Provenance) {
+			q = q.Arg("provenance", opts[i].Provenance)
+		}
+		// `signingKey` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SigningKey) {
+			q = q.Arg("signingKey", opts[i].SigningKey)
+		}
+		// `signingKeyPassword` optional argument
+		if !querybuilder.IsZeroValue(opts[i].SigningKeyPassword) {
+			q = q.Arg("signingKeyPassword", opts[i].SigningKeyPassword)
+		}
 	}
 	q = q.Arg("address", address)
 
@@ -1537,6 +2164,16 @@ type ContainerWithExecOpts struct {
 	//
 	// Default: SUCCESS
 	Expect ReturnType
+	// Exact exit codes this command is allowed to exit with without error, instead of "expect". Example: [0, 2]
+	ExpectExitCodes []int
+	// Fail the command with an EXEC_ASSERTION_ERROR if its standard output doesn't match this regular expression (RE2 syntax). Example: "listening on port \\d+"
+	ExpectStdoutRegex string
+	// Like expectStdoutRegex, but for standard error
+	ExpectStderrRegex string
+	// Fail the command with an EXEC_ASSERTION_ERROR if its standard output contains this string. Example: "panic:"
+	ExpectStdoutNotContains string
+	// Like expectStdoutNotContains, but for standard error
+	ExpectStderrNotContains string
 	// Provides Dagger access to the executed command.
 	ExperimentalPrivilegedNesting bool
 	// Execute the command with all root capabilities. Like --privileged in Docker
@@ -1549,6 +2186,18 @@ type ContainerWithExecOpts struct {
 	//
 	// Only use this if you specifically need the command to be pid 1 in the container. Otherwise it may result in unexpected behavior. If you're not sure, you don't need this.
 	NoInit bool
+	// Resource limits enforced on the command with cgroups. Example: {cpus: 0.5, memoryBytes: 536870912, pids: 100}
+	Limits ExecLimits
+	// Kill the command if it runs for longer than this duration, failing with an EXEC_TIMEOUT_ERROR. Example: "10m"
+	//
+	// When retrying, each attempt has its own timeout.
+	Timeout string
+	// Run the command again when it fails, up to the given number of attempts. Example: {attempts: 3, backoff: "1s", retryOnExitCodes: [1], retryOnStderrRegex: "connection reset"}
+	//
+	// Each attempt starts from the same filesystem: only changes to cache volumes are kept across attempts. Only the output of the last attempt is kept.
+	//
+	// To restore it, what the command may write to is copied before the first attempt: this is only the writable layer of the container with the overlayfs snapshotter, but would be the whole filesystem with other snapshotters, so retrying fails with them instead.
+	Retry ExecRetry
 }
 
 // Execute a command in the container, and return a new snapshot of the container state after execution.
@@ -1575,6 +2224,26 @@ func (r *Container) WithExec(args []string, opts ...ContainerWithExecOpts) *Cont
 		if !querybuilder.IsZeroValue(opts[i].Expect) {
 			q = q.Arg("expect", opts[i].Expect)
 		}
+		// `expectExitCodes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExpectExitCodes) {
+			q = q.Arg("expectExitCodes", opts[i].ExpectExitCodes)
+		}
+		// `expectStdoutRegex` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExpectStdoutRegex) {
+			q = q.Arg("expectStdoutRegex", opts[i].ExpectStdoutRegex)
+		}
+		// `expectStderrRegex` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExpectStderrRegex) {
+			q = q.Arg("expectStderrRegex", opts[i].ExpectStderrRegex)
+		}
+		// `expectStdoutNotContains` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExpectStdoutNotContains) {
+			q = q.Arg("expectStdoutNotContains", opts[i].ExpectStdoutNotContains)
+		}
+		// `expectStderrNotContains` optional argument
+		if !querybuilder.IsZeroValue(opts[i].ExpectStderrNotContains) {
+			q = q.Arg("expectStderrNotContains", opts[i].ExpectStderrNotContains)
+		}
 		// `experimentalPrivilegedNesting` optional argument
 		if !querybuilder.IsZeroValue(opts[i].ExperimentalPrivilegedNesting) {
 			q = q.Arg("experimentalPrivilegedNesting", opts[i].ExperimentalPrivilegedNesting)
@@ -1591,6 +2260,18 @@ func (r *Container) WithExec(args []string, opts ...ContainerWithExecOpts) *Cont
 		if !querybuilder.IsZeroValue(opts[i].NoInit) {
 			q = q.Arg("noInit", opts[i].NoInit)
 		}
+		// `limits` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Limits) {
+			q = q.Arg("limits", opts[i].Limits)
+		}
+		// `timeout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Timeout) {
+			q = q.Arg("timeout", opts[i].Timeout)
+		}
+		// `retry` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Retry) {
+			q = q.Arg("retry", opts[i].Retry)
+		}
 	}
 	q = q.Arg("args", args)
 
@@ -1720,6 +2401,73 @@ func (r *Container) WithFiles(path string, sources []*File, opts ...ContainerWit
 	}
 }
 
+// ContainerWithHealthcheckOpts contains options for Container.WithHealthcheck
+type ContainerWithHealthcheckOpts struct {
+	// Command to run in the service container. The service is healthy when it exits with status 0.
+	Args []string
+	// Path to request with an HTTP GET on the port, or the first exposed TCP port. The service is healthy on a 2xx or 3xx response.
+	HTTPPath string
+	// TCP port to check. Alone, the service is healthy once the port accepts connections.
+	Port int
+	// Time between two checks, as a duration string (e.g., "1s", "500ms").
+	//
+	// Default: "1s"
+	Interval string
+	// Time after which a check fails, as a duration string.
+	//
+	// Default: "10s"
+	Timeout string
+	// Number of consecutive failed checks after which the service is unhealthy and fails to start.
+	//
+	// Default: 30
+	Retries int
+	// Time during which failed checks don't count towards retries, as a duration string.
+	//
+	// Default: "0s"
+	StartPeriod string
+}
+
+// Retrieves this container with a health check run when it is started as a service.
+//
+// The service is ready once the check succeeds, instead of once its exposed ports accept connections. Set exactly one of args, or httpPath and/or port.
+func (r *Container) WithHealthcheck(opts ...ContainerWithHealthcheckOpts) *Container {
+	q := r.query.Select("withHealthcheck")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `args` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Args) {
+			q = q.Arg("args", opts[i].Args)
+		}
+		// `httpPath` optional argument
+		if !querybuilder.IsZeroValue(opts[i].HTTPPath) {
+			q = q.Arg("httpPath", opts[i].HTTPPath)
+		}
+		// `port` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Port) {
+			q = q.Arg("port", opts[i].Port)
+		}
+		// `interval` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Interval) {
+			q = q.Arg("interval", opts[i].Interval)
+		}
+		// `timeout` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Timeout) {
+			q = q.Arg("timeout", opts[i].Timeout)
+		}
+		// `retries` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Retries) {
+			q = q.Arg("retries", opts[i].Retries)
+		}
+		// `startPeriod` optional argument
+		if !querybuilder.IsZeroValue(opts[i].StartPeriod) {
+			q = q.Arg("startPeriod", opts[i].StartPeriod)
+		}
+	}
+
+	return &Container{
+		query: q,
+	}
+}
+
 // Retrieves this container plus the given label.
 func (r *Container) WithLabel(name string, value string) *Container {
 	q := r.query.Select("withLabel")
@@ -2016,6 +2764,18 @@ func (r *Container) WithServiceBinding(alias string, service *Service) *Containe
 	}
 }
 
+// Retrieves this container with the timestamps of its exported and published images clamped to the given time.
+//
+// Following the SOURCE_DATE_EPOCH specification, the image creation time, the history entries and the modification times of the files in the layers created by this container are set to the given time if they are more recent, so that building the same container always produces the same image digest.
+func (r *Container) WithSourceDateEpoch(epoch int) *Container {
+	q := r.query.Select("withSourceDateEpoch")
+	q = q.Arg("epoch", epoch)
+
+	return &Container{
+		query: q,
+	}
+}
+
 // ContainerWithUnixSocketOpts contains options for Container.WithUnixSocket
 type ContainerWithUnixSocketOpts struct {
 	// A user:group to set for the mounted socket.
@@ -2222,6 +2982,15 @@ func (r *Container) WithoutFiles(paths []string, opts ...ContainerWithoutFilesOp
 	}
 }
 
+// Retrieves this container without a health check, waiting for its exposed ports when started as a service.
+func (r *Container) WithoutHealthcheck() *Container {
+	q := r.query.Select("withoutHealthcheck")
+
+	return &Container{
+		query: q,
+	}
+}
+
 // Retrieves this container minus the given environment label.
 func (r *Container) WithoutLabel(name string) *Container {
 	q := r.query.Select("withoutLabel")
@@ -2470,6 +3239,31 @@ func (r *Directory) WithGraphQLQuery(q *querybuilder.Selection) *Directory {
 	}
 }
 
+// DirectoryAsComposeStackOpts contains options for Directory.AsComposeStack
+type DirectoryAsComposeStackOpts struct {
+	// Path to the Compose file (e.g., "stack/compose.yaml").
+	//
+	// Default: "docker-compose.yml"
+	File string
+}
+
+// Load the services of a Compose file in this directory.
+//
+// Variables are interpolated from the .env file next to the Compose file, if any.
+func (r *Directory) AsComposeStack(opts ...DirectoryAsComposeStackOpts) *ComposeStack {
+	q := r.query.Select("asComposeStack")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `file` optional argument
+		if !querybuilder.IsZeroValue(opts[i].File) {
+			q = q.Arg("file", opts[i].File)
+		}
+	}
+
+	return &ComposeStack{
+		query: q,
+	}
+}
+
 // Converts this directory to a local git repository
 func (r *Directory) AsGit() *GitRepository {
 	q := r.query.Select("asGit")
@@ -2529,6 +3323,19 @@ func (r *Directory) AsModuleSource(opts ...DirectoryAsModuleSourceOpts) *ModuleS
 	}
 }
 
+// Return the file changes from this directory to another directory.
+//
+// Unlike diff, the changes include removed and renamed files, along with a unified diff of each file.
+func (r *Directory) Changes(other *Directory) *Changeset {
+	assertNotNil("other", other)
+	q := r.query.Select("changes")
+	q = q.Arg("other", other)
+
+	return &Changeset{
+		query: q,
+	}
+}
+
 // Return the difference between this directory and an another directory. The difference is encoded as a directory.
 func (r *Directory) Diff(other *Directory) *Directory {
 	assertNotNil("other", other)
@@ -2769,6 +3576,66 @@ func (r *Directory) Name(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// DirectorySearchOpts contains options for Directory.Search
+type DirectorySearchOpts struct {
+	// Interpret the pattern as a literal string instead of a regular expression.
+	Literal bool
+	// If set, only search the paths matching one of these glob patterns (e.g., ["src/", "*.go"]).
+	Paths []string
+	// Allow matches to span multiple lines.
+	Multiline bool
+}
+
+// Searches the contents of the files in this directory, returning each match.
+//
+// Binary files and symbolic links are skipped.
+func (r *Directory) Search(ctx context.Context, pattern string, opts ...DirectorySearchOpts) ([]SearchResult, error) {
+	q := r.query.Select("search")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `literal` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Literal) {
+			q = q.Arg("literal", opts[i].Literal)
+		}
+		// `paths` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Paths) {
+			q = q.Arg("paths", opts[i].Paths)
+		}
+		// `multiline` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Multiline) {
+			q = q.Arg("multiline", opts[i].Multiline)
+		}
+	}
+	q = q.Arg("pattern", pattern)
+
+	q = q.Select("id")
+
+	type search struct {
+		Id SearchResultID
+	}
+
+	convert := func(fields []search) []SearchResult {
+		out := []SearchResult{}
+
+		for i := range fields {
+			val := SearchResult{id: &fields[i].Id}
+			val.query = q.Root().Select("loadSearchResultFromID").Arg("id", fields[i].Id)
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []search
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // Force evaluation in the engine.
 func (r *Directory) Sync(ctx context.Context) (*Directory, error) {
 	q := r.query.Select("sync")
@@ -2910,37 +3777,98 @@ type DirectoryWithNewDirectoryOpts struct {
 func (r *Directory) WithNewDirectory(path string, opts ...DirectoryWithNewDirectoryOpts) *Directory {
 	q := r.query.Select("withNewDirectory")
 	for i := len(opts) - 1; i >= 0; i-- {
-		// `permissions` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Permissions) {
-			q = q.Arg("permissions", opts[i].Permissions)
+		// `permissions` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Permissions) {
+			q = q.Arg("permissions", opts[i].Permissions)
+		}
+	}
+	q = q.Arg("path", path)
+
+	return &Directory{
+		query: q,
+	}
+}
+
+// DirectoryWithNewFileOpts contains options for Directory.WithNewFile
+type DirectoryWithNewFileOpts struct {
+	// Permissions of the new file. Example: 0600
+	//
+	// Default: 420
+	Permissions int
+}
+
+// Return a snapshot with a new file added
+func (r *Directory) WithNewFile(path string, contents string, opts ...DirectoryWithNewFileOpts) *Directory {
+	q := r.query.Select("withNewFile")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `permissions` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Permissions) {
+			q = q.Arg("permissions", opts[i].Permissions)
+		}
+	}
+	q = q.Arg("path", path)
+	q = q.Arg("contents", contents)
+
+	return &Directory{
+		query: q,
+	}
+}
+
+// DirectoryWithPatchOpts contains options for Directory.WithPatch
+type DirectoryWithPatchOpts struct {
+	// Require each hunk to apply at exactly the line numbers in its header.
+	Strict bool
+	// The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+	Fuzz int
+}
+
+// Retrieves this directory with a patch applied to it.
+//
+// The patch is a unified diff, such as the output of "git diff" or "git format-patch". If any hunk can't be applied, no change is made and the rejected hunks are listed in the error.
+func (r *Directory) WithPatch(patch string, opts ...DirectoryWithPatchOpts) *Directory {
+	q := r.query.Select("withPatch")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `strict` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Strict) {
+			q = q.Arg("strict", opts[i].Strict)
+		}
+		// `fuzz` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Fuzz) {
+			q = q.Arg("fuzz", opts[i].Fuzz)
 		}
 	}
-	q = q.Arg("path", path)
+	q = q.Arg("patch", patch)
 
 	return &Directory{
 		query: q,
 	}
 }
 
-// DirectoryWithNewFileOpts contains options for Directory.WithNewFile
-type DirectoryWithNewFileOpts struct {
-	// Permissions of the new file. Example: 0600
-	//
-	// Default: 420
-	Permissions int
+// DirectoryWithPatchFileOpts contains options for Directory.WithPatchFile
+type DirectoryWithPatchFileOpts struct {
+	// Require each hunk to apply at exactly the line numbers in its header.
+	Strict bool
+	// The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+	Fuzz int
 }
 
-// Return a snapshot with a new file added
-func (r *Directory) WithNewFile(path string, contents string, opts ...DirectoryWithNewFileOpts) *Directory {
-	q := r.query.Select("withNewFile")
+// Retrieves this directory with the patch in the given file applied to it.
+//
+// The patch is a unified diff, such as the output of "git diff" or "git format-patch". If any hunk can't be applied, no change is made and the rejected hunks are listed in the error.
+func (r *Directory) WithPatchFile(patch *File, opts ...DirectoryWithPatchFileOpts) *Directory {
+	assertNotNil("patch", patch)
+	q := r.query.Select("withPatchFile")
 	for i := len(opts) - 1; i >= 0; i-- {
-		// `permissions` optional argument
-		if !querybuilder.IsZeroValue(opts[i].Permissions) {
-			q = q.Arg("permissions", opts[i].Permissions)
+		// `strict` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Strict) {
+			q = q.Arg("strict", opts[i].Strict)
+		}
+		// `fuzz` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Fuzz) {
+			q = q.Arg("fuzz", opts[i].Fuzz)
 		}
 	}
-	q = q.Arg("path", path)
-	q = q.Arg("contents", contents)
+	q = q.Arg("patch", patch)
 
 	return &Directory{
 		query: q,
@@ -3000,6 +3928,51 @@ func (r *Engine) WithGraphQLQuery(q *querybuilder.Selection) *Engine {
 	}
 }
 
+// Explain why a call was not cached
+//
+// Only calls of the current session are explained: a call is compared to the most similar call of the same field made before it in the session. The most recent misses are kept until the session ends.
+func (r *Engine) CacheMiss(call string) *EngineCacheMiss {
+	q := r.query.Select("cacheMiss")
+	q = q.Arg("call", call)
+
+	return &EngineCacheMiss{
+		query: q,
+	}
+}
+
+// The cache volumes in the local cache of the Dagger engine
+func (r *Engine) CacheVolumes(ctx context.Context) ([]EngineCacheVolume, error) {
+	q := r.query.Select("cacheVolumes")
+
+	q = q.Select("id")
+
+	type cacheVolumes struct {
+		Id EngineCacheVolumeID
+	}
+
+	convert := func(fields []cacheVolumes) []EngineCacheVolume {
+		out := []EngineCacheVolume{}
+
+		for i := range fields {
+			val := EngineCacheVolume{id: &fields[i].Id}
+			val.query = q.Root().Select("loadEngineCacheVolumeFromID").Arg("id", fields[i].Id)
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []cacheVolumes
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
 // A unique identifier for this Engine.
 func (r *Engine) ID(ctx context.Context) (EngineID, error) {
 	if r.id != nil {
@@ -3057,7 +4030,6 @@ type EngineCache struct {
 	keepBytes     *int
 	maxUsedSpace  *int
 	minFreeSpace  *int
-	prune         *Void
 	reservedSpace *int
 }
 
@@ -3168,14 +4140,63 @@ func (r *EngineCache) MinFreeSpace(ctx context.Context) (int, error) {
 	return response, q.Execute(ctx)
 }
 
-// Prune the cache of releaseable entries
-func (r *EngineCache) Prune(ctx context.Context) error {
-	if r.prune != nil {
-		return nil
-	}
+// EngineCachePruneOpts contains options for EngineCache.Prune
+type EngineCachePruneOpts struct {
+	// Only prune entries created at least this long ago, as a duration string. Example: "168h"
+	OlderThan string
+	// Only prune entries that haven't been used for at least this long, as a duration string. Example: "72h"
+	UnusedFor string
+	// Only prune entries whose description matches this regular expression (RE2 syntax).
+	DescriptionRegex string
+	// Only prune entries of these record types. Example: ["exec.cachemount"]
+	RecordTypes []string
+	// Only prune entries using at least this much disk space.
+	MinSizeBytes int
+	// Only prune the entries with these IDs, as returned by entryID.
+	EntryIDs []string
+	// Return the entries that would be pruned without pruning them.
+	DryRun bool
+}
+
+// Prune the cache of releaseable entries, returning the pruned entries
+//
+// Entries in use are never pruned. When filters are given, only the entries matching all of them are pruned.
+func (r *EngineCache) Prune(opts ...EngineCachePruneOpts) *EngineCacheEntrySet {
 	q := r.query.Select("prune")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `olderThan` optional argument
+		if !querybuilder.IsZeroValue(opts[i].OlderThan) {
+			q = q.Arg("olderThan", opts[i].OlderThan)
+		}
+		// `unusedFor` optional argument
+		if !querybuilder.IsZeroValue(opts[i].UnusedFor) {
+			q = q.Arg("unusedFor", opts[i].UnusedFor)
+		}
+		// `descriptionRegex` optional argument
+		if !querybuilder.IsZeroValue(opts[i].DescriptionRegex) {
+			q = q.Arg("descriptionRegex", opts[i].DescriptionRegex)
+		}
+		// `recordTypes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].RecordTypes) {
+			q = q.Arg("recordTypes", opts[i].RecordTypes)
+		}
+		// `minSizeBytes` optional argument
+		if !querybuilder.IsZeroValue(opts[i].MinSizeBytes) {
+			q = q.Arg("minSizeBytes", opts[i].MinSizeBytes)
+		}
+		// `entryIDs` optional argument
+		if !querybuilder.IsZeroValue(opts[i].EntryIDs) {
+			q = q.Arg("entryIDs", opts[i].EntryIDs)
+		}
+		// `dryRun` optional argument
+		if !querybuilder.IsZeroValue(opts[i].DryRun) {
+			q = q.Arg("dryRun", opts[i].DryRun)
+		}
+	}
 
-	return q.Execute(ctx)
+	return &EngineCacheEntrySet{
+		query: q,
+	}
 }
 
 func (r *EngineCache) ReservedSpace(ctx context.Context) (int, error) {
@@ -3198,8 +4219,10 @@ type EngineCacheEntry struct {
 	createdTimeUnixNano       *int
 	description               *string
 	diskSpaceBytes            *int
+	entryID                   *string
 	id                        *EngineCacheEntryID
 	mostRecentUseTimeUnixNano *int
+	recordType                *string
 }
 
 func (r *EngineCacheEntry) WithGraphQLQuery(q *querybuilder.Selection) *EngineCacheEntry {
@@ -3260,6 +4283,19 @@ func (r *EngineCacheEntry) DiskSpaceBytes(ctx context.Context) (int, error) {
 	return response, q.Execute(ctx)
 }
 
+// The identifier of the cache entry, which can be used to prune it.
+func (r *EngineCacheEntry) EntryID(ctx context.Context) (string, error) {
+	if r.entryID != nil {
+		return *r.entryID, nil
+	}
+	q := r.query.Select("entryID")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // A unique identifier for this EngineCacheEntry.
 func (r *EngineCacheEntry) ID(ctx context.Context) (EngineCacheEntryID, error) {
 	if r.id != nil {
@@ -3313,6 +4349,19 @@ func (r *EngineCacheEntry) MostRecentUseTimeUnixNano(ctx context.Context) (int,
 	return response, q.Execute(ctx)
 }
 
+// The type of the cache entry (e.g. regular, exec.cachemount, source.local).
+func (r *EngineCacheEntry) RecordType(ctx context.Context) (string, error) {
+	if r.recordType != nil {
+		return *r.recordType, nil
+	}
+	q := r.query.Select("recordType")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // A set of cache entries returned by a query to a cache
 type EngineCacheEntrySet struct {
 	query *querybuilder.Selection
@@ -3362,24 +4411,253 @@ func (r *EngineCacheEntrySet) Entries(ctx context.Context) ([]EngineCacheEntry,
 
 		return out
 	}
-	var response []entries
+	var response []entries
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
+}
+
+// The number of cache entries in this set.
+func (r *EngineCacheEntrySet) EntryCount(ctx context.Context) (int, error) {
+	if r.entryCount != nil {
+		return *r.entryCount, nil
+	}
+	q := r.query.Select("entryCount")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this EngineCacheEntrySet.
+func (r *EngineCacheEntrySet) ID(ctx context.Context) (EngineCacheEntrySetID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response EngineCacheEntrySetID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *EngineCacheEntrySet) XXX_GraphQLType() string {
+	return "EngineCacheEntrySet"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *EngineCacheEntrySet) XXX_GraphQLIDType() string {
+	return "EngineCacheEntrySetID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *EngineCacheEntrySet) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *EngineCacheEntrySet) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// An explanation of why a call was not cached
+type EngineCacheMiss struct {
+	query *querybuilder.Selection
+
+	argument     *string
+	call         *string
+	changedCall  *string
+	description  *string
+	id           *EngineCacheMissID
+	previousCall *string
+	reason       *string
+}
+
+func (r *EngineCacheMiss) WithGraphQLQuery(q *querybuilder.Selection) *EngineCacheMiss {
+	return &EngineCacheMiss{
+		query: q,
+	}
+}
+
+// The argument of the changed call that changed, if any.
+func (r *EngineCacheMiss) Argument(ctx context.Context) (string, error) {
+	if r.argument != nil {
+		return *r.argument, nil
+	}
+	q := r.query.Select("argument")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The digest of the call that was not cached.
+func (r *EngineCacheMiss) Call(ctx context.Context) (string, error) {
+	if r.call != nil {
+		return *r.call, nil
+	}
+	q := r.query.Select("call")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The call, within the inputs of the call, that changed or has a cache policy, if any.
+func (r *EngineCacheMiss) ChangedCall(ctx context.Context) (string, error) {
+	if r.changedCall != nil {
+		return *r.changedCall, nil
+	}
+	q := r.query.Select("changedCall")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A human readable explanation of the cache miss.
+func (r *EngineCacheMiss) Description(ctx context.Context) (string, error) {
+	if r.description != nil {
+		return *r.description, nil
+	}
+	q := r.query.Select("description")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this EngineCacheMiss.
+func (r *EngineCacheMiss) ID(ctx context.Context) (EngineCacheMissID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response EngineCacheMissID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *EngineCacheMiss) XXX_GraphQLType() string {
+	return "EngineCacheMiss"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *EngineCacheMiss) XXX_GraphQLIDType() string {
+	return "EngineCacheMissID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *EngineCacheMiss) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *EngineCacheMiss) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The digest of the most similar previous call it was compared against, if any.
+func (r *EngineCacheMiss) PreviousCall(ctx context.Context) (string, error) {
+	if r.previousCall != nil {
+		return *r.previousCall, nil
+	}
+	q := r.query.Select("previousCall")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// Why the call was not cached: FIRST_SEEN, ARGUMENT_CHANGED, CONTENT_CHANGED, CACHE_POLICY, EXPIRED or UNKNOWN.
+func (r *EngineCacheMiss) Reason(ctx context.Context) (string, error) {
+	if r.reason != nil {
+		return *r.reason, nil
+	}
+	q := r.query.Select("reason")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A cache volume in the local cache
+type EngineCacheVolume struct {
+	query *querybuilder.Selection
+
+	activelyUsed              *bool
+	diskSpaceBytes            *int
+	id                        *EngineCacheVolumeID
+	mostRecentUseTimeUnixNano *int
+	sharingMode               *CacheSharingMode
+}
+
+func (r *EngineCacheVolume) WithGraphQLQuery(q *querybuilder.Selection) *EngineCacheVolume {
+	return &EngineCacheVolume{
+		query: q,
+	}
+}
+
+// Whether the cache volume is actively being used.
+func (r *EngineCacheVolume) ActivelyUsed(ctx context.Context) (bool, error) {
+	if r.activelyUsed != nil {
+		return *r.activelyUsed, nil
+	}
+	q := r.query.Select("activelyUsed")
+
+	var response bool
 
 	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
 
-	err := q.Execute(ctx)
-	if err != nil {
-		return nil, err
-	}
+// The cache volume, which can be mounted, snapshotted, seeded or deleted
+func (r *EngineCacheVolume) CacheVolume() *CacheVolume {
+	q := r.query.Select("cacheVolume")
 
-	return convert(response), nil
+	return &CacheVolume{
+		query: q,
+	}
 }
 
-// The number of cache entries in this set.
-func (r *EngineCacheEntrySet) EntryCount(ctx context.Context) (int, error) {
-	if r.entryCount != nil {
-		return *r.entryCount, nil
+// The disk space used by the cache volume.
+func (r *EngineCacheVolume) DiskSpaceBytes(ctx context.Context) (int, error) {
+	if r.diskSpaceBytes != nil {
+		return *r.diskSpaceBytes, nil
 	}
-	q := r.query.Select("entryCount")
+	q := r.query.Select("diskSpaceBytes")
 
 	var response int
 
@@ -3387,31 +4665,41 @@ func (r *EngineCacheEntrySet) EntryCount(ctx context.Context) (int, error) {
 	return response, q.Execute(ctx)
 }
 
-// A unique identifier for this EngineCacheEntrySet.
-func (r *EngineCacheEntrySet) ID(ctx context.Context) (EngineCacheEntrySetID, error) {
+// The IDs of the cache entries holding the contents of the cache volume.
+func (r *EngineCacheVolume) EntryIDs(ctx context.Context) ([]string, error) {
+	q := r.query.Select("entryIDs")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this EngineCacheVolume.
+func (r *EngineCacheVolume) ID(ctx context.Context) (EngineCacheVolumeID, error) {
 	if r.id != nil {
 		return *r.id, nil
 	}
 	q := r.query.Select("id")
 
-	var response EngineCacheEntrySetID
+	var response EngineCacheVolumeID
 
 	q = q.Bind(&response)
 	return response, q.Execute(ctx)
 }
 
 // XXX_GraphQLType is an internal function. It returns the native GraphQL type name
-func (r *EngineCacheEntrySet) XXX_GraphQLType() string {
-	return "EngineCacheEntrySet"
+func (r *EngineCacheVolume) XXX_GraphQLType() string {
+	return "EngineCacheVolume"
 }
 
 // XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
-func (r *EngineCacheEntrySet) XXX_GraphQLIDType() string {
-	return "EngineCacheEntrySetID"
+func (r *EngineCacheVolume) XXX_GraphQLIDType() string {
+	return "EngineCacheVolumeID"
 }
 
 // XXX_GraphQLID is an internal function. It returns the underlying type ID
-func (r *EngineCacheEntrySet) XXX_GraphQLID(ctx context.Context) (string, error) {
+func (r *EngineCacheVolume) XXX_GraphQLID(ctx context.Context) (string, error) {
 	id, err := r.ID(ctx)
 	if err != nil {
 		return "", err
@@ -3419,7 +4707,7 @@ func (r *EngineCacheEntrySet) XXX_GraphQLID(ctx context.Context) (string, error)
 	return string(id), nil
 }
 
-func (r *EngineCacheEntrySet) MarshalJSON() ([]byte, error) {
+func (r *EngineCacheVolume) MarshalJSON() ([]byte, error) {
 	id, err := r.ID(marshalCtx)
 	if err != nil {
 		return nil, err
@@ -3427,6 +4715,42 @@ func (r *EngineCacheEntrySet) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// The keys identifying the cache volume.
+func (r *EngineCacheVolume) Keys(ctx context.Context) ([]string, error) {
+	q := r.query.Select("keys")
+
+	var response []string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The most recent time the cache volume was used, in Unix nanoseconds.
+func (r *EngineCacheVolume) MostRecentUseTimeUnixNano(ctx context.Context) (int, error) {
+	if r.mostRecentUseTimeUnixNano != nil {
+		return *r.mostRecentUseTimeUnixNano, nil
+	}
+	q := r.query.Select("mostRecentUseTimeUnixNano")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The sharing mode the cache volume was most recently mounted with.
+func (r *EngineCacheVolume) SharingMode(ctx context.Context) (CacheSharingMode, error) {
+	if r.sharingMode != nil {
+		return *r.sharingMode, nil
+	}
+	q := r.query.Select("sharingMode")
+
+	var response CacheSharingMode
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // A definition of a custom enum defined in a Module.
 type EnumTypeDef struct {
 	query *querybuilder.Selection
@@ -3824,6 +5148,78 @@ func (r *Env) WithCacheVolumeOutput(name string, description string) *Env {
 	}
 }
 
+// Create or update a binding of type ChangesetEntry in the environment
+func (r *Env) WithChangesetEntryInput(name string, value *ChangesetEntry, description string) *Env {
+	assertNotNil("value", value)
+	q := r.query.Select("withChangesetEntryInput")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Declare a desired ChangesetEntry output to be assigned in the environment
+func (r *Env) WithChangesetEntryOutput(name string, description string) *Env {
+	q := r.query.Select("withChangesetEntryOutput")
+	q = q.Arg("name", name)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Create or update a binding of type Changeset in the environment
+func (r *Env) WithChangesetInput(name string, value *Changeset, description string) *Env {
+	assertNotNil("value", value)
+	q := r.query.Select("withChangesetInput")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Declare a desired Changeset output to be assigned in the environment
+func (r *Env) WithChangesetOutput(name string, description string) *Env {
+	q := r.query.Select("withChangesetOutput")
+	q = q.Arg("name", name)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Create or update a binding of type ComposeStack in the environment
+func (r *Env) WithComposeStackInput(name string, value *ComposeStack, description string) *Env {
+	assertNotNil("value", value)
+	q := r.query.Select("withComposeStackInput")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Declare a desired ComposeStack output to be assigned in the environment
+func (r *Env) WithComposeStackOutput(name string, description string) *Env {
+	q := r.query.Select("withComposeStackOutput")
+	q = q.Arg("name", name)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
 // Create or update a binding of type Container in the environment
 func (r *Env) WithContainerInput(name string, value *Container, description string) *Env {
 	assertNotNil("value", value)
@@ -3872,6 +5268,54 @@ func (r *Env) WithDirectoryOutput(name string, description string) *Env {
 	}
 }
 
+// Create or update a binding of type EngineCacheMiss in the environment
+func (r *Env) WithEngineCacheMissInput(name string, value *EngineCacheMiss, description string) *Env {
+	assertNotNil("value", value)
+	q := r.query.Select("withEngineCacheMissInput")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Declare a desired EngineCacheMiss output to be assigned in the environment
+func (r *Env) WithEngineCacheMissOutput(name string, description string) *Env {
+	q := r.query.Select("withEngineCacheMissOutput")
+	q = q.Arg("name", name)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Create or update a binding of type EngineCacheVolume in the environment
+func (r *Env) WithEngineCacheVolumeInput(name string, value *EngineCacheVolume, description string) *Env {
+	assertNotNil("value", value)
+	q := r.query.Select("withEngineCacheVolumeInput")
+	q = q.Arg("name", name)
+	q = q.Arg("value", value)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
+// Declare a desired EngineCacheVolume output to be assigned in the environment
+func (r *Env) WithEngineCacheVolumeOutput(name string, description string) *Env {
+	q := r.query.Select("withEngineCacheVolumeOutput")
+	q = q.Arg("name", name)
+	q = q.Arg("description", description)
+
+	return &Env{
+		query: q,
+	}
+}
+
 // Create or update a binding of type Env in the environment
 func (r *Env) WithEnvInput(name string, value *Env, description string) *Env {
 	assertNotNil("value", value)
@@ -4438,6 +5882,129 @@ func (r *ErrorValue) Value(ctx context.Context) (JSON, error) {
 	return response, q.Execute(ctx)
 }
 
+// The resource usage of an executed command.
+type ExecMetrics struct {
+	query *querybuilder.Selection
+
+	cpuTimeMicroseconds  *int
+	durationMicroseconds *int
+	id                   *ExecMetricsID
+	ioReadBytes          *int
+	ioWriteBytes         *int
+	memoryPeakBytes      *int
+}
+
+func (r *ExecMetrics) WithGraphQLQuery(q *querybuilder.Selection) *ExecMetrics {
+	return &ExecMetrics{
+		query: q,
+	}
+}
+
+// The CPU time used by the command in microseconds.
+func (r *ExecMetrics) CPUTimeMicroseconds(ctx context.Context) (int, error) {
+	if r.cpuTimeMicroseconds != nil {
+		return *r.cpuTimeMicroseconds, nil
+	}
+	q := r.query.Select("cpuTimeMicroseconds")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The wall-clock duration of the command in microseconds.
+func (r *ExecMetrics) DurationMicroseconds(ctx context.Context) (int, error) {
+	if r.durationMicroseconds != nil {
+		return *r.durationMicroseconds, nil
+	}
+	q := r.query.Select("durationMicroseconds")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this ExecMetrics.
+func (r *ExecMetrics) ID(ctx context.Context) (ExecMetricsID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response ExecMetricsID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *ExecMetrics) XXX_GraphQLType() string {
+	return "ExecMetrics"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *ExecMetrics) XXX_GraphQLIDType() string {
+	return "ExecMetricsID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *ExecMetrics) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *ExecMetrics) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The number of bytes read from disk by the command.
+func (r *ExecMetrics) IoReadBytes(ctx context.Context) (int, error) {
+	if r.ioReadBytes != nil {
+		return *r.ioReadBytes, nil
+	}
+	q := r.query.Select("ioReadBytes")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The number of bytes written to disk by the command.
+func (r *ExecMetrics) IoWriteBytes(ctx context.Context) (int, error) {
+	if r.ioWriteBytes != nil {
+		return *r.ioWriteBytes, nil
+	}
+	q := r.query.Select("ioWriteBytes")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The peak memory usage of the command in bytes.
+func (r *ExecMetrics) MemoryPeakBytes(ctx context.Context) (int, error) {
+	if r.memoryPeakBytes != nil {
+		return *r.memoryPeakBytes, nil
+	}
+	q := r.query.Select("memoryPeakBytes")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // A definition of a field on a custom object defined in a Module.
 //
 // A field on an object has a static value, as opposed to a function on an object whose value is computed by invoking code (and can accept arguments).
@@ -4680,7 +6247,61 @@ func (r *File) Name(ctx context.Context) (string, error) {
 	var response string
 
 	q = q.Bind(&response)
-	return response, q.Execute(ctx)
+	return response, q.Execute(ctx)
+}
+
+// FileSearchOpts contains options for File.Search
+type FileSearchOpts struct {
+	// Interpret the pattern as a literal string instead of a regular expression.
+	Literal bool
+	// Allow matches to span multiple lines.
+	Multiline bool
+}
+
+// Searches the contents of the file, returning each match.
+//
+// A binary file has no matches.
+func (r *File) Search(ctx context.Context, pattern string, opts ...FileSearchOpts) ([]SearchResult, error) {
+	q := r.query.Select("search")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `literal` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Literal) {
+			q = q.Arg("literal", opts[i].Literal)
+		}
+		// `multiline` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Multiline) {
+			q = q.Arg("multiline", opts[i].Multiline)
+		}
+	}
+	q = q.Arg("pattern", pattern)
+
+	q = q.Select("id")
+
+	type search struct {
+		Id SearchResultID
+	}
+
+	convert := func(fields []search) []SearchResult {
+		out := []SearchResult{}
+
+		for i := range fields {
+			val := SearchResult{id: &fields[i].Id}
+			val.query = q.Root().Select("loadSearchResultFromID").Arg("id", fields[i].Id)
+			out = append(out, val)
+		}
+
+		return out
+	}
+	var response []search
+
+	q = q.Bind(&response)
+
+	err := q.Execute(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return convert(response), nil
 }
 
 // Retrieves the size of the file, in bytes.
@@ -4719,6 +6340,79 @@ func (r *File) WithName(name string) *File {
 	}
 }
 
+// FileWithPatchOpts contains options for File.WithPatch
+type FileWithPatchOpts struct {
+	// Require each hunk to apply at exactly the line numbers in its header.
+	Strict bool
+	// The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+	Fuzz int
+}
+
+// Retrieves this file with a unified diff applied to it.
+//
+// The patch must change exactly one file; its file names are ignored.
+func (r *File) WithPatch(patch string, opts ...FileWithPatchOpts) *File {
+	q := r.query.Select("withPatch")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `strict` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Strict) {
+			q = q.Arg("strict", opts[i].Strict)
+		}
+		// `fuzz` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Fuzz) {
+			q = q.Arg("fuzz", opts[i].Fuzz)
+		}
+	}
+	q = q.Arg("patch", patch)
+
+	return &File{
+		query: q,
+	}
+}
+
+// FileWithReplacedOpts contains options for File.WithReplaced
+type FileWithReplacedOpts struct {
+	// Replace all occurrences of the search string.
+	All bool
+	// Replace only the first occurrence found at or after this line number, starting at 1.
+	FirstFrom int
+}
+
+// Retrieves this file with occurrences of a string replaced.
+//
+// Unless all or firstFrom is set, it is an error for the search string to be found more than once.
+func (r *File) WithReplaced(search string, replacement string, opts ...FileWithReplacedOpts) *File {
+	q := r.query.Select("withReplaced")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `all` optional argument
+		if !querybuilder.IsZeroValue(opts[i].All) {
+			q = q.Arg("all", opts[i].All)
+		}
+		// `firstFrom` optional argument
+		if !querybuilder.IsZeroValue(opts[i].FirstFrom) {
+			q = q.Arg("firstFrom", opts[i].FirstFrom)
+		}
+	}
+	q = q.Arg("search", search)
+	q = q.Arg("replacement", replacement)
+
+	return &File{
+		query: q,
+	}
+}
+
+// Retrieves this file with a range of lines replaced.
+func (r *File) WithReplacedLines(start int, end int, contents string) *File {
+	q := r.query.Select("withReplacedLines")
+	q = q.Arg("start", start)
+	q = q.Arg("end", end)
+	q = q.Arg("contents", contents)
+
+	return &File{
+		query: q,
+	}
+}
+
 // Retrieves this file with its created/modified timestamps set to the given time.
 func (r *File) WithTimestamps(timestamp int) *File {
 	q := r.query.Select("withTimestamps")
@@ -8156,6 +9850,36 @@ func (r *Client) LoadCacheVolumeFromID(id CacheVolumeID) *CacheVolume {
 	}
 }
 
+// Load a ChangesetEntry from its ID.
+func (r *Client) LoadChangesetEntryFromID(id ChangesetEntryID) *ChangesetEntry {
+	q := r.query.Select("loadChangesetEntryFromID")
+	q = q.Arg("id", id)
+
+	return &ChangesetEntry{
+		query: q,
+	}
+}
+
+// Load a Changeset from its ID.
+func (r *Client) LoadChangesetFromID(id ChangesetID) *Changeset {
+	q := r.query.Select("loadChangesetFromID")
+	q = q.Arg("id", id)
+
+	return &Changeset{
+		query: q,
+	}
+}
+
+// Load a ComposeStack from its ID.
+func (r *Client) LoadComposeStackFromID(id ComposeStackID) *ComposeStack {
+	q := r.query.Select("loadComposeStackFromID")
+	q = q.Arg("id", id)
+
+	return &ComposeStack{
+		query: q,
+	}
+}
+
 // Load a Container from its ID.
 func (r *Client) LoadContainerFromID(id ContainerID) *Container {
 	q := r.query.Select("loadContainerFromID")
@@ -8216,6 +9940,26 @@ func (r *Client) LoadEngineCacheFromID(id EngineCacheID) *EngineCache {
 	}
 }
 
+// Load a EngineCacheMiss from its ID.
+func (r *Client) LoadEngineCacheMissFromID(id EngineCacheMissID) *EngineCacheMiss {
+	q := r.query.Select("loadEngineCacheMissFromID")
+	q = q.Arg("id", id)
+
+	return &EngineCacheMiss{
+		query: q,
+	}
+}
+
+// Load a EngineCacheVolume from its ID.
+func (r *Client) LoadEngineCacheVolumeFromID(id EngineCacheVolumeID) *EngineCacheVolume {
+	q := r.query.Select("loadEngineCacheVolumeFromID")
+	q = q.Arg("id", id)
+
+	return &EngineCacheVolume{
+		query: q,
+	}
+}
+
 // Load a Engine from its ID.
 func (r *Client) LoadEngineFromID(id EngineID) *Engine {
 	q := r.query.Select("loadEngineFromID")
@@ -8286,6 +10030,16 @@ func (r *Client) LoadErrorValueFromID(id ErrorValueID) *ErrorValue {
 	}
 }
 
+// Load a ExecMetrics from its ID.
+func (r *Client) LoadExecMetricsFromID(id ExecMetricsID) *ExecMetrics {
+	q := r.query.Select("loadExecMetricsFromID")
+	q = q.Arg("id", id)
+
+	return &ExecMetrics{
+		query: q,
+	}
+}
+
 // Load a FieldTypeDef from its ID.
 func (r *Client) LoadFieldTypeDefFromID(id FieldTypeDefID) *FieldTypeDef {
 	q := r.query.Select("loadFieldTypeDefFromID")
@@ -8516,6 +10270,16 @@ func (r *Client) LoadScalarTypeDefFromID(id ScalarTypeDefID) *ScalarTypeDef {
 	}
 }
 
+// Load a SearchResult from its ID.
+func (r *Client) LoadSearchResultFromID(id SearchResultID) *SearchResult {
+	q := r.query.Select("loadSearchResultFromID")
+	q = q.Arg("id", id)
+
+	return &SearchResult{
+		query: q,
+	}
+}
+
 // Load a Secret from its ID.
 func (r *Client) LoadSecretFromID(id SecretID) *Secret {
 	q := r.query.Select("loadSecretFromID")
@@ -8841,6 +10605,115 @@ func (r *ScalarTypeDef) SourceModuleName(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// A match of a content search in a file.
+type SearchResult struct {
+	query *querybuilder.Selection
+
+	column      *int
+	filePath    *string
+	id          *SearchResultID
+	lineNumber  *int
+	matchedText *string
+}
+
+func (r *SearchResult) WithGraphQLQuery(q *querybuilder.Selection) *SearchResult {
+	return &SearchResult{
+		query: q,
+	}
+}
+
+// The byte offset of the match within its first line, starting at 1.
+func (r *SearchResult) Column(ctx context.Context) (int, error) {
+	if r.column != nil {
+		return *r.column, nil
+	}
+	q := r.query.Select("column")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The path to the file that matched.
+func (r *SearchResult) FilePath(ctx context.Context) (string, error) {
+	if r.filePath != nil {
+		return *r.filePath, nil
+	}
+	q := r.query.Select("filePath")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// A unique identifier for this SearchResult.
+func (r *SearchResult) ID(ctx context.Context) (SearchResultID, error) {
+	if r.id != nil {
+		return *r.id, nil
+	}
+	q := r.query.Select("id")
+
+	var response SearchResultID
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// XXX_GraphQLType is an internal function. It returns the native GraphQL type name
+func (r *SearchResult) XXX_GraphQLType() string {
+	return "SearchResult"
+}
+
+// XXX_GraphQLIDType is an internal function. It returns the native GraphQL type name for the ID of this object
+func (r *SearchResult) XXX_GraphQLIDType() string {
+	return "SearchResultID"
+}
+
+// XXX_GraphQLID is an internal function. It returns the underlying type ID
+func (r *SearchResult) XXX_GraphQLID(ctx context.Context) (string, error) {
+	id, err := r.ID(ctx)
+	if err != nil {
+		return "", err
+	}
+	return string(id), nil
+}
+
+func (r *SearchResult) MarshalJSON() ([]byte, error) {
+	id, err := r.ID(marshalCtx)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(id)
+}
+
+// The first line that matched, starting at 1.
+func (r *SearchResult) LineNumber(ctx context.Context) (int, error) {
+	if r.lineNumber != nil {
+		return *r.lineNumber, nil
+	}
+	q := r.query.Select("lineNumber")
+
+	var response int
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The text that matched the pattern.
+func (r *SearchResult) MatchedText(ctx context.Context) (string, error) {
+	if r.matchedText != nil {
+		return *r.matchedText, nil
+	}
+	q := r.query.Select("matchedText")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // A reference to a secret value, which can be handled more safely than the value itself.
 type Secret struct {
 	query *querybuilder.Selection
@@ -8848,6 +10721,7 @@ type Secret struct {
 	id        *SecretID
 	name      *string
 	plaintext *string
+	refresh   *SecretID
 	uri       *string
 }
 
@@ -8923,6 +10797,23 @@ func (r *Secret) Plaintext(ctx context.Context) (string, error) {
 	return response, q.Execute(ctx)
 }
 
+// Resolves this secret again from its provider, bypassing any cached value.
+//
+// Subsequent uses of the secret see the new value, which picks up rotated credentials in long-running sessions.
+//
+// This is a no-op for secrets created with setSecret.
+func (r *Secret) Refresh(ctx context.Context) (*Secret, error) {
+	q := r.query.Select("refresh")
+
+	var id SecretID
+	if err := q.Bind(&id).Execute(ctx); err != nil {
+		return nil, err
+	}
+	return &Secret{
+		query: q.Root().Select("loadSecretFromID").Arg("id", id),
+	}, nil
+}
+
 // The URI of this secret.
 func (r *Secret) URI(ctx context.Context) (string, error) {
 	if r.uri != nil {
@@ -8943,7 +10834,10 @@ type Service struct {
 	endpoint *string
 	hostname *string
 	id       *ServiceID
+	logs     *string
 	start    *ServiceID
+	stderr   *string
+	stdout   *string
 	stop     *ServiceID
 	up       *Void
 }
@@ -9050,6 +10944,47 @@ func (r *Service) MarshalJSON() ([]byte, error) {
 	return json.Marshal(id)
 }
 
+// ServiceLogsOpts contains options for Service.Logs
+type ServiceLogsOpts struct {
+	// Wait for the service to exit before returning its logs, instead of returning the output so far.
+	//
+	// The logs are not streamed while waiting: use "since" to poll for new lines instead.
+	WaitForExit bool
+	// Only return the lines written since the given time, either an RFC 3339 timestamp (e.g., "2006-01-02T15:04:05Z") or a duration before now (e.g., "5m").
+	Since string
+	// Only return the given number of last lines. All lines are returned if 0.
+	Tail int
+}
+
+// Retrieves the combined standard output and standard error of the service, in the order they were written.
+//
+// The service must be running or have run in the session. Only the most recent output is kept.
+func (r *Service) Logs(ctx context.Context, opts ...ServiceLogsOpts) (string, error) {
+	if r.logs != nil {
+		return *r.logs, nil
+	}
+	q := r.query.Select("logs")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `waitForExit` optional argument
+		if !querybuilder.IsZeroValue(opts[i].WaitForExit) {
+			q = q.Arg("waitForExit", opts[i].WaitForExit)
+		}
+		// `since` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Since) {
+			q = q.Arg("since", opts[i].Since)
+		}
+		// `tail` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Tail) {
+			q = q.Arg("tail", opts[i].Tail)
+		}
+	}
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // Retrieves the list of ports provided by the service.
 func (r *Service) Ports(ctx context.Context) ([]Port, error) {
 	q := r.query.Select("ports")
@@ -9083,6 +11018,19 @@ func (r *Service) Ports(ctx context.Context) ([]Port, error) {
 	return convert(response), nil
 }
 
+// Gracefully stops the service and captures its state as a container, starting it first if it's not running.
+//
+// The container's root filesystem includes the changes made by the service and the contents of its cache volumes, which are no longer mounted.
+//
+// The snapshot is cached like any other result, so later runs can start from the warmed-up state.
+func (r *Service) Snapshot() *Container {
+	q := r.query.Select("snapshot")
+
+	return &Container{
+		query: q,
+	}
+}
+
 // Start the service and wait for its health checks to succeed.
 //
 // Services bound to a Container do not need to be manually started.
@@ -9098,6 +11046,36 @@ func (r *Service) Start(ctx context.Context) (*Service, error) {
 	}, nil
 }
 
+// The standard error of the service so far.
+//
+// The service must be running or have run in the session. Only the most recent output is kept.
+func (r *Service) Stderr(ctx context.Context) (string, error) {
+	if r.stderr != nil {
+		return *r.stderr, nil
+	}
+	q := r.query.Select("stderr")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
+// The standard output of the service so far.
+//
+// The service must be running or have run in the session. Only the most recent output is kept.
+func (r *Service) Stdout(ctx context.Context) (string, error) {
+	if r.stdout != nil {
+		return *r.stdout, nil
+	}
+	q := r.query.Select("stdout")
+
+	var response string
+
+	q = q.Bind(&response)
+	return response, q.Execute(ctx)
+}
+
 // ServiceStopOpts contains options for Service.Stop
 type ServiceStopOpts struct {
 	// Immediately kill the service without waiting for a graceful exit
@@ -9153,6 +11131,37 @@ func (r *Service) Up(ctx context.Context, opts ...ServiceUpOpts) error {
 	return q.Execute(ctx)
 }
 
+// ServiceWithDependencyOpts contains options for Service.WithDependency
+type ServiceWithDependencyOpts struct {
+	// The condition the dependency must meet before this service starts.
+	//
+	// Use COMPLETED for jobs like database migrations, which must exit successfully.
+	//
+	// Default: HEALTHY
+	Condition ServiceDependencyCondition
+}
+
+// Configures a service to start before this service, and to stop along with it.
+//
+// Dependencies start in the order they are added, each once the previous one meets its condition.
+//
+// A dependency is reachable at its hostname within the session.
+func (r *Service) WithDependency(service *Service, opts ...ServiceWithDependencyOpts) *Service {
+	assertNotNil("service", service)
+	q := r.query.Select("withDependency")
+	for i := len(opts) - 1; i >= 0; i-- {
+		// `condition` optional argument
+		if !querybuilder.IsZeroValue(opts[i].Condition) {
+			q = q.Arg("condition", opts[i].Condition)
+		}
+	}
+	q = q.Arg("service", service)
+
+	return &Service{
+		query: q,
+	}
+}
+
 // Configures a hostname which can be used by clients within the session to reach this container.
 func (r *Service) WithHostname(hostname string) *Service {
 	q := r.query.Select("withHostname")
@@ -9771,6 +11780,25 @@ const (
 	CacheSharingModeShared CacheSharingMode = "SHARED"
 )
 
+// The kind of change made to a file.
+type ChangeKind string
+
+func (ChangeKind) IsEnum() {}
+
+const (
+	// The file was created.
+	ChangeKindAdded ChangeKind = "ADDED"
+
+	// The contents or permissions of the file were changed.
+	ChangeKindModified ChangeKind = "MODIFIED"
+
+	// The file was deleted.
+	ChangeKindRemoved ChangeKind = "REMOVED"
+
+	// The file was moved without changing its contents.
+	ChangeKindRenamed ChangeKind = "RENAMED"
+)
+
 // Compression algorithm to use for image layers.
 type ImageLayerCompression string
 
@@ -9837,6 +11865,35 @@ const (
 	ReturnTypeSuccess ReturnType = "SUCCESS"
 )
 
+// Format of a software bill of materials.
+type SBOMFormat string
+
+func (SBOMFormat) IsEnum() {}
+
+const (
+	// CycloneDX 1.5 JSON document.
+	SBOMFormatCyclonedx SBOMFormat = "CYCLONEDX"
+
+	// SPDX 2.3 JSON document.
+	SBOMFormatSpdx SBOMFormat = "SPDX"
+)
+
+// The condition a service dependency must meet before the service depending on it starts.
+type ServiceDependencyCondition string
+
+func (ServiceDependencyCondition) IsEnum() {}
+
+const (
+	// The dependency has run to completion and exited successfully.
+	ServiceDependencyConditionCompleted ServiceDependencyCondition = "COMPLETED"
+
+	// The dependency has started and its health checks passed.
+	ServiceDependencyConditionHealthy ServiceDependencyCondition = "HEALTHY"
+
+	// The dependency has started, regardless of its health checks.
+	ServiceDependencyConditionStarted ServiceDependencyCondition = "STARTED"
+)
+
 // Distinguishes the different kinds of TypeDefs.
 type TypeDefKind string
 
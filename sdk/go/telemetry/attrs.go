@@ -72,6 +72,22 @@ const (
 	// Indicates that this span was a cache hit and did nothing.
 	CachedAttr = "dagger.io/dag.cached"
 
+	// The cache policy scoping the cache key of the call, which explains why
+	// it may re-run where other calls would be cached.
+	//
+	// Example: CachePerClient, CachePerCall, DoNotCache
+	CachePolicyAttr = "dagger.io/dag.cache.policy"
+
+	// Why the call was not cached, recorded when it misses the cache because
+	// of its cache policy. Other misses are explained on demand, by the
+	// engine's cacheMiss API.
+	//
+	// Example: CACHE_POLICY
+	CacheMissReasonAttr = "dagger.io/dag.cache.miss.reason"
+
+	// A human readable explanation of why the call was not cached.
+	CacheMissDescriptionAttr = "dagger.io/dag.cache.miss.description"
+
 	// A list of completed effect IDs.
 	//
 	// This is primarily used for cached ops - since we don't see a span for a
@@ -58,6 +58,68 @@ export enum CacheSharingMode {
  */
 export type CacheVolumeID = string & { __CacheVolumeID: never }
 
+/**
+ * The kind of change made to a file.
+ */
+export enum ChangeKind {
+  /**
+   * The file was created.
+   */
+  Added = "ADDED",
+
+  /**
+   * The contents or permissions of the file were changed.
+   */
+  Modified = "MODIFIED",
+
+  /**
+   * The file was deleted.
+   */
+  Removed = "REMOVED",
+
+  /**
+   * The file was moved without changing its contents.
+   */
+  Renamed = "RENAMED",
+}
+/**
+ * The `ChangesetEntryID` scalar type represents an identifier for an object of type ChangesetEntry.
+ */
+export type ChangesetEntryID = string & { __ChangesetEntryID: never }
+
+/**
+ * The `ChangesetID` scalar type represents an identifier for an object of type Changeset.
+ */
+export type ChangesetID = string & { __ChangesetID: never }
+
+/**
+ * The `ComposeStackID` scalar type represents an identifier for an object of type ComposeStack.
+ */
+export type ComposeStackID = string & { __ComposeStackID: never }
+
+export type ContainerAsOcilayoutOpts = {
+  /**
+   * Identifiers for other platform specific containers.
+   *
+   * Used for multi-platform images.
+   */
+  platformVariants?: Container[]
+
+  /**
+   * Force each layer of the image to use the specified compression algorithm.
+   *
+   * If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+   */
+  forcedCompression?: ImageLayerCompression
+
+  /**
+   * Use the specified media types for the image's layers.
+   *
+   * Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+   */
+  mediaTypes?: ImageMediaTypes
+}
+
 export type ContainerAsServiceOpts = {
   /**
    * Command to run instead of the container's default command (e.g., ["go", "run", "main.go"]).
@@ -185,6 +247,34 @@ export type ContainerExportOpts = {
   expand?: boolean
 }
 
+export type ContainerExportOcilayoutOpts = {
+  /**
+   * Identifiers for other platform specific containers.
+   *
+   * Used for multi-platform image.
+   */
+  platformVariants?: Container[]
+
+  /**
+   * Force each layer of the exported image to use the specified compression algorithm.
+   *
+   * If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+   */
+  forcedCompression?: ImageLayerCompression
+
+  /**
+   * Use the specified media types for the exported image's layers.
+   *
+   * Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+   */
+  mediaTypes?: ImageMediaTypes
+
+  /**
+   * Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+   */
+  expand?: boolean
+}
+
 export type ContainerFileOpts = {
   /**
    * Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo.txt").
@@ -192,6 +282,15 @@ export type ContainerFileOpts = {
   expand?: boolean
 }
 
+export type ContainerFromOpts = {
+  /**
+   * PEM encoded public key to verify the image signature with.
+   *
+   * If set, the image must have a cosign signature made with the matching private key, or the download fails.
+   */
+  verifyKey?: Secret
+}
+
 export type ContainerImportOpts = {
   /**
    * Identifies the tag to import from the archive, if the archive bundles multiple tags.
@@ -199,6 +298,13 @@ export type ContainerImportOpts = {
   tag?: string
 }
 
+export type ContainerImportOcilayoutOpts = {
+  /**
+   * Identifies the tag to import from the layout, if the layout bundles multiple tags.
+   */
+  tag?: string
+}
+
 export type ContainerPublishOpts = {
   /**
    * Identifiers for other platform specific containers.
@@ -220,6 +326,32 @@ export type ContainerPublishOpts = {
    * Defaults to "OCI", which is compatible with most recent registries, but "Docker" may be needed for older registries without OCI support.
    */
   mediaTypes?: ImageMediaTypes
+
+  /**
+   * Attach a software bill of materials in the given format to the image of each platform.
+   *
+   * The packages are listed from the apk, dpkg and Python package metadata found in the container filesystem.
+   */
+  sbom?: SBOMFormat
+
+  /**
+   * Attach SLSA provenance to the image of each platform, recording the digest of the call that built it.
+   *
+   * The base images and git commits it was built from are listed as its resolved dependencies.
+   */
+  provenance?: boolean
+
+  /**
+   * PEM encoded private key to sign the published image with.
+   *
+   * The signature is pushed to the registry next to the image in the cosign format, so it can be verified with "cosign verify --key".
+   */
+  signingKey?: Secret
+
+  /**
+   * Password to decrypt the signing key, if it is encrypted as generated by "cosign generate-key-pair".
+   */
+  signingKeyPassword?: Secret
 }
 
 export type ContainerTerminalOpts = {
@@ -365,6 +497,31 @@ export type ContainerWithExecOpts = {
    */
   expect?: ReturnType
 
+  /**
+   * Exact exit codes this command is allowed to exit with without error, instead of "expect". Example: [0, 2]
+   */
+  expectExitCodes?: number[]
+
+  /**
+   * Fail the command with an EXEC_ASSERTION_ERROR if its standard output doesn't match this regular expression (RE2 syntax). Example: "listening on port \\d+"
+   */
+  expectStdoutRegex?: string
+
+  /**
+   * Like expectStdoutRegex, but for standard error
+   */
+  expectStderrRegex?: string
+
+  /**
+   * Fail the command with an EXEC_ASSERTION_ERROR if its standard output contains this string. Example: "panic:"
+   */
+  expectStdoutNotContains?: string
+
+  /**
+   * Like expectStdoutNotContains, but for standard error
+   */
+  expectStderrNotContains?: string
+
   /**
    * Provides Dagger access to the executed command.
    */
@@ -388,6 +545,27 @@ export type ContainerWithExecOpts = {
    * Only use this if you specifically need the command to be pid 1 in the container. Otherwise it may result in unexpected behavior. If you're not sure, you don't need this.
    */
   noInit?: boolean
+
+  /**
+   * Resource limits enforced on the command with cgroups. Example: {cpus: 0.5, memoryBytes: 536870912, pids: 100}
+   */
+  limits?: ExecLimits
+
+  /**
+   * Kill the command if it runs for longer than this duration, failing with an EXEC_TIMEOUT_ERROR. Example: "10m"
+   *
+   * When retrying, each attempt has its own timeout.
+   */
+  timeout?: string
+
+  /**
+   * Run the command again when it fails, up to the given number of attempts. Example: {attempts: 3, backoff: "1s", retryOnExitCodes: [1], retryOnStderrRegex: "connection reset"}
+   *
+   * Each attempt starts from the same filesystem: only changes to cache volumes are kept across attempts. Only the output of the last attempt is kept.
+   *
+   * To restore it, what the command may write to is copied before the first attempt: this is only the writable layer of the container with the overlayfs snapshotter, but would be the whole filesystem with other snapshotters, so retrying fails with them instead.
+   */
+  retry?: ExecRetry
 }
 
 export type ContainerWithExposedPortOpts = {
@@ -449,6 +627,43 @@ export type ContainerWithFilesOpts = {
   expand?: boolean
 }
 
+export type ContainerWithHealthcheckOpts = {
+  /**
+   * Command to run in the service container. The service is healthy when it exits with status 0.
+   */
+  args?: string[]
+
+  /**
+   * Path to request with an HTTP GET on the port, or the first exposed TCP port. The service is healthy on a 2xx or 3xx response.
+   */
+  httpPath?: string
+
+  /**
+   * TCP port to check. Alone, the service is healthy once the port accepts connections.
+   */
+  port?: number
+
+  /**
+   * Time between two checks, as a duration string (e.g., "1s", "500ms").
+   */
+  interval?: string
+
+  /**
+   * Time after which a check fails, as a duration string.
+   */
+  timeout?: string
+
+  /**
+   * Number of consecutive failed checks after which the service is unhealthy and fails to start.
+   */
+  retries?: number
+
+  /**
+   * Time during which failed checks don't count towards retries, as a duration string.
+   */
+  startPeriod?: string
+}
+
 export type ContainerWithMountedCacheOpts = {
   /**
    * Identifier of the directory to use as the cache volume's root.
@@ -659,6 +874,13 @@ export type CurrentModuleWorkdirOpts = {
  */
 export type CurrentModuleID = string & { __CurrentModuleID: never }
 
+export type DirectoryAsComposeStackOpts = {
+  /**
+   * Path to the Compose file (e.g., "stack/compose.yaml").
+   */
+  file?: string
+}
+
 export type DirectoryAsModuleOpts = {
   /**
    * An optional subpath of the directory which contains the module's configuration file.
@@ -739,6 +961,23 @@ export type DirectoryFilterOpts = {
   include?: string[]
 }
 
+export type DirectorySearchOpts = {
+  /**
+   * Interpret the pattern as a literal string instead of a regular expression.
+   */
+  literal?: boolean
+
+  /**
+   * If set, only search the paths matching one of these glob patterns (e.g., ["src/", "*.go"]).
+   */
+  paths?: string[]
+
+  /**
+   * Allow matches to span multiple lines.
+   */
+  multiline?: boolean
+}
+
 export type DirectoryTerminalOpts = {
   /**
    * If set, override the container's default terminal command and invoke these command arguments instead.
@@ -801,6 +1040,30 @@ export type DirectoryWithNewFileOpts = {
   permissions?: number
 }
 
+export type DirectoryWithPatchOpts = {
+  /**
+   * Require each hunk to apply at exactly the line numbers in its header.
+   */
+  strict?: boolean
+
+  /**
+   * The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+   */
+  fuzz?: number
+}
+
+export type DirectoryWithPatchFileOpts = {
+  /**
+   * Require each hunk to apply at exactly the line numbers in its header.
+   */
+  strict?: boolean
+
+  /**
+   * The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+   */
+  fuzz?: number
+}
+
 /**
  * The `DirectoryID` scalar type represents an identifier for an object of type Directory.
  */
@@ -810,6 +1073,43 @@ export type EngineCacheEntrySetOpts = {
   key?: string
 }
 
+export type EngineCachePruneOpts = {
+  /**
+   * Only prune entries created at least this long ago, as a duration string. Example: "168h"
+   */
+  olderThan?: string
+
+  /**
+   * Only prune entries that haven't been used for at least this long, as a duration string. Example: "72h"
+   */
+  unusedFor?: string
+
+  /**
+   * Only prune entries whose description matches this regular expression (RE2 syntax).
+   */
+  descriptionRegex?: string
+
+  /**
+   * Only prune entries of these record types. Example: ["exec.cachemount"]
+   */
+  recordTypes?: string[]
+
+  /**
+   * Only prune entries using at least this much disk space.
+   */
+  minSizeBytes?: number
+
+  /**
+   * Only prune the entries with these IDs, as returned by entryID.
+   */
+  entryIDs?: string[]
+
+  /**
+   * Return the entries that would be pruned without pruning them.
+   */
+  dryRun?: boolean
+}
+
 /**
  * The `EngineCacheEntryID` scalar type represents an identifier for an object of type EngineCacheEntry.
  */
@@ -825,6 +1125,16 @@ export type EngineCacheEntrySetID = string & { __EngineCacheEntrySetID: never }
  */
 export type EngineCacheID = string & { __EngineCacheID: never }
 
+/**
+ * The `EngineCacheMissID` scalar type represents an identifier for an object of type EngineCacheMiss.
+ */
+export type EngineCacheMissID = string & { __EngineCacheMissID: never }
+
+/**
+ * The `EngineCacheVolumeID` scalar type represents an identifier for an object of type EngineCacheVolume.
+ */
+export type EngineCacheVolumeID = string & { __EngineCacheVolumeID: never }
+
 /**
  * The `EngineID` scalar type represents an identifier for an object of type Engine.
  */
@@ -860,6 +1170,50 @@ export type ErrorID = string & { __ErrorID: never }
  */
 export type ErrorValueID = string & { __ErrorValueID: never }
 
+export type ExecLimits = {
+  /**
+   * Number of CPUs the command may use, possibly fractional (e.g., 0.5).
+   */
+  cpus?: float
+
+  /**
+   * Memory the command may use in bytes, beyond which it is killed.
+   */
+  memoryBytes?: number
+
+  /**
+   * Number of processes and threads the command may run at once.
+   */
+  pids?: number
+}
+
+/**
+ * The `ExecMetricsID` scalar type represents an identifier for an object of type ExecMetrics.
+ */
+export type ExecMetricsID = string & { __ExecMetricsID: never }
+
+export type ExecRetry = {
+  /**
+   * Maximum number of times to run the command, including the first attempt.
+   */
+  attempts: number
+
+  /**
+   * Delay before the first retry as a duration string, doubled after each retry until it reaches a minute. Defaults to retrying immediately.
+   */
+  backoff?: string
+
+  /**
+   * Only retry when the command exits with one of these codes.
+   */
+  retryOnExitCodes?: number[]
+
+  /**
+   * Only retry when the standard error of the command matches this regular expression (RE2 syntax).
+   */
+  retryOnStderrRegex?: string
+}
+
 /**
  * The `FieldTypeDefID` scalar type represents an identifier for an object of type FieldTypeDef.
  */
@@ -879,6 +1233,42 @@ export type FileExportOpts = {
   allowParentDirPath?: boolean
 }
 
+export type FileSearchOpts = {
+  /**
+   * Interpret the pattern as a literal string instead of a regular expression.
+   */
+  literal?: boolean
+
+  /**
+   * Allow matches to span multiple lines.
+   */
+  multiline?: boolean
+}
+
+export type FileWithPatchOpts = {
+  /**
+   * Require each hunk to apply at exactly the line numbers in its header.
+   */
+  strict?: boolean
+
+  /**
+   * The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+   */
+  fuzz?: number
+}
+
+export type FileWithReplacedOpts = {
+  /**
+   * Replace all occurrences of the search string.
+   */
+  all?: boolean
+
+  /**
+   * Replace only the first occurrence found at or after this line number, starting at 1.
+   */
+  firstFrom?: number
+}
+
 /**
  * The `FileID` scalar type represents an identifier for an object of type File.
  */
@@ -1251,15 +1641,34 @@ export enum ReturnType {
   Success = "SUCCESS",
 }
 /**
- * The `SDKConfigID` scalar type represents an identifier for an object of type SDKConfig.
+ * Format of a software bill of materials.
  */
-export type SDKConfigID = string & { __SDKConfigID: never }
+export enum SBOMFormat {
+  /**
+   * CycloneDX 1.5 JSON document.
+   */
+  Cyclonedx = "CYCLONEDX",
 
-/**
+  /**
+   * SPDX 2.3 JSON document.
+   */
+  Spdx = "SPDX",
+}
+/**
+ * The `SDKConfigID` scalar type represents an identifier for an object of type SDKConfig.
+ */
+export type SDKConfigID = string & { __SDKConfigID: never }
+
+/**
  * The `ScalarTypeDefID` scalar type represents an identifier for an object of type ScalarTypeDef.
  */
 export type ScalarTypeDefID = string & { __ScalarTypeDefID: never }
 
+/**
+ * The `SearchResultID` scalar type represents an identifier for an object of type SearchResult.
+ */
+export type SearchResultID = string & { __SearchResultID: never }
+
 /**
  * The `SecretID` scalar type represents an identifier for an object of type Secret.
  */
@@ -1277,6 +1686,25 @@ export type ServiceEndpointOpts = {
   scheme?: string
 }
 
+export type ServiceLogsOpts = {
+  /**
+   * Wait for the service to exit before returning its logs, instead of returning the output so far.
+   *
+   * The logs are not streamed while waiting: use "since" to poll for new lines instead.
+   */
+  waitForExit?: boolean
+
+  /**
+   * Only return the lines written since the given time, either an RFC 3339 timestamp (e.g., "2006-01-02T15:04:05Z") or a duration before now (e.g., "5m").
+   */
+  since?: string
+
+  /**
+   * Only return the given number of last lines. All lines are returned if 0.
+   */
+  tail?: number
+}
+
 export type ServiceStopOpts = {
   /**
    * Immediately kill the service without waiting for a graceful exit
@@ -1298,6 +1726,34 @@ export type ServiceUpOpts = {
   random?: boolean
 }
 
+export type ServiceWithDependencyOpts = {
+  /**
+   * The condition the dependency must meet before this service starts.
+   *
+   * Use COMPLETED for jobs like database migrations, which must exit successfully.
+   */
+  condition?: ServiceDependencyCondition
+}
+
+/**
+ * The condition a service dependency must meet before the service depending on it starts.
+ */
+export enum ServiceDependencyCondition {
+  /**
+   * The dependency has run to completion and exited successfully.
+   */
+  Completed = "COMPLETED",
+
+  /**
+   * The dependency has started and its health checks passed.
+   */
+  Healthy = "HEALTHY",
+
+  /**
+   * The dependency has started, regardless of its health checks.
+   */
+  Started = "STARTED",
+}
 /**
  * The `ServiceID` scalar type represents an identifier for an object of type Service.
  */
@@ -1510,6 +1966,30 @@ export class Binding extends BaseClient {
     return new CacheVolume(ctx)
   }
 
+  /**
+   * Retrieve the binding value, as type Changeset
+   */
+  asChangeset = (): Changeset => {
+    const ctx = this._ctx.select("asChangeset")
+    return new Changeset(ctx)
+  }
+
+  /**
+   * Retrieve the binding value, as type ChangesetEntry
+   */
+  asChangesetEntry = (): ChangesetEntry => {
+    const ctx = this._ctx.select("asChangesetEntry")
+    return new ChangesetEntry(ctx)
+  }
+
+  /**
+   * Retrieve the binding value, as type ComposeStack
+   */
+  asComposeStack = (): ComposeStack => {
+    const ctx = this._ctx.select("asComposeStack")
+    return new ComposeStack(ctx)
+  }
+
   /**
    * Retrieve the binding value, as type Container
    */
@@ -1526,6 +2006,22 @@ export class Binding extends BaseClient {
     return new Directory(ctx)
   }
 
+  /**
+   * Retrieve the binding value, as type EngineCacheMiss
+   */
+  asEngineCacheMiss = (): EngineCacheMiss => {
+    const ctx = this._ctx.select("asEngineCacheMiss")
+    return new EngineCacheMiss(ctx)
+  }
+
+  /**
+   * Retrieve the binding value, as type EngineCacheVolume
+   */
+  asEngineCacheVolume = (): EngineCacheVolume => {
+    const ctx = this._ctx.select("asEngineCacheVolume")
+    return new EngineCacheVolume(ctx)
+  }
+
   /**
    * Retrieve the binding value, as type Env
    */
@@ -1695,14 +2191,23 @@ export class Binding extends BaseClient {
  */
 export class CacheVolume extends BaseClient {
   private readonly _id?: CacheVolumeID = undefined
+  private readonly _delete?: Void = undefined
+  private readonly _seed?: Void = undefined
 
   /**
    * Constructor is used for internal usage only, do not create object from it.
    */
-  constructor(ctx?: Context, _id?: CacheVolumeID) {
+  constructor(
+    ctx?: Context,
+    _id?: CacheVolumeID,
+    _delete?: Void,
+    _seed?: Void,
+  ) {
     super(ctx)
 
     this._id = _id
+    this._delete = _delete
+    this._seed = _seed
   }
 
   /**
@@ -1719,147 +2224,120 @@ export class CacheVolume extends BaseClient {
 
     return response
   }
+
+  /**
+   * Deletes the contents of the cache volume from the local cache.
+   *
+   * Containers currently using the volume keep their contents until they exit; later mounts start empty.
+   */
+  delete_ = async (): Promise<void> => {
+    if (this._delete) {
+      return
+    }
+
+    const ctx = this._ctx.select("delete")
+
+    await ctx.execute()
+  }
+
+  /**
+   * Replaces the contents of the cache volume with the contents of a directory.
+   *
+   * The contents are used when the volume is mounted without a source directory.
+   * @param source The directory to copy into the cache volume.
+   */
+  seed = async (source: Directory): Promise<void> => {
+    if (this._seed) {
+      return
+    }
+
+    const ctx = this._ctx.select("seed", { source })
+
+    await ctx.execute()
+  }
+
+  /**
+   * Returns a copy of the current contents of the cache volume.
+   *
+   * Only the contents of the volume when mounted without a source directory are included.
+   */
+  snapshot = (): Directory => {
+    const ctx = this._ctx.select("snapshot")
+    return new Directory(ctx)
+  }
 }
 
 /**
- * An OCI-compatible container, also known as a Docker container.
+ * The file changes between two directories.
  */
-export class Container extends BaseClient {
-  private readonly _id?: ContainerID = undefined
-  private readonly _envVariable?: string = undefined
-  private readonly _exitCode?: number = undefined
-  private readonly _export?: string = undefined
-  private readonly _imageRef?: string = undefined
-  private readonly _label?: string = undefined
-  private readonly _platform?: Platform = undefined
-  private readonly _publish?: string = undefined
-  private readonly _stderr?: string = undefined
-  private readonly _stdout?: string = undefined
-  private readonly _sync?: ContainerID = undefined
-  private readonly _up?: Void = undefined
-  private readonly _user?: string = undefined
-  private readonly _workdir?: string = undefined
+export class Changeset extends BaseClient {
+  private readonly _id?: ChangesetID = undefined
 
   /**
    * Constructor is used for internal usage only, do not create object from it.
    */
-  constructor(
-    ctx?: Context,
-    _id?: ContainerID,
-    _envVariable?: string,
-    _exitCode?: number,
-    _export?: string,
-    _imageRef?: string,
-    _label?: string,
-    _platform?: Platform,
-    _publish?: string,
-    _stderr?: string,
-    _stdout?: string,
-    _sync?: ContainerID,
-    _up?: Void,
-    _user?: string,
-    _workdir?: string,
-  ) {
+  constructor(ctx?: Context, _id?: ChangesetID) {
     super(ctx)
 
     this._id = _id
-    this._envVariable = _envVariable
-    this._exitCode = _exitCode
-    this._export = _export
-    this._imageRef = _imageRef
-    this._label = _label
-    this._platform = _platform
-    this._publish = _publish
-    this._stderr = _stderr
-    this._stdout = _stdout
-    this._sync = _sync
-    this._up = _up
-    this._user = _user
-    this._workdir = _workdir
   }
 
   /**
-   * A unique identifier for this Container.
+   * A unique identifier for this Changeset.
    */
-  id = async (): Promise<ContainerID> => {
+  id = async (): Promise<ChangesetID> => {
     if (this._id) {
       return this._id
     }
 
     const ctx = this._ctx.select("id")
 
-    const response: Awaited<ContainerID> = await ctx.execute()
+    const response: Awaited<ChangesetID> = await ctx.execute()
 
     return response
   }
 
   /**
-   * Turn the container into a Service.
-   *
-   * Be sure to set any exposed ports before this conversion.
-   * @param opts.args Command to run instead of the container's default command (e.g., ["go", "run", "main.go"]).
-   *
-   * If empty, the container's default command is used.
-   * @param opts.useEntrypoint If the container has an entrypoint, prepend it to the args.
-   * @param opts.experimentalPrivilegedNesting Provides Dagger access to the executed command.
-   * @param opts.insecureRootCapabilities Execute the command with all root capabilities. This is similar to running a command with "sudo" or executing "docker run" with the "--privileged" flag. Containerization does not provide any security guarantees when using this option. It should only be used when absolutely necessary and only with trusted commands.
-   * @param opts.expand Replace "${VAR}" or "$VAR" in the args according to the current environment variables defined in the container (e.g. "/$VAR/foo").
-   * @param opts.noInit If set, skip the automatic init process injected into containers by default.
-   *
-   * This should only be used if the user requires that their exec process be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
+   * The paths of the files that were created.
    */
-  asService = (opts?: ContainerAsServiceOpts): Service => {
-    const ctx = this._ctx.select("asService", { ...opts })
-    return new Service(ctx)
+  addedPaths = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("addedPaths")
+
+    const response: Awaited<string[]> = await ctx.execute()
+
+    return response
   }
 
   /**
-   * Package the container state as an OCI image, and return it as a tar archive
-   * @param opts.platformVariants Identifiers for other platform specific containers.
-   *
-   * Used for multi-platform images.
-   * @param opts.forcedCompression Force each layer of the image to use the specified compression algorithm.
-   *
-   * If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
-   * @param opts.mediaTypes Use the specified media types for the image's layers.
-   *
-   * Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+   * Returns the changes as a patch file in the format of "git diff", which can be applied with Directory.withPatchFile.
    */
-  asTarball = (opts?: ContainerAsTarballOpts): File => {
-    const metadata = {
-      forcedCompression: { is_enum: true },
-      mediaTypes: { is_enum: true },
-    }
-
-    const ctx = this._ctx.select("asTarball", { ...opts, __metadata: metadata })
+  asPatch = (): File => {
+    const ctx = this._ctx.select("asPatch")
     return new File(ctx)
   }
 
   /**
-   * Initializes this container from a Dockerfile build.
-   * @param context Directory context used by the Dockerfile.
-   * @param opts.dockerfile Path to the Dockerfile to use.
-   * @param opts.target Target build stage to build.
-   * @param opts.buildArgs Additional build arguments.
-   * @param opts.secrets Secrets to pass to the build.
-   *
-   * They will be mounted at /run/secrets/[secret-name] in the build container
-   *
-   * They can be accessed in the Dockerfile using the "secret" mount type and mount path /run/secrets/[secret-name], e.g. RUN --mount=type=secret,id=my-secret curl [http://example.com?token=$(cat /run/secrets/my-secret)](http://example.com?token=$(cat /run/secrets/my-secret))
-   * @param opts.noInit If set, skip the automatic init process injected into containers created by RUN statements.
-   *
-   * This should only be used if the user requires that their exec processes be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
+   * The changes made to each file, sorted by path.
    */
-  build = (context: Directory, opts?: ContainerBuildOpts): Container => {
-    const ctx = this._ctx.select("build", { context, ...opts })
-    return new Container(ctx)
+  entries = async (): Promise<ChangesetEntry[]> => {
+    type entries = {
+      id: ChangesetEntryID
+    }
+
+    const ctx = this._ctx.select("entries").select("id")
+
+    const response: Awaited<entries[]> = await ctx.execute()
+
+    return response.map((r) =>
+      new Client(ctx.copy()).loadChangesetEntryFromID(r.id),
+    )
   }
 
   /**
-   * Return the container's default arguments.
+   * The paths of the files whose contents or permissions were changed.
    */
-  defaultArgs = async (): Promise<string[]> => {
-    const ctx = this._ctx.select("defaultArgs")
+  modifiedPaths = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("modifiedPaths")
 
     const response: Awaited<string[]> = await ctx.execute()
 
@@ -1867,64 +2345,431 @@ export class Container extends BaseClient {
   }
 
   /**
-   * Retrieve a directory from the container's root filesystem
-   *
-   * Mounts are included.
-   * @param path The path of the directory to retrieve (e.g., "./src").
-   * @param opts.expand Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+   * The paths of the files that were deleted.
    */
-  directory = (path: string, opts?: ContainerDirectoryOpts): Directory => {
-    const ctx = this._ctx.select("directory", { path, ...opts })
-    return new Directory(ctx)
+  removedPaths = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("removedPaths")
+
+    const response: Awaited<string[]> = await ctx.execute()
+
+    return response
   }
 
   /**
-   * Return the container's OCI entrypoint.
+   * The new paths of the files that were moved without changing their contents.
    */
-  entrypoint = async (): Promise<string[]> => {
-    const ctx = this._ctx.select("entrypoint")
+  renamedPaths = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("renamedPaths")
 
     const response: Awaited<string[]> = await ctx.execute()
 
     return response
   }
+}
+
+/**
+ * A change made to a file.
+ */
+export class ChangesetEntry extends BaseClient {
+  private readonly _id?: ChangesetEntryID = undefined
+  private readonly _diff?: string = undefined
+  private readonly _kind?: ChangeKind = undefined
+  private readonly _oldPath?: string = undefined
+  private readonly _path?: string = undefined
 
   /**
-   * Retrieves the value of the specified environment variable.
-   * @param name The name of the environment variable to retrieve (e.g., "PATH").
+   * Constructor is used for internal usage only, do not create object from it.
    */
-  envVariable = async (name: string): Promise<string> => {
-    if (this._envVariable) {
-      return this._envVariable
+  constructor(
+    ctx?: Context,
+    _id?: ChangesetEntryID,
+    _diff?: string,
+    _kind?: ChangeKind,
+    _oldPath?: string,
+    _path?: string,
+  ) {
+    super(ctx)
+
+    this._id = _id
+    this._diff = _diff
+    this._kind = _kind
+    this._oldPath = _oldPath
+    this._path = _path
+  }
+
+  /**
+   * A unique identifier for this ChangesetEntry.
+   */
+  id = async (): Promise<ChangesetEntryID> => {
+    if (this._id) {
+      return this._id
     }
 
-    const ctx = this._ctx.select("envVariable", { name })
+    const ctx = this._ctx.select("id")
 
-    const response: Awaited<string> = await ctx.execute()
+    const response: Awaited<ChangesetEntryID> = await ctx.execute()
 
     return response
   }
 
   /**
-   * Retrieves the list of environment variables passed to commands.
+   * The unified diff of the file, in the format of git diff.
    */
-  envVariables = async (): Promise<EnvVariable[]> => {
-    type envVariables = {
-      id: EnvVariableID
+  diff = async (): Promise<string> => {
+    if (this._diff) {
+      return this._diff
     }
 
-    const ctx = this._ctx.select("envVariables").select("id")
+    const ctx = this._ctx.select("diff")
 
-    const response: Awaited<envVariables[]> = await ctx.execute()
+    const response: Awaited<string> = await ctx.execute()
 
-    return response.map((r) =>
-      new Client(ctx.copy()).loadEnvVariableFromID(r.id),
-    )
+    return response
   }
 
   /**
-   * The exit code of the last executed command
-   *
+   * The kind of change.
+   */
+  kind = async (): Promise<ChangeKind> => {
+    if (this._kind) {
+      return this._kind
+    }
+
+    const ctx = this._ctx.select("kind")
+
+    const response: Awaited<ChangeKind> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The path of the file before the change, if it was renamed.
+   */
+  oldPath = async (): Promise<string> => {
+    if (this._oldPath) {
+      return this._oldPath
+    }
+
+    const ctx = this._ctx.select("oldPath")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The path of the file after the change, or before it if it was removed.
+   */
+  path = async (): Promise<string> => {
+    if (this._path) {
+      return this._path
+    }
+
+    const ctx = this._ctx.select("path")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+}
+
+/**
+ * The services defined by a Compose file.
+ */
+export class ComposeStack extends BaseClient {
+  private readonly _id?: ComposeStackID = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(ctx?: Context, _id?: ComposeStackID) {
+    super(ctx)
+
+    this._id = _id
+  }
+
+  /**
+   * A unique identifier for this ComposeStack.
+   */
+  id = async (): Promise<ComposeStackID> => {
+    if (this._id) {
+      return this._id
+    }
+
+    const ctx = this._ctx.select("id")
+
+    const response: Awaited<ComposeStackID> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Retrieves a service of the stack.
+   *
+   * The service is reachable by its name within the session, and starts the services it depends on first.
+   *
+   * Named volumes are mounted as cache volumes, and bind mounts from the directory of the Compose file.
+   * @param name The name of the service in the Compose file.
+   */
+  service = (name: string): Service => {
+    const ctx = this._ctx.select("service", { name })
+    return new Service(ctx)
+  }
+
+  /**
+   * The names of the services of the stack, in alphabetical order.
+   */
+  serviceNames = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("serviceNames")
+
+    const response: Awaited<string[]> = await ctx.execute()
+
+    return response
+  }
+}
+
+/**
+ * An OCI-compatible container, also known as a Docker container.
+ */
+export class Container extends BaseClient {
+  private readonly _id?: ContainerID = undefined
+  private readonly _envVariable?: string = undefined
+  private readonly _exitCode?: number = undefined
+  private readonly _export?: string = undefined
+  private readonly _exportOCILayout?: string = undefined
+  private readonly _imageRef?: string = undefined
+  private readonly _label?: string = undefined
+  private readonly _platform?: Platform = undefined
+  private readonly _publish?: string = undefined
+  private readonly _stderr?: string = undefined
+  private readonly _stdout?: string = undefined
+  private readonly _sync?: ContainerID = undefined
+  private readonly _up?: Void = undefined
+  private readonly _user?: string = undefined
+  private readonly _workdir?: string = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+    _id?: ContainerID,
+    _envVariable?: string,
+    _exitCode?: number,
+    _export?: string,
+    _exportOCILayout?: string,
+    _imageRef?: string,
+    _label?: string,
+    _platform?: Platform,
+    _publish?: string,
+    _stderr?: string,
+    _stdout?: string,
+    _sync?: ContainerID,
+    _up?: Void,
+    _user?: string,
+    _workdir?: string,
+  ) {
+    super(ctx)
+
+    this._id = _id
+    this._envVariable = _envVariable
+    this._exitCode = _exitCode
+    this._export = _export
+    this._exportOCILayout = _exportOCILayout
+    this._imageRef = _imageRef
+    this._label = _label
+    this._platform = _platform
+    this._publish = _publish
+    this._stderr = _stderr
+    this._stdout = _stdout
+    this._sync = _sync
+    this._up = _up
+    this._user = _user
+    this._workdir = _workdir
+  }
+
+  /**
+   * A unique identifier for this Container.
+   */
+  id = async (): Promise<ContainerID> => {
+    if (this._id) {
+      return this._id
+    }
+
+    const ctx = this._ctx.select("id")
+
+    const response: Awaited<ContainerID> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Package the container state as an OCI image, and return it as an OCI image layout directory, with an "index.json" and the image blobs.
+   * @param opts.platformVariants Identifiers for other platform specific containers.
+   *
+   * Used for multi-platform images.
+   * @param opts.forcedCompression Force each layer of the image to use the specified compression algorithm.
+   *
+   * If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+   * @param opts.mediaTypes Use the specified media types for the image's layers.
+   *
+   * Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+   */
+  asOCILayout = (opts?: ContainerAsOcilayoutOpts): Directory => {
+    const metadata = {
+      forcedCompression: { is_enum: true },
+      mediaTypes: { is_enum: true },
+    }
+
+    const ctx = this._ctx.select("asOCILayout", {
+      ...opts,
+      __metadata: metadata,
+    })
+    return new Directory(ctx)
+  }
+
+  /**
+   * Turn the container into a Service.
+   *
+   * Be sure to set any exposed ports before this conversion.
+   * @param opts.args Command to run instead of the container's default command (e.g., ["go", "run", "main.go"]).
+   *
+   * If empty, the container's default command is used.
+   * @param opts.useEntrypoint If the container has an entrypoint, prepend it to the args.
+   * @param opts.experimentalPrivilegedNesting Provides Dagger access to the executed command.
+   * @param opts.insecureRootCapabilities Execute the command with all root capabilities. This is similar to running a command with "sudo" or executing "docker run" with the "--privileged" flag. Containerization does not provide any security guarantees when using this option. It should only be used when absolutely necessary and only with trusted commands.
+   * @param opts.expand Replace "${VAR}" or "$VAR" in the args according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+   * @param opts.noInit If set, skip the automatic init process injected into containers by default.
+   *
+   * This should only be used if the user requires that their exec process be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
+   */
+  asService = (opts?: ContainerAsServiceOpts): Service => {
+    const ctx = this._ctx.select("asService", { ...opts })
+    return new Service(ctx)
+  }
+
+  /**
+   * Package the container state as an OCI image, and return it as a tar archive
+   * @param opts.platformVariants Identifiers for other platform specific containers.
+   *
+   * Used for multi-platform images.
+   * @param opts.forcedCompression Force each layer of the image to use the specified compression algorithm.
+   *
+   * If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+   * @param opts.mediaTypes Use the specified media types for the image's layers.
+   *
+   * Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+   */
+  asTarball = (opts?: ContainerAsTarballOpts): File => {
+    const metadata = {
+      forcedCompression: { is_enum: true },
+      mediaTypes: { is_enum: true },
+    }
+
+    const ctx = this._ctx.select("asTarball", { ...opts, __metadata: metadata })
+    return new File(ctx)
+  }
+
+  /**
+   * Initializes this container from a Dockerfile build.
+   * @param context Directory context used by the Dockerfile.
+   * @param opts.dockerfile Path to the Dockerfile to use.
+   * @param opts.target Target build stage to build.
+   * @param opts.buildArgs Additional build arguments.
+   * @param opts.secrets Secrets to pass to the build.
+   *
+   * They will be mounted at /run/secrets/[secret-name] in the build container
+   *
+   * They can be accessed in the Dockerfile using the "secret" mount type and mount path /run/secrets/[secret-name], e.g. RUN --mount=type=secret,id=my-secret curl [http://example.com?token=$(cat /run/secrets/my-secret)](http://example.com?token=$(cat /run/secrets/my-secret))
+   * @param opts.noInit If set, skip the automatic init process injected into containers created by RUN statements.
+   *
+   * This should only be used if the user requires that their exec processes be the pid 1 process in the container. Otherwise it may result in unexpected behavior.
+   */
+  build = (context: Directory, opts?: ContainerBuildOpts): Container => {
+    const ctx = this._ctx.select("build", { context, ...opts })
+    return new Container(ctx)
+  }
+
+  /**
+   * Return the container's default arguments.
+   */
+  defaultArgs = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("defaultArgs")
+
+    const response: Awaited<string[]> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Retrieve a directory from the container's root filesystem
+   *
+   * Mounts are included.
+   * @param path The path of the directory to retrieve (e.g., "./src").
+   * @param opts.expand Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+   */
+  directory = (path: string, opts?: ContainerDirectoryOpts): Directory => {
+    const ctx = this._ctx.select("directory", { path, ...opts })
+    return new Directory(ctx)
+  }
+
+  /**
+   * Return the container's OCI entrypoint.
+   */
+  entrypoint = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("entrypoint")
+
+    const response: Awaited<string[]> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Retrieves the value of the specified environment variable.
+   * @param name The name of the environment variable to retrieve (e.g., "PATH").
+   */
+  envVariable = async (name: string): Promise<string> => {
+    if (this._envVariable) {
+      return this._envVariable
+    }
+
+    const ctx = this._ctx.select("envVariable", { name })
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Retrieves the list of environment variables passed to commands.
+   */
+  envVariables = async (): Promise<EnvVariable[]> => {
+    type envVariables = {
+      id: EnvVariableID
+    }
+
+    const ctx = this._ctx.select("envVariables").select("id")
+
+    const response: Awaited<envVariables[]> = await ctx.execute()
+
+    return response.map((r) =>
+      new Client(ctx.copy()).loadEnvVariableFromID(r.id),
+    )
+  }
+
+  /**
+   * The resource usage of the last executed command
+   *
+   * Returns an error if no command was executed
+   */
+  execMetrics = (): ExecMetrics => {
+    const ctx = this._ctx.select("execMetrics")
+    return new ExecMetrics(ctx)
+  }
+
+  /**
+   * The exit code of the last executed command
+   *
    * Returns an error if no command was executed
    */
   exitCode = async (): Promise<number> => {
@@ -2006,6 +2851,50 @@ export class Container extends BaseClient {
     return response
   }
 
+  /**
+   * Writes the container as an OCI image layout directory to the destination path on the host.
+   *
+   * It can also export platform variants.
+   * @param path Host's destination directory (e.g., "./image").
+   *
+   * Path can be relative to the engine's workdir or absolute.
+   *
+   * Any existing contents of the directory are replaced, so that it only holds the blobs of the exported images.
+   * @param opts.platformVariants Identifiers for other platform specific containers.
+   *
+   * Used for multi-platform image.
+   * @param opts.forcedCompression Force each layer of the exported image to use the specified compression algorithm.
+   *
+   * If this is unset, then if a layer already has a compressed blob in the engine's cache, that will be used (this can result in a mix of compression algorithms for different layers). If this is unset and a layer has no compressed blob in the engine's cache, then it will be compressed using Gzip.
+   * @param opts.mediaTypes Use the specified media types for the exported image's layers.
+   *
+   * Defaults to OCI, which is largely compatible with most recent container runtimes, but Docker may be needed for older runtimes without OCI support.
+   * @param opts.expand Replace "${VAR}" or "$VAR" in the value of path according to the current environment variables defined in the container (e.g. "/$VAR/foo").
+   */
+  exportOCILayout = async (
+    path: string,
+    opts?: ContainerExportOcilayoutOpts,
+  ): Promise<string> => {
+    if (this._exportOCILayout) {
+      return this._exportOCILayout
+    }
+
+    const metadata = {
+      forcedCompression: { is_enum: true },
+      mediaTypes: { is_enum: true },
+    }
+
+    const ctx = this._ctx.select("exportOCILayout", {
+      path,
+      ...opts,
+      __metadata: metadata,
+    })
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
   /**
    * Retrieves the list of exposed ports.
    *
@@ -2038,9 +2927,12 @@ export class Container extends BaseClient {
   /**
    * Download a container image, and apply it to the container state. All previous state will be lost.
    * @param address Address of the container image to download, in standard OCI ref format. Example:"registry.dagger.io/engine:latest"
+   * @param opts.verifyKey PEM encoded public key to verify the image signature with.
+   *
+   * If set, the image must have a cosign signature made with the matching private key, or the download fails.
    */
-  from = (address: string): Container => {
-    const ctx = this._ctx.select("from", { address })
+  from = (address: string, opts?: ContainerFromOpts): Container => {
+    const ctx = this._ctx.select("from", { address, ...opts })
     return new Container(ctx)
   }
 
@@ -2069,6 +2961,19 @@ export class Container extends BaseClient {
     return new Container(ctx)
   }
 
+  /**
+   * Reads the container from an OCI image layout directory.
+   * @param source Directory to read the container from, containing an "oci-layout" file, an "index.json" and the image blobs.
+   * @param opts.tag Identifies the tag to import from the layout, if the layout bundles multiple tags.
+   */
+  importOCILayout = (
+    source: Directory,
+    opts?: ContainerImportOcilayoutOpts,
+  ): Container => {
+    const ctx = this._ctx.select("importOCILayout", { source, ...opts })
+    return new Container(ctx)
+  }
+
   /**
    * Retrieves the value of the specified label.
    * @param name The name of the label (e.g., "org.opencontainers.artifact.created").
@@ -2142,6 +3047,16 @@ export class Container extends BaseClient {
    * @param opts.mediaTypes Use the specified media types for the published image's layers.
    *
    * Defaults to "OCI", which is compatible with most recent registries, but "Docker" may be needed for older registries without OCI support.
+   * @param opts.sbom Attach a software bill of materials in the given format to the image of each platform.
+   *
+   * The packages are listed from the apk, dpkg and Python package metadata found in the container filesystem.
+   * @param opts.provenance Attach SLSA provenance to the image of each platform, recording the digest of the call that built it.
+   *
+   * The base images and git commits it was built from are listed as its resolved dependencies.
+   * @param opts.signingKey PEM encoded private key to sign the published image with.
+   *
+   * The signature is pushed to the registry next to the image in the cosign format, so it can be verified with "cosign verify --key".
+   * @param opts.signingKeyPassword Password to decrypt the signing key, if it is encrypted as generated by "cosign generate-key-pair".
    */
   publish = async (
     address: string,
@@ -2154,6 +3069,7 @@ export class Container extends BaseClient {
     const metadata = {
       forcedCompression: { is_enum: true },
       mediaTypes: { is_enum: true },
+      sbom: { is_enum: true },
     }
 
     const ctx = this._ctx.select("publish", {
@@ -2372,6 +3288,11 @@ export class Container extends BaseClient {
    * @param opts.redirectStdout Redirect the command's standard output to a file in the container. Example: "./stdout.txt"
    * @param opts.redirectStderr Like redirectStdout, but for standard error
    * @param opts.expect Exit codes this command is allowed to exit with without error
+   * @param opts.expectExitCodes Exact exit codes this command is allowed to exit with without error, instead of "expect". Example: [0, 2]
+   * @param opts.expectStdoutRegex Fail the command with an EXEC_ASSERTION_ERROR if its standard output doesn't match this regular expression (RE2 syntax). Example: "listening on port \\d+"
+   * @param opts.expectStderrRegex Like expectStdoutRegex, but for standard error
+   * @param opts.expectStdoutNotContains Fail the command with an EXEC_ASSERTION_ERROR if its standard output contains this string. Example: "panic:"
+   * @param opts.expectStderrNotContains Like expectStdoutNotContains, but for standard error
    * @param opts.experimentalPrivilegedNesting Provides Dagger access to the executed command.
    * @param opts.insecureRootCapabilities Execute the command with all root capabilities. Like --privileged in Docker
    *
@@ -2380,6 +3301,15 @@ export class Container extends BaseClient {
    * @param opts.noInit Skip the automatic init process injected into containers by default.
    *
    * Only use this if you specifically need the command to be pid 1 in the container. Otherwise it may result in unexpected behavior. If you're not sure, you don't need this.
+   * @param opts.limits Resource limits enforced on the command with cgroups. Example: {cpus: 0.5, memoryBytes: 536870912, pids: 100}
+   * @param opts.timeout Kill the command if it runs for longer than this duration, failing with an EXEC_TIMEOUT_ERROR. Example: "10m"
+   *
+   * When retrying, each attempt has its own timeout.
+   * @param opts.retry Run the command again when it fails, up to the given number of attempts. Example: {attempts: 3, backoff: "1s", retryOnExitCodes: [1], retryOnStderrRegex: "connection reset"}
+   *
+   * Each attempt starts from the same filesystem: only changes to cache volumes are kept across attempts. Only the output of the last attempt is kept.
+   *
+   * To restore it, what the command may write to is copied before the first attempt: this is only the writable layer of the container with the overlayfs snapshotter, but would be the whole filesystem with other snapshotters, so retrying fails with them instead.
    */
   withExec = (args: string[], opts?: ContainerWithExecOpts): Container => {
     const metadata = {
@@ -2465,6 +3395,23 @@ export class Container extends BaseClient {
     return new Container(ctx)
   }
 
+  /**
+   * Retrieves this container with a health check run when it is started as a service.
+   *
+   * The service is ready once the check succeeds, instead of once its exposed ports accept connections. Set exactly one of args, or httpPath and/or port.
+   * @param opts.args Command to run in the service container. The service is healthy when it exits with status 0.
+   * @param opts.httpPath Path to request with an HTTP GET on the port, or the first exposed TCP port. The service is healthy on a 2xx or 3xx response.
+   * @param opts.port TCP port to check. Alone, the service is healthy once the port accepts connections.
+   * @param opts.interval Time between two checks, as a duration string (e.g., "1s", "500ms").
+   * @param opts.timeout Time after which a check fails, as a duration string.
+   * @param opts.retries Number of consecutive failed checks after which the service is unhealthy and fails to start.
+   * @param opts.startPeriod Time during which failed checks don't count towards retries, as a duration string.
+   */
+  withHealthcheck = (opts?: ContainerWithHealthcheckOpts): Container => {
+    const ctx = this._ctx.select("withHealthcheck", { ...opts })
+    return new Container(ctx)
+  }
+
   /**
    * Retrieves this container plus the given label.
    * @param name The name of the label (e.g., "org.opencontainers.artifact.created").
@@ -2664,6 +3611,17 @@ export class Container extends BaseClient {
     return new Container(ctx)
   }
 
+  /**
+   * Retrieves this container with the timestamps of its exported and published images clamped to the given time.
+   *
+   * Following the SOURCE_DATE_EPOCH specification, the image creation time, the history entries and the modification times of the files in the layers created by this container are set to the given time if they are more recent, so that building the same container always produces the same image digest.
+   * @param epoch Timestamp in seconds following Unix epoch (e.g., 1672531199).
+   */
+  withSourceDateEpoch = (epoch: number): Container => {
+    const ctx = this._ctx.select("withSourceDateEpoch", { epoch })
+    return new Container(ctx)
+  }
+
   /**
    * Retrieves this container plus a socket forwarded to the given Unix socket path.
    * @param path Location of the forwarded Unix socket (e.g., "/tmp/socket").
@@ -2795,6 +3753,14 @@ export class Container extends BaseClient {
     return new Container(ctx)
   }
 
+  /**
+   * Retrieves this container without a health check, waiting for its exposed ports when started as a service.
+   */
+  withoutHealthcheck = (): Container => {
+    const ctx = this._ctx.select("withoutHealthcheck")
+    return new Container(ctx)
+  }
+
   /**
    * Retrieves this container minus the given environment label.
    * @param name The name of the label to remove (e.g., "org.opencontainers.artifact.created").
@@ -3016,6 +3982,17 @@ export class Directory extends BaseClient {
     return response
   }
 
+  /**
+   * Load the services of a Compose file in this directory.
+   *
+   * Variables are interpolated from the .env file next to the Compose file, if any.
+   * @param opts.file Path to the Compose file (e.g., "stack/compose.yaml").
+   */
+  asComposeStack = (opts?: DirectoryAsComposeStackOpts): ComposeStack => {
+    const ctx = this._ctx.select("asComposeStack", { ...opts })
+    return new ComposeStack(ctx)
+  }
+
   /**
    * Converts this directory to a local git repository
    */
@@ -3046,6 +4023,17 @@ export class Directory extends BaseClient {
     return new ModuleSource(ctx)
   }
 
+  /**
+   * Return the file changes from this directory to another directory.
+   *
+   * Unlike diff, the changes include removed and renamed files, along with a unified diff of each file.
+   * @param other The directory after the changes.
+   */
+  changes = (other: Directory): Changeset => {
+    const ctx = this._ctx.select("changes", { other })
+    return new Changeset(ctx)
+  }
+
   /**
    * Return the difference between this directory and an another directory. The difference is encoded as a directory.
    * @param other The directory to compare against
@@ -3168,11 +4156,37 @@ export class Directory extends BaseClient {
       return this._name
     }
 
-    const ctx = this._ctx.select("name")
+    const ctx = this._ctx.select("name")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Searches the contents of the files in this directory, returning each match.
+   *
+   * Binary files and symbolic links are skipped.
+   * @param pattern The regular expression to search for, in RE2 syntax (e.g., "TODO|FIXME").
+   * @param opts.literal Interpret the pattern as a literal string instead of a regular expression.
+   * @param opts.paths If set, only search the paths matching one of these glob patterns (e.g., ["src/", "*.go"]).
+   * @param opts.multiline Allow matches to span multiple lines.
+   */
+  search = async (
+    pattern: string,
+    opts?: DirectorySearchOpts,
+  ): Promise<SearchResult[]> => {
+    type search = {
+      id: SearchResultID
+    }
+
+    const ctx = this._ctx.select("search", { pattern, ...opts}).select("id")
 
-    const response: Awaited<string> = await ctx.execute()
+    const response: Awaited<search[]> = await ctx.execute()
 
-    return response
+    return response.map((r) =>
+      new Client(ctx.copy()).loadSearchResultFromID(r.id),
+    )
   }
 
   /**
@@ -3272,6 +4286,35 @@ export class Directory extends BaseClient {
     return new Directory(ctx)
   }
 
+  /**
+   * Retrieves this directory with a patch applied to it.
+   *
+   * The patch is a unified diff, such as the output of "git diff" or "git format-patch". If any hunk can't be applied, no change is made and the rejected hunks are listed in the error.
+   * @param patch The patch to apply.
+   * @param opts.strict Require each hunk to apply at exactly the line numbers in its header.
+   * @param opts.fuzz The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+   */
+  withPatch = (patch: string, opts?: DirectoryWithPatchOpts): Directory => {
+    const ctx = this._ctx.select("withPatch", { patch, ...opts })
+    return new Directory(ctx)
+  }
+
+  /**
+   * Retrieves this directory with the patch in the given file applied to it.
+   *
+   * The patch is a unified diff, such as the output of "git diff" or "git format-patch". If any hunk can't be applied, no change is made and the rejected hunks are listed in the error.
+   * @param patch The file containing the patch to apply.
+   * @param opts.strict Require each hunk to apply at exactly the line numbers in its header.
+   * @param opts.fuzz The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+   */
+  withPatchFile = (
+    patch: File,
+    opts?: DirectoryWithPatchFileOpts,
+  ): Directory => {
+    const ctx = this._ctx.select("withPatchFile", { patch, ...opts })
+    return new Directory(ctx)
+  }
+
   /**
    * Retrieves this directory with all file/dir timestamps set to the given time.
    * @param timestamp Timestamp to set dir/files in.
@@ -3350,6 +4393,34 @@ export class Engine extends BaseClient {
     return response
   }
 
+  /**
+   * Explain why a call was not cached
+   *
+   * Only calls of the current session are explained: a call is compared to the most similar call of the same field made before it in the session. The most recent misses are kept until the session ends.
+   * @param call The digest of the call, as recorded in its telemetry, or its encoded ID.
+   */
+  cacheMiss = (call: string): EngineCacheMiss => {
+    const ctx = this._ctx.select("cacheMiss", { call })
+    return new EngineCacheMiss(ctx)
+  }
+
+  /**
+   * The cache volumes in the local cache of the Dagger engine
+   */
+  cacheVolumes = async (): Promise<EngineCacheVolume[]> => {
+    type cacheVolumes = {
+      id: EngineCacheVolumeID
+    }
+
+    const ctx = this._ctx.select("cacheVolumes").select("id")
+
+    const response: Awaited<cacheVolumes[]> = await ctx.execute()
+
+    return response.map((r) =>
+      new Client(ctx.copy()).loadEngineCacheVolumeFromID(r.id),
+    )
+  }
+
   /**
    * The local (on-disk) cache for the Dagger engine
    */
@@ -3367,7 +4438,6 @@ export class EngineCache extends BaseClient {
   private readonly _keepBytes?: number = undefined
   private readonly _maxUsedSpace?: number = undefined
   private readonly _minFreeSpace?: number = undefined
-  private readonly _prune?: Void = undefined
   private readonly _reservedSpace?: number = undefined
 
   /**
@@ -3379,7 +4449,6 @@ export class EngineCache extends BaseClient {
     _keepBytes?: number,
     _maxUsedSpace?: number,
     _minFreeSpace?: number,
-    _prune?: Void,
     _reservedSpace?: number,
   ) {
     super(ctx)
@@ -3388,7 +4457,6 @@ export class EngineCache extends BaseClient {
     this._keepBytes = _keepBytes
     this._maxUsedSpace = _maxUsedSpace
     this._minFreeSpace = _minFreeSpace
-    this._prune = _prune
     this._reservedSpace = _reservedSpace
   }
 
@@ -3462,16 +4530,20 @@ export class EngineCache extends BaseClient {
   }
 
   /**
-   * Prune the cache of releaseable entries
+   * Prune the cache of releaseable entries, returning the pruned entries
+   *
+   * Entries in use are never pruned. When filters are given, only the entries matching all of them are pruned.
+   * @param opts.olderThan Only prune entries created at least this long ago, as a duration string. Example: "168h"
+   * @param opts.unusedFor Only prune entries that haven't been used for at least this long, as a duration string. Example: "72h"
+   * @param opts.descriptionRegex Only prune entries whose description matches this regular expression (RE2 syntax).
+   * @param opts.recordTypes Only prune entries of these record types. Example: ["exec.cachemount"]
+   * @param opts.minSizeBytes Only prune entries using at least this much disk space.
+   * @param opts.entryIDs Only prune the entries with these IDs, as returned by entryID.
+   * @param opts.dryRun Return the entries that would be pruned without pruning them.
    */
-  prune = async (): Promise<void> => {
-    if (this._prune) {
-      return
-    }
-
-    const ctx = this._ctx.select("prune")
-
-    await ctx.execute()
+  prune = (opts?: EngineCachePruneOpts): EngineCacheEntrySet => {
+    const ctx = this._ctx.select("prune", { ...opts })
+    return new EngineCacheEntrySet(ctx)
   }
   reservedSpace = async (): Promise<number> => {
     if (this._reservedSpace) {
@@ -3495,7 +4567,9 @@ export class EngineCacheEntry extends BaseClient {
   private readonly _createdTimeUnixNano?: number = undefined
   private readonly _description?: string = undefined
   private readonly _diskSpaceBytes?: number = undefined
+  private readonly _entryID?: string = undefined
   private readonly _mostRecentUseTimeUnixNano?: number = undefined
+  private readonly _recordType?: string = undefined
 
   /**
    * Constructor is used for internal usage only, do not create object from it.
@@ -3507,7 +4581,9 @@ export class EngineCacheEntry extends BaseClient {
     _createdTimeUnixNano?: number,
     _description?: string,
     _diskSpaceBytes?: number,
+    _entryID?: string,
     _mostRecentUseTimeUnixNano?: number,
+    _recordType?: string,
   ) {
     super(ctx)
 
@@ -3516,7 +4592,9 @@ export class EngineCacheEntry extends BaseClient {
     this._createdTimeUnixNano = _createdTimeUnixNano
     this._description = _description
     this._diskSpaceBytes = _diskSpaceBytes
+    this._entryID = _entryID
     this._mostRecentUseTimeUnixNano = _mostRecentUseTimeUnixNano
+    this._recordType = _recordType
   }
 
   /**
@@ -3594,6 +4672,21 @@ export class EngineCacheEntry extends BaseClient {
     return response
   }
 
+  /**
+   * The identifier of the cache entry, which can be used to prune it.
+   */
+  entryID = async (): Promise<string> => {
+    if (this._entryID) {
+      return this._entryID
+    }
+
+    const ctx = this._ctx.select("entryID")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
   /**
    * The most recent time the cache entry was used, in Unix nanoseconds.
    */
@@ -3608,6 +4701,21 @@ export class EngineCacheEntry extends BaseClient {
 
     return response
   }
+
+  /**
+   * The type of the cache entry (e.g. regular, exec.cachemount, source.local).
+   */
+  recordType = async (): Promise<string> => {
+    if (this._recordType) {
+      return this._recordType
+    }
+
+    const ctx = this._ctx.select("recordType")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
 }
 
 /**
@@ -3646,11 +4754,269 @@ export class EngineCacheEntrySet extends BaseClient {
 
     const response: Awaited<EngineCacheEntrySetID> = await ctx.execute()
 
-    return response
+    return response
+  }
+
+  /**
+   * The total disk space used by the cache entries in this set.
+   */
+  diskSpaceBytes = async (): Promise<number> => {
+    if (this._diskSpaceBytes) {
+      return this._diskSpaceBytes
+    }
+
+    const ctx = this._ctx.select("diskSpaceBytes")
+
+    const response: Awaited<number> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The list of individual cache entries in the set
+   */
+  entries = async (): Promise<EngineCacheEntry[]> => {
+    type entries = {
+      id: EngineCacheEntryID
+    }
+
+    const ctx = this._ctx.select("entries").select("id")
+
+    const response: Awaited<entries[]> = await ctx.execute()
+
+    return response.map((r) =>
+      new Client(ctx.copy()).loadEngineCacheEntryFromID(r.id),
+    )
+  }
+
+  /**
+   * The number of cache entries in this set.
+   */
+  entryCount = async (): Promise<number> => {
+    if (this._entryCount) {
+      return this._entryCount
+    }
+
+    const ctx = this._ctx.select("entryCount")
+
+    const response: Awaited<number> = await ctx.execute()
+
+    return response
+  }
+}
+
+/**
+ * An explanation of why a call was not cached
+ */
+export class EngineCacheMiss extends BaseClient {
+  private readonly _id?: EngineCacheMissID = undefined
+  private readonly _argument?: string = undefined
+  private readonly _call?: string = undefined
+  private readonly _changedCall?: string = undefined
+  private readonly _description?: string = undefined
+  private readonly _previousCall?: string = undefined
+  private readonly _reason?: string = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+    _id?: EngineCacheMissID,
+    _argument?: string,
+    _call?: string,
+    _changedCall?: string,
+    _description?: string,
+    _previousCall?: string,
+    _reason?: string,
+  ) {
+    super(ctx)
+
+    this._id = _id
+    this._argument = _argument
+    this._call = _call
+    this._changedCall = _changedCall
+    this._description = _description
+    this._previousCall = _previousCall
+    this._reason = _reason
+  }
+
+  /**
+   * A unique identifier for this EngineCacheMiss.
+   */
+  id = async (): Promise<EngineCacheMissID> => {
+    if (this._id) {
+      return this._id
+    }
+
+    const ctx = this._ctx.select("id")
+
+    const response: Awaited<EngineCacheMissID> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The argument of the changed call that changed, if any.
+   */
+  argument = async (): Promise<string> => {
+    if (this._argument) {
+      return this._argument
+    }
+
+    const ctx = this._ctx.select("argument")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The digest of the call that was not cached.
+   */
+  call = async (): Promise<string> => {
+    if (this._call) {
+      return this._call
+    }
+
+    const ctx = this._ctx.select("call")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The call, within the inputs of the call, that changed or has a cache policy, if any.
+   */
+  changedCall = async (): Promise<string> => {
+    if (this._changedCall) {
+      return this._changedCall
+    }
+
+    const ctx = this._ctx.select("changedCall")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * A human readable explanation of the cache miss.
+   */
+  description = async (): Promise<string> => {
+    if (this._description) {
+      return this._description
+    }
+
+    const ctx = this._ctx.select("description")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The digest of the most similar previous call it was compared against, if any.
+   */
+  previousCall = async (): Promise<string> => {
+    if (this._previousCall) {
+      return this._previousCall
+    }
+
+    const ctx = this._ctx.select("previousCall")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Why the call was not cached: FIRST_SEEN, ARGUMENT_CHANGED, CONTENT_CHANGED, CACHE_POLICY, EXPIRED or UNKNOWN.
+   */
+  reason = async (): Promise<string> => {
+    if (this._reason) {
+      return this._reason
+    }
+
+    const ctx = this._ctx.select("reason")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+}
+
+/**
+ * A cache volume in the local cache
+ */
+export class EngineCacheVolume extends BaseClient {
+  private readonly _id?: EngineCacheVolumeID = undefined
+  private readonly _activelyUsed?: boolean = undefined
+  private readonly _diskSpaceBytes?: number = undefined
+  private readonly _mostRecentUseTimeUnixNano?: number = undefined
+  private readonly _sharingMode?: CacheSharingMode = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+    _id?: EngineCacheVolumeID,
+    _activelyUsed?: boolean,
+    _diskSpaceBytes?: number,
+    _mostRecentUseTimeUnixNano?: number,
+    _sharingMode?: CacheSharingMode,
+  ) {
+    super(ctx)
+
+    this._id = _id
+    this._activelyUsed = _activelyUsed
+    this._diskSpaceBytes = _diskSpaceBytes
+    this._mostRecentUseTimeUnixNano = _mostRecentUseTimeUnixNano
+    this._sharingMode = _sharingMode
+  }
+
+  /**
+   * A unique identifier for this EngineCacheVolume.
+   */
+  id = async (): Promise<EngineCacheVolumeID> => {
+    if (this._id) {
+      return this._id
+    }
+
+    const ctx = this._ctx.select("id")
+
+    const response: Awaited<EngineCacheVolumeID> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * Whether the cache volume is actively being used.
+   */
+  activelyUsed = async (): Promise<boolean> => {
+    if (this._activelyUsed) {
+      return this._activelyUsed
+    }
+
+    const ctx = this._ctx.select("activelyUsed")
+
+    const response: Awaited<boolean> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The cache volume, which can be mounted, snapshotted, seeded or deleted
+   */
+  cacheVolume = (): CacheVolume => {
+    const ctx = this._ctx.select("cacheVolume")
+    return new CacheVolume(ctx)
   }
 
   /**
-   * The total disk space used by the cache entries in this set.
+   * The disk space used by the cache volume.
    */
   diskSpaceBytes = async (): Promise<number> => {
     if (this._diskSpaceBytes) {
@@ -3665,36 +5031,56 @@ export class EngineCacheEntrySet extends BaseClient {
   }
 
   /**
-   * The list of individual cache entries in the set
+   * The IDs of the cache entries holding the contents of the cache volume.
    */
-  entries = async (): Promise<EngineCacheEntry[]> => {
-    type entries = {
-      id: EngineCacheEntryID
-    }
+  entryIDs = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("entryIDs")
 
-    const ctx = this._ctx.select("entries").select("id")
+    const response: Awaited<string[]> = await ctx.execute()
 
-    const response: Awaited<entries[]> = await ctx.execute()
+    return response
+  }
 
-    return response.map((r) =>
-      new Client(ctx.copy()).loadEngineCacheEntryFromID(r.id),
-    )
+  /**
+   * The keys identifying the cache volume.
+   */
+  keys = async (): Promise<string[]> => {
+    const ctx = this._ctx.select("keys")
+
+    const response: Awaited<string[]> = await ctx.execute()
+
+    return response
   }
 
   /**
-   * The number of cache entries in this set.
+   * The most recent time the cache volume was used, in Unix nanoseconds.
    */
-  entryCount = async (): Promise<number> => {
-    if (this._entryCount) {
-      return this._entryCount
+  mostRecentUseTimeUnixNano = async (): Promise<number> => {
+    if (this._mostRecentUseTimeUnixNano) {
+      return this._mostRecentUseTimeUnixNano
     }
 
-    const ctx = this._ctx.select("entryCount")
+    const ctx = this._ctx.select("mostRecentUseTimeUnixNano")
 
     const response: Awaited<number> = await ctx.execute()
 
     return response
   }
+
+  /**
+   * The sharing mode the cache volume was most recently mounted with.
+   */
+  sharingMode = async (): Promise<CacheSharingMode> => {
+    if (this._sharingMode) {
+      return this._sharingMode
+    }
+
+    const ctx = this._ctx.select("sharingMode")
+
+    const response: Awaited<CacheSharingMode> = await ctx.execute()
+
+    return response
+  }
 }
 
 /**
@@ -3990,6 +5376,99 @@ export class Env extends BaseClient {
     return new Env(ctx)
   }
 
+  /**
+   * Create or update a binding of type ChangesetEntry in the environment
+   * @param name The name of the binding
+   * @param value The ChangesetEntry value to assign to the binding
+   * @param description The purpose of the input
+   */
+  withChangesetEntryInput = (
+    name: string,
+    value: ChangesetEntry,
+    description: string,
+  ): Env => {
+    const ctx = this._ctx.select("withChangesetEntryInput", {
+      name,
+      value,
+      description,
+    })
+    return new Env(ctx)
+  }
+
+  /**
+   * Declare a desired ChangesetEntry output to be assigned in the environment
+   * @param name The name of the binding
+   * @param description A description of the desired value of the binding
+   */
+  withChangesetEntryOutput = (name: string, description: string): Env => {
+    const ctx = this._ctx.select("withChangesetEntryOutput", {
+      name,
+      description,
+    })
+    return new Env(ctx)
+  }
+
+  /**
+   * Create or update a binding of type Changeset in the environment
+   * @param name The name of the binding
+   * @param value The Changeset value to assign to the binding
+   * @param description The purpose of the input
+   */
+  withChangesetInput = (
+    name: string,
+    value: Changeset,
+    description: string,
+  ): Env => {
+    const ctx = this._ctx.select("withChangesetInput", {
+      name,
+      value,
+      description,
+    })
+    return new Env(ctx)
+  }
+
+  /**
+   * Declare a desired Changeset output to be assigned in the environment
+   * @param name The name of the binding
+   * @param description A description of the desired value of the binding
+   */
+  withChangesetOutput = (name: string, description: string): Env => {
+    const ctx = this._ctx.select("withChangesetOutput", { name, description })
+    return new Env(ctx)
+  }
+
+  /**
+   * Create or update a binding of type ComposeStack in the environment
+   * @param name The name of the binding
+   * @param value The ComposeStack value to assign to the binding
+   * @param description The purpose of the input
+   */
+  withComposeStackInput = (
+    name: string,
+    value: ComposeStack,
+    description: string,
+  ): Env => {
+    const ctx = this._ctx.select("withComposeStackInput", {
+      name,
+      value,
+      description,
+    })
+    return new Env(ctx)
+  }
+
+  /**
+   * Declare a desired ComposeStack output to be assigned in the environment
+   * @param name The name of the binding
+   * @param description A description of the desired value of the binding
+   */
+  withComposeStackOutput = (name: string, description: string): Env => {
+    const ctx = this._ctx.select("withComposeStackOutput", {
+      name,
+      description,
+    })
+    return new Env(ctx)
+  }
+
   /**
    * Create or update a binding of type Container in the environment
    * @param name The name of the binding
@@ -4048,6 +5527,70 @@ export class Env extends BaseClient {
     return new Env(ctx)
   }
 
+  /**
+   * Create or update a binding of type EngineCacheMiss in the environment
+   * @param name The name of the binding
+   * @param value The EngineCacheMiss value to assign to the binding
+   * @param description The purpose of the input
+   */
+  withEngineCacheMissInput = (
+    name: string,
+    value: EngineCacheMiss,
+    description: string,
+  ): Env => {
+    const ctx = this._ctx.select("withEngineCacheMissInput", {
+      name,
+      value,
+      description,
+    })
+    return new Env(ctx)
+  }
+
+  /**
+   * Declare a desired EngineCacheMiss output to be assigned in the environment
+   * @param name The name of the binding
+   * @param description A description of the desired value of the binding
+   */
+  withEngineCacheMissOutput = (name: string, description: string): Env => {
+    const ctx = this._ctx.select("withEngineCacheMissOutput", {
+      name,
+      description,
+    })
+    return new Env(ctx)
+  }
+
+  /**
+   * Create or update a binding of type EngineCacheVolume in the environment
+   * @param name The name of the binding
+   * @param value The EngineCacheVolume value to assign to the binding
+   * @param description The purpose of the input
+   */
+  withEngineCacheVolumeInput = (
+    name: string,
+    value: EngineCacheVolume,
+    description: string,
+  ): Env => {
+    const ctx = this._ctx.select("withEngineCacheVolumeInput", {
+      name,
+      value,
+      description,
+    })
+    return new Env(ctx)
+  }
+
+  /**
+   * Declare a desired EngineCacheVolume output to be assigned in the environment
+   * @param name The name of the binding
+   * @param description A description of the desired value of the binding
+   */
+  withEngineCacheVolumeOutput = (name: string, description: string): Env => {
+    const ctx = this._ctx.select("withEngineCacheVolumeOutput", {
+      name,
+      description,
+    })
+    return new Env(ctx)
+  }
+
   /**
    * Create or update a binding of type Env in the environment
    * @param name The name of the binding
@@ -4526,68 +6069,192 @@ export class Error extends BaseClient {
   }
 }
 
-export class ErrorValue extends BaseClient {
-  private readonly _id?: ErrorValueID = undefined
-  private readonly _name?: string = undefined
-  private readonly _value?: JSON = undefined
+export class ErrorValue extends BaseClient {
+  private readonly _id?: ErrorValueID = undefined
+  private readonly _name?: string = undefined
+  private readonly _value?: JSON = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+    _id?: ErrorValueID,
+    _name?: string,
+    _value?: JSON,
+  ) {
+    super(ctx)
+
+    this._id = _id
+    this._name = _name
+    this._value = _value
+  }
+
+  /**
+   * A unique identifier for this ErrorValue.
+   */
+  id = async (): Promise<ErrorValueID> => {
+    if (this._id) {
+      return this._id
+    }
+
+    const ctx = this._ctx.select("id")
+
+    const response: Awaited<ErrorValueID> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The name of the value.
+   */
+  name = async (): Promise<string> => {
+    if (this._name) {
+      return this._name
+    }
+
+    const ctx = this._ctx.select("name")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The value.
+   */
+  value = async (): Promise<JSON> => {
+    if (this._value) {
+      return this._value
+    }
+
+    const ctx = this._ctx.select("value")
+
+    const response: Awaited<JSON> = await ctx.execute()
+
+    return response
+  }
+}
+
+/**
+ * The resource usage of an executed command.
+ */
+export class ExecMetrics extends BaseClient {
+  private readonly _id?: ExecMetricsID = undefined
+  private readonly _cpuTimeMicroseconds?: number = undefined
+  private readonly _durationMicroseconds?: number = undefined
+  private readonly _ioReadBytes?: number = undefined
+  private readonly _ioWriteBytes?: number = undefined
+  private readonly _memoryPeakBytes?: number = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+    _id?: ExecMetricsID,
+    _cpuTimeMicroseconds?: number,
+    _durationMicroseconds?: number,
+    _ioReadBytes?: number,
+    _ioWriteBytes?: number,
+    _memoryPeakBytes?: number,
+  ) {
+    super(ctx)
+
+    this._id = _id
+    this._cpuTimeMicroseconds = _cpuTimeMicroseconds
+    this._durationMicroseconds = _durationMicroseconds
+    this._ioReadBytes = _ioReadBytes
+    this._ioWriteBytes = _ioWriteBytes
+    this._memoryPeakBytes = _memoryPeakBytes
+  }
+
+  /**
+   * A unique identifier for this ExecMetrics.
+   */
+  id = async (): Promise<ExecMetricsID> => {
+    if (this._id) {
+      return this._id
+    }
+
+    const ctx = this._ctx.select("id")
+
+    const response: Awaited<ExecMetricsID> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The CPU time used by the command in microseconds.
+   */
+  cpuTimeMicroseconds = async (): Promise<number> => {
+    if (this._cpuTimeMicroseconds) {
+      return this._cpuTimeMicroseconds
+    }
+
+    const ctx = this._ctx.select("cpuTimeMicroseconds")
+
+    const response: Awaited<number> = await ctx.execute()
+
+    return response
+  }
 
   /**
-   * Constructor is used for internal usage only, do not create object from it.
+   * The wall-clock duration of the command in microseconds.
    */
-  constructor(
-    ctx?: Context,
-    _id?: ErrorValueID,
-    _name?: string,
-    _value?: JSON,
-  ) {
-    super(ctx)
+  durationMicroseconds = async (): Promise<number> => {
+    if (this._durationMicroseconds) {
+      return this._durationMicroseconds
+    }
 
-    this._id = _id
-    this._name = _name
-    this._value = _value
+    const ctx = this._ctx.select("durationMicroseconds")
+
+    const response: Awaited<number> = await ctx.execute()
+
+    return response
   }
 
   /**
-   * A unique identifier for this ErrorValue.
+   * The number of bytes read from disk by the command.
    */
-  id = async (): Promise<ErrorValueID> => {
-    if (this._id) {
-      return this._id
+  ioReadBytes = async (): Promise<number> => {
+    if (this._ioReadBytes) {
+      return this._ioReadBytes
     }
 
-    const ctx = this._ctx.select("id")
+    const ctx = this._ctx.select("ioReadBytes")
 
-    const response: Awaited<ErrorValueID> = await ctx.execute()
+    const response: Awaited<number> = await ctx.execute()
 
     return response
   }
 
   /**
-   * The name of the value.
+   * The number of bytes written to disk by the command.
    */
-  name = async (): Promise<string> => {
-    if (this._name) {
-      return this._name
+  ioWriteBytes = async (): Promise<number> => {
+    if (this._ioWriteBytes) {
+      return this._ioWriteBytes
     }
 
-    const ctx = this._ctx.select("name")
+    const ctx = this._ctx.select("ioWriteBytes")
 
-    const response: Awaited<string> = await ctx.execute()
+    const response: Awaited<number> = await ctx.execute()
 
     return response
   }
 
   /**
-   * The value.
+   * The peak memory usage of the command in bytes.
    */
-  value = async (): Promise<JSON> => {
-    if (this._value) {
-      return this._value
+  memoryPeakBytes = async (): Promise<number> => {
+    if (this._memoryPeakBytes) {
+      return this._memoryPeakBytes
     }
 
-    const ctx = this._ctx.select("value")
+    const ctx = this._ctx.select("memoryPeakBytes")
 
-    const response: Awaited<JSON> = await ctx.execute()
+    const response: Awaited<number> = await ctx.execute()
 
     return response
   }
@@ -4795,6 +6462,31 @@ export class File extends BaseClient {
     return response
   }
 
+  /**
+   * Searches the contents of the file, returning each match.
+   *
+   * A binary file has no matches.
+   * @param pattern The regular expression to search for, in RE2 syntax (e.g., "TODO|FIXME").
+   * @param opts.literal Interpret the pattern as a literal string instead of a regular expression.
+   * @param opts.multiline Allow matches to span multiple lines.
+   */
+  search = async (
+    pattern: string,
+    opts?: FileSearchOpts,
+  ): Promise<SearchResult[]> => {
+    type search = {
+      id: SearchResultID
+    }
+
+    const ctx = this._ctx.select("search", { pattern, ...opts}).select("id")
+
+    const response: Awaited<search[]> = await ctx.execute()
+
+    return response.map((r) =>
+      new Client(ctx.copy()).loadSearchResultFromID(r.id),
+    )
+  }
+
   /**
    * Retrieves the size of the file, in bytes.
    */
@@ -4830,6 +6522,52 @@ export class File extends BaseClient {
     return new File(ctx)
   }
 
+  /**
+   * Retrieves this file with a unified diff applied to it.
+   *
+   * The patch must change exactly one file; its file names are ignored.
+   * @param patch The unified diff to apply, e.g. the output of "diff -u" or "git diff".
+   * @param opts.strict Require each hunk to apply at exactly the line numbers in its header.
+   * @param opts.fuzz The maximum number of leading and trailing context lines of a hunk that may be ignored when it doesn't match otherwise. Ignored in strict mode.
+   */
+  withPatch = (patch: string, opts?: FileWithPatchOpts): File => {
+    const ctx = this._ctx.select("withPatch", { patch, ...opts })
+    return new File(ctx)
+  }
+
+  /**
+   * Retrieves this file with occurrences of a string replaced.
+   *
+   * Unless all or firstFrom is set, it is an error for the search string to be found more than once.
+   * @param search The text to search for.
+   * @param replacement The text to replace the search string with.
+   * @param opts.all Replace all occurrences of the search string.
+   * @param opts.firstFrom Replace only the first occurrence found at or after this line number, starting at 1.
+   */
+  withReplaced = (
+    search: string,
+    replacement: string,
+    opts?: FileWithReplacedOpts,
+  ): File => {
+    const ctx = this._ctx.select("withReplaced", {
+      search,
+      replacement,
+      ...opts,
+    })
+    return new File(ctx)
+  }
+
+  /**
+   * Retrieves this file with a range of lines replaced.
+   * @param start The first line to replace, starting at 1.
+   * @param end The last line to replace, inclusive.
+   * @param contents The text to replace the lines with. An empty string deletes the lines.
+   */
+  withReplacedLines = (start: number, end: number, contents: string): File => {
+    const ctx = this._ctx.select("withReplacedLines", { start, end, contents })
+    return new File(ctx)
+  }
+
   /**
    * Retrieves this file with its created/modified timestamps set to the given time.
    * @param timestamp Timestamp to set dir/files in.
@@ -7641,6 +9379,30 @@ export class Client extends BaseClient {
     return new CacheVolume(ctx)
   }
 
+  /**
+   * Load a ChangesetEntry from its ID.
+   */
+  loadChangesetEntryFromID = (id: ChangesetEntryID): ChangesetEntry => {
+    const ctx = this._ctx.select("loadChangesetEntryFromID", { id })
+    return new ChangesetEntry(ctx)
+  }
+
+  /**
+   * Load a Changeset from its ID.
+   */
+  loadChangesetFromID = (id: ChangesetID): Changeset => {
+    const ctx = this._ctx.select("loadChangesetFromID", { id })
+    return new Changeset(ctx)
+  }
+
+  /**
+   * Load a ComposeStack from its ID.
+   */
+  loadComposeStackFromID = (id: ComposeStackID): ComposeStack => {
+    const ctx = this._ctx.select("loadComposeStackFromID", { id })
+    return new ComposeStack(ctx)
+  }
+
   /**
    * Load a Container from its ID.
    */
@@ -7691,6 +9453,24 @@ export class Client extends BaseClient {
     return new EngineCache(ctx)
   }
 
+  /**
+   * Load a EngineCacheMiss from its ID.
+   */
+  loadEngineCacheMissFromID = (id: EngineCacheMissID): EngineCacheMiss => {
+    const ctx = this._ctx.select("loadEngineCacheMissFromID", { id })
+    return new EngineCacheMiss(ctx)
+  }
+
+  /**
+   * Load a EngineCacheVolume from its ID.
+   */
+  loadEngineCacheVolumeFromID = (
+    id: EngineCacheVolumeID,
+  ): EngineCacheVolume => {
+    const ctx = this._ctx.select("loadEngineCacheVolumeFromID", { id })
+    return new EngineCacheVolume(ctx)
+  }
+
   /**
    * Load a Engine from its ID.
    */
@@ -7747,6 +9527,14 @@ export class Client extends BaseClient {
     return new ErrorValue(ctx)
   }
 
+  /**
+   * Load a ExecMetrics from its ID.
+   */
+  loadExecMetricsFromID = (id: ExecMetricsID): ExecMetrics => {
+    const ctx = this._ctx.select("loadExecMetricsFromID", { id })
+    return new ExecMetrics(ctx)
+  }
+
   /**
    * Load a FieldTypeDef from its ID.
    */
@@ -7935,6 +9723,14 @@ export class Client extends BaseClient {
     return new ScalarTypeDef(ctx)
   }
 
+  /**
+   * Load a SearchResult from its ID.
+   */
+  loadSearchResultFromID = (id: SearchResultID): SearchResult => {
+    const ctx = this._ctx.select("loadSearchResultFromID", { id })
+    return new SearchResult(ctx)
+  }
+
   /**
    * Load a Secret from its ID.
    */
@@ -8203,6 +9999,112 @@ export class ScalarTypeDef extends BaseClient {
   }
 }
 
+/**
+ * A match of a content search in a file.
+ */
+export class SearchResult extends BaseClient {
+  private readonly _id?: SearchResultID = undefined
+  private readonly _column?: number = undefined
+  private readonly _filePath?: string = undefined
+  private readonly _lineNumber?: number = undefined
+  private readonly _matchedText?: string = undefined
+
+  /**
+   * Constructor is used for internal usage only, do not create object from it.
+   */
+  constructor(
+    ctx?: Context,
+    _id?: SearchResultID,
+    _column?: number,
+    _filePath?: string,
+    _lineNumber?: number,
+    _matchedText?: string,
+  ) {
+    super(ctx)
+
+    this._id = _id
+    this._column = _column
+    this._filePath = _filePath
+    this._lineNumber = _lineNumber
+    this._matchedText = _matchedText
+  }
+
+  /**
+   * A unique identifier for this SearchResult.
+   */
+  id = async (): Promise<SearchResultID> => {
+    if (this._id) {
+      return this._id
+    }
+
+    const ctx = this._ctx.select("id")
+
+    const response: Awaited<SearchResultID> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The byte offset of the match within its first line, starting at 1.
+   */
+  column = async (): Promise<number> => {
+    if (this._column) {
+      return this._column
+    }
+
+    const ctx = this._ctx.select("column")
+
+    const response: Awaited<number> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The path to the file that matched.
+   */
+  filePath = async (): Promise<string> => {
+    if (this._filePath) {
+      return this._filePath
+    }
+
+    const ctx = this._ctx.select("filePath")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The first line that matched, starting at 1.
+   */
+  lineNumber = async (): Promise<number> => {
+    if (this._lineNumber) {
+      return this._lineNumber
+    }
+
+    const ctx = this._ctx.select("lineNumber")
+
+    const response: Awaited<number> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The text that matched the pattern.
+   */
+  matchedText = async (): Promise<string> => {
+    if (this._matchedText) {
+      return this._matchedText
+    }
+
+    const ctx = this._ctx.select("matchedText")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+}
+
 /**
  * A reference to a secret value, which can be handled more safely than the value itself.
  */
@@ -8210,6 +10112,7 @@ export class Secret extends BaseClient {
   private readonly _id?: SecretID = undefined
   private readonly _name?: string = undefined
   private readonly _plaintext?: string = undefined
+  private readonly _refresh?: SecretID = undefined
   private readonly _uri?: string = undefined
 
   /**
@@ -8220,6 +10123,7 @@ export class Secret extends BaseClient {
     _id?: SecretID,
     _name?: string,
     _plaintext?: string,
+    _refresh?: SecretID,
     _uri?: string,
   ) {
     super(ctx)
@@ -8227,6 +10131,7 @@ export class Secret extends BaseClient {
     this._id = _id
     this._name = _name
     this._plaintext = _plaintext
+    this._refresh = _refresh
     this._uri = _uri
   }
 
@@ -8275,6 +10180,21 @@ export class Secret extends BaseClient {
     return response
   }
 
+  /**
+   * Resolves this secret again from its provider, bypassing any cached value.
+   *
+   * Subsequent uses of the secret see the new value, which picks up rotated credentials in long-running sessions.
+   *
+   * This is a no-op for secrets created with setSecret.
+   */
+  refresh = async (): Promise<Secret> => {
+    const ctx = this._ctx.select("refresh")
+
+    const response: Awaited<SecretID> = await ctx.execute()
+
+    return new Client(ctx.copy()).loadSecretFromID(response)
+  }
+
   /**
    * The URI of this secret.
    */
@@ -8298,7 +10218,10 @@ export class Service extends BaseClient {
   private readonly _id?: ServiceID = undefined
   private readonly _endpoint?: string = undefined
   private readonly _hostname?: string = undefined
+  private readonly _logs?: string = undefined
   private readonly _start?: ServiceID = undefined
+  private readonly _stderr?: string = undefined
+  private readonly _stdout?: string = undefined
   private readonly _stop?: ServiceID = undefined
   private readonly _up?: Void = undefined
 
@@ -8310,7 +10233,10 @@ export class Service extends BaseClient {
     _id?: ServiceID,
     _endpoint?: string,
     _hostname?: string,
+    _logs?: string,
     _start?: ServiceID,
+    _stderr?: string,
+    _stdout?: string,
     _stop?: ServiceID,
     _up?: Void,
   ) {
@@ -8319,7 +10245,10 @@ export class Service extends BaseClient {
     this._id = _id
     this._endpoint = _endpoint
     this._hostname = _hostname
+    this._logs = _logs
     this._start = _start
+    this._stderr = _stderr
+    this._stdout = _stdout
     this._stop = _stop
     this._up = _up
   }
@@ -8375,6 +10304,28 @@ export class Service extends BaseClient {
     return response
   }
 
+  /**
+   * Retrieves the combined standard output and standard error of the service, in the order they were written.
+   *
+   * The service must be running or have run in the session. Only the most recent output is kept.
+   * @param opts.waitForExit Wait for the service to exit before returning its logs, instead of returning the output so far.
+   *
+   * The logs are not streamed while waiting: use "since" to poll for new lines instead.
+   * @param opts.since Only return the lines written since the given time, either an RFC 3339 timestamp (e.g., "2006-01-02T15:04:05Z") or a duration before now (e.g., "5m").
+   * @param opts.tail Only return the given number of last lines. All lines are returned if 0.
+   */
+  logs = async (opts?: ServiceLogsOpts): Promise<string> => {
+    if (this._logs) {
+      return this._logs
+    }
+
+    const ctx = this._ctx.select("logs", { ...opts })
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
   /**
    * Retrieves the list of ports provided by the service.
    */
@@ -8390,6 +10341,18 @@ export class Service extends BaseClient {
     return response.map((r) => new Client(ctx.copy()).loadPortFromID(r.id))
   }
 
+  /**
+   * Gracefully stops the service and captures its state as a container, starting it first if it's not running.
+   *
+   * The container's root filesystem includes the changes made by the service and the contents of its cache volumes, which are no longer mounted.
+   *
+   * The snapshot is cached like any other result, so later runs can start from the warmed-up state.
+   */
+  snapshot = (): Container => {
+    const ctx = this._ctx.select("snapshot")
+    return new Container(ctx)
+  }
+
   /**
    * Start the service and wait for its health checks to succeed.
    *
@@ -8403,6 +10366,40 @@ export class Service extends BaseClient {
     return new Client(ctx.copy()).loadServiceFromID(response)
   }
 
+  /**
+   * The standard error of the service so far.
+   *
+   * The service must be running or have run in the session. Only the most recent output is kept.
+   */
+  stderr = async (): Promise<string> => {
+    if (this._stderr) {
+      return this._stderr
+    }
+
+    const ctx = this._ctx.select("stderr")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
+  /**
+   * The standard output of the service so far.
+   *
+   * The service must be running or have run in the session. Only the most recent output is kept.
+   */
+  stdout = async (): Promise<string> => {
+    if (this._stdout) {
+      return this._stdout
+    }
+
+    const ctx = this._ctx.select("stdout")
+
+    const response: Awaited<string> = await ctx.execute()
+
+    return response
+  }
+
   /**
    * Stop the service.
    * @param opts.kill Immediately kill the service without waiting for a graceful exit
@@ -8432,6 +10429,33 @@ export class Service extends BaseClient {
     await ctx.execute()
   }
 
+  /**
+   * Configures a service to start before this service, and to stop along with it.
+   *
+   * Dependencies start in the order they are added, each once the previous one meets its condition.
+   *
+   * A dependency is reachable at its hostname within the session.
+   * @param service The service to start first.
+   * @param opts.condition The condition the dependency must meet before this service starts.
+   *
+   * Use COMPLETED for jobs like database migrations, which must exit successfully.
+   */
+  withDependency = (
+    service: Service,
+    opts?: ServiceWithDependencyOpts,
+  ): Service => {
+    const metadata = {
+      condition: { is_enum: true },
+    }
+
+    const ctx = this._ctx.select("withDependency", {
+      service,
+      ...opts,
+      __metadata: metadata,
+    })
+    return new Service(ctx)
+  }
+
   /**
    * Configures a hostname which can be used by clients within the session to reach this container.
    * @param hostname The hostname to use.